@@ -22,6 +22,10 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "verify-results" {
+		os.Exit(runVerifyResults(os.Args[2:]))
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -59,17 +63,46 @@ func main() {
 	redisStore := auth.NewRedisStore(redisClient)
 	authService := auth.NewAuthService(database.Queries, cfg, redisStore)
 
-	stakingService := services.NewStakingService(database.Queries, authService)
-	dashboardService := services.NewDashboardService(database.Queries, authService)
-	governanceService := services.NewGovernanceService(database.Queries, authService)
+	securityService := services.NewSecurityService(database.Queries).
+		WithCache(redisStore)
+	authService.WithThresholds(securityService)
+	stakingService := services.NewStakingService(database.Queries, authService).
+		WithTx(database).
+		WithConfig(cfg.Staking).
+		WithMonitor(securityService).
+		WithCache(redisStore)
 	assetsService := services.NewAssetsService(database.Queries)
+	liquidityService := services.NewLiquidityService(database.Queries).
+		WithTx(database).
+		WithConfig(cfg.Liquidity)
+	dashboardService := services.NewDashboardService(database.Queries, authService).
+		WithPriceSource(liquidityService)
+	governanceService := services.NewGovernanceService(database.Queries, authService).
+		WithTx(database).
+		WithConfig(cfg.Governance).
+		WithExecutors(services.ProposalExecutors{
+			Products:   stakingService,
+			Tokens:     assetsService,
+			Thresholds: securityService,
+			Pools:      liquidityService,
+		})
+
+	// Background jobs
+	scheduler := services.NewScheduler()
+	scheduler.Every("stake-lifecycle", cfg.Staking.LifecycleInterval, stakingService.ProcessLifecycle)
+	scheduler.Every("reward-snapshots", cfg.Staking.SnapshotInterval, stakingService.SnapshotRewards)
+	scheduler.Every("governance-proposals", cfg.Governance.ProcessInterval, governanceService.ProcessProposals)
+	scheduler.Every("pool-snapshots", cfg.Liquidity.SnapshotInterval, liquidityService.SnapshotPools)
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	scheduler.Start(jobsCtx)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService, database.Queries)
 	stakeHandler := handlers.NewStakeHandler(database.Queries, stakingService, authService)
 	dashboardHandler := handlers.NewDashboardHandler(dashboardService)
-	governanceHandler := handlers.NewGovernanceHandler(database.Queries, governanceService)
+	governanceHandler := handlers.NewGovernanceHandler(governanceService, authService)
 	assetHandler := handlers.NewAssetsHandler(assetsService)
+	liquidityHandler := handlers.NewLiquidityHandler(liquidityService, authService)
 
 	// Setup router
 	r := chi.NewRouter()
@@ -84,7 +117,7 @@ func main() {
 	// CORS
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://*", "http://*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: true,
@@ -111,6 +144,7 @@ func main() {
 			dashboardHandler.RegisterRoutes(r)
 			governanceHandler.RegisterRoutes(r)
 			assetHandler.RegisterRoutes(r)
+			liquidityHandler.RegisterRoutes(r)
 		})
 	})
 
@@ -138,6 +172,9 @@ func main() {
 
 	log.Println("Shutting down server...")
 
+	stopJobs()
+	scheduler.Wait()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
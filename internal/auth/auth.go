@@ -26,9 +26,10 @@ var (
 )
 
 type AuthService struct {
-	queries *db.Queries
-	config  *config.Config
-	store   Store
+	queries    *db.Queries
+	config     *config.Config
+	store      Store
+	thresholds ThresholdReader
 }
 
 type Store interface {
@@ -57,6 +58,35 @@ func NewAuthService(queries *db.Queries, config *config.Config, store Store) *Au
 	}
 }
 
+// ThresholdMaxLoginAttempts is the security threshold governance can set to
+// override Security.MaxLoginAttempts.
+const ThresholdMaxLoginAttempts = "max_login_attempts"
+
+// ThresholdReader reads governance overrides of security thresholds.
+type ThresholdReader interface {
+	Threshold(ctx context.Context, name string, fallback float64) float64
+}
+
+// WithThresholds makes login lockout honour governance overrides.
+func (s *AuthService) WithThresholds(r ThresholdReader) *AuthService {
+	s.thresholds = r
+	return s
+}
+
+// maxLoginAttempts returns the governance override for failed logins before
+// lockout, falling back to the configured value.
+func (s *AuthService) maxLoginAttempts(ctx context.Context) int {
+	fallback := s.config.Security.MaxLoginAttempts
+	if s.thresholds == nil {
+		return fallback
+	}
+	n := s.thresholds.Threshold(ctx, ThresholdMaxLoginAttempts, float64(fallback))
+	if n < 1 {
+		return fallback
+	}
+	return int(n)
+}
+
 func (s *AuthService) Register(ctx context.Context, params db.CreateUserParams) (*db.User, error) {
 	// Check if user exists
 	existing, err := s.queries.GetUserByEmail(ctx, params.Email)
@@ -126,7 +156,7 @@ func (s *AuthService) Login(ctx context.Context, email, password string) (*Token
 			currentAttempts = user.FailedLoginAttempts.Int32
 		}
 		attempts := currentAttempts + 1
-		if int(attempts) >= s.config.Security.MaxLoginAttempts {
+		if int(attempts) >= s.maxLoginAttempts(ctx) {
 			lockUntil := time.Now().Add(s.config.Security.LockoutDuration)
 			s.store.Set(ctx, lockKey, "locked", s.config.Security.LockoutDuration)
 			s.queries.UpdateLoginAttempts(ctx, db.UpdateLoginAttemptsParams{
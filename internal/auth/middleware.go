@@ -85,6 +85,34 @@ func (s *AuthService) OptionalAuthMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// AdminMiddleware restricts a route to users listed in ADMIN_EMAILS. It must
+// run after AuthMiddleware.
+func (s *AuthService) AdminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := GetUserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Authorization header required", http.StatusUnauthorized)
+			return
+		}
+		if !s.IsAdmin(user.Email) {
+			http.Error(w, "Admin access required", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// IsAdmin reports whether email belongs to a configured admin.
+func (s *AuthService) IsAdmin(email string) bool {
+	email = strings.ToLower(email)
+	for _, admin := range s.config.Security.AdminEmails {
+		if admin == email {
+			return true
+		}
+	}
+	return false
+}
+
 func GetUserIDFromContext(ctx context.Context) (uuid.UUID, bool) {
 	userID, ok := ctx.Value(UserIDKey).(uuid.UUID)
 	return userID, ok
@@ -4,15 +4,19 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Security SecurityConfig
-	Redis    RedisConfig
+	Server     ServerConfig
+	Database   DatabaseConfig
+	JWT        JWTConfig
+	Security   SecurityConfig
+	Redis      RedisConfig
+	Staking    StakingConfig
+	Governance GovernanceConfig
+	Liquidity  LiquidityConfig
 }
 
 type ServerConfig struct {
@@ -41,6 +45,7 @@ type JWTConfig struct {
 type SecurityConfig struct {
 	MaxLoginAttempts int
 	LockoutDuration  time.Duration
+	AdminEmails      []string // users allowed to use admin endpoints
 }
 
 type RedisConfig struct {
@@ -50,9 +55,71 @@ type RedisConfig struct {
 	DB       int
 }
 
+// StakingConfig holds the defaults applied to stakes that are not tied to a
+// staking product with its own lifecycle rules.
+type StakingConfig struct {
+	EarlyUnstakePenalty float64 // percent of principal forfeited on early unstake
+	UnbondingPeriod     time.Duration
+	LifecycleInterval   time.Duration
+	SnapshotInterval    time.Duration
+	RewardTolerance     float64 // percent drift allowed between snapshots and claims
+	StatsCacheTTL       time.Duration
+}
+
+// GovernanceConfig holds the eligibility rules and bounds for proposals.
+type GovernanceConfig struct {
+	Token            string  // staked token that carries governance rights
+	MinProposerStake float64 // active stake required to create a proposal
+	MaxOpenProposals int     // pending or active proposals allowed per proposer
+	MinVotingPeriod  time.Duration
+	MaxVotingPeriod  time.Duration
+	MaxStartDelay    time.Duration
+	MinQuorum        float64 // percent
+	MinThreshold     float64 // percent
+	LockWeighting    bool    // weight vote power by stake lock duration
+	ProcessInterval  time.Duration
+	Timelock         time.Duration // delay between passing and executing a proposal
+}
+
+// LiquidityConfig holds the swap parameters of liquidity pools.
+type LiquidityConfig struct {
+	SwapFeeBps         int   // fee tier of new pools unless one is given, in basis points
+	FeeTiers           []int // fee tiers pools may be created with or moved to
+	DefaultSlippageBps int   // tolerance used for quotes' minimum received
+	MaxHops            int   // longest path the swap router considers
+
+	// OracleQuote is the token pool prices are quoted in for PriceSource,
+	// and OracleWindow the default TWAP window.
+	OracleQuote  string
+	OracleWindow time.Duration
+
+	// SnapshotInterval is how often pools are checked for a missing
+	// hourly analytics snapshot.
+	SnapshotInterval time.Duration
+}
+
 func Load() (*Config, error) {
 	readTimeout, _ := strconv.Atoi(getEnv("SERVER_READ_TIMEOUT", "10"))
 	writeTimeout, _ := strconv.Atoi(getEnv("SERVER_WRITE_TIMEOUT", "10"))
+	earlyPenalty, _ := strconv.ParseFloat(getEnv("STAKING_EARLY_UNSTAKE_PENALTY", "10"), 64)
+	unbondingDays, _ := strconv.Atoi(getEnv("STAKING_UNBONDING_DAYS", "7"))
+	rewardTolerance, _ := strconv.ParseFloat(getEnv("STAKING_REWARD_TOLERANCE_PERCENT", "1"), 64)
+	statsCacheSeconds, _ := strconv.Atoi(getEnv("STAKING_STATS_CACHE_SECONDS", "30"))
+	minProposerStake, _ := strconv.ParseFloat(getEnv("GOVERNANCE_MIN_PROPOSER_STAKE", "1000"), 64)
+	maxOpenProposals, _ := strconv.Atoi(getEnv("GOVERNANCE_MAX_OPEN_PROPOSALS", "3"))
+	minVotingHours, _ := strconv.Atoi(getEnv("GOVERNANCE_MIN_VOTING_HOURS", "24"))
+	maxVotingDays, _ := strconv.Atoi(getEnv("GOVERNANCE_MAX_VOTING_DAYS", "30"))
+	maxStartDelayDays, _ := strconv.Atoi(getEnv("GOVERNANCE_MAX_START_DELAY_DAYS", "14"))
+	minQuorum, _ := strconv.ParseFloat(getEnv("GOVERNANCE_MIN_QUORUM", "10"), 64)
+	minThreshold, _ := strconv.ParseFloat(getEnv("GOVERNANCE_MIN_THRESHOLD", "50"), 64)
+	lockWeighting, _ := strconv.ParseBool(getEnv("GOVERNANCE_LOCK_WEIGHTING", "true"))
+	timelockHours, _ := strconv.Atoi(getEnv("GOVERNANCE_TIMELOCK_HOURS", "48"))
+	swapFeeBps, _ := strconv.Atoi(getEnv("LIQUIDITY_SWAP_FEE_BPS", "30"))
+	slippageBps, _ := strconv.Atoi(getEnv("LIQUIDITY_DEFAULT_SLIPPAGE_BPS", "50"))
+	maxHops, _ := strconv.Atoi(getEnv("LIQUIDITY_MAX_HOPS", "3"))
+	oracleMinutes, _ := strconv.Atoi(getEnv("LIQUIDITY_ORACLE_WINDOW_MINUTES", "30"))
+	feeTiers := parseInts(strings.Split(getEnv("LIQUIDITY_FEE_TIERS", "5,30,100"), ","))
+	adminEmails := strings.Split(getEnv("ADMIN_EMAILS", ""), ",")
 
 	return &Config{
 		Server: ServerConfig{
@@ -78,6 +145,7 @@ func Load() (*Config, error) {
 		Security: SecurityConfig{
 			MaxLoginAttempts: 5,
 			LockoutDuration:  15 * time.Minute,
+			AdminEmails:      normalizeEmails(adminEmails),
 		},
 		Redis: RedisConfig{
 			Host:     getEnv("REDIS_HOST", "localhost"),
@@ -85,6 +153,36 @@ func Load() (*Config, error) {
 			Password: getEnv("REDIS_PASSWORD", ""),
 			DB:       0,
 		},
+		Staking: StakingConfig{
+			EarlyUnstakePenalty: earlyPenalty,
+			UnbondingPeriod:     time.Duration(unbondingDays) * 24 * time.Hour,
+			LifecycleInterval:   getEnvInterval("STAKING_LIFECYCLE_INTERVAL_MINUTES", 5, time.Minute),
+			SnapshotInterval:    getEnvInterval("STAKING_SNAPSHOT_INTERVAL_MINUTES", 60, time.Minute),
+			RewardTolerance:     rewardTolerance,
+			StatsCacheTTL:       time.Duration(statsCacheSeconds) * time.Second,
+		},
+		Governance: GovernanceConfig{
+			Token:            getEnv("GOVERNANCE_TOKEN", "AOG"),
+			MinProposerStake: minProposerStake,
+			MaxOpenProposals: maxOpenProposals,
+			MinVotingPeriod:  time.Duration(minVotingHours) * time.Hour,
+			MaxVotingPeriod:  time.Duration(maxVotingDays) * 24 * time.Hour,
+			MaxStartDelay:    time.Duration(maxStartDelayDays) * 24 * time.Hour,
+			MinQuorum:        minQuorum,
+			MinThreshold:     minThreshold,
+			LockWeighting:    lockWeighting,
+			ProcessInterval:  getEnvInterval("GOVERNANCE_PROCESS_INTERVAL_SECONDS", 60, time.Second),
+			Timelock:         time.Duration(timelockHours) * time.Hour,
+		},
+		Liquidity: LiquidityConfig{
+			SwapFeeBps:         swapFeeBps,
+			FeeTiers:           feeTiers,
+			DefaultSlippageBps: slippageBps,
+			MaxHops:            maxHops,
+			OracleQuote:        getEnv("LIQUIDITY_ORACLE_QUOTE", "USDT"),
+			OracleWindow:       time.Duration(oracleMinutes) * time.Minute,
+			SnapshotInterval:   getEnvInterval("LIQUIDITY_SNAPSHOT_INTERVAL_MINUTES", 15, time.Minute),
+		},
 	}, nil
 }
 
@@ -94,3 +192,36 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvInterval reads a job interval as a count of unit. Values that are
+// not positive integers fall back to defaultValue, since the scheduler
+// cannot tick on them.
+func getEnvInterval(key string, defaultValue int, unit time.Duration) time.Duration {
+	n, err := strconv.Atoi(getEnv(key, ""))
+	if err != nil || n <= 0 {
+		n = defaultValue
+	}
+	return time.Duration(n) * unit
+}
+
+// parseInts parses a list of integers, skipping entries that are not.
+func parseInts(in []string) []int {
+	out := make([]int, 0, len(in))
+	for _, v := range in {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// normalizeEmails trims, lowercases and drops empty entries.
+func normalizeEmails(in []string) []string {
+	out := make([]string, 0, len(in))
+	for _, e := range in {
+		if e = strings.ToLower(strings.TrimSpace(e)); e != "" {
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -32,6 +32,44 @@ func (q *Queries) GetAssetMetrics(ctx context.Context) (GetAssetMetricsRow, erro
 	return i, err
 }
 
+const getAssetTVLByToken = `-- name: GetAssetTVLByToken :many
+SELECT t.symbol::text AS symbol,
+    COALESCE(SUM(a.total_value_locked), 0)::decimal AS total_value_locked,
+    SUM(a.total_value_locked / NULLIF(a.market_price, 0))::decimal AS locked_amount
+FROM assets a
+JOIN tokens t ON t.id = a.token_id
+GROUP BY t.symbol
+ORDER BY t.symbol
+`
+
+type GetAssetTVLByTokenRow struct {
+	Symbol           string         `json:"symbol"`
+	TotalValueLocked pgtype.Numeric `json:"total_value_locked"`
+	LockedAmount     pgtype.Numeric `json:"locked_amount"`
+}
+
+// Each token's stored TVL and the amount of it that TVL represents at the
+// stored market price, null where there is no price to divide by.
+func (q *Queries) GetAssetTVLByToken(ctx context.Context) ([]GetAssetTVLByTokenRow, error) {
+	rows, err := q.db.Query(ctx, getAssetTVLByToken)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetAssetTVLByTokenRow{}
+	for rows.Next() {
+		var i GetAssetTVLByTokenRow
+		if err := rows.Scan(&i.Symbol, &i.TotalValueLocked, &i.LockedAmount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getTokenList = `-- name: GetTokenList :many
 SELECT t.id, t.symbol, t.name, t.contract_address, t.decimals, t.is_active, t.created_at, a.market_price, a.price_change_24h
 FROM tokens t
@@ -82,6 +120,23 @@ func (q *Queries) GetTokenList(ctx context.Context) ([]GetTokenListRow, error) {
 	return items, nil
 }
 
+const setTokenActive = `-- name: SetTokenActive :execrows
+UPDATE tokens SET is_active = $2 WHERE symbol = $1
+`
+
+type SetTokenActiveParams struct {
+	Symbol   string      `json:"symbol"`
+	IsActive pgtype.Bool `json:"is_active"`
+}
+
+func (q *Queries) SetTokenActive(ctx context.Context, arg SetTokenActiveParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setTokenActive, arg.Symbol, arg.IsActive)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const updateAssetPrice = `-- name: UpdateAssetPrice :exec
 INSERT INTO assets (token_id, market_price, price_change_24h, volume_24h, recorded_at)
 VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
@@ -110,3 +165,39 @@ func (q *Queries) UpdateAssetPrice(ctx context.Context, arg UpdateAssetPricePara
 	)
 	return err
 }
+
+const upsertToken = `-- name: UpsertToken :one
+INSERT INTO tokens (symbol, name, contract_address, decimals, is_active)
+VALUES ($1, $2, $3, $4, true)
+ON CONFLICT (symbol)
+DO UPDATE SET name = EXCLUDED.name, contract_address = EXCLUDED.contract_address,
+    decimals = EXCLUDED.decimals, is_active = true
+RETURNING id, symbol, name, contract_address, decimals, is_active, created_at
+`
+
+type UpsertTokenParams struct {
+	Symbol          string      `json:"symbol"`
+	Name            string      `json:"name"`
+	ContractAddress pgtype.Text `json:"contract_address"`
+	Decimals        pgtype.Int4 `json:"decimals"`
+}
+
+func (q *Queries) UpsertToken(ctx context.Context, arg UpsertTokenParams) (Token, error) {
+	row := q.db.QueryRow(ctx, upsertToken,
+		arg.Symbol,
+		arg.Name,
+		arg.ContractAddress,
+		arg.Decimals,
+	)
+	var i Token
+	err := row.Scan(
+		&i.ID,
+		&i.Symbol,
+		&i.Name,
+		&i.ContractAddress,
+		&i.Decimals,
+		&i.IsActive,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jd7008911/aogeri-api/internal/config"
 )
@@ -56,3 +57,18 @@ func NewDatabase(cfg *config.DatabaseConfig) (*Database, error) {
 func (db *Database) Close() {
 	db.Pool.Close()
 }
+
+// ExecTx runs fn with a Queries bound to a single transaction. The
+// transaction is committed when fn returns nil and rolled back otherwise.
+func (db *Database) ExecTx(ctx context.Context, fn func(*Queries) error) error {
+	tx, err := db.Pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(db.Queries.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -11,12 +11,116 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const activateProposal = `-- name: ActivateProposal :one
+UPDATE governance_proposals
+SET status = 'active',
+    snapshot_at = CURRENT_TIMESTAMP,
+    eligible_power = (SELECT COALESCE(SUM(g.vote_power), 0) FROM governance_snapshots g WHERE g.proposal_id = $1),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'pending'
+RETURNING id, title, description, proposer_id, proposal_type, status, voting_start, voting_end, quorum, threshold, for_votes, against_votes, abstain_votes, total_votes, created_at, updated_at, snapshot_at, eligible_power, finalized_at, result, payload, executable_at, executed_at, execution_error, cancelled_at, cancelled_by, discussion_locked_at, discussion_locked_by, voting_method, options, option_votes, ballot_root, ballot_count
+`
+
+func (q *Queries) ActivateProposal(ctx context.Context, proposalID pgtype.UUID) (GovernanceProposal, error) {
+	row := q.db.QueryRow(ctx, activateProposal, proposalID)
+	var i GovernanceProposal
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Description,
+		&i.ProposerID,
+		&i.ProposalType,
+		&i.Status,
+		&i.VotingStart,
+		&i.VotingEnd,
+		&i.Quorum,
+		&i.Threshold,
+		&i.ForVotes,
+		&i.AgainstVotes,
+		&i.AbstainVotes,
+		&i.TotalVotes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SnapshotAt,
+		&i.EligiblePower,
+		&i.FinalizedAt,
+		&i.Result,
+		&i.Payload,
+		&i.ExecutableAt,
+		&i.ExecutedAt,
+		&i.ExecutionError,
+		&i.CancelledAt,
+		&i.CancelledBy,
+		&i.DiscussionLockedAt,
+		&i.DiscussionLockedBy,
+		&i.VotingMethod,
+		&i.Options,
+		&i.OptionVotes,
+		&i.BallotRoot,
+		&i.BallotCount,
+	)
+	return i, err
+}
+
+const cancelProposal = `-- name: CancelProposal :one
+UPDATE governance_proposals
+SET status = 'cancelled', cancelled_at = CURRENT_TIMESTAMP, cancelled_by = $2, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'passed' AND executable_at > CURRENT_TIMESTAMP
+RETURNING id, title, description, proposer_id, proposal_type, status, voting_start, voting_end, quorum, threshold, for_votes, against_votes, abstain_votes, total_votes, created_at, updated_at, snapshot_at, eligible_power, finalized_at, result, payload, executable_at, executed_at, execution_error, cancelled_at, cancelled_by, discussion_locked_at, discussion_locked_by, voting_method, options, option_votes, ballot_root, ballot_count
+`
+
+type CancelProposalParams struct {
+	ID          pgtype.UUID `json:"id"`
+	CancelledBy pgtype.UUID `json:"cancelled_by"`
+}
+
+func (q *Queries) CancelProposal(ctx context.Context, arg CancelProposalParams) (GovernanceProposal, error) {
+	row := q.db.QueryRow(ctx, cancelProposal, arg.ID, arg.CancelledBy)
+	var i GovernanceProposal
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Description,
+		&i.ProposerID,
+		&i.ProposalType,
+		&i.Status,
+		&i.VotingStart,
+		&i.VotingEnd,
+		&i.Quorum,
+		&i.Threshold,
+		&i.ForVotes,
+		&i.AgainstVotes,
+		&i.AbstainVotes,
+		&i.TotalVotes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SnapshotAt,
+		&i.EligiblePower,
+		&i.FinalizedAt,
+		&i.Result,
+		&i.Payload,
+		&i.ExecutableAt,
+		&i.ExecutedAt,
+		&i.ExecutionError,
+		&i.CancelledAt,
+		&i.CancelledBy,
+		&i.DiscussionLockedAt,
+		&i.DiscussionLockedBy,
+		&i.VotingMethod,
+		&i.Options,
+		&i.OptionVotes,
+		&i.BallotRoot,
+		&i.BallotCount,
+	)
+	return i, err
+}
+
 const castVote = `-- name: CastVote :one
-INSERT INTO user_votes (user_id, proposal_id, vote_power, vote_choice)
-VALUES ($1, $2, $3, $4)
+INSERT INTO user_votes (user_id, proposal_id, vote_power, vote_choice, ballot)
+VALUES ($1, $2, $3, $4, $5)
 ON CONFLICT (user_id, proposal_id) 
-DO UPDATE SET vote_power = EXCLUDED.vote_power, vote_choice = EXCLUDED.voice_choice
-RETURNING id, user_id, proposal_id, vote_power, vote_choice, voted_at
+DO UPDATE SET vote_power = EXCLUDED.vote_power, vote_choice = EXCLUDED.vote_choice, ballot = EXCLUDED.ballot, voted_at = CURRENT_TIMESTAMP
+RETURNING id, user_id, proposal_id, vote_power, vote_choice, voted_at, ballot
 `
 
 type CastVoteParams struct {
@@ -24,6 +128,7 @@ type CastVoteParams struct {
 	ProposalID pgtype.UUID    `json:"proposal_id"`
 	VotePower  pgtype.Numeric `json:"vote_power"`
 	VoteChoice string         `json:"vote_choice"`
+	Ballot     []byte         `json:"ballot"`
 }
 
 func (q *Queries) CastVote(ctx context.Context, arg CastVoteParams) (UserVote, error) {
@@ -32,6 +137,7 @@ func (q *Queries) CastVote(ctx context.Context, arg CastVoteParams) (UserVote, e
 		arg.ProposalID,
 		arg.VotePower,
 		arg.VoteChoice,
+		arg.Ballot,
 	)
 	var i UserVote
 	err := row.Scan(
@@ -41,16 +147,76 @@ func (q *Queries) CastVote(ctx context.Context, arg CastVoteParams) (UserVote, e
 		&i.VotePower,
 		&i.VoteChoice,
 		&i.VotedAt,
+		&i.Ballot,
 	)
 	return i, err
 }
 
+const countOpenProposalsByProposer = `-- name: CountOpenProposalsByProposer :one
+SELECT COUNT(*) FROM governance_proposals
+WHERE proposer_id = $1 AND status IN ('pending', 'active')
+`
+
+func (q *Queries) CountOpenProposalsByProposer(ctx context.Context, proposerID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countOpenProposalsByProposer, proposerID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countProposals = `-- name: CountProposals :one
+SELECT COUNT(*) FROM governance_proposals
+WHERE ($1::text IS NULL OR status = $1::text)
+  AND ($2::text IS NULL OR proposal_type = $2::text)
+`
+
+type CountProposalsParams struct {
+	Status       pgtype.Text `json:"status"`
+	ProposalType pgtype.Text `json:"proposal_type"`
+}
+
+func (q *Queries) CountProposals(ctx context.Context, arg CountProposalsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countProposals, arg.Status, arg.ProposalType)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countSnapshotDelegators = `-- name: CountSnapshotDelegators :one
+SELECT COUNT(*) FROM governance_snapshots
+WHERE proposal_id = $1 AND delegate_id = $2
+`
+
+type CountSnapshotDelegatorsParams struct {
+	ProposalID pgtype.UUID `json:"proposal_id"`
+	DelegateID pgtype.UUID `json:"delegate_id"`
+}
+
+func (q *Queries) CountSnapshotDelegators(ctx context.Context, arg CountSnapshotDelegatorsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countSnapshotDelegators, arg.ProposalID, arg.DelegateID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUserVotes = `-- name: CountUserVotes :one
+SELECT COUNT(*) FROM user_votes WHERE user_id = $1
+`
+
+func (q *Queries) CountUserVotes(ctx context.Context, userID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countUserVotes, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createProposal = `-- name: CreateProposal :one
 INSERT INTO governance_proposals (
     title, description, proposer_id, proposal_type, 
-    voting_end, quorum, threshold
-) VALUES ($1, $2, $3, $4, $5, $6, $7)
-RETURNING id, title, description, proposer_id, proposal_type, status, voting_start, voting_end, quorum, threshold, for_votes, against_votes, abstain_votes, total_votes, created_at, updated_at
+    voting_end, quorum, threshold, voting_start, status, payload,
+    voting_method, options
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+RETURNING id, title, description, proposer_id, proposal_type, status, voting_start, voting_end, quorum, threshold, for_votes, against_votes, abstain_votes, total_votes, created_at, updated_at, snapshot_at, eligible_power, finalized_at, result, payload, executable_at, executed_at, execution_error, cancelled_at, cancelled_by, discussion_locked_at, discussion_locked_by, voting_method, options, option_votes, ballot_root, ballot_count
 `
 
 type CreateProposalParams struct {
@@ -61,6 +227,11 @@ type CreateProposalParams struct {
 	VotingEnd    pgtype.Timestamp `json:"voting_end"`
 	Quorum       pgtype.Numeric   `json:"quorum"`
 	Threshold    pgtype.Numeric   `json:"threshold"`
+	VotingStart  pgtype.Timestamp `json:"voting_start"`
+	Status       pgtype.Text      `json:"status"`
+	Payload      []byte           `json:"payload"`
+	VotingMethod string           `json:"voting_method"`
+	Options      []byte           `json:"options"`
 }
 
 // internal/db/queries/governance.sql
@@ -73,6 +244,105 @@ func (q *Queries) CreateProposal(ctx context.Context, arg CreateProposalParams)
 		arg.VotingEnd,
 		arg.Quorum,
 		arg.Threshold,
+		arg.VotingStart,
+		arg.Status,
+		arg.Payload,
+		arg.VotingMethod,
+		arg.Options,
+	)
+	var i GovernanceProposal
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Description,
+		&i.ProposerID,
+		&i.ProposalType,
+		&i.Status,
+		&i.VotingStart,
+		&i.VotingEnd,
+		&i.Quorum,
+		&i.Threshold,
+		&i.ForVotes,
+		&i.AgainstVotes,
+		&i.AbstainVotes,
+		&i.TotalVotes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SnapshotAt,
+		&i.EligiblePower,
+		&i.FinalizedAt,
+		&i.Result,
+		&i.Payload,
+		&i.ExecutableAt,
+		&i.ExecutedAt,
+		&i.ExecutionError,
+		&i.CancelledAt,
+		&i.CancelledBy,
+		&i.DiscussionLockedAt,
+		&i.DiscussionLockedBy,
+		&i.VotingMethod,
+		&i.Options,
+		&i.OptionVotes,
+		&i.BallotRoot,
+		&i.BallotCount,
+	)
+	return i, err
+}
+
+const createVoteSnapshot = `-- name: CreateVoteSnapshot :execrows
+INSERT INTO governance_snapshots (proposal_id, user_id, staked_amount, vote_power)
+SELECT $1::uuid, s.user_id, SUM(s.amount)::decimal,
+    SUM(s.amount * CASE
+        WHEN $2::bool THEN
+            1 + LEAST(GREATEST(EXTRACT(EPOCH FROM (s.end_date - s.start_date)) / 86400, 0), 365) / 365
+        ELSE 1
+    END)::decimal
+FROM stakes s
+JOIN tokens t ON s.token_id = t.id
+WHERE t.symbol = $3::text AND s.status = 'active' AND s.user_id IS NOT NULL
+GROUP BY s.user_id
+ON CONFLICT (proposal_id, user_id) DO NOTHING
+`
+
+type CreateVoteSnapshotParams struct {
+	ProposalID    pgtype.UUID `json:"proposal_id"`
+	LockWeighting bool        `json:"lock_weighting"`
+	Symbol        string      `json:"symbol"`
+}
+
+func (q *Queries) CreateVoteSnapshot(ctx context.Context, arg CreateVoteSnapshotParams) (int64, error) {
+	result, err := q.db.Exec(ctx, createVoteSnapshot, arg.ProposalID, arg.LockWeighting, arg.Symbol)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const finalizeProposal = `-- name: FinalizeProposal :one
+UPDATE governance_proposals
+SET status = $2, result = $3, executable_at = $4, ballot_root = $5, ballot_count = $6,
+    finalized_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'active'
+RETURNING id, title, description, proposer_id, proposal_type, status, voting_start, voting_end, quorum, threshold, for_votes, against_votes, abstain_votes, total_votes, created_at, updated_at, snapshot_at, eligible_power, finalized_at, result, payload, executable_at, executed_at, execution_error, cancelled_at, cancelled_by, discussion_locked_at, discussion_locked_by, voting_method, options, option_votes, ballot_root, ballot_count
+`
+
+type FinalizeProposalParams struct {
+	ID           pgtype.UUID      `json:"id"`
+	Status       pgtype.Text      `json:"status"`
+	Result       []byte           `json:"result"`
+	ExecutableAt pgtype.Timestamp `json:"executable_at"`
+	BallotRoot   pgtype.Text      `json:"ballot_root"`
+	BallotCount  pgtype.Int4      `json:"ballot_count"`
+}
+
+func (q *Queries) FinalizeProposal(ctx context.Context, arg FinalizeProposalParams) (GovernanceProposal, error) {
+	row := q.db.QueryRow(ctx, finalizeProposal,
+		arg.ID,
+		arg.Status,
+		arg.Result,
+		arg.ExecutableAt,
+		arg.BallotRoot,
+		arg.BallotCount,
 	)
 	var i GovernanceProposal
 	err := row.Scan(
@@ -92,12 +362,29 @@ func (q *Queries) CreateProposal(ctx context.Context, arg CreateProposalParams)
 		&i.TotalVotes,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SnapshotAt,
+		&i.EligiblePower,
+		&i.FinalizedAt,
+		&i.Result,
+		&i.Payload,
+		&i.ExecutableAt,
+		&i.ExecutedAt,
+		&i.ExecutionError,
+		&i.CancelledAt,
+		&i.CancelledBy,
+		&i.DiscussionLockedAt,
+		&i.DiscussionLockedBy,
+		&i.VotingMethod,
+		&i.Options,
+		&i.OptionVotes,
+		&i.BallotRoot,
+		&i.BallotCount,
 	)
 	return i, err
 }
 
 const getActiveProposals = `-- name: GetActiveProposals :many
-SELECT id, title, description, proposer_id, proposal_type, status, voting_start, voting_end, quorum, threshold, for_votes, against_votes, abstain_votes, total_votes, created_at, updated_at FROM governance_proposals 
+SELECT id, title, description, proposer_id, proposal_type, status, voting_start, voting_end, quorum, threshold, for_votes, against_votes, abstain_votes, total_votes, created_at, updated_at, snapshot_at, eligible_power, finalized_at, result, payload, executable_at, executed_at, execution_error, cancelled_at, cancelled_by, discussion_locked_at, discussion_locked_by, voting_method, options, option_votes, ballot_root, ballot_count FROM governance_proposals 
 WHERE status = 'active' AND voting_end > CURRENT_TIMESTAMP
 ORDER BY created_at DESC
 `
@@ -128,6 +415,23 @@ func (q *Queries) GetActiveProposals(ctx context.Context) ([]GovernanceProposal,
 			&i.TotalVotes,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.SnapshotAt,
+			&i.EligiblePower,
+			&i.FinalizedAt,
+			&i.Result,
+			&i.Payload,
+			&i.ExecutableAt,
+			&i.ExecutedAt,
+			&i.ExecutionError,
+			&i.CancelledAt,
+			&i.CancelledBy,
+			&i.DiscussionLockedAt,
+			&i.DiscussionLockedBy,
+			&i.VotingMethod,
+			&i.Options,
+			&i.OptionVotes,
+			&i.BallotRoot,
+			&i.BallotCount,
 		); err != nil {
 			return nil, err
 		}
@@ -139,8 +443,58 @@ func (q *Queries) GetActiveProposals(ctx context.Context) ([]GovernanceProposal,
 	return items, nil
 }
 
+const getDelegatedVotePower = `-- name: GetDelegatedVotePower :one
+WITH RECURSIVE chain AS (
+    SELECT s.user_id AS holder, s.user_id AS receiver, s.vote_power, 0 AS depth
+    FROM governance_snapshots s
+    WHERE s.proposal_id = $1::uuid
+    UNION ALL
+    SELECT c.holder, s.delegate_id, c.vote_power, c.depth + 1
+    FROM chain c
+    JOIN governance_snapshots s ON s.proposal_id = $1::uuid AND s.user_id = c.receiver
+    WHERE s.delegate_id IS NOT NULL AND c.depth < 32
+      AND NOT EXISTS (SELECT 1 FROM user_votes v WHERE v.proposal_id = $1::uuid AND v.user_id = c.receiver)
+)
+SELECT COALESCE(SUM(c.vote_power), 0)::decimal AS delegated_power
+FROM chain c
+WHERE c.receiver = $2::uuid AND c.holder <> $2::uuid
+`
+
+type GetDelegatedVotePowerParams struct {
+	ProposalID pgtype.UUID `json:"proposal_id"`
+	UserID     pgtype.UUID `json:"user_id"`
+}
+
+func (q *Queries) GetDelegatedVotePower(ctx context.Context, arg GetDelegatedVotePowerParams) (pgtype.Numeric, error) {
+	row := q.db.QueryRow(ctx, getDelegatedVotePower, arg.ProposalID, arg.UserID)
+	var delegated_power pgtype.Numeric
+	err := row.Scan(&delegated_power)
+	return delegated_power, err
+}
+
+const getParticipationSummary = `-- name: GetParticipationSummary :one
+SELECT COUNT(*)::bigint AS proposals,
+    COALESCE(AVG(CASE WHEN p.eligible_power > 0 THEN p.total_votes / p.eligible_power * 100 END), 0)::decimal AS average_turnout,
+    (SELECT COUNT(DISTINCT v.user_id) FROM user_votes v)::bigint AS unique_voters
+FROM governance_proposals p
+WHERE p.finalized_at IS NOT NULL
+`
+
+type GetParticipationSummaryRow struct {
+	Proposals      int64          `json:"proposals"`
+	AverageTurnout pgtype.Numeric `json:"average_turnout"`
+	UniqueVoters   int64          `json:"unique_voters"`
+}
+
+func (q *Queries) GetParticipationSummary(ctx context.Context) (GetParticipationSummaryRow, error) {
+	row := q.db.QueryRow(ctx, getParticipationSummary)
+	var i GetParticipationSummaryRow
+	err := row.Scan(&i.Proposals, &i.AverageTurnout, &i.UniqueVoters)
+	return i, err
+}
+
 const getProposalByID = `-- name: GetProposalByID :one
-SELECT id, title, description, proposer_id, proposal_type, status, voting_start, voting_end, quorum, threshold, for_votes, against_votes, abstain_votes, total_votes, created_at, updated_at FROM governance_proposals WHERE id = $1
+SELECT id, title, description, proposer_id, proposal_type, status, voting_start, voting_end, quorum, threshold, for_votes, against_votes, abstain_votes, total_votes, created_at, updated_at, snapshot_at, eligible_power, finalized_at, result, payload, executable_at, executed_at, execution_error, cancelled_at, cancelled_by, discussion_locked_at, discussion_locked_by, voting_method, options, option_votes, ballot_root, ballot_count FROM governance_proposals WHERE id = $1
 `
 
 func (q *Queries) GetProposalByID(ctx context.Context, id pgtype.UUID) (GovernanceProposal, error) {
@@ -163,29 +517,268 @@ func (q *Queries) GetProposalByID(ctx context.Context, id pgtype.UUID) (Governan
 		&i.TotalVotes,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SnapshotAt,
+		&i.EligiblePower,
+		&i.FinalizedAt,
+		&i.Result,
+		&i.Payload,
+		&i.ExecutableAt,
+		&i.ExecutedAt,
+		&i.ExecutionError,
+		&i.CancelledAt,
+		&i.CancelledBy,
+		&i.DiscussionLockedAt,
+		&i.DiscussionLockedBy,
+		&i.VotingMethod,
+		&i.Options,
+		&i.OptionVotes,
+		&i.BallotRoot,
+		&i.BallotCount,
+	)
+	return i, err
+}
+
+const getProposalForUpdate = `-- name: GetProposalForUpdate :one
+SELECT id, title, description, proposer_id, proposal_type, status, voting_start, voting_end, quorum, threshold, for_votes, against_votes, abstain_votes, total_votes, created_at, updated_at, snapshot_at, eligible_power, finalized_at, result, payload, executable_at, executed_at, execution_error, cancelled_at, cancelled_by, discussion_locked_at, discussion_locked_by, voting_method, options, option_votes, ballot_root, ballot_count FROM governance_proposals WHERE id = $1 FOR UPDATE
+`
+
+func (q *Queries) GetProposalForUpdate(ctx context.Context, id pgtype.UUID) (GovernanceProposal, error) {
+	row := q.db.QueryRow(ctx, getProposalForUpdate, id)
+	var i GovernanceProposal
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Description,
+		&i.ProposerID,
+		&i.ProposalType,
+		&i.Status,
+		&i.VotingStart,
+		&i.VotingEnd,
+		&i.Quorum,
+		&i.Threshold,
+		&i.ForVotes,
+		&i.AgainstVotes,
+		&i.AbstainVotes,
+		&i.TotalVotes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SnapshotAt,
+		&i.EligiblePower,
+		&i.FinalizedAt,
+		&i.Result,
+		&i.Payload,
+		&i.ExecutableAt,
+		&i.ExecutedAt,
+		&i.ExecutionError,
+		&i.CancelledAt,
+		&i.CancelledBy,
+		&i.DiscussionLockedAt,
+		&i.DiscussionLockedBy,
+		&i.VotingMethod,
+		&i.Options,
+		&i.OptionVotes,
+		&i.BallotRoot,
+		&i.BallotCount,
 	)
 	return i, err
 }
 
+const getUserStakedBalance = `-- name: GetUserStakedBalance :one
+SELECT COALESCE(SUM(s.amount), 0)::decimal AS staked
+FROM stakes s
+JOIN tokens t ON s.token_id = t.id
+WHERE s.user_id = $1 AND t.symbol = $2 AND s.status = 'active'
+`
+
+type GetUserStakedBalanceParams struct {
+	UserID pgtype.UUID `json:"user_id"`
+	Symbol string      `json:"symbol"`
+}
+
+func (q *Queries) GetUserStakedBalance(ctx context.Context, arg GetUserStakedBalanceParams) (pgtype.Numeric, error) {
+	row := q.db.QueryRow(ctx, getUserStakedBalance, arg.UserID, arg.Symbol)
+	var staked pgtype.Numeric
+	err := row.Scan(&staked)
+	return staked, err
+}
+
 const getUserVotes = `-- name: GetUserVotes :many
-SELECT id, user_id, proposal_id, vote_power, vote_choice, voted_at FROM user_votes WHERE user_id = $1
+SELECT v.proposal_id::uuid AS proposal_id, p.title::text AS title, COALESCE(p.status, '')::text AS status,
+    p.voting_method::text AS voting_method, v.vote_choice::text AS vote_choice, v.ballot::jsonb AS ballot,
+    v.vote_power::decimal AS vote_power, v.voted_at::timestamp AS voted_at, p.result::jsonb AS result
+FROM user_votes v
+JOIN governance_proposals p ON p.id = v.proposal_id
+WHERE v.user_id = $1::uuid
+ORDER BY v.voted_at DESC, v.proposal_id
+LIMIT $2::int OFFSET $3::int
 `
 
-func (q *Queries) GetUserVotes(ctx context.Context, userID pgtype.UUID) ([]UserVote, error) {
-	rows, err := q.db.Query(ctx, getUserVotes, userID)
+type GetUserVotesParams struct {
+	UserID     pgtype.UUID `json:"user_id"`
+	PageLimit  int32       `json:"page_limit"`
+	PageOffset int32       `json:"page_offset"`
+}
+
+type GetUserVotesRow struct {
+	ProposalID   pgtype.UUID      `json:"proposal_id"`
+	Title        string           `json:"title"`
+	Status       string           `json:"status"`
+	VotingMethod string           `json:"voting_method"`
+	VoteChoice   string           `json:"vote_choice"`
+	Ballot       []byte           `json:"ballot"`
+	VotePower    pgtype.Numeric   `json:"vote_power"`
+	VotedAt      pgtype.Timestamp `json:"voted_at"`
+	Result       []byte           `json:"result"`
+}
+
+// A user's votes, newest first, with the state of each proposal.
+func (q *Queries) GetUserVotes(ctx context.Context, arg GetUserVotesParams) ([]GetUserVotesRow, error) {
+	rows, err := q.db.Query(ctx, getUserVotes, arg.UserID, arg.PageLimit, arg.PageOffset)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []UserVote{}
+	items := []GetUserVotesRow{}
 	for rows.Next() {
-		var i UserVote
+		var i GetUserVotesRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.UserID,
 			&i.ProposalID,
+			&i.Title,
+			&i.Status,
+			&i.VotingMethod,
+			&i.VoteChoice,
+			&i.Ballot,
 			&i.VotePower,
+			&i.VotedAt,
+			&i.Result,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getVoteSnapshot = `-- name: GetVoteSnapshot :one
+SELECT proposal_id, user_id, staked_amount, vote_power, created_at, delegate_id FROM governance_snapshots
+WHERE proposal_id = $1 AND user_id = $2
+`
+
+type GetVoteSnapshotParams struct {
+	ProposalID pgtype.UUID `json:"proposal_id"`
+	UserID     pgtype.UUID `json:"user_id"`
+}
+
+func (q *Queries) GetVoteSnapshot(ctx context.Context, arg GetVoteSnapshotParams) (GovernanceSnapshot, error) {
+	row := q.db.QueryRow(ctx, getVoteSnapshot, arg.ProposalID, arg.UserID)
+	var i GovernanceSnapshot
+	err := row.Scan(
+		&i.ProposalID,
+		&i.UserID,
+		&i.StakedAmount,
+		&i.VotePower,
+		&i.CreatedAt,
+		&i.DelegateID,
+	)
+	return i, err
+}
+
+const listEffectiveVotes = `-- name: ListEffectiveVotes :many
+WITH RECURSIVE chain AS (
+    SELECT s.user_id AS holder, s.user_id AS receiver, s.vote_power, 0 AS depth
+    FROM governance_snapshots s
+    WHERE s.proposal_id = $1::uuid
+    UNION ALL
+    SELECT c.holder, s.delegate_id, c.vote_power, c.depth + 1
+    FROM chain c
+    JOIN governance_snapshots s ON s.proposal_id = $1::uuid AND s.user_id = c.receiver
+    WHERE s.delegate_id IS NOT NULL AND c.depth < 32
+      AND NOT EXISTS (SELECT 1 FROM user_votes v WHERE v.proposal_id = $1::uuid AND v.user_id = c.receiver)
+)
+SELECT v.user_id::uuid AS user_id, v.vote_choice::text AS vote_choice, v.ballot::jsonb AS ballot,
+    SUM(c.vote_power)::decimal AS vote_power,
+    SUM(SQRT(c.vote_power))::decimal AS root_power
+FROM chain c
+JOIN user_votes v ON v.proposal_id = $1::uuid AND v.user_id = c.receiver
+GROUP BY v.user_id, v.vote_choice, v.ballot
+ORDER BY v.user_id
+`
+
+type ListEffectiveVotesRow struct {
+	UserID     pgtype.UUID    `json:"user_id"`
+	VoteChoice string         `json:"vote_choice"`
+	Ballot     []byte         `json:"ballot"`
+	VotePower  pgtype.Numeric `json:"vote_power"`
+	RootPower  pgtype.Numeric `json:"root_power"`
+}
+
+// Each direct voter with the snapshotted power that reaches them: their own
+// plus that of holders whose delegation chain ends at them. root_power sums
+// the square root of each of those holders' power, for quadratic voting.
+func (q *Queries) ListEffectiveVotes(ctx context.Context, proposalID pgtype.UUID) ([]ListEffectiveVotesRow, error) {
+	rows, err := q.db.Query(ctx, listEffectiveVotes, proposalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListEffectiveVotesRow{}
+	for rows.Next() {
+		var i ListEffectiveVotesRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.VoteChoice,
+			&i.Ballot,
+			&i.VotePower,
+			&i.RootPower,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProposalBallots = `-- name: ListProposalBallots :many
+SELECT s.user_id::uuid AS voter, s.vote_power::decimal AS snapshot_power, s.delegate_id::uuid AS delegate_id,
+    COALESCE(v.vote_choice, '')::text AS vote_choice, v.ballot::jsonb AS ballot, v.voted_at::timestamp AS voted_at
+FROM governance_snapshots s
+LEFT JOIN user_votes v ON v.proposal_id = s.proposal_id AND v.user_id = s.user_id
+WHERE s.proposal_id = $1
+ORDER BY s.user_id
+`
+
+type ListProposalBallotsRow struct {
+	Voter         pgtype.UUID      `json:"voter"`
+	SnapshotPower pgtype.Numeric   `json:"snapshot_power"`
+	DelegateID    pgtype.UUID      `json:"delegate_id"`
+	VoteChoice    string           `json:"vote_choice"`
+	Ballot        []byte           `json:"ballot"`
+	VotedAt       pgtype.Timestamp `json:"voted_at"`
+}
+
+// Every snapshot holder of a proposal with their delegation and, if they
+// voted directly, their ballot.
+func (q *Queries) ListProposalBallots(ctx context.Context, proposalID pgtype.UUID) ([]ListProposalBallotsRow, error) {
+	rows, err := q.db.Query(ctx, listProposalBallots, proposalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListProposalBallotsRow{}
+	for rows.Next() {
+		var i ListProposalBallotsRow
+		if err := rows.Scan(
+			&i.Voter,
+			&i.SnapshotPower,
+			&i.DelegateID,
 			&i.VoteChoice,
+			&i.Ballot,
 			&i.VotedAt,
 		); err != nil {
 			return nil, err
@@ -198,6 +791,534 @@ func (q *Queries) GetUserVotes(ctx context.Context, userID pgtype.UUID) ([]UserV
 	return items, nil
 }
 
+const listProposalEvents = `-- name: ListProposalEvents :many
+SELECT id, proposal_id, event_type, actor_id, data, created_at FROM proposal_events
+WHERE proposal_id = $1
+ORDER BY created_at, id
+`
+
+func (q *Queries) ListProposalEvents(ctx context.Context, proposalID pgtype.UUID) ([]ProposalEvent, error) {
+	rows, err := q.db.Query(ctx, listProposalEvents, proposalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ProposalEvent{}
+	for rows.Next() {
+		var i ProposalEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProposalID,
+			&i.EventType,
+			&i.ActorID,
+			&i.Data,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProposalParticipation = `-- name: ListProposalParticipation :many
+SELECT p.id::uuid AS id, p.title::text AS title, COALESCE(p.status, '')::text AS status,
+    p.voting_method::text AS voting_method, p.eligible_power::decimal AS eligible_power,
+    COALESCE(p.total_votes, 0)::decimal AS total_votes,
+    (SELECT COUNT(*) FROM user_votes v WHERE v.proposal_id = p.id)::bigint AS voters,
+    (SELECT COUNT(*) FROM governance_snapshots s WHERE s.proposal_id = p.id AND s.vote_power > 0)::bigint AS holders,
+    p.finalized_at::timestamp AS finalized_at
+FROM governance_proposals p
+WHERE p.finalized_at IS NOT NULL
+ORDER BY p.finalized_at DESC
+LIMIT $1::int OFFSET $2::int
+`
+
+type ListProposalParticipationParams struct {
+	PageLimit  int32 `json:"page_limit"`
+	PageOffset int32 `json:"page_offset"`
+}
+
+type ListProposalParticipationRow struct {
+	ID            pgtype.UUID      `json:"id"`
+	Title         string           `json:"title"`
+	Status        string           `json:"status"`
+	VotingMethod  string           `json:"voting_method"`
+	EligiblePower pgtype.Numeric   `json:"eligible_power"`
+	TotalVotes    pgtype.Numeric   `json:"total_votes"`
+	Voters        int64            `json:"voters"`
+	Holders       int64            `json:"holders"`
+	FinalizedAt   pgtype.Timestamp `json:"finalized_at"`
+}
+
+// Finalized proposals, most recently closed first, with their turnout.
+func (q *Queries) ListProposalParticipation(ctx context.Context, arg ListProposalParticipationParams) ([]ListProposalParticipationRow, error) {
+	rows, err := q.db.Query(ctx, listProposalParticipation, arg.PageLimit, arg.PageOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListProposalParticipationRow{}
+	for rows.Next() {
+		var i ListProposalParticipationRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Status,
+			&i.VotingMethod,
+			&i.EligiblePower,
+			&i.TotalVotes,
+			&i.Voters,
+			&i.Holders,
+			&i.FinalizedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProposalTurnout = `-- name: ListProposalTurnout :many
+WITH RECURSIVE chain AS (
+    SELECT s.user_id AS holder, s.user_id AS receiver, s.vote_power, 0 AS depth
+    FROM governance_snapshots s
+    WHERE s.proposal_id = $1::uuid
+    UNION ALL
+    SELECT c.holder, s.delegate_id, c.vote_power, c.depth + 1
+    FROM chain c
+    JOIN governance_snapshots s ON s.proposal_id = $1::uuid AND s.user_id = c.receiver
+    WHERE s.delegate_id IS NOT NULL AND c.depth < 32
+      AND NOT EXISTS (SELECT 1 FROM user_votes v WHERE v.proposal_id = $1::uuid AND v.user_id = c.receiver)
+)
+SELECT date_trunc($2::text, v.voted_at)::timestamp AS bucket,
+    COUNT(DISTINCT v.user_id)::bigint AS voters,
+    SUM(c.vote_power)::decimal AS power
+FROM chain c
+JOIN user_votes v ON v.proposal_id = $1::uuid AND v.user_id = c.receiver
+GROUP BY 1
+ORDER BY 1
+`
+
+type ListProposalTurnoutParams struct {
+	ProposalID pgtype.UUID `json:"proposal_id"`
+	Bucket     string      `json:"bucket"`
+}
+
+type ListProposalTurnoutRow struct {
+	Bucket pgtype.Timestamp `json:"bucket"`
+	Voters int64            `json:"voters"`
+	Power  pgtype.Numeric   `json:"power"`
+}
+
+// Power reaching direct voters per time bucket, delegated power included,
+// counted when the voter it reaches cast their (latest) vote.
+func (q *Queries) ListProposalTurnout(ctx context.Context, arg ListProposalTurnoutParams) ([]ListProposalTurnoutRow, error) {
+	rows, err := q.db.Query(ctx, listProposalTurnout, arg.ProposalID, arg.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListProposalTurnoutRow{}
+	for rows.Next() {
+		var i ListProposalTurnoutRow
+		if err := rows.Scan(&i.Bucket, &i.Voters, &i.Power); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProposals = `-- name: ListProposals :many
+SELECT id, title, description, proposer_id, proposal_type, status, voting_start, voting_end, quorum, threshold, for_votes, against_votes, abstain_votes, total_votes, created_at, updated_at, snapshot_at, eligible_power, finalized_at, result, payload, executable_at, executed_at, execution_error, cancelled_at, cancelled_by, discussion_locked_at, discussion_locked_by, voting_method, options, option_votes, ballot_root, ballot_count FROM governance_proposals
+WHERE ($1::text IS NULL OR status = $1::text)
+  AND ($2::text IS NULL OR proposal_type = $2::text)
+ORDER BY created_at DESC
+LIMIT $3::int OFFSET $4::int
+`
+
+type ListProposalsParams struct {
+	Status       pgtype.Text `json:"status"`
+	ProposalType pgtype.Text `json:"proposal_type"`
+	PageLimit    int32       `json:"page_limit"`
+	PageOffset   int32       `json:"page_offset"`
+}
+
+func (q *Queries) ListProposals(ctx context.Context, arg ListProposalsParams) ([]GovernanceProposal, error) {
+	rows, err := q.db.Query(ctx, listProposals,
+		arg.Status,
+		arg.ProposalType,
+		arg.PageLimit,
+		arg.PageOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GovernanceProposal{}
+	for rows.Next() {
+		var i GovernanceProposal
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Description,
+			&i.ProposerID,
+			&i.ProposalType,
+			&i.Status,
+			&i.VotingStart,
+			&i.VotingEnd,
+			&i.Quorum,
+			&i.Threshold,
+			&i.ForVotes,
+			&i.AgainstVotes,
+			&i.AbstainVotes,
+			&i.TotalVotes,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SnapshotAt,
+			&i.EligiblePower,
+			&i.FinalizedAt,
+			&i.Result,
+			&i.Payload,
+			&i.ExecutableAt,
+			&i.ExecutedAt,
+			&i.ExecutionError,
+			&i.CancelledAt,
+			&i.CancelledBy,
+			&i.DiscussionLockedAt,
+			&i.DiscussionLockedBy,
+			&i.VotingMethod,
+			&i.Options,
+			&i.OptionVotes,
+			&i.BallotRoot,
+			&i.BallotCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProposalsToActivate = `-- name: ListProposalsToActivate :many
+SELECT id FROM governance_proposals
+WHERE status = 'pending' AND voting_start <= CURRENT_TIMESTAMP
+ORDER BY voting_start
+`
+
+func (q *Queries) ListProposalsToActivate(ctx context.Context) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, listProposalsToActivate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []pgtype.UUID{}
+	for rows.Next() {
+		var id pgtype.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProposalsToExecute = `-- name: ListProposalsToExecute :many
+SELECT id FROM governance_proposals
+WHERE status = 'passed' AND executable_at IS NOT NULL AND executable_at <= CURRENT_TIMESTAMP
+ORDER BY executable_at
+`
+
+func (q *Queries) ListProposalsToExecute(ctx context.Context) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, listProposalsToExecute)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []pgtype.UUID{}
+	for rows.Next() {
+		var id pgtype.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProposalsToFinalize = `-- name: ListProposalsToFinalize :many
+SELECT id FROM governance_proposals
+WHERE status = 'active' AND voting_end <= CURRENT_TIMESTAMP
+ORDER BY voting_end
+`
+
+func (q *Queries) ListProposalsToFinalize(ctx context.Context) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, listProposalsToFinalize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []pgtype.UUID{}
+	for rows.Next() {
+		var id pgtype.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTopVoters = `-- name: ListTopVoters :many
+SELECT v.user_id::uuid AS user_id, COALESCE(u.wallet_address, '')::text AS wallet_address,
+    COUNT(*)::bigint AS votes_cast,
+    (SELECT COUNT(*) FROM governance_snapshots s WHERE s.user_id = v.user_id AND s.vote_power > 0)::bigint AS eligible_proposals,
+    SUM(v.vote_power)::decimal AS power_cast,
+    MAX(v.voted_at)::timestamp AS last_voted_at
+FROM user_votes v
+LEFT JOIN users u ON u.id = v.user_id
+GROUP BY v.user_id, u.wallet_address
+ORDER BY votes_cast DESC, power_cast DESC, v.user_id
+LIMIT $1::int OFFSET $2::int
+`
+
+type ListTopVotersParams struct {
+	PageLimit  int32 `json:"page_limit"`
+	PageOffset int32 `json:"page_offset"`
+}
+
+type ListTopVotersRow struct {
+	UserID            pgtype.UUID      `json:"user_id"`
+	WalletAddress     string           `json:"wallet_address"`
+	VotesCast         int64            `json:"votes_cast"`
+	EligibleProposals int64            `json:"eligible_proposals"`
+	PowerCast         pgtype.Numeric   `json:"power_cast"`
+	LastVotedAt       pgtype.Timestamp `json:"last_voted_at"`
+}
+
+// Users by votes cast, with how many snapshots they held power in.
+func (q *Queries) ListTopVoters(ctx context.Context, arg ListTopVotersParams) ([]ListTopVotersRow, error) {
+	rows, err := q.db.Query(ctx, listTopVoters, arg.PageLimit, arg.PageOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTopVotersRow{}
+	for rows.Next() {
+		var i ListTopVotersRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.WalletAddress,
+			&i.VotesCast,
+			&i.EligibleProposals,
+			&i.PowerCast,
+			&i.LastVotedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markProposalExecuted = `-- name: MarkProposalExecuted :one
+UPDATE governance_proposals
+SET status = $2, execution_error = $3, executed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'passed'
+RETURNING id, title, description, proposer_id, proposal_type, status, voting_start, voting_end, quorum, threshold, for_votes, against_votes, abstain_votes, total_votes, created_at, updated_at, snapshot_at, eligible_power, finalized_at, result, payload, executable_at, executed_at, execution_error, cancelled_at, cancelled_by, discussion_locked_at, discussion_locked_by, voting_method, options, option_votes, ballot_root, ballot_count
+`
+
+type MarkProposalExecutedParams struct {
+	ID             pgtype.UUID `json:"id"`
+	Status         pgtype.Text `json:"status"`
+	ExecutionError pgtype.Text `json:"execution_error"`
+}
+
+func (q *Queries) MarkProposalExecuted(ctx context.Context, arg MarkProposalExecutedParams) (GovernanceProposal, error) {
+	row := q.db.QueryRow(ctx, markProposalExecuted, arg.ID, arg.Status, arg.ExecutionError)
+	var i GovernanceProposal
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Description,
+		&i.ProposerID,
+		&i.ProposalType,
+		&i.Status,
+		&i.VotingStart,
+		&i.VotingEnd,
+		&i.Quorum,
+		&i.Threshold,
+		&i.ForVotes,
+		&i.AgainstVotes,
+		&i.AbstainVotes,
+		&i.TotalVotes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SnapshotAt,
+		&i.EligiblePower,
+		&i.FinalizedAt,
+		&i.Result,
+		&i.Payload,
+		&i.ExecutableAt,
+		&i.ExecutedAt,
+		&i.ExecutionError,
+		&i.CancelledAt,
+		&i.CancelledBy,
+		&i.DiscussionLockedAt,
+		&i.DiscussionLockedBy,
+		&i.VotingMethod,
+		&i.Options,
+		&i.OptionVotes,
+		&i.BallotRoot,
+		&i.BallotCount,
+	)
+	return i, err
+}
+
+const recordProposalEvent = `-- name: RecordProposalEvent :exec
+INSERT INTO proposal_events (proposal_id, event_type, actor_id, data)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (proposal_id, event_type) DO NOTHING
+`
+
+type RecordProposalEventParams struct {
+	ProposalID pgtype.UUID `json:"proposal_id"`
+	EventType  string      `json:"event_type"`
+	ActorID    pgtype.UUID `json:"actor_id"`
+	Data       []byte      `json:"data"`
+}
+
+func (q *Queries) RecordProposalEvent(ctx context.Context, arg RecordProposalEventParams) error {
+	_, err := q.db.Exec(ctx, recordProposalEvent,
+		arg.ProposalID,
+		arg.EventType,
+		arg.ActorID,
+		arg.Data,
+	)
+	return err
+}
+
+const setProposalTallies = `-- name: SetProposalTallies :one
+UPDATE governance_proposals
+SET for_votes = $2, against_votes = $3, abstain_votes = $4, total_votes = $5, option_votes = $6, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, title, description, proposer_id, proposal_type, status, voting_start, voting_end, quorum, threshold, for_votes, against_votes, abstain_votes, total_votes, created_at, updated_at, snapshot_at, eligible_power, finalized_at, result, payload, executable_at, executed_at, execution_error, cancelled_at, cancelled_by, discussion_locked_at, discussion_locked_by, voting_method, options, option_votes, ballot_root, ballot_count
+`
+
+type SetProposalTalliesParams struct {
+	ID           pgtype.UUID    `json:"id"`
+	ForVotes     pgtype.Numeric `json:"for_votes"`
+	AgainstVotes pgtype.Numeric `json:"against_votes"`
+	AbstainVotes pgtype.Numeric `json:"abstain_votes"`
+	TotalVotes   pgtype.Numeric `json:"total_votes"`
+	OptionVotes  []byte         `json:"option_votes"`
+}
+
+func (q *Queries) SetProposalTallies(ctx context.Context, arg SetProposalTalliesParams) (GovernanceProposal, error) {
+	row := q.db.QueryRow(ctx, setProposalTallies,
+		arg.ID,
+		arg.ForVotes,
+		arg.AgainstVotes,
+		arg.AbstainVotes,
+		arg.TotalVotes,
+		arg.OptionVotes,
+	)
+	var i GovernanceProposal
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Description,
+		&i.ProposerID,
+		&i.ProposalType,
+		&i.Status,
+		&i.VotingStart,
+		&i.VotingEnd,
+		&i.Quorum,
+		&i.Threshold,
+		&i.ForVotes,
+		&i.AgainstVotes,
+		&i.AbstainVotes,
+		&i.TotalVotes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SnapshotAt,
+		&i.EligiblePower,
+		&i.FinalizedAt,
+		&i.Result,
+		&i.Payload,
+		&i.ExecutableAt,
+		&i.ExecutedAt,
+		&i.ExecutionError,
+		&i.CancelledAt,
+		&i.CancelledBy,
+		&i.DiscussionLockedAt,
+		&i.DiscussionLockedBy,
+		&i.VotingMethod,
+		&i.Options,
+		&i.OptionVotes,
+		&i.BallotRoot,
+		&i.BallotCount,
+	)
+	return i, err
+}
+
+const snapshotDelegates = `-- name: SnapshotDelegates :exec
+INSERT INTO governance_snapshots (proposal_id, user_id, staked_amount, vote_power)
+SELECT DISTINCT $1::uuid, g.delegate_id, 0::decimal, 0::decimal
+FROM governance_snapshots g
+WHERE g.proposal_id = $1::uuid AND g.delegate_id IS NOT NULL
+ON CONFLICT (proposal_id, user_id) DO NOTHING
+`
+
+func (q *Queries) SnapshotDelegates(ctx context.Context, proposalID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, snapshotDelegates, proposalID)
+	return err
+}
+
+const snapshotDelegations = `-- name: SnapshotDelegations :exec
+INSERT INTO governance_snapshots (proposal_id, user_id, staked_amount, vote_power, delegate_id)
+SELECT DISTINCT ON (d.delegator_id) $1::uuid, d.delegator_id, 0::decimal, 0::decimal, d.delegate_id
+FROM vote_delegations d
+WHERE d.token_symbol = $2::text OR d.token_symbol IS NULL
+ORDER BY d.delegator_id, d.token_symbol NULLS LAST
+ON CONFLICT (proposal_id, user_id) DO UPDATE SET delegate_id = EXCLUDED.delegate_id
+`
+
+type SnapshotDelegationsParams struct {
+	ProposalID pgtype.UUID `json:"proposal_id"`
+	Symbol     string      `json:"symbol"`
+}
+
+func (q *Queries) SnapshotDelegations(ctx context.Context, arg SnapshotDelegationsParams) error {
+	_, err := q.db.Exec(ctx, snapshotDelegations, arg.ProposalID, arg.Symbol)
+	return err
+}
+
 const updateProposalVotes = `-- name: UpdateProposalVotes :exec
 UPDATE governance_proposals 
 SET 
@@ -0,0 +1,11 @@
+-- internal/db/migrations/000004_stake_lifecycle.down.sql
+DROP INDEX IF EXISTS idx_stakes_end_date;
+DROP INDEX IF EXISTS idx_stakes_unbonding;
+
+ALTER TABLE stakes
+    DROP COLUMN IF EXISTS penalty_amount,
+    DROP COLUMN IF EXISTS withdrawn_at,
+    DROP COLUMN IF EXISTS unbonding_until,
+    DROP COLUMN IF EXISTS product_id;
+
+DROP TABLE IF EXISTS staking_products;
@@ -0,0 +1,39 @@
+-- internal/db/migrations/000004_stake_lifecycle.up.sql
+
+-- Staking products define per-token lock tiers and their unstake rules
+CREATE TABLE staking_products (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    token_id UUID NOT NULL REFERENCES tokens(id),
+    name VARCHAR(100) NOT NULL,
+    min_duration_days INTEGER NOT NULL DEFAULT 30,
+    apy DECIMAL(10, 4) NOT NULL DEFAULT 0,
+    early_unstake_allowed BOOLEAN NOT NULL DEFAULT TRUE,
+    early_unstake_penalty DECIMAL(5, 2) NOT NULL DEFAULT 0, -- percent of principal
+    unbonding_days INTEGER NOT NULL DEFAULT 7,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(token_id, min_duration_days)
+);
+
+-- Stake lifecycle: active -> unbonding -> withdrawable -> withdrawn
+ALTER TABLE stakes
+    ADD COLUMN product_id UUID REFERENCES staking_products(id),
+    ADD COLUMN unbonding_until TIMESTAMP,
+    ADD COLUMN withdrawn_at TIMESTAMP,
+    ADD COLUMN penalty_amount DECIMAL(36, 18) DEFAULT 0;
+
+CREATE INDEX idx_stakes_unbonding ON stakes(unbonding_until) WHERE status = 'unbonding';
+CREATE INDEX idx_stakes_end_date ON stakes(end_date) WHERE status = 'active';
+
+INSERT INTO staking_products (token_id, name, min_duration_days, apy, early_unstake_allowed, early_unstake_penalty, unbonding_days)
+SELECT id, 'AOG Flexible 30d', 30, 33.29, true, 10.00, 7 FROM tokens WHERE symbol='AOG'
+ON CONFLICT (token_id, min_duration_days) DO NOTHING;
+
+INSERT INTO staking_products (token_id, name, min_duration_days, apy, early_unstake_allowed, early_unstake_penalty, unbonding_days)
+SELECT id, 'AOG Locked 180d', 180, 33.29, false, 0, 14 FROM tokens WHERE symbol='AOG'
+ON CONFLICT (token_id, min_duration_days) DO NOTHING;
+
+INSERT INTO staking_products (token_id, name, min_duration_days, apy, early_unstake_allowed, early_unstake_penalty, unbonding_days)
+SELECT id, 'BNB Flexible 30d', 30, 12.50, true, 5.00, 3 FROM tokens WHERE symbol='BNB'
+ON CONFLICT (token_id, min_duration_days) DO NOTHING;
@@ -0,0 +1,6 @@
+-- internal/db/migrations/000005_stake_adjustments.down.sql
+DROP TABLE IF EXISTS stake_history;
+
+ALTER TABLE stakes
+    DROP COLUMN IF EXISTS parent_stake_id,
+    DROP COLUMN IF EXISTS rewards_settled_at;
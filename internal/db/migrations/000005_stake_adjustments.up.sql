@@ -0,0 +1,21 @@
+-- internal/db/migrations/000005_stake_adjustments.up.sql
+
+-- Rewards accrue from the later of start_date and the last settlement
+ALTER TABLE stakes
+    ADD COLUMN rewards_settled_at TIMESTAMP,
+    ADD COLUMN parent_stake_id UUID REFERENCES stakes(id);
+
+-- Stake history records every change to a position's principal
+CREATE TABLE stake_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    stake_id UUID NOT NULL REFERENCES stakes(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    event_type VARCHAR(30) NOT NULL, -- created, top_up, partial_withdraw, unstake, release
+    amount DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    rewards_settled DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    balance_after DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    related_stake_id UUID REFERENCES stakes(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_stake_history_stake ON stake_history(stake_id, created_at);
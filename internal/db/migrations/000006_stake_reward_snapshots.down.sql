@@ -0,0 +1,2 @@
+-- internal/db/migrations/000006_stake_reward_snapshots.down.sql
+DROP TABLE IF EXISTS stake_reward_snapshots;
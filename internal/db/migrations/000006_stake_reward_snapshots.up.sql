@@ -0,0 +1,18 @@
+-- internal/db/migrations/000006_stake_reward_snapshots.up.sql
+
+-- Daily per-stake reward accrual, used for reward history and reconciliation
+CREATE TABLE stake_reward_snapshots (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    stake_id UUID NOT NULL REFERENCES stakes(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    snapshot_at TIMESTAMP NOT NULL,
+    principal DECIMAL(36, 18) NOT NULL,
+    apy DECIMAL(10, 4) NOT NULL,
+    accrued_rewards DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    cumulative_rewards DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    rewards_claimed DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(stake_id, snapshot_at)
+);
+
+CREATE INDEX idx_stake_reward_snapshots_at ON stake_reward_snapshots(snapshot_at);
@@ -0,0 +1,2 @@
+-- internal/db/migrations/000007_rewards_pools.down.sql
+DROP TABLE IF EXISTS rewards_pools;
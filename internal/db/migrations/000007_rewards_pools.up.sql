@@ -0,0 +1,28 @@
+-- internal/db/migrations/000007_rewards_pools.up.sql
+
+-- Rewards pools fund staking rewards per token. Emission follows the schedule:
+--   fixed        daily_emission every day
+--   halving      daily_emission halves every halving_days
+--   linear_decay daily_emission falls linearly to zero over decay_days
+CREATE TABLE rewards_pools (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    token_id UUID NOT NULL UNIQUE REFERENCES tokens(id),
+    budget DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    distributed DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    schedule VARCHAR(20) NOT NULL DEFAULT 'fixed', -- fixed, halving, linear_decay
+    daily_emission DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    halving_days INTEGER NOT NULL DEFAULT 365,
+    decay_days INTEGER NOT NULL DEFAULT 730,
+    starts_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO rewards_pools (token_id, budget, schedule, daily_emission, halving_days)
+SELECT id, 50000000, 'halving', 100000, 365 FROM tokens WHERE symbol='AOG'
+ON CONFLICT (token_id) DO NOTHING;
+
+INSERT INTO rewards_pools (token_id, budget, schedule, daily_emission, decay_days)
+SELECT id, 5000, 'linear_decay', 20, 730 FROM tokens WHERE symbol='BNB'
+ON CONFLICT (token_id) DO NOTHING;
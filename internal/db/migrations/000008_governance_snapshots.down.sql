@@ -0,0 +1,6 @@
+-- internal/db/migrations/000008_governance_snapshots.down.sql
+ALTER TABLE governance_proposals
+    DROP COLUMN IF EXISTS eligible_power,
+    DROP COLUMN IF EXISTS snapshot_at;
+
+DROP TABLE IF EXISTS governance_snapshots;
@@ -0,0 +1,15 @@
+-- internal/db/migrations/000008_governance_snapshots.up.sql
+
+-- Vote power is fixed per user when a proposal becomes active
+CREATE TABLE governance_snapshots (
+    proposal_id UUID NOT NULL REFERENCES governance_proposals(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    staked_amount DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    vote_power DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (proposal_id, user_id)
+);
+
+ALTER TABLE governance_proposals
+    ADD COLUMN snapshot_at TIMESTAMP,
+    ADD COLUMN eligible_power DECIMAL(36, 18) NOT NULL DEFAULT 0;
@@ -0,0 +1,6 @@
+-- internal/db/migrations/000009_proposal_results.down.sql
+DROP INDEX IF EXISTS idx_governance_proposals_status;
+
+ALTER TABLE governance_proposals
+    DROP COLUMN IF EXISTS result,
+    DROP COLUMN IF EXISTS finalized_at;
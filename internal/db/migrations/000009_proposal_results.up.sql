@@ -0,0 +1,8 @@
+-- internal/db/migrations/000009_proposal_results.up.sql
+
+-- Proposal lifecycle: pending -> active -> passed | rejected -> executed
+ALTER TABLE governance_proposals
+    ADD COLUMN finalized_at TIMESTAMP,
+    ADD COLUMN result JSONB;
+
+CREATE INDEX idx_governance_proposals_status ON governance_proposals(status, voting_start, voting_end);
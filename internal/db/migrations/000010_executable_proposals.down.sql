@@ -0,0 +1,12 @@
+-- internal/db/migrations/000010_executable_proposals.down.sql
+DROP TABLE IF EXISTS security_thresholds;
+
+DROP INDEX IF EXISTS idx_governance_proposals_executable;
+
+ALTER TABLE governance_proposals
+    DROP COLUMN IF EXISTS cancelled_by,
+    DROP COLUMN IF EXISTS cancelled_at,
+    DROP COLUMN IF EXISTS execution_error,
+    DROP COLUMN IF EXISTS executed_at,
+    DROP COLUMN IF EXISTS executable_at,
+    DROP COLUMN IF EXISTS payload;
@@ -0,0 +1,23 @@
+-- internal/db/migrations/000010_executable_proposals.up.sql
+
+-- Passed proposals with a payload wait out a timelock before the executor
+-- applies them: passed -> executed | failed, or cancelled by an admin while
+-- the timelock runs.
+ALTER TABLE governance_proposals
+    ADD COLUMN payload JSONB,
+    ADD COLUMN executable_at TIMESTAMP,
+    ADD COLUMN executed_at TIMESTAMP,
+    ADD COLUMN execution_error TEXT,
+    ADD COLUMN cancelled_at TIMESTAMP,
+    ADD COLUMN cancelled_by UUID REFERENCES users(id);
+
+CREATE INDEX idx_governance_proposals_executable ON governance_proposals(executable_at)
+    WHERE status = 'passed' AND executable_at IS NOT NULL;
+
+-- Security thresholds that governance can tune without a deploy. Services
+-- fall back to their configured defaults when a threshold is not set.
+CREATE TABLE security_thresholds (
+    name VARCHAR(100) PRIMARY KEY,
+    value DECIMAL(20, 6) NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,4 @@
+-- internal/db/migrations/000011_vote_delegations.down.sql
+ALTER TABLE governance_snapshots DROP COLUMN IF EXISTS delegate_id;
+
+DROP TABLE IF EXISTS vote_delegations;
@@ -0,0 +1,22 @@
+-- internal/db/migrations/000011_vote_delegations.up.sql
+
+-- A delegation hands a user's vote power to another user, either for one
+-- governance token or globally (token_symbol NULL). A token delegation takes
+-- precedence over a global one.
+CREATE TABLE vote_delegations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    delegator_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    delegate_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_symbol VARCHAR(10),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (delegator_id <> delegate_id)
+);
+
+CREATE UNIQUE INDEX idx_vote_delegations_scope ON vote_delegations(delegator_id, (COALESCE(token_symbol, '')));
+CREATE INDEX idx_vote_delegations_delegate ON vote_delegations(delegate_id);
+
+-- Delegations are frozen into the snapshot when a proposal becomes active.
+-- Delegates without stake get a zero-power row so they can vote.
+ALTER TABLE governance_snapshots
+    ADD COLUMN delegate_id UUID REFERENCES users(id) ON DELETE SET NULL;
@@ -0,0 +1,10 @@
+-- internal/db/migrations/000012_proposal_discussions.down.sql
+DROP TABLE IF EXISTS proposal_events;
+
+ALTER TABLE governance_proposals
+    DROP COLUMN IF EXISTS discussion_locked_by,
+    DROP COLUMN IF EXISTS discussion_locked_at;
+
+DROP TABLE IF EXISTS proposal_comment_reactions;
+DROP TABLE IF EXISTS proposal_comment_edits;
+DROP TABLE IF EXISTS proposal_comments;
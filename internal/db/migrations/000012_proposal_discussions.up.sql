@@ -0,0 +1,73 @@
+-- internal/db/migrations/000012_proposal_discussions.up.sql
+
+-- Threaded discussion on proposals. Replies point at their parent comment;
+-- moderators can hide individual comments or lock the whole thread.
+CREATE TABLE proposal_comments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    proposal_id UUID NOT NULL REFERENCES governance_proposals(id) ON DELETE CASCADE,
+    author_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    parent_id UUID REFERENCES proposal_comments(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    hidden BOOLEAN NOT NULL DEFAULT FALSE,
+    hidden_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    hidden_at TIMESTAMP,
+    edited_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_proposal_comments_proposal ON proposal_comments(proposal_id, created_at);
+CREATE INDEX idx_proposal_comments_parent ON proposal_comments(parent_id);
+
+-- Every edit keeps the body it replaced.
+CREATE TABLE proposal_comment_edits (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    comment_id UUID NOT NULL REFERENCES proposal_comments(id) ON DELETE CASCADE,
+    previous_body TEXT NOT NULL,
+    edited_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_proposal_comment_edits_comment ON proposal_comment_edits(comment_id, edited_at);
+
+CREATE TABLE proposal_comment_reactions (
+    comment_id UUID NOT NULL REFERENCES proposal_comments(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reaction VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (comment_id, user_id, reaction)
+);
+
+ALTER TABLE governance_proposals
+    ADD COLUMN discussion_locked_at TIMESTAMP,
+    ADD COLUMN discussion_locked_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+-- Lifecycle events for the proposal timeline. Each event type is recorded at
+-- most once per proposal so transitions and milestones can be retried.
+CREATE TABLE proposal_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    proposal_id UUID NOT NULL REFERENCES governance_proposals(id) ON DELETE CASCADE,
+    event_type VARCHAR(30) NOT NULL,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    data JSONB,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_proposal_events_type ON proposal_events(proposal_id, event_type);
+CREATE INDEX idx_proposal_events_timeline ON proposal_events(proposal_id, created_at);
+
+-- Backfill the timeline of existing proposals from their lifecycle columns.
+INSERT INTO proposal_events (proposal_id, event_type, actor_id, created_at)
+SELECT id, 'created', proposer_id, created_at FROM governance_proposals;
+
+INSERT INTO proposal_events (proposal_id, event_type, created_at)
+SELECT id, 'activated', snapshot_at FROM governance_proposals WHERE snapshot_at IS NOT NULL;
+
+INSERT INTO proposal_events (proposal_id, event_type, data, created_at)
+SELECT id, 'closed', result, finalized_at FROM governance_proposals WHERE finalized_at IS NOT NULL;
+
+INSERT INTO proposal_events (proposal_id, event_type, created_at)
+SELECT id, CASE WHEN status = 'failed' THEN 'execution_failed' ELSE 'executed' END, executed_at
+FROM governance_proposals WHERE executed_at IS NOT NULL;
+
+INSERT INTO proposal_events (proposal_id, event_type, actor_id, created_at)
+SELECT id, 'cancelled', cancelled_by, cancelled_at FROM governance_proposals WHERE cancelled_at IS NOT NULL;
@@ -0,0 +1,7 @@
+-- internal/db/migrations/000013_voting_methods.down.sql
+ALTER TABLE user_votes DROP COLUMN IF EXISTS ballot;
+
+ALTER TABLE governance_proposals
+    DROP COLUMN IF EXISTS option_votes,
+    DROP COLUMN IF EXISTS options,
+    DROP COLUMN IF EXISTS voting_method;
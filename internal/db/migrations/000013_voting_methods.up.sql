@@ -0,0 +1,14 @@
+-- internal/db/migrations/000013_voting_methods.up.sql
+
+-- The tally method is copied from the proposal type when the proposal is
+-- created. Ranked-choice proposals list their options, and option_votes
+-- holds the live first-preference totals.
+ALTER TABLE governance_proposals
+    ADD COLUMN voting_method VARCHAR(20) NOT NULL DEFAULT 'token_weighted',
+    ADD COLUMN options JSONB,
+    ADD COLUMN option_votes JSONB;
+
+-- Ranked ballots store option indices in preference order; vote_choice is
+-- 'ranked' for them.
+ALTER TABLE user_votes
+    ADD COLUMN ballot JSONB;
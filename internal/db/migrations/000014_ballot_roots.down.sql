@@ -0,0 +1,4 @@
+-- internal/db/migrations/000014_ballot_roots.down.sql
+ALTER TABLE governance_proposals
+    DROP COLUMN IF EXISTS ballot_count,
+    DROP COLUMN IF EXISTS ballot_root;
@@ -0,0 +1,7 @@
+-- internal/db/migrations/000014_ballot_roots.up.sql
+
+-- Merkle root (hex SHA-256) of the ballot set, fixed when a proposal is
+-- finalized so exports can be audited against it.
+ALTER TABLE governance_proposals
+    ADD COLUMN ballot_root VARCHAR(64),
+    ADD COLUMN ballot_count INT;
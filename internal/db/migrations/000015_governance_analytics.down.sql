@@ -0,0 +1,3 @@
+-- internal/db/migrations/000015_governance_analytics.down.sql
+DROP INDEX IF EXISTS idx_governance_proposals_finalized;
+DROP INDEX IF EXISTS idx_user_votes_user_voted_at;
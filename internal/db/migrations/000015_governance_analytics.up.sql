@@ -0,0 +1,7 @@
+-- internal/db/migrations/000015_governance_analytics.up.sql
+
+-- Voter history is listed newest first and participation reports walk
+-- finalized proposals by close time.
+CREATE INDEX idx_user_votes_user_voted_at ON user_votes(user_id, voted_at DESC);
+CREATE INDEX idx_governance_proposals_finalized ON governance_proposals(finalized_at)
+    WHERE finalized_at IS NOT NULL;
@@ -0,0 +1,8 @@
+-- internal/db/migrations/000016_pool_reserves.down.sql
+DROP INDEX IF EXISTS idx_user_liquidity_open;
+
+ALTER TABLE liquidity_pools
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS total_shares,
+    DROP COLUMN IF EXISTS reserve1,
+    DROP COLUMN IF EXISTS reserve0;
@@ -0,0 +1,13 @@
+-- internal/db/migrations/000016_pool_reserves.up.sql
+
+-- Constant-product pool state: token reserves and the LP shares issued
+-- against them.
+ALTER TABLE liquidity_pools
+    ADD COLUMN reserve0 DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    ADD COLUMN reserve1 DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    ADD COLUMN total_shares DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    ADD COLUMN updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+
+-- A user holds at most one open position per pool; deposits add to it.
+CREATE UNIQUE INDEX idx_user_liquidity_open ON user_liquidity(user_id, pool_id)
+    WHERE status = 'active';
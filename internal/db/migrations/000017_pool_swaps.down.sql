@@ -0,0 +1,2 @@
+-- internal/db/migrations/000017_pool_swaps.down.sql
+DROP TABLE IF EXISTS pool_swaps;
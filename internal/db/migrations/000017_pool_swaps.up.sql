@@ -0,0 +1,20 @@
+-- internal/db/migrations/000017_pool_swaps.up.sql
+
+-- Executed swaps with the pool's reserves right after each one.
+CREATE TABLE pool_swaps (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    pool_id UUID NOT NULL REFERENCES liquidity_pools(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    token_in_id UUID NOT NULL REFERENCES tokens(id),
+    token_out_id UUID NOT NULL REFERENCES tokens(id),
+    amount_in DECIMAL(36, 18) NOT NULL,
+    amount_out DECIMAL(36, 18) NOT NULL,
+    fee DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    price_impact DECIMAL(10, 4) NOT NULL DEFAULT 0,
+    reserve0_after DECIMAL(36, 18) NOT NULL,
+    reserve1_after DECIMAL(36, 18) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_pool_swaps_pool ON pool_swaps(pool_id, created_at DESC);
+CREATE INDEX idx_pool_swaps_user ON pool_swaps(user_id, created_at DESC);
@@ -0,0 +1,13 @@
+-- internal/db/migrations/000018_lp_fees.down.sql
+DROP TABLE IF EXISTS pool_fee_days;
+
+ALTER TABLE user_liquidity
+    DROP COLUMN IF EXISTS fees0,
+    DROP COLUMN IF EXISTS fees1,
+    DROP COLUMN IF EXISTS fee_growth0_last,
+    DROP COLUMN IF EXISTS fee_growth1_last;
+
+ALTER TABLE liquidity_pools
+    DROP COLUMN IF EXISTS fee_growth0,
+    DROP COLUMN IF EXISTS fee_growth1,
+    ALTER COLUMN apr TYPE DECIMAL(10, 4);
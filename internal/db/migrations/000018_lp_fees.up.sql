@@ -0,0 +1,29 @@
+-- internal/db/migrations/000018_lp_fees.up.sql
+
+-- Cumulative swap fees per LP share, per token. A position settles the
+-- growth since its last checkpoint into fees0/fees1 whenever its shares
+-- change, so fees earned never depend on later deposits or withdrawals.
+ALTER TABLE liquidity_pools
+    ADD COLUMN fee_growth0 DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    ADD COLUMN fee_growth1 DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    ALTER COLUMN apr TYPE DECIMAL(20, 4);
+
+ALTER TABLE user_liquidity
+    ADD COLUMN fees0 DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    ADD COLUMN fees1 DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    ADD COLUMN fee_growth0_last DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    ADD COLUMN fee_growth1_last DECIMAL(36, 18) NOT NULL DEFAULT 0;
+
+-- Daily swap volume and fee income of each pool. fees_value is priced at
+-- the token prices of the time of each swap.
+CREATE TABLE pool_fee_days (
+    pool_id UUID NOT NULL REFERENCES liquidity_pools(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    swaps INT NOT NULL DEFAULT 0,
+    volume0 DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    volume1 DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    fees0 DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    fees1 DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    fees_value DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    PRIMARY KEY (pool_id, day)
+);
@@ -0,0 +1,5 @@
+-- internal/db/migrations/000019_liquidity_farms.down.sql
+ALTER TABLE user_liquidity DROP COLUMN IF EXISTS farmed_shares;
+
+DROP TABLE IF EXISTS farm_stakes;
+DROP TABLE IF EXISTS liquidity_farms;
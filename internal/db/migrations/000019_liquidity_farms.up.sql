@@ -0,0 +1,48 @@
+-- internal/db/migrations/000019_liquidity_farms.up.sql
+
+-- Liquidity mining programs. A farm emits reward_per_day of its reward
+-- token between starts_at and ends_at, split across the LP shares staked
+-- in it. reward_per_share accumulates the reward earned by one staked
+-- share up to last_update_at.
+CREATE TABLE liquidity_farms (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    pool_id UUID NOT NULL REFERENCES liquidity_pools(id) ON DELETE CASCADE,
+    reward_token_id UUID NOT NULL REFERENCES tokens(id),
+    reward_per_day DECIMAL(36, 18) NOT NULL,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    total_staked DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    reward_per_share DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    last_update_at TIMESTAMP NOT NULL,
+    rewards_paid DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX idx_liquidity_farms_pool ON liquidity_farms(pool_id);
+
+-- LP shares a user locked in a farm. reward_per_share_paid is the farm's
+-- accumulator when rewards were last settled into rewards_pending.
+CREATE TABLE farm_stakes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    farm_id UUID NOT NULL REFERENCES liquidity_farms(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    position_id UUID NOT NULL REFERENCES user_liquidity(id),
+    shares DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    reward_per_share_paid DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    rewards_pending DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    rewards_claimed DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'active', -- active, exited
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_farm_stakes_open ON farm_stakes(farm_id, user_id)
+    WHERE status = 'active';
+CREATE INDEX idx_farm_stakes_user ON farm_stakes(user_id, status);
+
+-- Shares of a position locked across farms, which cannot be withdrawn.
+ALTER TABLE user_liquidity
+    ADD COLUMN farmed_shares DECIMAL(36, 18) NOT NULL DEFAULT 0;
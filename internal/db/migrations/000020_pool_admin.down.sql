@@ -0,0 +1,7 @@
+-- internal/db/migrations/000020_pool_admin.down.sql
+DROP INDEX IF EXISTS idx_audit_logs_resource;
+DROP INDEX IF EXISTS idx_liquidity_pools_pair;
+
+ALTER TABLE liquidity_pools
+    DROP COLUMN IF EXISTS status,
+    DROP COLUMN IF EXISTS fee_bps;
@@ -0,0 +1,20 @@
+-- internal/db/migrations/000020_pool_admin.up.sql
+
+-- Pools carry their own swap fee tier and a lifecycle status:
+--   active      deposits, swaps and withdrawals
+--   paused      withdrawals only, until unpaused
+--   deprecated  withdrawals only, for good; hidden from listings
+-- is_active stays true exactly while the status is active.
+ALTER TABLE liquidity_pools
+    ADD COLUMN fee_bps INTEGER NOT NULL DEFAULT 30,
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'active';
+
+UPDATE liquidity_pools SET status = 'paused' WHERE is_active IS NOT TRUE;
+
+-- One live pool per token pair and fee tier, whichever way round the pair
+-- is given.
+CREATE UNIQUE INDEX idx_liquidity_pools_pair ON liquidity_pools
+    (LEAST(token0_id, token1_id), GREATEST(token0_id, token1_id), fee_bps)
+    WHERE status <> 'deprecated';
+
+CREATE INDEX idx_audit_logs_resource ON audit_logs(resource_type, resource_id, created_at DESC);
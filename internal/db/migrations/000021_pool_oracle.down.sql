@@ -0,0 +1,7 @@
+-- internal/db/migrations/000021_pool_oracle.down.sql
+DROP TABLE IF EXISTS pool_price_observations;
+
+ALTER TABLE liquidity_pools
+    DROP COLUMN IF EXISTS price_updated_at,
+    DROP COLUMN IF EXISTS price1_cumulative,
+    DROP COLUMN IF EXISTS price0_cumulative;
@@ -0,0 +1,22 @@
+-- internal/db/migrations/000021_pool_oracle.up.sql
+
+-- Price accumulators. price0_cumulative sums the pool's price of token0 in
+-- token1 (reserve1 / reserve0) times the seconds it held, up to
+-- price_updated_at; price1_cumulative does the same for the inverse. They
+-- are advanced before every reserve change, so the difference between two
+-- readings divided by the seconds between them is the time-weighted
+-- average price over that window.
+ALTER TABLE liquidity_pools
+    ADD COLUMN price0_cumulative NUMERIC NOT NULL DEFAULT 0,
+    ADD COLUMN price1_cumulative NUMERIC NOT NULL DEFAULT 0,
+    ADD COLUMN price_updated_at TIMESTAMP;
+
+-- The accumulators as of each reserve change, so a TWAP can start at any
+-- point in the pool's history.
+CREATE TABLE pool_price_observations (
+    pool_id UUID NOT NULL REFERENCES liquidity_pools(id) ON DELETE CASCADE,
+    observed_at TIMESTAMP NOT NULL,
+    price0_cumulative NUMERIC NOT NULL,
+    price1_cumulative NUMERIC NOT NULL,
+    PRIMARY KEY (pool_id, observed_at)
+);
@@ -0,0 +1,23 @@
+-- internal/db/migrations/000022_concentrated_liquidity.down.sql
+DROP INDEX IF EXISTS idx_user_liquidity_open;
+UPDATE user_liquidity SET status = 'withdrawn' WHERE tick_lower IS NOT NULL;
+CREATE UNIQUE INDEX idx_user_liquidity_open ON user_liquidity(user_id, pool_id)
+    WHERE status = 'active';
+
+ALTER TABLE user_liquidity
+    DROP CONSTRAINT IF EXISTS user_liquidity_range_check,
+    DROP CONSTRAINT IF EXISTS user_liquidity_nft_id_key,
+    DROP COLUMN IF EXISTS fees_collected1,
+    DROP COLUMN IF EXISTS fees_collected0,
+    DROP COLUMN IF EXISTS tick_upper,
+    DROP COLUMN IF EXISTS tick_lower,
+    DROP COLUMN IF EXISTS nft_id;
+
+DROP TABLE IF EXISTS pool_ticks;
+
+ALTER TABLE liquidity_pools
+    DROP COLUMN IF EXISTS liquidity,
+    DROP COLUMN IF EXISTS tick,
+    DROP COLUMN IF EXISTS sqrt_price,
+    DROP COLUMN IF EXISTS tick_spacing,
+    DROP COLUMN IF EXISTS pool_type;
@@ -0,0 +1,44 @@
+-- internal/db/migrations/000022_concentrated_liquidity.up.sql
+
+-- Concentrated-liquidity pools. Prices are ticks, price = 1.0001^tick,
+-- and positions provide liquidity only between a lower and an upper tick.
+-- sqrt_price and tick are the current price, liquidity the sum of the
+-- positions in range at it. For these pools fee_growth0/1 is per unit of
+-- in-range liquidity rather than per share, and a position's shares are
+-- its liquidity.
+ALTER TABLE liquidity_pools
+    ADD COLUMN pool_type VARCHAR(20) NOT NULL DEFAULT 'constant_product',
+    ADD COLUMN tick_spacing INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN sqrt_price NUMERIC NOT NULL DEFAULT 0,
+    ADD COLUMN tick INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN liquidity NUMERIC NOT NULL DEFAULT 0;
+
+-- Initialized ticks of a concentrated pool. liquidity_net is added to the
+-- pool's liquidity when the price crosses the tick upwards and removed when
+-- it crosses downwards. fee_growth_outside is the fee growth on the other
+-- side of the tick from the current price, flipped on every crossing.
+CREATE TABLE pool_ticks (
+    pool_id UUID NOT NULL REFERENCES liquidity_pools(id) ON DELETE CASCADE,
+    tick INTEGER NOT NULL,
+    liquidity_gross NUMERIC NOT NULL DEFAULT 0,
+    liquidity_net NUMERIC NOT NULL DEFAULT 0,
+    fee_growth_outside0 NUMERIC NOT NULL DEFAULT 0,
+    fee_growth_outside1 NUMERIC NOT NULL DEFAULT 0,
+    PRIMARY KEY (pool_id, tick)
+);
+
+-- Every position gets a sequential, NFT-like number. Range positions are
+-- never merged: each deposit into a concentrated pool opens its own, and
+-- fees they earn are paid out on withdrawal, tracked by fees_collected.
+ALTER TABLE user_liquidity
+    ADD COLUMN nft_id BIGSERIAL,
+    ADD COLUMN tick_lower INTEGER,
+    ADD COLUMN tick_upper INTEGER,
+    ADD COLUMN fees_collected0 DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    ADD COLUMN fees_collected1 DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    ADD CONSTRAINT user_liquidity_nft_id_key UNIQUE (nft_id),
+    ADD CONSTRAINT user_liquidity_range_check CHECK (tick_lower < tick_upper);
+
+DROP INDEX IF EXISTS idx_user_liquidity_open;
+CREATE UNIQUE INDEX idx_user_liquidity_open ON user_liquidity(user_id, pool_id)
+    WHERE status = 'active' AND tick_lower IS NULL;
@@ -0,0 +1,2 @@
+-- internal/db/migrations/000023_pool_snapshots.down.sql
+DROP TABLE IF EXISTS pool_snapshots;
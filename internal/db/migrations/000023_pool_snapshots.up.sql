@@ -0,0 +1,25 @@
+-- internal/db/migrations/000023_pool_snapshots.up.sql
+
+-- Hourly pool snapshots for analytics. Each row holds the pool's state
+-- when the snapshot was taken and the swaps of the hour before
+-- snapshot_at. Volumes are counted on the input side of each swap and
+-- valued, like fees, at the token prices of the time.
+CREATE TABLE pool_snapshots (
+    pool_id UUID NOT NULL REFERENCES liquidity_pools(id) ON DELETE CASCADE,
+    snapshot_at TIMESTAMP NOT NULL,
+    reserve0 NUMERIC NOT NULL,
+    reserve1 NUMERIC NOT NULL,
+    total_shares NUMERIC NOT NULL,
+    price NUMERIC NOT NULL,
+    tvl NUMERIC NOT NULL,
+    swaps INTEGER NOT NULL DEFAULT 0,
+    volume0 NUMERIC NOT NULL DEFAULT 0,
+    volume1 NUMERIC NOT NULL DEFAULT 0,
+    volume_value NUMERIC NOT NULL DEFAULT 0,
+    fees0 NUMERIC NOT NULL DEFAULT 0,
+    fees1 NUMERIC NOT NULL DEFAULT 0,
+    fees_value NUMERIC NOT NULL DEFAULT 0,
+    providers INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (pool_id, snapshot_at)
+);
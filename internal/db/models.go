@@ -33,34 +33,219 @@ type AuditLog struct {
 	CreatedAt    pgtype.Timestamp `json:"created_at"`
 }
 
+type FarmStake struct {
+	ID                 pgtype.UUID      `json:"id"`
+	FarmID             pgtype.UUID      `json:"farm_id"`
+	UserID             pgtype.UUID      `json:"user_id"`
+	PositionID         pgtype.UUID      `json:"position_id"`
+	Shares             pgtype.Numeric   `json:"shares"`
+	RewardPerSharePaid pgtype.Numeric   `json:"reward_per_share_paid"`
+	RewardsPending     pgtype.Numeric   `json:"rewards_pending"`
+	RewardsClaimed     pgtype.Numeric   `json:"rewards_claimed"`
+	Status             string           `json:"status"`
+	CreatedAt          pgtype.Timestamp `json:"created_at"`
+	UpdatedAt          pgtype.Timestamp `json:"updated_at"`
+}
+
 type GovernanceProposal struct {
-	ID           pgtype.UUID      `json:"id"`
-	Title        string           `json:"title"`
-	Description  string           `json:"description"`
-	ProposerID   pgtype.UUID      `json:"proposer_id"`
-	ProposalType string           `json:"proposal_type"`
-	Status       pgtype.Text      `json:"status"`
-	VotingStart  pgtype.Timestamp `json:"voting_start"`
-	VotingEnd    pgtype.Timestamp `json:"voting_end"`
-	Quorum       pgtype.Numeric   `json:"quorum"`
-	Threshold    pgtype.Numeric   `json:"threshold"`
-	ForVotes     pgtype.Numeric   `json:"for_votes"`
-	AgainstVotes pgtype.Numeric   `json:"against_votes"`
-	AbstainVotes pgtype.Numeric   `json:"abstain_votes"`
-	TotalVotes   pgtype.Numeric   `json:"total_votes"`
+	ID                 pgtype.UUID      `json:"id"`
+	Title              string           `json:"title"`
+	Description        string           `json:"description"`
+	ProposerID         pgtype.UUID      `json:"proposer_id"`
+	ProposalType       string           `json:"proposal_type"`
+	Status             pgtype.Text      `json:"status"`
+	VotingStart        pgtype.Timestamp `json:"voting_start"`
+	VotingEnd          pgtype.Timestamp `json:"voting_end"`
+	Quorum             pgtype.Numeric   `json:"quorum"`
+	Threshold          pgtype.Numeric   `json:"threshold"`
+	ForVotes           pgtype.Numeric   `json:"for_votes"`
+	AgainstVotes       pgtype.Numeric   `json:"against_votes"`
+	AbstainVotes       pgtype.Numeric   `json:"abstain_votes"`
+	TotalVotes         pgtype.Numeric   `json:"total_votes"`
+	CreatedAt          pgtype.Timestamp `json:"created_at"`
+	UpdatedAt          pgtype.Timestamp `json:"updated_at"`
+	SnapshotAt         pgtype.Timestamp `json:"snapshot_at"`
+	EligiblePower      pgtype.Numeric   `json:"eligible_power"`
+	FinalizedAt        pgtype.Timestamp `json:"finalized_at"`
+	Result             []byte           `json:"result"`
+	Payload            []byte           `json:"payload"`
+	ExecutableAt       pgtype.Timestamp `json:"executable_at"`
+	ExecutedAt         pgtype.Timestamp `json:"executed_at"`
+	ExecutionError     pgtype.Text      `json:"execution_error"`
+	CancelledAt        pgtype.Timestamp `json:"cancelled_at"`
+	CancelledBy        pgtype.UUID      `json:"cancelled_by"`
+	DiscussionLockedAt pgtype.Timestamp `json:"discussion_locked_at"`
+	DiscussionLockedBy pgtype.UUID      `json:"discussion_locked_by"`
+	VotingMethod       string           `json:"voting_method"`
+	Options            []byte           `json:"options"`
+	OptionVotes        []byte           `json:"option_votes"`
+	BallotRoot         pgtype.Text      `json:"ballot_root"`
+	BallotCount        pgtype.Int4      `json:"ballot_count"`
+}
+
+type GovernanceSnapshot struct {
+	ProposalID   pgtype.UUID      `json:"proposal_id"`
+	UserID       pgtype.UUID      `json:"user_id"`
+	StakedAmount pgtype.Numeric   `json:"staked_amount"`
+	VotePower    pgtype.Numeric   `json:"vote_power"`
 	CreatedAt    pgtype.Timestamp `json:"created_at"`
-	UpdatedAt    pgtype.Timestamp `json:"updated_at"`
+	DelegateID   pgtype.UUID      `json:"delegate_id"`
 }
 
-type LiquidityPool struct {
+type LiquidityFarm struct {
 	ID             pgtype.UUID      `json:"id"`
-	Name           string           `json:"name"`
-	Token0ID       pgtype.UUID      `json:"token0_id"`
-	Token1ID       pgtype.UUID      `json:"token1_id"`
-	TotalLiquidity pgtype.Numeric   `json:"total_liquidity"`
-	Apr            pgtype.Numeric   `json:"apr"`
-	IsActive       pgtype.Bool      `json:"is_active"`
+	PoolID         pgtype.UUID      `json:"pool_id"`
+	RewardTokenID  pgtype.UUID      `json:"reward_token_id"`
+	RewardPerDay   pgtype.Numeric   `json:"reward_per_day"`
+	StartsAt       pgtype.Timestamp `json:"starts_at"`
+	EndsAt         pgtype.Timestamp `json:"ends_at"`
+	TotalStaked    pgtype.Numeric   `json:"total_staked"`
+	RewardPerShare pgtype.Numeric   `json:"reward_per_share"`
+	LastUpdateAt   pgtype.Timestamp `json:"last_update_at"`
+	RewardsPaid    pgtype.Numeric   `json:"rewards_paid"`
+	CreatedBy      pgtype.UUID      `json:"created_by"`
 	CreatedAt      pgtype.Timestamp `json:"created_at"`
+	UpdatedAt      pgtype.Timestamp `json:"updated_at"`
+}
+
+type LiquidityPool struct {
+	ID               pgtype.UUID      `json:"id"`
+	Name             string           `json:"name"`
+	Token0ID         pgtype.UUID      `json:"token0_id"`
+	Token1ID         pgtype.UUID      `json:"token1_id"`
+	TotalLiquidity   pgtype.Numeric   `json:"total_liquidity"`
+	Apr              pgtype.Numeric   `json:"apr"`
+	IsActive         pgtype.Bool      `json:"is_active"`
+	CreatedAt        pgtype.Timestamp `json:"created_at"`
+	Reserve0         pgtype.Numeric   `json:"reserve0"`
+	Reserve1         pgtype.Numeric   `json:"reserve1"`
+	TotalShares      pgtype.Numeric   `json:"total_shares"`
+	UpdatedAt        pgtype.Timestamp `json:"updated_at"`
+	FeeGrowth0       pgtype.Numeric   `json:"fee_growth0"`
+	FeeGrowth1       pgtype.Numeric   `json:"fee_growth1"`
+	FeeBps           int32            `json:"fee_bps"`
+	Status           string           `json:"status"`
+	Price0Cumulative pgtype.Numeric   `json:"price0_cumulative"`
+	Price1Cumulative pgtype.Numeric   `json:"price1_cumulative"`
+	PriceUpdatedAt   pgtype.Timestamp `json:"price_updated_at"`
+	PoolType         string           `json:"pool_type"`
+	TickSpacing      int32            `json:"tick_spacing"`
+	SqrtPrice        pgtype.Numeric   `json:"sqrt_price"`
+	Tick             int32            `json:"tick"`
+	Liquidity        pgtype.Numeric   `json:"liquidity"`
+}
+
+type PoolFeeDay struct {
+	PoolID    pgtype.UUID    `json:"pool_id"`
+	Day       pgtype.Date    `json:"day"`
+	Swaps     int32          `json:"swaps"`
+	Volume0   pgtype.Numeric `json:"volume0"`
+	Volume1   pgtype.Numeric `json:"volume1"`
+	Fees0     pgtype.Numeric `json:"fees0"`
+	Fees1     pgtype.Numeric `json:"fees1"`
+	FeesValue pgtype.Numeric `json:"fees_value"`
+}
+
+type PoolPriceObservation struct {
+	PoolID           pgtype.UUID      `json:"pool_id"`
+	ObservedAt       pgtype.Timestamp `json:"observed_at"`
+	Price0Cumulative pgtype.Numeric   `json:"price0_cumulative"`
+	Price1Cumulative pgtype.Numeric   `json:"price1_cumulative"`
+}
+
+type PoolSnapshot struct {
+	PoolID      pgtype.UUID      `json:"pool_id"`
+	SnapshotAt  pgtype.Timestamp `json:"snapshot_at"`
+	Reserve0    pgtype.Numeric   `json:"reserve0"`
+	Reserve1    pgtype.Numeric   `json:"reserve1"`
+	TotalShares pgtype.Numeric   `json:"total_shares"`
+	Price       pgtype.Numeric   `json:"price"`
+	Tvl         pgtype.Numeric   `json:"tvl"`
+	Swaps       int32            `json:"swaps"`
+	Volume0     pgtype.Numeric   `json:"volume0"`
+	Volume1     pgtype.Numeric   `json:"volume1"`
+	VolumeValue pgtype.Numeric   `json:"volume_value"`
+	Fees0       pgtype.Numeric   `json:"fees0"`
+	Fees1       pgtype.Numeric   `json:"fees1"`
+	FeesValue   pgtype.Numeric   `json:"fees_value"`
+	Providers   int32            `json:"providers"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+}
+
+type PoolSwap struct {
+	ID            pgtype.UUID      `json:"id"`
+	PoolID        pgtype.UUID      `json:"pool_id"`
+	UserID        pgtype.UUID      `json:"user_id"`
+	TokenInID     pgtype.UUID      `json:"token_in_id"`
+	TokenOutID    pgtype.UUID      `json:"token_out_id"`
+	AmountIn      pgtype.Numeric   `json:"amount_in"`
+	AmountOut     pgtype.Numeric   `json:"amount_out"`
+	Fee           pgtype.Numeric   `json:"fee"`
+	PriceImpact   pgtype.Numeric   `json:"price_impact"`
+	Reserve0After pgtype.Numeric   `json:"reserve0_after"`
+	Reserve1After pgtype.Numeric   `json:"reserve1_after"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+}
+
+type PoolTick struct {
+	PoolID            pgtype.UUID    `json:"pool_id"`
+	Tick              int32          `json:"tick"`
+	LiquidityGross    pgtype.Numeric `json:"liquidity_gross"`
+	LiquidityNet      pgtype.Numeric `json:"liquidity_net"`
+	FeeGrowthOutside0 pgtype.Numeric `json:"fee_growth_outside0"`
+	FeeGrowthOutside1 pgtype.Numeric `json:"fee_growth_outside1"`
+}
+
+type ProposalComment struct {
+	ID         pgtype.UUID      `json:"id"`
+	ProposalID pgtype.UUID      `json:"proposal_id"`
+	AuthorID   pgtype.UUID      `json:"author_id"`
+	ParentID   pgtype.UUID      `json:"parent_id"`
+	Body       string           `json:"body"`
+	Hidden     bool             `json:"hidden"`
+	HiddenBy   pgtype.UUID      `json:"hidden_by"`
+	HiddenAt   pgtype.Timestamp `json:"hidden_at"`
+	EditedAt   pgtype.Timestamp `json:"edited_at"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+	UpdatedAt  pgtype.Timestamp `json:"updated_at"`
+}
+
+type ProposalCommentEdit struct {
+	ID           pgtype.UUID      `json:"id"`
+	CommentID    pgtype.UUID      `json:"comment_id"`
+	PreviousBody string           `json:"previous_body"`
+	EditedAt     pgtype.Timestamp `json:"edited_at"`
+}
+
+type ProposalCommentReaction struct {
+	CommentID pgtype.UUID      `json:"comment_id"`
+	UserID    pgtype.UUID      `json:"user_id"`
+	Reaction  string           `json:"reaction"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type ProposalEvent struct {
+	ID         pgtype.UUID      `json:"id"`
+	ProposalID pgtype.UUID      `json:"proposal_id"`
+	EventType  string           `json:"event_type"`
+	ActorID    pgtype.UUID      `json:"actor_id"`
+	Data       []byte           `json:"data"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+}
+
+type RewardsPool struct {
+	ID            pgtype.UUID      `json:"id"`
+	TokenID       pgtype.UUID      `json:"token_id"`
+	Budget        pgtype.Numeric   `json:"budget"`
+	Distributed   pgtype.Numeric   `json:"distributed"`
+	Schedule      string           `json:"schedule"`
+	DailyEmission pgtype.Numeric   `json:"daily_emission"`
+	HalvingDays   int32            `json:"halving_days"`
+	DecayDays     int32            `json:"decay_days"`
+	StartsAt      pgtype.Timestamp `json:"starts_at"`
+	IsActive      pgtype.Bool      `json:"is_active"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+	UpdatedAt     pgtype.Timestamp `json:"updated_at"`
 }
 
 type SecurityMonitor struct {
@@ -73,19 +258,70 @@ type SecurityMonitor struct {
 	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
 }
 
+type SecurityThreshold struct {
+	Name      string           `json:"name"`
+	Value     pgtype.Numeric   `json:"value"`
+	UpdatedAt pgtype.Timestamp `json:"updated_at"`
+}
+
 type Stake struct {
+	ID               pgtype.UUID      `json:"id"`
+	UserID           pgtype.UUID      `json:"user_id"`
+	TokenID          pgtype.UUID      `json:"token_id"`
+	Amount           pgtype.Numeric   `json:"amount"`
+	Apy              pgtype.Numeric   `json:"apy"`
+	StartDate        pgtype.Timestamp `json:"start_date"`
+	EndDate          pgtype.Timestamp `json:"end_date"`
+	Status           pgtype.Text      `json:"status"`
+	AutoCompound     pgtype.Bool      `json:"auto_compound"`
+	RewardsClaimed   pgtype.Numeric   `json:"rewards_claimed"`
+	CreatedAt        pgtype.Timestamp `json:"created_at"`
+	UpdatedAt        pgtype.Timestamp `json:"updated_at"`
+	ProductID        pgtype.UUID      `json:"product_id"`
+	UnbondingUntil   pgtype.Timestamp `json:"unbonding_until"`
+	WithdrawnAt      pgtype.Timestamp `json:"withdrawn_at"`
+	PenaltyAmount    pgtype.Numeric   `json:"penalty_amount"`
+	RewardsSettledAt pgtype.Timestamp `json:"rewards_settled_at"`
+	ParentStakeID    pgtype.UUID      `json:"parent_stake_id"`
+}
+
+type StakeHistory struct {
 	ID             pgtype.UUID      `json:"id"`
+	StakeID        pgtype.UUID      `json:"stake_id"`
 	UserID         pgtype.UUID      `json:"user_id"`
-	TokenID        pgtype.UUID      `json:"token_id"`
+	EventType      string           `json:"event_type"`
 	Amount         pgtype.Numeric   `json:"amount"`
-	Apy            pgtype.Numeric   `json:"apy"`
-	StartDate      pgtype.Timestamp `json:"start_date"`
-	EndDate        pgtype.Timestamp `json:"end_date"`
-	Status         pgtype.Text      `json:"status"`
-	AutoCompound   pgtype.Bool      `json:"auto_compound"`
-	RewardsClaimed pgtype.Numeric   `json:"rewards_claimed"`
+	RewardsSettled pgtype.Numeric   `json:"rewards_settled"`
+	BalanceAfter   pgtype.Numeric   `json:"balance_after"`
+	RelatedStakeID pgtype.UUID      `json:"related_stake_id"`
 	CreatedAt      pgtype.Timestamp `json:"created_at"`
-	UpdatedAt      pgtype.Timestamp `json:"updated_at"`
+}
+
+type StakeRewardSnapshot struct {
+	ID                pgtype.UUID      `json:"id"`
+	StakeID           pgtype.UUID      `json:"stake_id"`
+	UserID            pgtype.UUID      `json:"user_id"`
+	SnapshotAt        pgtype.Timestamp `json:"snapshot_at"`
+	Principal         pgtype.Numeric   `json:"principal"`
+	Apy               pgtype.Numeric   `json:"apy"`
+	AccruedRewards    pgtype.Numeric   `json:"accrued_rewards"`
+	CumulativeRewards pgtype.Numeric   `json:"cumulative_rewards"`
+	RewardsClaimed    pgtype.Numeric   `json:"rewards_claimed"`
+	CreatedAt         pgtype.Timestamp `json:"created_at"`
+}
+
+type StakingProduct struct {
+	ID                  pgtype.UUID      `json:"id"`
+	TokenID             pgtype.UUID      `json:"token_id"`
+	Name                string           `json:"name"`
+	MinDurationDays     int32            `json:"min_duration_days"`
+	Apy                 pgtype.Numeric   `json:"apy"`
+	EarlyUnstakeAllowed bool             `json:"early_unstake_allowed"`
+	EarlyUnstakePenalty pgtype.Numeric   `json:"early_unstake_penalty"`
+	UnbondingDays       int32            `json:"unbonding_days"`
+	IsActive            pgtype.Bool      `json:"is_active"`
+	CreatedAt           pgtype.Timestamp `json:"created_at"`
+	UpdatedAt           pgtype.Timestamp `json:"updated_at"`
 }
 
 type Token struct {
@@ -114,16 +350,26 @@ type User struct {
 }
 
 type UserLiquidity struct {
-	ID        pgtype.UUID      `json:"id"`
-	UserID    pgtype.UUID      `json:"user_id"`
-	PoolID    pgtype.UUID      `json:"pool_id"`
-	Amount0   pgtype.Numeric   `json:"amount0"`
-	Amount1   pgtype.Numeric   `json:"amount1"`
-	Shares    pgtype.Numeric   `json:"shares"`
-	AprEarned pgtype.Numeric   `json:"apr_earned"`
-	Status    pgtype.Text      `json:"status"`
-	CreatedAt pgtype.Timestamp `json:"created_at"`
-	UpdatedAt pgtype.Timestamp `json:"updated_at"`
+	ID             pgtype.UUID      `json:"id"`
+	UserID         pgtype.UUID      `json:"user_id"`
+	PoolID         pgtype.UUID      `json:"pool_id"`
+	Amount0        pgtype.Numeric   `json:"amount0"`
+	Amount1        pgtype.Numeric   `json:"amount1"`
+	Shares         pgtype.Numeric   `json:"shares"`
+	AprEarned      pgtype.Numeric   `json:"apr_earned"`
+	Status         pgtype.Text      `json:"status"`
+	CreatedAt      pgtype.Timestamp `json:"created_at"`
+	UpdatedAt      pgtype.Timestamp `json:"updated_at"`
+	Fees0          pgtype.Numeric   `json:"fees0"`
+	Fees1          pgtype.Numeric   `json:"fees1"`
+	FeeGrowth0Last pgtype.Numeric   `json:"fee_growth0_last"`
+	FeeGrowth1Last pgtype.Numeric   `json:"fee_growth1_last"`
+	FarmedShares   pgtype.Numeric   `json:"farmed_shares"`
+	NftID          int64            `json:"nft_id"`
+	TickLower      pgtype.Int4      `json:"tick_lower"`
+	TickUpper      pgtype.Int4      `json:"tick_upper"`
+	FeesCollected0 pgtype.Numeric   `json:"fees_collected0"`
+	FeesCollected1 pgtype.Numeric   `json:"fees_collected1"`
 }
 
 type UserProfile struct {
@@ -158,4 +404,14 @@ type UserVote struct {
 	VotePower  pgtype.Numeric   `json:"vote_power"`
 	VoteChoice string           `json:"vote_choice"`
 	VotedAt    pgtype.Timestamp `json:"voted_at"`
+	Ballot     []byte           `json:"ballot"`
+}
+
+type VoteDelegation struct {
+	ID          pgtype.UUID      `json:"id"`
+	DelegatorID pgtype.UUID      `json:"delegator_id"`
+	DelegateID  pgtype.UUID      `json:"delegate_id"`
+	TokenSymbol pgtype.Text      `json:"token_symbol"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
 }
@@ -11,35 +11,229 @@ import (
 )
 
 type Querier interface {
+	// Grows the pool's fees per share and stores its trailing APR.
+	AccruePoolFees(ctx context.Context, arg AccruePoolFeesParams) error
+	ActivateProposal(ctx context.Context, proposalID pgtype.UUID) (GovernanceProposal, error)
+	AddCommentReaction(ctx context.Context, arg AddCommentReactionParams) (int64, error)
+	// Locks (positive delta) or releases (negative delta) a position's shares.
+	AddFarmedShares(ctx context.Context, arg AddFarmedSharesParams) error
+	// Opens a position or adds a deposit to the user's open one, first
+	// settling the fees its existing shares earned up to the pool's current
+	// fee growth.
+	AddToPosition(ctx context.Context, arg AddToPositionParams) (UserLiquidity, error)
+	AdvisoryLock(ctx context.Context, lockKey int64) error
+	// Waits for the lock on one subject, such as a user id, within a class of
+	// locks, so writers are serialised per subject rather than globally.
+	AdvisoryLockSubject(ctx context.Context, arg AdvisoryLockSubjectParams) error
+	BeginUnbonding(ctx context.Context, arg BeginUnbondingParams) error
+	CancelProposal(ctx context.Context, arg CancelProposalParams) (GovernanceProposal, error)
 	CastVote(ctx context.Context, arg CastVoteParams) (UserVote, error)
+	// Marks every fee a position has settled as paid out.
+	CollectPositionFees(ctx context.Context, id pgtype.UUID) error
+	CountOpenProposalsByProposer(ctx context.Context, proposerID pgtype.UUID) (int64, error)
+	CountProposals(ctx context.Context, arg CountProposalsParams) (int64, error)
+	CountSnapshotDelegators(ctx context.Context, arg CountSnapshotDelegatorsParams) (int64, error)
+	CountUserVotes(ctx context.Context, userID pgtype.UUID) (int64, error)
+	CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) error
+	// internal/db/queries/discussions.sql
+	CreateComment(ctx context.Context, arg CreateCommentParams) (ProposalComment, error)
+	CreateCommentEdit(ctx context.Context, arg CreateCommentEditParams) error
+	// internal/db/queries/farms.sql
+	// Creates a farm paying the token with the given symbol; no row is
+	// returned if the token does not exist.
+	CreateFarm(ctx context.Context, arg CreateFarmParams) (LiquidityFarm, error)
+	CreateFarmStake(ctx context.Context, arg CreateFarmStakeParams) (FarmStake, error)
+	CreatePool(ctx context.Context, arg CreatePoolParams) (LiquidityPool, error)
+	// Snapshots every pool that is not deprecated at snapshot_at, with the
+	// swaps made from from_at up to it. Pools already snapshotted at that time
+	// are skipped, so reruns are no-ops.
+	CreatePoolSnapshots(ctx context.Context, arg CreatePoolSnapshotsParams) (int64, error)
 	// internal/db/queries/governance.sql
 	CreateProposal(ctx context.Context, arg CreateProposalParams) (GovernanceProposal, error)
+	CreateRangePosition(ctx context.Context, arg CreateRangePositionParams) (UserLiquidity, error)
+	CreateRewardSnapshot(ctx context.Context, arg CreateRewardSnapshotParams) (int64, error)
+	// internal/db/queries/security.sql
+	CreateSecurityMonitor(ctx context.Context, arg CreateSecurityMonitorParams) (SecurityMonitor, error)
+	CreateSplitStake(ctx context.Context, arg CreateSplitStakeParams) (Stake, error)
 	// internal/db/queries/stakes.sql
 	CreateStake(ctx context.Context, arg CreateStakeParams) (Stake, error)
+	CreateStakeHistory(ctx context.Context, arg CreateStakeHistoryParams) error
+	CreateSwap(ctx context.Context, arg CreateSwapParams) (PoolSwap, error)
 	// internal/db/queries/users.sql
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	CreateUserProfile(ctx context.Context, arg CreateUserProfileParams) (UserProfile, error)
+	CreateVoteSnapshot(ctx context.Context, arg CreateVoteSnapshotParams) (int64, error)
+	// Flips a tick's fee growth outside as the price crosses it, given the
+	// pool's fee growth at that moment.
+	CrossTick(ctx context.Context, arg CrossTickParams) error
+	DeleteDelegation(ctx context.Context, arg DeleteDelegationParams) (int64, error)
+	FinalizeProposal(ctx context.Context, arg FinalizeProposalParams) (GovernanceProposal, error)
 	GetActiveProposals(ctx context.Context) ([]GovernanceProposal, error)
 	GetAssetMetrics(ctx context.Context) (GetAssetMetricsRow, error)
+	// Each token's stored TVL and the amount of it that TVL represents at the
+	// stored market price, null where there is no price to divide by.
+	GetAssetTVLByToken(ctx context.Context) ([]GetAssetTVLByTokenRow, error)
+	GetComment(ctx context.Context, id pgtype.UUID) (ProposalComment, error)
+	GetCommentForUpdate(ctx context.Context, id pgtype.UUID) (ProposalComment, error)
+	GetDelegatedVotePower(ctx context.Context, arg GetDelegatedVotePowerParams) (pgtype.Numeric, error)
+	GetEffectiveDelegate(ctx context.Context, arg GetEffectiveDelegateParams) (pgtype.UUID, error)
+	GetFarm(ctx context.Context, id pgtype.UUID) (GetFarmRow, error)
+	GetFarmForUpdate(ctx context.Context, id pgtype.UUID) (LiquidityFarm, error)
+	// A pool's first observation, for pools younger than a TWAP window.
+	GetFirstPriceObservation(ctx context.Context, poolID pgtype.UUID) (PoolPriceObservation, error)
+	GetOpenFarmStakeForUpdate(ctx context.Context, arg GetOpenFarmStakeForUpdateParams) (FarmStake, error)
+	// The user's open constant-product position in a pool.
+	GetOpenPositionForUpdate(ctx context.Context, arg GetOpenPositionForUpdateParams) (UserLiquidity, error)
+	GetParticipationSummary(ctx context.Context) (GetParticipationSummaryRow, error)
+	GetPool(ctx context.Context, id pgtype.UUID) (GetPoolRow, error)
+	// A pool's TVL and the value of the fees it earned over the trailing 7
+	// and 30 days, today included.
+	GetPoolFeeIncome(ctx context.Context, id pgtype.UUID) (GetPoolFeeIncomeRow, error)
+	GetPoolForUpdate(ctx context.Context, id pgtype.UUID) (LiquidityPool, error)
+	// A pool's snapshots bucketed by hour or day. A snapshot covers the hour
+	// before it, so buckets start an hour before their first snapshot: their
+	// swaps are summed and their state is the last snapshot's.
+	GetPoolHistory(ctx context.Context, arg GetPoolHistoryParams) ([]GetPoolHistoryRow, error)
+	GetPoolState(ctx context.Context, id pgtype.UUID) (LiquidityPool, error)
+	GetPositionForUpdate(ctx context.Context, arg GetPositionForUpdateParams) (UserLiquidity, error)
+	// The last observation at or before the given time.
+	GetPriceObservation(ctx context.Context, arg GetPriceObservationParams) (PoolPriceObservation, error)
 	GetProposalByID(ctx context.Context, id pgtype.UUID) (GovernanceProposal, error)
+	GetProposalForUpdate(ctx context.Context, id pgtype.UUID) (GovernanceProposal, error)
+	// What active stakes are still owed: rewards accrued since their last
+	// settlement plus those still to accrue by their end date. Stakes are
+	// projected to the horizon, the later of until and the last end date, so
+	// the caller can compare against the pool's emission over the same span.
+	GetRewardObligations(ctx context.Context, arg GetRewardObligationsParams) (GetRewardObligationsRow, error)
+	GetRewardSnapshotsAt(ctx context.Context, snapshotAt pgtype.Timestamp) ([]StakeRewardSnapshot, error)
+	// internal/db/queries/rewards.sql
+	GetRewardsPoolForUpdate(ctx context.Context, tokenID pgtype.UUID) (RewardsPool, error)
+	GetSecurityThreshold(ctx context.Context, name string) (pgtype.Numeric, error)
 	GetStakeByID(ctx context.Context, id pgtype.UUID) (GetStakeByIDRow, error)
+	GetStakeForUpdate(ctx context.Context, id pgtype.UUID) (GetStakeForUpdateRow, error)
+	GetStakeHistory(ctx context.Context, stakeID pgtype.UUID) ([]StakeHistory, error)
+	GetStakeLockTiers(ctx context.Context, userID pgtype.UUID) ([]GetStakeLockTiersRow, error)
+	GetStakeRewardSeries(ctx context.Context, arg GetStakeRewardSeriesParams) ([]GetStakeRewardSeriesRow, error)
+	GetStakeTotalsByToken(ctx context.Context, userID pgtype.UUID) ([]GetStakeTotalsByTokenRow, error)
+	GetStakingOverview(ctx context.Context, userID pgtype.UUID) (GetStakingOverviewRow, error)
+	GetStakingProductForDuration(ctx context.Context, arg GetStakingProductForDurationParams) (StakingProduct, error)
+	GetTokenBySymbol(ctx context.Context, symbol string) (GetTokenBySymbolRow, error)
 	GetTokenList(ctx context.Context) ([]GetTokenListRow, error)
 	GetTotalStakedValue(ctx context.Context) (pgtype.Numeric, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByID(ctx context.Context, id pgtype.UUID) (User, error)
 	GetUserByWallet(ctx context.Context, walletAddress pgtype.Text) (User, error)
+	GetUserPosition(ctx context.Context, arg GetUserPositionParams) (GetUserPositionRow, error)
 	GetUserProfile(ctx context.Context, userID pgtype.UUID) (UserProfile, error)
+	GetUserStakedBalance(ctx context.Context, arg GetUserStakedBalanceParams) (pgtype.Numeric, error)
 	GetUserStakes(ctx context.Context, userID pgtype.UUID) ([]GetUserStakesRow, error)
-	GetUserVotes(ctx context.Context, userID pgtype.UUID) ([]UserVote, error)
-	Unstake(ctx context.Context, arg UnstakeParams) error
+	// A user's votes, newest first, with the state of each proposal.
+	GetUserVotes(ctx context.Context, arg GetUserVotesParams) ([]GetUserVotesRow, error)
+	GetVoteSnapshot(ctx context.Context, arg GetVoteSnapshotParams) (GovernanceSnapshot, error)
+	// Audit entries for one resource, newest first.
+	ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([]ListAuditLogsRow, error)
+	ListCommentEdits(ctx context.Context, commentID pgtype.UUID) ([]ProposalCommentEdit, error)
+	ListDelegates(ctx context.Context, arg ListDelegatesParams) ([]ListDelegatesRow, error)
+	ListDelegationSymbols(ctx context.Context) ([]string, error)
+	ListDelegationsByDelegate(ctx context.Context, delegateID pgtype.UUID) ([]VoteDelegation, error)
+	ListDelegationsByDelegator(ctx context.Context, delegatorID pgtype.UUID) ([]VoteDelegation, error)
+	// Each direct voter with the snapshotted power that reaches them: their own
+	// plus that of holders whose delegation chain ends at them. root_power sums
+	// the square root of each of those holders' power, for quadratic voting.
+	ListEffectiveVotes(ctx context.Context, proposalID pgtype.UUID) ([]ListEffectiveVotesRow, error)
+	// Farms with their pool, reward token and the pool's share supply and
+	// TVL, newest first. Ended farms are left out unless include_ended.
+	ListFarms(ctx context.Context, includeEnded bool) ([]ListFarmsRow, error)
+	ListPoolSwaps(ctx context.Context, arg ListPoolSwapsParams) ([]ListPoolSwapsRow, error)
+	// A concentrated pool's initialized ticks, lowest first.
+	ListPoolTicks(ctx context.Context, poolID pgtype.UUID) ([]PoolTick, error)
+	// internal/db/queries/liquidity.sql
+	// Pools that are not deprecated with their token pair, optionally only
+	// those containing the token symbol, largest first.
+	ListPools(ctx context.Context, symbol pgtype.Text) ([]ListPoolsRow, error)
+	// Every snapshot holder of a proposal with their delegation and, if they
+	// voted directly, their ballot.
+	ListProposalBallots(ctx context.Context, proposalID pgtype.UUID) ([]ListProposalBallotsRow, error)
+	ListProposalComments(ctx context.Context, proposalID pgtype.UUID) ([]ProposalComment, error)
+	ListProposalEvents(ctx context.Context, proposalID pgtype.UUID) ([]ProposalEvent, error)
+	// Finalized proposals, most recently closed first, with their turnout.
+	ListProposalParticipation(ctx context.Context, arg ListProposalParticipationParams) ([]ListProposalParticipationRow, error)
+	ListProposalReactions(ctx context.Context, arg ListProposalReactionsParams) ([]ListProposalReactionsRow, error)
+	// Power reaching direct voters per time bucket, delegated power included,
+	// counted when the voter it reaches cast their (latest) vote.
+	ListProposalTurnout(ctx context.Context, arg ListProposalTurnoutParams) ([]ListProposalTurnoutRow, error)
+	ListProposals(ctx context.Context, arg ListProposalsParams) ([]GovernanceProposal, error)
+	ListProposalsToActivate(ctx context.Context) ([]pgtype.UUID, error)
+	ListProposalsToExecute(ctx context.Context) ([]pgtype.UUID, error)
+	ListProposalsToFinalize(ctx context.Context) ([]pgtype.UUID, error)
+	ListRewardsPoolStats(ctx context.Context) ([]ListRewardsPoolStatsRow, error)
+	ListStakesDueForSnapshot(ctx context.Context, snapshotAt pgtype.Timestamp) ([]ListStakesDueForSnapshotRow, error)
+	// Users by votes cast, with how many snapshots they held power in.
+	ListTopVoters(ctx context.Context, arg ListTopVotersParams) ([]ListTopVotersRow, error)
+	// A user's open farm stakes with the farm state needed to compute their
+	// pending rewards.
+	ListUserFarmStakes(ctx context.Context, userID pgtype.UUID) ([]ListUserFarmStakesRow, error)
+	// A user's active positions with their pool's reserves, share supply,
+	// current price and token prices, and the fees earned including those not
+	// yet settled. A range position earns only the fee growth inside its
+	// ticks.
+	ListUserPositions(ctx context.Context, userID pgtype.UUID) ([]ListUserPositionsRow, error)
+	ListUserSwaps(ctx context.Context, arg ListUserSwapsParams) ([]ListUserSwapsRow, error)
+	MarkProposalExecuted(ctx context.Context, arg MarkProposalExecutedParams) (GovernanceProposal, error)
+	MarkStakeWithdrawn(ctx context.Context, id pgtype.UUID) error
+	// Whether a live pool already pairs the two tokens, in either order, at
+	// the fee tier.
+	PoolPairExists(ctx context.Context, arg PoolPairExistsParams) (bool, error)
+	RecordPoolDistribution(ctx context.Context, arg RecordPoolDistributionParams) error
+	// Adds a swap's volume and fee to the pool's row for today, valuing the
+	// fee at current token prices.
+	RecordPoolFees(ctx context.Context, arg RecordPoolFeesParams) error
+	RecordPriceObservation(ctx context.Context, arg RecordPriceObservationParams) error
+	RecordProposalEvent(ctx context.Context, arg RecordProposalEventParams) error
+	ReleaseUnbondedStakes(ctx context.Context) (int64, error)
+	RemoveCommentReaction(ctx context.Context, arg RemoveCommentReactionParams) (int64, error)
+	SetCommentHidden(ctx context.Context, arg SetCommentHiddenParams) (ProposalComment, error)
+	SetDiscussionLock(ctx context.Context, arg SetDiscussionLockParams) (GovernanceProposal, error)
+	SetPoolCurve(ctx context.Context, arg SetPoolCurveParams) error
+	SetPoolFee(ctx context.Context, arg SetPoolFeeParams) error
+	// Stores new reserves and share supply with the price accumulators
+	// advanced to the change, revaluing the pool's TVL at the given token
+	// prices, or the stored market price for a token without one.
+	SetPoolReserves(ctx context.Context, arg SetPoolReservesParams) (LiquidityPool, error)
+	SetPoolStatus(ctx context.Context, arg SetPoolStatusParams) error
+	SetProposalTallies(ctx context.Context, arg SetProposalTalliesParams) (GovernanceProposal, error)
+	SetStakingProductAPY(ctx context.Context, arg SetStakingProductAPYParams) (int64, error)
+	SetTokenActive(ctx context.Context, arg SetTokenActiveParams) (int64, error)
+	SnapshotDelegates(ctx context.Context, proposalID pgtype.UUID) error
+	SnapshotDelegations(ctx context.Context, arg SnapshotDelegationsParams) error
+	StartUnbondingMaturedStakes(ctx context.Context, defaultUnbondingDays int32) (int64, error)
+	// internal/db/queries/locks.sql
+	TryAdvisoryLock(ctx context.Context, lockKey int64) (bool, error)
 	// internal/db/queries/assets.sql
 	UpdateAssetPrice(ctx context.Context, arg UpdateAssetPriceParams) error
+	UpdateCommentBody(ctx context.Context, arg UpdateCommentBodyParams) (ProposalComment, error)
+	// Stores a farm's accumulator after accrual, its staked total and the
+	// rewards it has paid out.
+	UpdateFarm(ctx context.Context, arg UpdateFarmParams) error
+	UpdateFarmStake(ctx context.Context, arg UpdateFarmStakeParams) (FarmStake, error)
 	UpdateLoginAttempts(ctx context.Context, arg UpdateLoginAttemptsParams) error
+	// Sets a position's amounts and shares, first settling the fees its old
+	// shares earned up to the pool's current fee growth.
+	UpdatePosition(ctx context.Context, arg UpdatePositionParams) (UserLiquidity, error)
 	UpdateProposalVotes(ctx context.Context, arg UpdateProposalVotesParams) error
+	UpdateStakePosition(ctx context.Context, arg UpdateStakePositionParams) error
 	UpdateStakeRewards(ctx context.Context, arg UpdateStakeRewardsParams) error
+	// Adds a position's liquidity to one of its ticks. A tick being
+	// initialized takes the given fee growth outside; one already in use keeps
+	// its own.
+	UpdateTick(ctx context.Context, arg UpdateTickParams) error
 	UpdateUser2FA(ctx context.Context, arg UpdateUser2FAParams) error
 	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error
 	UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) error
+	// internal/db/queries/delegations.sql
+	UpsertDelegation(ctx context.Context, arg UpsertDelegationParams) (VoteDelegation, error)
+	UpsertSecurityThreshold(ctx context.Context, arg UpsertSecurityThresholdParams) error
+	UpsertToken(ctx context.Context, arg UpsertTokenParams) (Token, error)
 }
 
 var _ Querier = (*Queries)(nil)
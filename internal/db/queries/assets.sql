@@ -16,9 +16,31 @@ SELECT
     COUNT(*) as total_assets
 FROM assets;
 
+-- name: GetAssetTVLByToken :many
+-- Each token's stored TVL and the amount of it that TVL represents at the
+-- stored market price, null where there is no price to divide by.
+SELECT t.symbol::text AS symbol,
+    COALESCE(SUM(a.total_value_locked), 0)::decimal AS total_value_locked,
+    SUM(a.total_value_locked / NULLIF(a.market_price, 0))::decimal AS locked_amount
+FROM assets a
+JOIN tokens t ON t.id = a.token_id
+GROUP BY t.symbol
+ORDER BY t.symbol;
+
 -- name: GetTokenList :many
 SELECT t.*, a.market_price, a.price_change_24h
 FROM tokens t
 LEFT JOIN assets a ON t.id = a.token_id
 WHERE t.is_active = true
-ORDER BY t.symbol;
\ No newline at end of file
+ORDER BY t.symbol;
+
+-- name: UpsertToken :one
+INSERT INTO tokens (symbol, name, contract_address, decimals, is_active)
+VALUES ($1, $2, $3, $4, true)
+ON CONFLICT (symbol)
+DO UPDATE SET name = EXCLUDED.name, contract_address = EXCLUDED.contract_address,
+    decimals = EXCLUDED.decimals, is_active = true
+RETURNING *;
+
+-- name: SetTokenActive :execrows
+UPDATE tokens SET is_active = $2 WHERE symbol = $1;
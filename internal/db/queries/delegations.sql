@@ -0,0 +1,67 @@
+-- internal/db/queries/delegations.sql
+-- name: UpsertDelegation :one
+INSERT INTO vote_delegations (delegator_id, delegate_id, token_symbol)
+VALUES ($1, $2, $3)
+ON CONFLICT (delegator_id, (COALESCE(token_symbol, '')))
+DO UPDATE SET delegate_id = EXCLUDED.delegate_id, updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: DeleteDelegation :execrows
+DELETE FROM vote_delegations
+WHERE delegator_id = sqlc.arg(delegator_id)::uuid AND token_symbol IS NOT DISTINCT FROM sqlc.narg(token_symbol)::text;
+
+-- name: ListDelegationsByDelegator :many
+SELECT * FROM vote_delegations
+WHERE delegator_id = $1
+ORDER BY token_symbol NULLS FIRST;
+
+-- name: ListDelegationsByDelegate :many
+SELECT * FROM vote_delegations
+WHERE delegate_id = $1
+ORDER BY created_at DESC;
+
+-- name: GetEffectiveDelegate :one
+SELECT delegate_id FROM vote_delegations
+WHERE delegator_id = $1 AND (token_symbol = $2 OR token_symbol IS NULL)
+ORDER BY token_symbol NULLS LAST
+LIMIT 1;
+
+-- name: ListDelegationSymbols :many
+SELECT DISTINCT token_symbol::text AS token_symbol FROM vote_delegations
+WHERE token_symbol IS NOT NULL;
+
+-- name: ListDelegates :many
+WITH RECURSIVE effective AS (
+    SELECT DISTINCT ON (d.delegator_id) d.delegator_id, d.delegate_id
+    FROM vote_delegations d
+    WHERE d.token_symbol = sqlc.arg(symbol)::text OR d.token_symbol IS NULL
+    ORDER BY d.delegator_id, d.token_symbol NULLS LAST
+),
+balances AS (
+    SELECT s.user_id, SUM(s.amount * CASE
+        WHEN sqlc.arg(lock_weighting)::bool THEN
+            1 + LEAST(GREATEST(EXTRACT(EPOCH FROM (s.end_date - s.start_date)) / 86400, 0), 365) / 365
+        ELSE 1
+    END) AS amount
+    FROM stakes s
+    JOIN tokens t ON s.token_id = t.id
+    WHERE t.symbol = sqlc.arg(symbol)::text AND s.status = 'active'
+    GROUP BY s.user_id
+),
+chain AS (
+    SELECT e.delegator_id AS holder, e.delegate_id AS receiver, 1 AS depth
+    FROM effective e
+    UNION ALL
+    SELECT c.holder, e.delegate_id, c.depth + 1
+    FROM chain c
+    JOIN effective e ON e.delegator_id = c.receiver
+    WHERE c.depth < 32 AND e.delegate_id <> c.holder
+)
+SELECT c.receiver::uuid AS delegate_id,
+    COUNT(DISTINCT c.holder)::bigint AS delegator_count,
+    COALESCE(SUM(b.amount), 0)::decimal AS received_power
+FROM chain c
+LEFT JOIN balances b ON b.user_id = c.holder
+GROUP BY c.receiver
+ORDER BY received_power DESC, delegator_count DESC
+LIMIT sqlc.arg(page_limit)::int OFFSET sqlc.arg(page_offset)::int;
@@ -0,0 +1,67 @@
+-- internal/db/queries/discussions.sql
+-- name: CreateComment :one
+INSERT INTO proposal_comments (proposal_id, author_id, parent_id, body)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetComment :one
+SELECT * FROM proposal_comments WHERE id = $1;
+
+-- name: GetCommentForUpdate :one
+SELECT * FROM proposal_comments WHERE id = $1 FOR UPDATE;
+
+-- name: ListProposalComments :many
+SELECT * FROM proposal_comments
+WHERE proposal_id = $1
+ORDER BY created_at, id;
+
+-- name: UpdateCommentBody :one
+UPDATE proposal_comments
+SET body = $2, edited_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateCommentEdit :exec
+INSERT INTO proposal_comment_edits (comment_id, previous_body)
+VALUES ($1, $2);
+
+-- name: ListCommentEdits :many
+SELECT * FROM proposal_comment_edits
+WHERE comment_id = $1
+ORDER BY edited_at DESC;
+
+-- name: SetCommentHidden :one
+UPDATE proposal_comments
+SET hidden = sqlc.arg(hidden)::bool,
+    hidden_by = sqlc.narg(hidden_by)::uuid,
+    hidden_at = CASE WHEN sqlc.arg(hidden)::bool THEN CURRENT_TIMESTAMP ELSE NULL END,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id)::uuid
+RETURNING *;
+
+-- name: AddCommentReaction :execrows
+INSERT INTO proposal_comment_reactions (comment_id, user_id, reaction)
+VALUES ($1, $2, $3)
+ON CONFLICT (comment_id, user_id, reaction) DO NOTHING;
+
+-- name: RemoveCommentReaction :execrows
+DELETE FROM proposal_comment_reactions
+WHERE comment_id = $1 AND user_id = $2 AND reaction = $3;
+
+-- name: ListProposalReactions :many
+SELECT r.comment_id::uuid AS comment_id, r.reaction::text AS reaction,
+    COUNT(*)::bigint AS reaction_count,
+    BOOL_OR(r.user_id = sqlc.arg(user_id)::uuid)::bool AS reacted
+FROM proposal_comment_reactions r
+JOIN proposal_comments c ON c.id = r.comment_id
+WHERE c.proposal_id = sqlc.arg(proposal_id)::uuid
+GROUP BY r.comment_id, r.reaction
+ORDER BY r.comment_id, r.reaction;
+
+-- name: SetDiscussionLock :one
+UPDATE governance_proposals
+SET discussion_locked_at = CASE WHEN sqlc.arg(locked)::bool THEN CURRENT_TIMESTAMP ELSE NULL END,
+    discussion_locked_by = sqlc.narg(locked_by)::uuid,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id)::uuid
+RETURNING *;
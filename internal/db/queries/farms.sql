@@ -0,0 +1,101 @@
+-- internal/db/queries/farms.sql
+-- name: CreateFarm :one
+-- Creates a farm paying the token with the given symbol; no row is
+-- returned if the token does not exist.
+INSERT INTO liquidity_farms (pool_id, reward_token_id, reward_per_day, starts_at, ends_at, last_update_at, created_by)
+SELECT sqlc.arg(pool_id)::uuid, t.id, sqlc.arg(reward_per_day)::decimal,
+    sqlc.arg(starts_at)::timestamp, sqlc.arg(ends_at)::timestamp, sqlc.arg(starts_at)::timestamp,
+    sqlc.arg(created_by)::uuid
+FROM tokens t
+WHERE t.symbol = sqlc.arg(reward_token)::text
+RETURNING *;
+
+-- name: ListFarms :many
+-- Farms with their pool, reward token and the pool's share supply and
+-- TVL, newest first. Ended farms are left out unless include_ended.
+SELECT f.id::uuid AS id, f.pool_id::uuid AS pool_id, p.name::text AS pool_name,
+    t.symbol::text AS reward_token,
+    COALESCE((SELECT a.market_price FROM assets a WHERE a.token_id = f.reward_token_id), 0)::decimal AS reward_price,
+    f.reward_per_day::decimal AS reward_per_day, f.starts_at::timestamp AS starts_at, f.ends_at::timestamp AS ends_at,
+    f.total_staked::decimal AS total_staked, f.reward_per_share::decimal AS reward_per_share,
+    f.last_update_at::timestamp AS last_update_at, f.rewards_paid::decimal AS rewards_paid,
+    p.total_shares::decimal AS pool_shares, COALESCE(p.total_liquidity, 0)::decimal AS pool_tvl,
+    f.created_at::timestamp AS created_at
+FROM liquidity_farms f
+JOIN liquidity_pools p ON p.id = f.pool_id
+JOIN tokens t ON t.id = f.reward_token_id
+WHERE sqlc.arg(include_ended)::bool OR f.ends_at > CURRENT_TIMESTAMP
+ORDER BY f.created_at DESC, f.id;
+
+-- name: GetFarm :one
+SELECT f.id::uuid AS id, f.pool_id::uuid AS pool_id, p.name::text AS pool_name,
+    t.symbol::text AS reward_token,
+    COALESCE((SELECT a.market_price FROM assets a WHERE a.token_id = f.reward_token_id), 0)::decimal AS reward_price,
+    f.reward_per_day::decimal AS reward_per_day, f.starts_at::timestamp AS starts_at, f.ends_at::timestamp AS ends_at,
+    f.total_staked::decimal AS total_staked, f.reward_per_share::decimal AS reward_per_share,
+    f.last_update_at::timestamp AS last_update_at, f.rewards_paid::decimal AS rewards_paid,
+    p.total_shares::decimal AS pool_shares, COALESCE(p.total_liquidity, 0)::decimal AS pool_tvl,
+    f.created_at::timestamp AS created_at
+FROM liquidity_farms f
+JOIN liquidity_pools p ON p.id = f.pool_id
+JOIN tokens t ON t.id = f.reward_token_id
+WHERE f.id = $1;
+
+-- name: GetFarmForUpdate :one
+SELECT * FROM liquidity_farms WHERE id = $1 FOR UPDATE;
+
+-- name: UpdateFarm :exec
+-- Stores a farm's accumulator after accrual, its staked total and the
+-- rewards it has paid out.
+UPDATE liquidity_farms
+SET reward_per_share = sqlc.arg(reward_per_share)::decimal,
+    last_update_at = sqlc.arg(last_update_at)::timestamp,
+    total_staked = sqlc.arg(total_staked)::decimal,
+    rewards_paid = sqlc.arg(rewards_paid)::decimal,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id)::uuid;
+
+-- name: GetOpenFarmStakeForUpdate :one
+SELECT * FROM farm_stakes
+WHERE farm_id = $1 AND user_id = $2 AND status = 'active'
+FOR UPDATE;
+
+-- name: CreateFarmStake :one
+INSERT INTO farm_stakes (farm_id, user_id, position_id, shares, reward_per_share_paid)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: UpdateFarmStake :one
+UPDATE farm_stakes
+SET shares = sqlc.arg(shares)::decimal,
+    reward_per_share_paid = sqlc.arg(reward_per_share_paid)::decimal,
+    rewards_pending = sqlc.arg(rewards_pending)::decimal,
+    rewards_claimed = sqlc.arg(rewards_claimed)::decimal,
+    status = sqlc.arg(status)::text,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id)::uuid
+RETURNING *;
+
+-- name: AddFarmedShares :exec
+-- Locks (positive delta) or releases (negative delta) a position's shares.
+UPDATE user_liquidity
+SET farmed_shares = farmed_shares + sqlc.arg(delta)::decimal, updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id)::uuid;
+
+-- name: ListUserFarmStakes :many
+-- A user's open farm stakes with the farm state needed to compute their
+-- pending rewards.
+SELECT s.id::uuid AS id, s.farm_id::uuid AS farm_id, s.position_id::uuid AS position_id,
+    f.pool_id::uuid AS pool_id, p.name::text AS pool_name, t.symbol::text AS reward_token,
+    s.shares::decimal AS shares, s.reward_per_share_paid::decimal AS reward_per_share_paid,
+    s.rewards_pending::decimal AS rewards_pending, s.rewards_claimed::decimal AS rewards_claimed,
+    s.status::text AS status, s.created_at::timestamp AS created_at,
+    f.reward_per_day::decimal AS reward_per_day, f.starts_at::timestamp AS starts_at, f.ends_at::timestamp AS ends_at,
+    f.total_staked::decimal AS total_staked, f.reward_per_share::decimal AS reward_per_share,
+    f.last_update_at::timestamp AS last_update_at
+FROM farm_stakes s
+JOIN liquidity_farms f ON f.id = s.farm_id
+JOIN liquidity_pools p ON p.id = f.pool_id
+JOIN tokens t ON t.id = f.reward_token_id
+WHERE s.user_id = $1 AND s.status = 'active'
+ORDER BY s.created_at DESC;
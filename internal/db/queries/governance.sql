@@ -2,8 +2,9 @@
 -- name: CreateProposal :one
 INSERT INTO governance_proposals (
     title, description, proposer_id, proposal_type, 
-    voting_end, quorum, threshold
-) VALUES ($1, $2, $3, $4, $5, $6, $7)
+    voting_end, quorum, threshold, voting_start, status, payload,
+    voting_method, options
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 RETURNING *;
 
 -- name: GetActiveProposals :many
@@ -14,11 +15,171 @@ ORDER BY created_at DESC;
 -- name: GetProposalByID :one
 SELECT * FROM governance_proposals WHERE id = $1;
 
+-- name: GetProposalForUpdate :one
+SELECT * FROM governance_proposals WHERE id = $1 FOR UPDATE;
+
+-- name: CreateVoteSnapshot :execrows
+INSERT INTO governance_snapshots (proposal_id, user_id, staked_amount, vote_power)
+SELECT sqlc.arg(proposal_id)::uuid, s.user_id, SUM(s.amount)::decimal,
+    SUM(s.amount * CASE
+        WHEN sqlc.arg(lock_weighting)::bool THEN
+            1 + LEAST(GREATEST(EXTRACT(EPOCH FROM (s.end_date - s.start_date)) / 86400, 0), 365) / 365
+        ELSE 1
+    END)::decimal
+FROM stakes s
+JOIN tokens t ON s.token_id = t.id
+WHERE t.symbol = sqlc.arg(symbol)::text AND s.status = 'active' AND s.user_id IS NOT NULL
+GROUP BY s.user_id
+ON CONFLICT (proposal_id, user_id) DO NOTHING;
+
+-- name: SnapshotDelegations :exec
+INSERT INTO governance_snapshots (proposal_id, user_id, staked_amount, vote_power, delegate_id)
+SELECT DISTINCT ON (d.delegator_id) sqlc.arg(proposal_id)::uuid, d.delegator_id, 0::decimal, 0::decimal, d.delegate_id
+FROM vote_delegations d
+WHERE d.token_symbol = sqlc.arg(symbol)::text OR d.token_symbol IS NULL
+ORDER BY d.delegator_id, d.token_symbol NULLS LAST
+ON CONFLICT (proposal_id, user_id) DO UPDATE SET delegate_id = EXCLUDED.delegate_id;
+
+-- name: SnapshotDelegates :exec
+INSERT INTO governance_snapshots (proposal_id, user_id, staked_amount, vote_power)
+SELECT DISTINCT sqlc.arg(proposal_id)::uuid, g.delegate_id, 0::decimal, 0::decimal
+FROM governance_snapshots g
+WHERE g.proposal_id = sqlc.arg(proposal_id)::uuid AND g.delegate_id IS NOT NULL
+ON CONFLICT (proposal_id, user_id) DO NOTHING;
+
+-- name: CountSnapshotDelegators :one
+SELECT COUNT(*) FROM governance_snapshots
+WHERE proposal_id = $1 AND delegate_id = $2;
+
+-- name: GetDelegatedVotePower :one
+WITH RECURSIVE chain AS (
+    SELECT s.user_id AS holder, s.user_id AS receiver, s.vote_power, 0 AS depth
+    FROM governance_snapshots s
+    WHERE s.proposal_id = sqlc.arg(proposal_id)::uuid
+    UNION ALL
+    SELECT c.holder, s.delegate_id, c.vote_power, c.depth + 1
+    FROM chain c
+    JOIN governance_snapshots s ON s.proposal_id = sqlc.arg(proposal_id)::uuid AND s.user_id = c.receiver
+    WHERE s.delegate_id IS NOT NULL AND c.depth < 32
+      AND NOT EXISTS (SELECT 1 FROM user_votes v WHERE v.proposal_id = sqlc.arg(proposal_id)::uuid AND v.user_id = c.receiver)
+)
+SELECT COALESCE(SUM(c.vote_power), 0)::decimal AS delegated_power
+FROM chain c
+WHERE c.receiver = sqlc.arg(user_id)::uuid AND c.holder <> sqlc.arg(user_id)::uuid;
+
+-- name: ActivateProposal :one
+UPDATE governance_proposals
+SET status = 'active',
+    snapshot_at = CURRENT_TIMESTAMP,
+    eligible_power = (SELECT COALESCE(SUM(g.vote_power), 0) FROM governance_snapshots g WHERE g.proposal_id = $1),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
+
+-- name: ListProposalsToActivate :many
+SELECT id FROM governance_proposals
+WHERE status = 'pending' AND voting_start <= CURRENT_TIMESTAMP
+ORDER BY voting_start;
+
+-- name: ListProposalsToFinalize :many
+SELECT id FROM governance_proposals
+WHERE status = 'active' AND voting_end <= CURRENT_TIMESTAMP
+ORDER BY voting_end;
+
+-- name: FinalizeProposal :one
+UPDATE governance_proposals
+SET status = $2, result = $3, executable_at = $4, ballot_root = $5, ballot_count = $6,
+    finalized_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'active'
+RETURNING *;
+
+-- name: ListProposalsToExecute :many
+SELECT id FROM governance_proposals
+WHERE status = 'passed' AND executable_at IS NOT NULL AND executable_at <= CURRENT_TIMESTAMP
+ORDER BY executable_at;
+
+-- name: MarkProposalExecuted :one
+UPDATE governance_proposals
+SET status = $2, execution_error = $3, executed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'passed'
+RETURNING *;
+
+-- name: CancelProposal :one
+UPDATE governance_proposals
+SET status = 'cancelled', cancelled_at = CURRENT_TIMESTAMP, cancelled_by = $2, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'passed' AND executable_at > CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: GetVoteSnapshot :one
+SELECT * FROM governance_snapshots
+WHERE proposal_id = $1 AND user_id = $2;
+
+-- name: ListEffectiveVotes :many
+-- Each direct voter with the snapshotted power that reaches them: their own
+-- plus that of holders whose delegation chain ends at them. root_power sums
+-- the square root of each of those holders' power, for quadratic voting.
+WITH RECURSIVE chain AS (
+    SELECT s.user_id AS holder, s.user_id AS receiver, s.vote_power, 0 AS depth
+    FROM governance_snapshots s
+    WHERE s.proposal_id = sqlc.arg(proposal_id)::uuid
+    UNION ALL
+    SELECT c.holder, s.delegate_id, c.vote_power, c.depth + 1
+    FROM chain c
+    JOIN governance_snapshots s ON s.proposal_id = sqlc.arg(proposal_id)::uuid AND s.user_id = c.receiver
+    WHERE s.delegate_id IS NOT NULL AND c.depth < 32
+      AND NOT EXISTS (SELECT 1 FROM user_votes v WHERE v.proposal_id = sqlc.arg(proposal_id)::uuid AND v.user_id = c.receiver)
+)
+SELECT v.user_id::uuid AS user_id, v.vote_choice::text AS vote_choice, v.ballot::jsonb AS ballot,
+    SUM(c.vote_power)::decimal AS vote_power,
+    SUM(SQRT(c.vote_power))::decimal AS root_power
+FROM chain c
+JOIN user_votes v ON v.proposal_id = sqlc.arg(proposal_id)::uuid AND v.user_id = c.receiver
+GROUP BY v.user_id, v.vote_choice, v.ballot
+ORDER BY v.user_id;
+
+-- name: ListProposalBallots :many
+-- Every snapshot holder of a proposal with their delegation and, if they
+-- voted directly, their ballot.
+SELECT s.user_id::uuid AS voter, s.vote_power::decimal AS snapshot_power, s.delegate_id::uuid AS delegate_id,
+    COALESCE(v.vote_choice, '')::text AS vote_choice, v.ballot::jsonb AS ballot, v.voted_at::timestamp AS voted_at
+FROM governance_snapshots s
+LEFT JOIN user_votes v ON v.proposal_id = s.proposal_id AND v.user_id = s.user_id
+WHERE s.proposal_id = $1
+ORDER BY s.user_id;
+
+-- name: SetProposalTallies :one
+UPDATE governance_proposals
+SET for_votes = $2, against_votes = $3, abstain_votes = $4, total_votes = $5, option_votes = $6, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: ListProposals :many
+SELECT * FROM governance_proposals
+WHERE (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text)
+  AND (sqlc.narg(proposal_type)::text IS NULL OR proposal_type = sqlc.narg(proposal_type)::text)
+ORDER BY created_at DESC
+LIMIT sqlc.arg(page_limit)::int OFFSET sqlc.arg(page_offset)::int;
+
+-- name: CountProposals :one
+SELECT COUNT(*) FROM governance_proposals
+WHERE (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text)
+  AND (sqlc.narg(proposal_type)::text IS NULL OR proposal_type = sqlc.narg(proposal_type)::text);
+
+-- name: CountOpenProposalsByProposer :one
+SELECT COUNT(*) FROM governance_proposals
+WHERE proposer_id = $1 AND status IN ('pending', 'active');
+
+-- name: GetUserStakedBalance :one
+SELECT COALESCE(SUM(s.amount), 0)::decimal AS staked
+FROM stakes s
+JOIN tokens t ON s.token_id = t.id
+WHERE s.user_id = $1 AND t.symbol = $2 AND s.status = 'active';
+
 -- name: CastVote :one
-INSERT INTO user_votes (user_id, proposal_id, vote_power, vote_choice)
-VALUES ($1, $2, $3, $4)
+INSERT INTO user_votes (user_id, proposal_id, vote_power, vote_choice, ballot)
+VALUES ($1, $2, $3, $4, $5)
 ON CONFLICT (user_id, proposal_id) 
-DO UPDATE SET vote_power = EXCLUDED.vote_power, vote_choice = EXCLUDED.voice_choice
+DO UPDATE SET vote_power = EXCLUDED.vote_power, vote_choice = EXCLUDED.vote_choice, ballot = EXCLUDED.ballot, voted_at = CURRENT_TIMESTAMP
 RETURNING *;
 
 -- name: UpdateProposalVotes :exec
@@ -33,4 +194,80 @@ SET
 WHERE id = $1;
 
 -- name: GetUserVotes :many
-SELECT * FROM user_votes WHERE user_id = $1;
\ No newline at end of file
+-- A user's votes, newest first, with the state of each proposal.
+SELECT v.proposal_id::uuid AS proposal_id, p.title::text AS title, COALESCE(p.status, '')::text AS status,
+    p.voting_method::text AS voting_method, v.vote_choice::text AS vote_choice, v.ballot::jsonb AS ballot,
+    v.vote_power::decimal AS vote_power, v.voted_at::timestamp AS voted_at, p.result::jsonb AS result
+FROM user_votes v
+JOIN governance_proposals p ON p.id = v.proposal_id
+WHERE v.user_id = sqlc.arg(user_id)::uuid
+ORDER BY v.voted_at DESC, v.proposal_id
+LIMIT sqlc.arg(page_limit)::int OFFSET sqlc.arg(page_offset)::int;
+
+-- name: CountUserVotes :one
+SELECT COUNT(*) FROM user_votes WHERE user_id = $1;
+
+-- name: ListProposalTurnout :many
+-- Power reaching direct voters per time bucket, delegated power included,
+-- counted when the voter it reaches cast their (latest) vote.
+WITH RECURSIVE chain AS (
+    SELECT s.user_id AS holder, s.user_id AS receiver, s.vote_power, 0 AS depth
+    FROM governance_snapshots s
+    WHERE s.proposal_id = sqlc.arg(proposal_id)::uuid
+    UNION ALL
+    SELECT c.holder, s.delegate_id, c.vote_power, c.depth + 1
+    FROM chain c
+    JOIN governance_snapshots s ON s.proposal_id = sqlc.arg(proposal_id)::uuid AND s.user_id = c.receiver
+    WHERE s.delegate_id IS NOT NULL AND c.depth < 32
+      AND NOT EXISTS (SELECT 1 FROM user_votes v WHERE v.proposal_id = sqlc.arg(proposal_id)::uuid AND v.user_id = c.receiver)
+)
+SELECT date_trunc(sqlc.arg(bucket)::text, v.voted_at)::timestamp AS bucket,
+    COUNT(DISTINCT v.user_id)::bigint AS voters,
+    SUM(c.vote_power)::decimal AS power
+FROM chain c
+JOIN user_votes v ON v.proposal_id = sqlc.arg(proposal_id)::uuid AND v.user_id = c.receiver
+GROUP BY 1
+ORDER BY 1;
+
+-- name: ListProposalParticipation :many
+-- Finalized proposals, most recently closed first, with their turnout.
+SELECT p.id::uuid AS id, p.title::text AS title, COALESCE(p.status, '')::text AS status,
+    p.voting_method::text AS voting_method, p.eligible_power::decimal AS eligible_power,
+    COALESCE(p.total_votes, 0)::decimal AS total_votes,
+    (SELECT COUNT(*) FROM user_votes v WHERE v.proposal_id = p.id)::bigint AS voters,
+    (SELECT COUNT(*) FROM governance_snapshots s WHERE s.proposal_id = p.id AND s.vote_power > 0)::bigint AS holders,
+    p.finalized_at::timestamp AS finalized_at
+FROM governance_proposals p
+WHERE p.finalized_at IS NOT NULL
+ORDER BY p.finalized_at DESC
+LIMIT sqlc.arg(page_limit)::int OFFSET sqlc.arg(page_offset)::int;
+
+-- name: GetParticipationSummary :one
+SELECT COUNT(*)::bigint AS proposals,
+    COALESCE(AVG(CASE WHEN p.eligible_power > 0 THEN p.total_votes / p.eligible_power * 100 END), 0)::decimal AS average_turnout,
+    (SELECT COUNT(DISTINCT v.user_id) FROM user_votes v)::bigint AS unique_voters
+FROM governance_proposals p
+WHERE p.finalized_at IS NOT NULL;
+
+-- name: ListTopVoters :many
+-- Users by votes cast, with how many snapshots they held power in.
+SELECT v.user_id::uuid AS user_id, COALESCE(u.wallet_address, '')::text AS wallet_address,
+    COUNT(*)::bigint AS votes_cast,
+    (SELECT COUNT(*) FROM governance_snapshots s WHERE s.user_id = v.user_id AND s.vote_power > 0)::bigint AS eligible_proposals,
+    SUM(v.vote_power)::decimal AS power_cast,
+    MAX(v.voted_at)::timestamp AS last_voted_at
+FROM user_votes v
+LEFT JOIN users u ON u.id = v.user_id
+GROUP BY v.user_id, u.wallet_address
+ORDER BY votes_cast DESC, power_cast DESC, v.user_id
+LIMIT sqlc.arg(page_limit)::int OFFSET sqlc.arg(page_offset)::int;
+
+-- name: RecordProposalEvent :exec
+INSERT INTO proposal_events (proposal_id, event_type, actor_id, data)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (proposal_id, event_type) DO NOTHING;
+
+-- name: ListProposalEvents :many
+SELECT * FROM proposal_events
+WHERE proposal_id = $1
+ORDER BY created_at, id;
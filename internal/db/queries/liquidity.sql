@@ -0,0 +1,373 @@
+-- internal/db/queries/liquidity.sql
+-- name: ListPools :many
+-- Pools that are not deprecated with their token pair, optionally only
+-- those containing the token symbol, largest first.
+SELECT p.id::uuid AS id, p.name::text AS name,
+    p.token0_id::uuid AS token0_id, t0.symbol::text AS token0_symbol,
+    p.token1_id::uuid AS token1_id, t1.symbol::text AS token1_symbol,
+    COALESCE(p.total_liquidity, 0)::decimal AS total_liquidity, COALESCE(p.apr, 0)::decimal AS apr,
+    p.reserve0::decimal AS reserve0, p.reserve1::decimal AS reserve1, p.total_shares::decimal AS total_shares,
+    COALESCE(p.is_active, false)::bool AS is_active, p.created_at::timestamp AS created_at,
+    p.fee_bps::int AS fee_bps, p.status::text AS status,
+    p.pool_type::text AS pool_type, p.tick_spacing::int AS tick_spacing,
+    p.sqrt_price::decimal AS sqrt_price, p.tick::int AS tick, p.liquidity::decimal AS liquidity,
+    (SELECT COUNT(DISTINCT ul.user_id) FROM user_liquidity ul WHERE ul.pool_id = p.id AND ul.status = 'active')::bigint AS providers
+FROM liquidity_pools p
+JOIN tokens t0 ON t0.id = p.token0_id
+JOIN tokens t1 ON t1.id = p.token1_id
+WHERE p.status <> 'deprecated'
+  AND (sqlc.narg(symbol)::text IS NULL OR t0.symbol = sqlc.narg(symbol)::text OR t1.symbol = sqlc.narg(symbol)::text)
+ORDER BY total_liquidity DESC, p.name;
+
+-- name: GetPool :one
+SELECT p.id::uuid AS id, p.name::text AS name,
+    p.token0_id::uuid AS token0_id, t0.symbol::text AS token0_symbol,
+    p.token1_id::uuid AS token1_id, t1.symbol::text AS token1_symbol,
+    COALESCE(p.total_liquidity, 0)::decimal AS total_liquidity, COALESCE(p.apr, 0)::decimal AS apr,
+    p.reserve0::decimal AS reserve0, p.reserve1::decimal AS reserve1, p.total_shares::decimal AS total_shares,
+    COALESCE(p.is_active, false)::bool AS is_active, p.created_at::timestamp AS created_at,
+    p.fee_bps::int AS fee_bps, p.status::text AS status,
+    p.pool_type::text AS pool_type, p.tick_spacing::int AS tick_spacing,
+    p.sqrt_price::decimal AS sqrt_price, p.tick::int AS tick, p.liquidity::decimal AS liquidity,
+    (SELECT COUNT(DISTINCT ul.user_id) FROM user_liquidity ul WHERE ul.pool_id = p.id AND ul.status = 'active')::bigint AS providers
+FROM liquidity_pools p
+JOIN tokens t0 ON t0.id = p.token0_id
+JOIN tokens t1 ON t1.id = p.token1_id
+WHERE p.id = $1;
+
+-- name: GetTokenBySymbol :one
+SELECT id, symbol, COALESCE(is_active, false)::bool AS is_active FROM tokens WHERE symbol = $1;
+
+-- name: PoolPairExists :one
+-- Whether a live pool already pairs the two tokens, in either order, at
+-- the fee tier.
+SELECT EXISTS (
+    SELECT 1 FROM liquidity_pools
+    WHERE LEAST(token0_id, token1_id) = LEAST(sqlc.arg(token_a)::uuid, sqlc.arg(token_b)::uuid)
+      AND GREATEST(token0_id, token1_id) = GREATEST(sqlc.arg(token_a)::uuid, sqlc.arg(token_b)::uuid)
+      AND fee_bps = sqlc.arg(fee_bps)::int
+      AND status <> 'deprecated'
+)::bool AS exists;
+
+-- name: CreatePool :one
+INSERT INTO liquidity_pools (name, token0_id, token1_id, fee_bps, pool_type, tick_spacing, sqrt_price, tick, status, is_active)
+VALUES (sqlc.arg(name)::text, sqlc.arg(token0_id)::uuid, sqlc.arg(token1_id)::uuid, sqlc.arg(fee_bps)::int,
+    sqlc.arg(pool_type)::text, sqlc.arg(tick_spacing)::int, sqlc.arg(sqrt_price)::decimal, sqlc.arg(tick)::int, 'active', true)
+RETURNING *;
+
+-- name: SetPoolFee :exec
+UPDATE liquidity_pools SET fee_bps = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1;
+
+-- name: SetPoolStatus :exec
+UPDATE liquidity_pools
+SET status = sqlc.arg(status)::text, is_active = sqlc.arg(status)::text = 'active', updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id)::uuid;
+
+-- name: ListUserPositions :many
+-- A user's active positions with their pool's reserves, share supply,
+-- current price and token prices, and the fees earned including those not
+-- yet settled. A range position earns only the fee growth inside its
+-- ticks.
+SELECT ul.id::uuid AS id, ul.pool_id::uuid AS pool_id, p.name::text AS pool_name,
+    t0.symbol::text AS token0_symbol, t1.symbol::text AS token1_symbol,
+    ul.amount0::decimal AS amount0, ul.amount1::decimal AS amount1, ul.shares::decimal AS shares,
+    COALESCE(ul.status, '')::text AS status, ul.created_at::timestamp AS created_at,
+    p.reserve0::decimal AS reserve0, p.reserve1::decimal AS reserve1, p.total_shares::decimal AS pool_shares,
+    COALESCE((SELECT a.market_price FROM assets a WHERE a.token_id = p.token0_id), 0)::decimal AS price0,
+    COALESCE((SELECT a.market_price FROM assets a WHERE a.token_id = p.token1_id), 0)::decimal AS price1,
+    ul.nft_id::bigint AS nft_id, ul.tick_lower, ul.tick_upper, p.pool_type::text AS pool_type,
+    p.sqrt_price::decimal AS sqrt_price, p.tick::int AS pool_tick, p.liquidity::decimal AS pool_liquidity,
+    (ul.fees0 + ul.shares * (CASE WHEN ul.tick_lower IS NULL THEN p.fee_growth0 ELSE p.fee_growth0
+        - CASE WHEN p.tick >= ul.tick_lower THEN COALESCE(lo.fee_growth_outside0, 0) ELSE p.fee_growth0 - COALESCE(lo.fee_growth_outside0, 0) END
+        - CASE WHEN p.tick < ul.tick_upper THEN COALESCE(hi.fee_growth_outside0, 0) ELSE p.fee_growth0 - COALESCE(hi.fee_growth_outside0, 0) END
+    END - ul.fee_growth0_last))::decimal AS fees0,
+    (ul.fees1 + ul.shares * (CASE WHEN ul.tick_lower IS NULL THEN p.fee_growth1 ELSE p.fee_growth1
+        - CASE WHEN p.tick >= ul.tick_lower THEN COALESCE(lo.fee_growth_outside1, 0) ELSE p.fee_growth1 - COALESCE(lo.fee_growth_outside1, 0) END
+        - CASE WHEN p.tick < ul.tick_upper THEN COALESCE(hi.fee_growth_outside1, 0) ELSE p.fee_growth1 - COALESCE(hi.fee_growth_outside1, 0) END
+    END - ul.fee_growth1_last))::decimal AS fees1
+FROM user_liquidity ul
+JOIN liquidity_pools p ON p.id = ul.pool_id
+JOIN tokens t0 ON t0.id = p.token0_id
+JOIN tokens t1 ON t1.id = p.token1_id
+LEFT JOIN pool_ticks lo ON lo.pool_id = ul.pool_id AND lo.tick = ul.tick_lower
+LEFT JOIN pool_ticks hi ON hi.pool_id = ul.pool_id AND hi.tick = ul.tick_upper
+WHERE ul.user_id = $1 AND ul.status = 'active'
+ORDER BY ul.created_at DESC;
+
+-- name: GetUserPosition :one
+SELECT ul.id::uuid AS id, ul.pool_id::uuid AS pool_id, p.name::text AS pool_name,
+    t0.symbol::text AS token0_symbol, t1.symbol::text AS token1_symbol,
+    ul.amount0::decimal AS amount0, ul.amount1::decimal AS amount1, ul.shares::decimal AS shares,
+    COALESCE(ul.status, '')::text AS status, ul.created_at::timestamp AS created_at,
+    p.reserve0::decimal AS reserve0, p.reserve1::decimal AS reserve1, p.total_shares::decimal AS pool_shares,
+    COALESCE((SELECT a.market_price FROM assets a WHERE a.token_id = p.token0_id), 0)::decimal AS price0,
+    COALESCE((SELECT a.market_price FROM assets a WHERE a.token_id = p.token1_id), 0)::decimal AS price1,
+    ul.nft_id::bigint AS nft_id, ul.tick_lower, ul.tick_upper, p.pool_type::text AS pool_type,
+    p.sqrt_price::decimal AS sqrt_price, p.tick::int AS pool_tick, p.liquidity::decimal AS pool_liquidity,
+    (ul.fees0 + ul.shares * (CASE WHEN ul.tick_lower IS NULL THEN p.fee_growth0 ELSE p.fee_growth0
+        - CASE WHEN p.tick >= ul.tick_lower THEN COALESCE(lo.fee_growth_outside0, 0) ELSE p.fee_growth0 - COALESCE(lo.fee_growth_outside0, 0) END
+        - CASE WHEN p.tick < ul.tick_upper THEN COALESCE(hi.fee_growth_outside0, 0) ELSE p.fee_growth0 - COALESCE(hi.fee_growth_outside0, 0) END
+    END - ul.fee_growth0_last))::decimal AS fees0,
+    (ul.fees1 + ul.shares * (CASE WHEN ul.tick_lower IS NULL THEN p.fee_growth1 ELSE p.fee_growth1
+        - CASE WHEN p.tick >= ul.tick_lower THEN COALESCE(lo.fee_growth_outside1, 0) ELSE p.fee_growth1 - COALESCE(lo.fee_growth_outside1, 0) END
+        - CASE WHEN p.tick < ul.tick_upper THEN COALESCE(hi.fee_growth_outside1, 0) ELSE p.fee_growth1 - COALESCE(hi.fee_growth_outside1, 0) END
+    END - ul.fee_growth1_last))::decimal AS fees1
+FROM user_liquidity ul
+JOIN liquidity_pools p ON p.id = ul.pool_id
+JOIN tokens t0 ON t0.id = p.token0_id
+JOIN tokens t1 ON t1.id = p.token1_id
+LEFT JOIN pool_ticks lo ON lo.pool_id = ul.pool_id AND lo.tick = ul.tick_lower
+LEFT JOIN pool_ticks hi ON hi.pool_id = ul.pool_id AND hi.tick = ul.tick_upper
+WHERE ul.id = sqlc.arg(id)::uuid AND ul.user_id = sqlc.arg(user_id)::uuid;
+
+-- name: GetPoolForUpdate :one
+SELECT * FROM liquidity_pools WHERE id = $1 FOR UPDATE;
+
+-- name: SetPoolReserves :one
+-- Stores new reserves and share supply with the price accumulators
+-- advanced to the change, revaluing the pool's TVL at the given token
+-- prices, or the stored market price for a token without one.
+UPDATE liquidity_pools p
+SET reserve0 = sqlc.arg(reserve0)::decimal, reserve1 = sqlc.arg(reserve1)::decimal,
+    total_shares = sqlc.arg(total_shares)::decimal,
+    price0_cumulative = sqlc.arg(price0_cumulative)::decimal, price1_cumulative = sqlc.arg(price1_cumulative)::decimal,
+    price_updated_at = sqlc.arg(price_updated_at)::timestamp,
+    total_liquidity = sqlc.arg(reserve0)::decimal * COALESCE(sqlc.narg(price0)::decimal,
+            (SELECT a.market_price FROM assets a WHERE a.token_id = p.token0_id), 0)
+        + sqlc.arg(reserve1)::decimal * COALESCE(sqlc.narg(price1)::decimal,
+            (SELECT a.market_price FROM assets a WHERE a.token_id = p.token1_id), 0),
+    updated_at = CURRENT_TIMESTAMP
+WHERE p.id = sqlc.arg(id)::uuid
+RETURNING *;
+
+-- name: GetPoolState :one
+SELECT * FROM liquidity_pools WHERE id = $1;
+
+-- name: RecordPriceObservation :exec
+INSERT INTO pool_price_observations (pool_id, observed_at, price0_cumulative, price1_cumulative)
+VALUES (sqlc.arg(pool_id)::uuid, sqlc.arg(observed_at)::timestamp, sqlc.arg(price0_cumulative)::decimal, sqlc.arg(price1_cumulative)::decimal)
+ON CONFLICT (pool_id, observed_at) DO UPDATE
+SET price0_cumulative = EXCLUDED.price0_cumulative, price1_cumulative = EXCLUDED.price1_cumulative;
+
+-- name: GetPriceObservation :one
+-- The last observation at or before the given time.
+SELECT * FROM pool_price_observations
+WHERE pool_id = sqlc.arg(pool_id)::uuid AND observed_at <= sqlc.arg(at)::timestamp
+ORDER BY observed_at DESC
+LIMIT 1;
+
+-- name: GetFirstPriceObservation :one
+-- A pool's first observation, for pools younger than a TWAP window.
+SELECT * FROM pool_price_observations
+WHERE pool_id = $1
+ORDER BY observed_at ASC
+LIMIT 1;
+
+-- name: GetOpenPositionForUpdate :one
+-- The user's open constant-product position in a pool.
+SELECT * FROM user_liquidity
+WHERE user_id = $1 AND pool_id = $2 AND status = 'active' AND tick_lower IS NULL
+FOR UPDATE;
+
+-- name: GetPositionForUpdate :one
+SELECT * FROM user_liquidity
+WHERE id = sqlc.arg(id)::uuid AND user_id = sqlc.arg(user_id)::uuid
+FOR UPDATE;
+
+-- name: CreateRangePosition :one
+INSERT INTO user_liquidity (user_id, pool_id, amount0, amount1, shares, tick_lower, tick_upper, fee_growth0_last, fee_growth1_last)
+VALUES (sqlc.arg(user_id)::uuid, sqlc.arg(pool_id)::uuid, sqlc.arg(amount0)::decimal, sqlc.arg(amount1)::decimal,
+    sqlc.arg(shares)::decimal, sqlc.arg(tick_lower)::int, sqlc.arg(tick_upper)::int,
+    sqlc.arg(fee_growth0_last)::decimal, sqlc.arg(fee_growth1_last)::decimal)
+RETURNING *;
+
+-- name: CollectPositionFees :exec
+-- Marks every fee a position has settled as paid out.
+UPDATE user_liquidity SET fees_collected0 = fees0, fees_collected1 = fees1 WHERE id = $1;
+
+-- name: AddToPosition :one
+-- Opens a position or adds a deposit to the user's open one, first
+-- settling the fees its existing shares earned up to the pool's current
+-- fee growth.
+INSERT INTO user_liquidity (user_id, pool_id, amount0, amount1, shares, fee_growth0_last, fee_growth1_last)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (user_id, pool_id) WHERE status = 'active' AND tick_lower IS NULL
+DO UPDATE SET amount0 = user_liquidity.amount0 + EXCLUDED.amount0,
+    amount1 = user_liquidity.amount1 + EXCLUDED.amount1,
+    shares = user_liquidity.shares + EXCLUDED.shares,
+    fees0 = user_liquidity.fees0 + user_liquidity.shares * (EXCLUDED.fee_growth0_last - user_liquidity.fee_growth0_last),
+    fees1 = user_liquidity.fees1 + user_liquidity.shares * (EXCLUDED.fee_growth1_last - user_liquidity.fee_growth1_last),
+    fee_growth0_last = EXCLUDED.fee_growth0_last,
+    fee_growth1_last = EXCLUDED.fee_growth1_last,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: UpdatePosition :one
+-- Sets a position's amounts and shares, first settling the fees its old
+-- shares earned up to the pool's current fee growth.
+UPDATE user_liquidity
+SET amount0 = sqlc.arg(amount0)::decimal, amount1 = sqlc.arg(amount1)::decimal,
+    shares = sqlc.arg(shares)::decimal, status = sqlc.arg(status)::text,
+    fees0 = fees0 + shares * (sqlc.arg(fee_growth0)::decimal - fee_growth0_last),
+    fees1 = fees1 + shares * (sqlc.arg(fee_growth1)::decimal - fee_growth1_last),
+    fee_growth0_last = sqlc.arg(fee_growth0)::decimal,
+    fee_growth1_last = sqlc.arg(fee_growth1)::decimal,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id)::uuid
+RETURNING *;
+
+-- name: ListPoolTicks :many
+-- A concentrated pool's initialized ticks, lowest first.
+SELECT * FROM pool_ticks WHERE pool_id = $1 AND liquidity_gross > 0 ORDER BY tick;
+
+-- name: UpdateTick :exec
+-- Adds a position's liquidity to one of its ticks. A tick being
+-- initialized takes the given fee growth outside; one already in use keeps
+-- its own.
+INSERT INTO pool_ticks (pool_id, tick, liquidity_gross, liquidity_net, fee_growth_outside0, fee_growth_outside1)
+VALUES (sqlc.arg(pool_id)::uuid, sqlc.arg(tick)::int, sqlc.arg(gross_delta)::decimal, sqlc.arg(net_delta)::decimal,
+    sqlc.arg(fee_growth_outside0)::decimal, sqlc.arg(fee_growth_outside1)::decimal)
+ON CONFLICT (pool_id, tick) DO UPDATE
+SET liquidity_gross = pool_ticks.liquidity_gross + EXCLUDED.liquidity_gross,
+    liquidity_net = pool_ticks.liquidity_net + EXCLUDED.liquidity_net,
+    fee_growth_outside0 = CASE WHEN pool_ticks.liquidity_gross > 0 THEN pool_ticks.fee_growth_outside0 ELSE EXCLUDED.fee_growth_outside0 END,
+    fee_growth_outside1 = CASE WHEN pool_ticks.liquidity_gross > 0 THEN pool_ticks.fee_growth_outside1 ELSE EXCLUDED.fee_growth_outside1 END;
+
+-- name: CrossTick :exec
+-- Flips a tick's fee growth outside as the price crosses it, given the
+-- pool's fee growth at that moment.
+UPDATE pool_ticks
+SET fee_growth_outside0 = sqlc.arg(fee_growth0)::decimal - fee_growth_outside0,
+    fee_growth_outside1 = sqlc.arg(fee_growth1)::decimal - fee_growth_outside1
+WHERE pool_id = sqlc.arg(pool_id)::uuid AND tick = sqlc.arg(tick)::int;
+
+-- name: SetPoolCurve :exec
+UPDATE liquidity_pools
+SET sqrt_price = sqlc.arg(sqrt_price)::decimal, tick = sqlc.arg(tick)::int, liquidity = sqlc.arg(liquidity)::decimal
+WHERE id = sqlc.arg(id)::uuid;
+
+-- name: CreateSwap :one
+INSERT INTO pool_swaps (pool_id, user_id, token_in_id, token_out_id, amount_in, amount_out, fee, price_impact, reserve0_after, reserve1_after)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING *;
+
+-- name: RecordPoolFees :exec
+-- Adds a swap's volume and fee to the pool's row for today, valuing the
+-- fee at current token prices.
+INSERT INTO pool_fee_days (pool_id, day, swaps, volume0, volume1, fees0, fees1, fees_value)
+SELECT p.id, CURRENT_DATE, 1, sqlc.arg(volume0)::decimal, sqlc.arg(volume1)::decimal,
+    sqlc.arg(fees0)::decimal, sqlc.arg(fees1)::decimal,
+    sqlc.arg(fees0)::decimal * COALESCE((SELECT a.market_price FROM assets a WHERE a.token_id = p.token0_id), 0)
+        + sqlc.arg(fees1)::decimal * COALESCE((SELECT a.market_price FROM assets a WHERE a.token_id = p.token1_id), 0)
+FROM liquidity_pools p
+WHERE p.id = sqlc.arg(pool_id)::uuid
+ON CONFLICT (pool_id, day)
+DO UPDATE SET swaps = pool_fee_days.swaps + 1,
+    volume0 = pool_fee_days.volume0 + EXCLUDED.volume0,
+    volume1 = pool_fee_days.volume1 + EXCLUDED.volume1,
+    fees0 = pool_fee_days.fees0 + EXCLUDED.fees0,
+    fees1 = pool_fee_days.fees1 + EXCLUDED.fees1,
+    fees_value = pool_fee_days.fees_value + EXCLUDED.fees_value;
+
+-- name: GetPoolFeeIncome :one
+-- A pool's TVL and the value of the fees it earned over the trailing 7
+-- and 30 days, today included.
+SELECT COALESCE(p.total_liquidity, 0)::decimal AS tvl,
+    COALESCE((SELECT SUM(d.fees_value) FROM pool_fee_days d
+        WHERE d.pool_id = p.id AND d.day > CURRENT_DATE - 7), 0)::decimal AS fees_7d,
+    COALESCE((SELECT SUM(d.fees_value) FROM pool_fee_days d
+        WHERE d.pool_id = p.id AND d.day > CURRENT_DATE - 30), 0)::decimal AS fees_30d
+FROM liquidity_pools p
+WHERE p.id = $1;
+
+-- name: AccruePoolFees :exec
+-- Grows the pool's fees per share and stores its trailing APR.
+UPDATE liquidity_pools
+SET fee_growth0 = fee_growth0 + sqlc.arg(growth0)::decimal,
+    fee_growth1 = fee_growth1 + sqlc.arg(growth1)::decimal,
+    apr = sqlc.arg(apr)::decimal
+WHERE id = sqlc.arg(id)::uuid;
+
+-- name: ListPoolSwaps :many
+SELECT s.id::uuid AS id, s.pool_id::uuid AS pool_id, s.user_id::uuid AS user_id,
+    ti.symbol::text AS token_in, tout.symbol::text AS token_out,
+    s.amount_in::decimal AS amount_in, s.amount_out::decimal AS amount_out, s.fee::decimal AS fee,
+    s.price_impact::decimal AS price_impact, s.created_at::timestamp AS created_at
+FROM pool_swaps s
+JOIN tokens ti ON ti.id = s.token_in_id
+JOIN tokens tout ON tout.id = s.token_out_id
+WHERE s.pool_id = sqlc.arg(pool_id)::uuid
+ORDER BY s.created_at DESC, s.id
+LIMIT sqlc.arg(page_limit)::int OFFSET sqlc.arg(page_offset)::int;
+
+-- name: ListUserSwaps :many
+SELECT s.id::uuid AS id, s.pool_id::uuid AS pool_id, s.user_id::uuid AS user_id,
+    ti.symbol::text AS token_in, tout.symbol::text AS token_out,
+    s.amount_in::decimal AS amount_in, s.amount_out::decimal AS amount_out, s.fee::decimal AS fee,
+    s.price_impact::decimal AS price_impact, s.created_at::timestamp AS created_at
+FROM pool_swaps s
+JOIN tokens ti ON ti.id = s.token_in_id
+JOIN tokens tout ON tout.id = s.token_out_id
+WHERE s.user_id = sqlc.arg(user_id)::uuid
+ORDER BY s.created_at DESC, s.id
+LIMIT sqlc.arg(page_limit)::int OFFSET sqlc.arg(page_offset)::int;
+
+-- name: CreatePoolSnapshots :execrows
+-- Snapshots every pool that is not deprecated at snapshot_at, with the
+-- swaps made from from_at up to it. Pools already snapshotted at that time
+-- are skipped, so reruns are no-ops.
+INSERT INTO pool_snapshots (pool_id, snapshot_at, reserve0, reserve1, total_shares, price, tvl,
+    swaps, volume0, volume1, volume_value, fees0, fees1, fees_value, providers)
+SELECT p.id, sqlc.arg(snapshot_at)::timestamp, p.reserve0, p.reserve1, p.total_shares,
+    CASE WHEN p.pool_type = 'concentrated' THEN p.sqrt_price * p.sqrt_price
+         WHEN p.reserve0 > 0 THEN p.reserve1 / p.reserve0
+         ELSE 0 END,
+    COALESCE(p.total_liquidity, 0),
+    s.swaps, s.volume0, s.volume1, s.volume0 * px.price0 + s.volume1 * px.price1,
+    s.fees0, s.fees1, s.fees0 * px.price0 + s.fees1 * px.price1,
+    (SELECT COUNT(DISTINCT ul.user_id) FROM user_liquidity ul WHERE ul.pool_id = p.id AND ul.status = 'active')
+FROM liquidity_pools p
+CROSS JOIN LATERAL (
+    SELECT COALESCE((SELECT a.market_price FROM assets a WHERE a.token_id = p.token0_id), 0) AS price0,
+        COALESCE((SELECT a.market_price FROM assets a WHERE a.token_id = p.token1_id), 0) AS price1
+) px
+CROSS JOIN LATERAL (
+    SELECT COUNT(*) AS swaps,
+        COALESCE(SUM(sw.amount_in) FILTER (WHERE sw.token_in_id = p.token0_id), 0) AS volume0,
+        COALESCE(SUM(sw.amount_in) FILTER (WHERE sw.token_in_id = p.token1_id), 0) AS volume1,
+        COALESCE(SUM(sw.fee) FILTER (WHERE sw.token_in_id = p.token0_id), 0) AS fees0,
+        COALESCE(SUM(sw.fee) FILTER (WHERE sw.token_in_id = p.token1_id), 0) AS fees1
+    FROM pool_swaps sw
+    WHERE sw.pool_id = p.id
+      AND sw.created_at >= sqlc.arg(from_at)::timestamp
+      AND sw.created_at < sqlc.arg(snapshot_at)::timestamp
+) s
+WHERE p.status <> 'deprecated'
+ON CONFLICT (pool_id, snapshot_at) DO NOTHING;
+
+-- name: GetPoolHistory :many
+-- A pool's snapshots bucketed by hour or day. A snapshot covers the hour
+-- before it, so buckets start an hour before their first snapshot: their
+-- swaps are summed and their state is the last snapshot's.
+SELECT date_trunc(sqlc.arg(bucket)::text, snapshot_at - INTERVAL '1 hour')::timestamp AS bucket_start,
+    (array_agg(reserve0 ORDER BY snapshot_at DESC))[1]::decimal AS reserve0,
+    (array_agg(reserve1 ORDER BY snapshot_at DESC))[1]::decimal AS reserve1,
+    (array_agg(total_shares ORDER BY snapshot_at DESC))[1]::decimal AS total_shares,
+    (array_agg(price ORDER BY snapshot_at DESC))[1]::decimal AS price,
+    MIN(price)::decimal AS price_low,
+    MAX(price)::decimal AS price_high,
+    (array_agg(tvl ORDER BY snapshot_at DESC))[1]::decimal AS tvl,
+    (array_agg(providers ORDER BY snapshot_at DESC))[1]::int AS providers,
+    SUM(swaps)::bigint AS swaps,
+    SUM(volume0)::decimal AS volume0,
+    SUM(volume1)::decimal AS volume1,
+    SUM(volume_value)::decimal AS volume_value,
+    SUM(fees0)::decimal AS fees0,
+    SUM(fees1)::decimal AS fees1,
+    SUM(fees_value)::decimal AS fees_value
+FROM pool_snapshots
+WHERE pool_id = sqlc.arg(pool_id)::uuid
+  AND snapshot_at > sqlc.arg(from_at)::timestamp
+  AND snapshot_at <= sqlc.arg(to_at)::timestamp
+GROUP BY 1
+ORDER BY 1;
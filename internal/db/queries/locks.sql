@@ -0,0 +1,11 @@
+-- internal/db/queries/locks.sql
+-- name: TryAdvisoryLock :one
+SELECT pg_try_advisory_xact_lock(sqlc.arg(lock_key)::bigint)::bool AS acquired;
+
+-- name: AdvisoryLock :exec
+SELECT pg_advisory_xact_lock(sqlc.arg(lock_key)::bigint)::text AS locked;
+
+-- name: AdvisoryLockSubject :exec
+-- Waits for the lock on one subject, such as a user id, within a class of
+-- locks, so writers are serialised per subject rather than globally.
+SELECT pg_advisory_xact_lock(sqlc.arg(lock_class)::int, hashtext(sqlc.arg(subject)::text))::text AS locked;
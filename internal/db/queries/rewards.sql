@@ -0,0 +1,42 @@
+-- internal/db/queries/rewards.sql
+-- name: GetRewardsPoolForUpdate :one
+SELECT * FROM rewards_pools
+WHERE token_id = $1 AND is_active = true
+FOR UPDATE;
+
+-- name: ListRewardsPoolStats :many
+SELECT p.*, t.symbol,
+    COALESCE((SELECT SUM(s.amount) FROM stakes s WHERE s.token_id = p.token_id AND s.status = 'active'), 0)::decimal AS total_staked,
+    COALESCE((SELECT SUM(s.amount * s.apy / 100 / 365) FROM stakes s WHERE s.token_id = p.token_id AND s.status = 'active'), 0)::decimal AS daily_liability
+FROM rewards_pools p
+JOIN tokens t ON p.token_id = t.id
+WHERE p.is_active = true
+ORDER BY t.symbol;
+
+-- name: GetRewardObligations :one
+-- What active stakes are still owed: rewards accrued since their last
+-- settlement plus those still to accrue by their end date. Stakes are
+-- projected to the horizon, the later of until and the last end date, so
+-- the caller can compare against the pool's emission over the same span.
+WITH active AS (
+    SELECT s.amount, s.apy, s.end_date,
+        GREATEST(COALESCE(s.start_date, s.created_at), COALESCE(s.rewards_settled_at, s.start_date, s.created_at)) AS accrual_start
+    FROM stakes s
+    WHERE s.token_id = sqlc.arg(token_id) AND s.status = 'active'
+), horizon AS (
+    SELECT GREATEST(sqlc.arg(until)::timestamp, COALESCE(MAX(end_date), sqlc.arg(until)::timestamp)) AS at
+    FROM active
+)
+SELECT COALESCE(SUM(
+    a.amount * a.apy / 100 / 365 *
+    GREATEST(EXTRACT(EPOCH FROM (COALESCE(a.end_date, h.at) - a.accrual_start)) / 86400, 0)
+), 0)::decimal AS outstanding,
+    h.at::timestamp AS horizon
+FROM horizon h
+LEFT JOIN active a ON true
+GROUP BY h.at;
+
+-- name: RecordPoolDistribution :exec
+UPDATE rewards_pools
+SET distributed = distributed + $2, updated_at = CURRENT_TIMESTAMP
+WHERE token_id = $1;
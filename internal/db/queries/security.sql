@@ -0,0 +1,31 @@
+-- internal/db/queries/security.sql
+-- name: CreateSecurityMonitor :one
+INSERT INTO security_monitors (metric_name, metric_value, severity)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+
+-- name: UpsertSecurityThreshold :exec
+INSERT INTO security_thresholds (name, value)
+VALUES ($1, $2)
+ON CONFLICT (name) DO UPDATE SET value = EXCLUDED.value, updated_at = CURRENT_TIMESTAMP;
+
+-- name: GetSecurityThreshold :one
+SELECT value FROM security_thresholds WHERE name = $1;
+
+-- name: CreateAuditLog :exec
+INSERT INTO audit_logs (user_id, action, resource_type, resource_id, details, ip_address, user_agent)
+VALUES (sqlc.arg(user_id)::uuid, sqlc.arg(action)::text, sqlc.arg(resource_type)::text, sqlc.arg(resource_id)::text,
+    sqlc.arg(details)::jsonb, sqlc.narg(ip_address)::inet, sqlc.arg(user_agent)::text);
+
+-- name: ListAuditLogs :many
+-- Audit entries for one resource, newest first.
+SELECT a.id::uuid AS id, a.user_id::uuid AS user_id, COALESCE(u.email, '')::text AS user_email,
+    a.action::text AS action, COALESCE(a.details, '{}')::jsonb AS details,
+    COALESCE(host(a.ip_address), '')::text AS ip_address, COALESCE(a.user_agent, '')::text AS user_agent,
+    a.created_at::timestamp AS created_at
+FROM audit_logs a
+LEFT JOIN users u ON u.id = a.user_id
+WHERE a.resource_type = sqlc.arg(resource_type)::text AND a.resource_id = sqlc.arg(resource_id)::text
+ORDER BY a.created_at DESC, a.id
+LIMIT sqlc.arg(page_limit)::int OFFSET sqlc.arg(page_offset)::int;
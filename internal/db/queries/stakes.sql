@@ -1,14 +1,14 @@
 -- internal/db/queries/stakes.sql
 -- name: CreateStake :one
-INSERT INTO stakes (user_id, token_id, amount, apy, end_date, auto_compound)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO stakes (user_id, token_id, amount, apy, end_date, auto_compound, product_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
 RETURNING *;
 
 -- name: GetUserStakes :many
 SELECT s.*, t.symbol, t.name
 FROM stakes s
 JOIN tokens t ON s.token_id = t.id
-WHERE s.user_id = $1 AND s.status = 'active'
+WHERE s.user_id = $1 AND s.status IN ('active', 'unbonding', 'withdrawable')
 ORDER BY s.created_at DESC;
 
 -- name: GetStakeByID :one
@@ -17,18 +17,157 @@ FROM stakes s
 JOIN tokens t ON s.token_id = t.id
 WHERE s.id = $1;
 
+-- name: GetStakeForUpdate :one
+SELECT s.*, p.early_unstake_allowed, p.early_unstake_penalty, p.unbonding_days
+FROM stakes s
+LEFT JOIN staking_products p ON s.product_id = p.id
+WHERE s.id = $1
+FOR UPDATE OF s;
+
 -- name: UpdateStakeRewards :exec
 UPDATE stakes 
-SET rewards_claimed = rewards_claimed + $2, updated_at = CURRENT_TIMESTAMP
+SET rewards_claimed = rewards_claimed + $2, rewards_settled_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
 WHERE id = $1;
 
--- name: Unstake :exec
-UPDATE stakes 
-SET status = 'unstaked', end_date = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
-WHERE id = $1 AND user_id = $2;
+-- name: UpdateStakePosition :exec
+UPDATE stakes
+SET amount = $2, start_date = $3, end_date = $4, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'active';
+
+-- name: CreateSplitStake :one
+INSERT INTO stakes (
+    user_id, token_id, amount, apy, start_date, end_date, status, auto_compound,
+    product_id, parent_stake_id, penalty_amount, unbonding_until, rewards_settled_at
+) VALUES ($1, $2, $3, $4, $5, $6, 'unbonding', $7, $8, $9, $10, $11, CURRENT_TIMESTAMP)
+RETURNING *;
+
+-- name: CreateStakeHistory :exec
+INSERT INTO stake_history (stake_id, user_id, event_type, amount, rewards_settled, balance_after, related_stake_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: GetStakeHistory :many
+SELECT * FROM stake_history
+WHERE stake_id = $1
+ORDER BY created_at ASC;
+
+-- name: BeginUnbonding :exec
+UPDATE stakes
+SET status = 'unbonding', penalty_amount = $2, unbonding_until = $3, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'active';
+
+-- name: StartUnbondingMaturedStakes :execrows
+UPDATE stakes s
+SET status = 'unbonding',
+    unbonding_until = CURRENT_TIMESTAMP + make_interval(days => COALESCE(
+        (SELECT p.unbonding_days FROM staking_products p WHERE p.id = s.product_id),
+        sqlc.arg(default_unbonding_days)::int
+    )),
+    updated_at = CURRENT_TIMESTAMP
+WHERE s.status = 'active'
+  AND s.end_date <= CURRENT_TIMESTAMP
+  AND s.auto_compound IS NOT TRUE;
+
+-- name: ReleaseUnbondedStakes :execrows
+UPDATE stakes
+SET status = 'withdrawable', updated_at = CURRENT_TIMESTAMP
+WHERE status = 'unbonding' AND unbonding_until <= CURRENT_TIMESTAMP;
+
+-- name: MarkStakeWithdrawn :exec
+UPDATE stakes
+SET status = 'withdrawn', withdrawn_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'withdrawable';
+
+-- name: GetStakingProductForDuration :one
+SELECT * FROM staking_products
+WHERE token_id = $1 AND min_duration_days <= $2 AND is_active = true
+ORDER BY min_duration_days DESC
+LIMIT 1;
+
+-- name: SetStakingProductAPY :execrows
+UPDATE staking_products
+SET apy = $2, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: ListStakesDueForSnapshot :many
+SELECT s.id, s.user_id, s.amount, s.apy, s.rewards_claimed,
+    COALESCE((SELECT MAX(r.snapshot_at) FROM stake_reward_snapshots r WHERE r.stake_id = s.id), s.start_date)::timestamp AS last_snapshot_at,
+    COALESCE((SELECT r.cumulative_rewards FROM stake_reward_snapshots r WHERE r.stake_id = s.id ORDER BY r.snapshot_at DESC LIMIT 1), 0)::decimal AS cumulative_rewards
+FROM stakes s
+WHERE s.status = 'active'
+  AND s.start_date < sqlc.arg(snapshot_at)::timestamp
+  AND NOT EXISTS (
+    SELECT 1 FROM stake_reward_snapshots r
+    WHERE r.stake_id = s.id AND r.snapshot_at = sqlc.arg(snapshot_at)::timestamp
+  );
+
+-- name: CreateRewardSnapshot :execrows
+INSERT INTO stake_reward_snapshots (
+    stake_id, user_id, snapshot_at, principal, apy, accrued_rewards, cumulative_rewards, rewards_claimed
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (stake_id, snapshot_at) DO NOTHING;
+
+-- name: GetRewardSnapshotsAt :many
+SELECT * FROM stake_reward_snapshots
+WHERE snapshot_at = $1;
+
+-- name: GetStakeRewardSeries :many
+SELECT date_trunc(sqlc.arg(bucket)::text, snapshot_at)::timestamp AS bucket_start,
+    MAX(principal)::decimal AS principal,
+    SUM(accrued_rewards)::decimal AS accrued_rewards,
+    MAX(cumulative_rewards)::decimal AS cumulative_rewards,
+    MAX(rewards_claimed)::decimal AS rewards_claimed
+FROM stake_reward_snapshots
+WHERE stake_id = sqlc.arg(stake_id)::uuid
+  AND snapshot_at >= sqlc.arg(from_at)::timestamp
+  AND snapshot_at < sqlc.arg(to_at)::timestamp
+GROUP BY 1
+ORDER BY 1;
 
 -- name: GetTotalStakedValue :one
 SELECT COALESCE(SUM(s.amount * a.market_price), 0)::decimal as total_value
 FROM stakes s
 JOIN assets a ON s.token_id = a.token_id
-WHERE s.status = 'active';
\ No newline at end of file
+WHERE s.status = 'active';
+
+-- name: GetStakeTotalsByToken :many
+SELECT t.symbol,
+    COALESCE(SUM(s.amount), 0)::decimal AS total_staked,
+    COALESCE(SUM(s.amount * a.market_price), 0)::decimal AS total_value,
+    COALESCE(SUM(s.amount * s.apy) / NULLIF(SUM(s.amount), 0), 0)::decimal AS weighted_apy,
+    COUNT(*)::bigint AS stake_count
+FROM stakes s
+JOIN tokens t ON s.token_id = t.id
+LEFT JOIN assets a ON a.token_id = s.token_id
+WHERE s.status = 'active'
+  AND (sqlc.narg(user_id)::uuid IS NULL OR s.user_id = sqlc.narg(user_id)::uuid)
+GROUP BY t.symbol
+ORDER BY t.symbol;
+
+-- name: GetStakingOverview :one
+SELECT
+    COUNT(DISTINCT s.user_id) FILTER (WHERE s.status = 'active')::bigint AS active_stakers,
+    COALESCE(SUM(s.rewards_claimed), 0)::decimal AS rewards_paid,
+    COUNT(*) FILTER (WHERE s.status = 'active' AND s.end_date > CURRENT_TIMESTAMP AND s.end_date <= CURRENT_TIMESTAMP + INTERVAL '7 days')::bigint AS maturing_7d_count,
+    COALESCE(SUM(s.amount * a.market_price) FILTER (WHERE s.status = 'active' AND s.end_date > CURRENT_TIMESTAMP AND s.end_date <= CURRENT_TIMESTAMP + INTERVAL '7 days'), 0)::decimal AS maturing_7d_value,
+    COUNT(*) FILTER (WHERE s.status = 'active' AND s.end_date > CURRENT_TIMESTAMP AND s.end_date <= CURRENT_TIMESTAMP + INTERVAL '30 days')::bigint AS maturing_30d_count,
+    COALESCE(SUM(s.amount * a.market_price) FILTER (WHERE s.status = 'active' AND s.end_date > CURRENT_TIMESTAMP AND s.end_date <= CURRENT_TIMESTAMP + INTERVAL '30 days'), 0)::decimal AS maturing_30d_value
+FROM stakes s
+LEFT JOIN assets a ON a.token_id = s.token_id
+WHERE sqlc.narg(user_id)::uuid IS NULL OR s.user_id = sqlc.narg(user_id)::uuid;
+
+-- name: GetStakeLockTiers :many
+SELECT
+    (CASE
+        WHEN s.end_date - s.start_date < INTERVAL '30 days' THEN 'under_30d'
+        WHEN s.end_date - s.start_date < INTERVAL '90 days' THEN '30_89d'
+        WHEN s.end_date - s.start_date < INTERVAL '180 days' THEN '90_179d'
+        ELSE '180d_plus'
+    END)::text AS tier,
+    COUNT(*)::bigint AS stake_count,
+    COALESCE(SUM(s.amount * a.market_price), 0)::decimal AS total_value
+FROM stakes s
+LEFT JOIN assets a ON a.token_id = s.token_id
+WHERE s.status = 'active'
+  AND (sqlc.narg(user_id)::uuid IS NULL OR s.user_id = sqlc.narg(user_id)::uuid)
+GROUP BY 1
+ORDER BY MIN(s.end_date - s.start_date);
@@ -11,10 +11,122 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const beginUnbonding = `-- name: BeginUnbonding :exec
+UPDATE stakes
+SET status = 'unbonding', penalty_amount = $2, unbonding_until = $3, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'active'
+`
+
+type BeginUnbondingParams struct {
+	ID             pgtype.UUID      `json:"id"`
+	PenaltyAmount  pgtype.Numeric   `json:"penalty_amount"`
+	UnbondingUntil pgtype.Timestamp `json:"unbonding_until"`
+}
+
+func (q *Queries) BeginUnbonding(ctx context.Context, arg BeginUnbondingParams) error {
+	_, err := q.db.Exec(ctx, beginUnbonding, arg.ID, arg.PenaltyAmount, arg.UnbondingUntil)
+	return err
+}
+
+const createRewardSnapshot = `-- name: CreateRewardSnapshot :execrows
+INSERT INTO stake_reward_snapshots (
+    stake_id, user_id, snapshot_at, principal, apy, accrued_rewards, cumulative_rewards, rewards_claimed
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (stake_id, snapshot_at) DO NOTHING
+`
+
+type CreateRewardSnapshotParams struct {
+	StakeID           pgtype.UUID      `json:"stake_id"`
+	UserID            pgtype.UUID      `json:"user_id"`
+	SnapshotAt        pgtype.Timestamp `json:"snapshot_at"`
+	Principal         pgtype.Numeric   `json:"principal"`
+	Apy               pgtype.Numeric   `json:"apy"`
+	AccruedRewards    pgtype.Numeric   `json:"accrued_rewards"`
+	CumulativeRewards pgtype.Numeric   `json:"cumulative_rewards"`
+	RewardsClaimed    pgtype.Numeric   `json:"rewards_claimed"`
+}
+
+func (q *Queries) CreateRewardSnapshot(ctx context.Context, arg CreateRewardSnapshotParams) (int64, error) {
+	result, err := q.db.Exec(ctx, createRewardSnapshot,
+		arg.StakeID,
+		arg.UserID,
+		arg.SnapshotAt,
+		arg.Principal,
+		arg.Apy,
+		arg.AccruedRewards,
+		arg.CumulativeRewards,
+		arg.RewardsClaimed,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const createSplitStake = `-- name: CreateSplitStake :one
+INSERT INTO stakes (
+    user_id, token_id, amount, apy, start_date, end_date, status, auto_compound,
+    product_id, parent_stake_id, penalty_amount, unbonding_until, rewards_settled_at
+) VALUES ($1, $2, $3, $4, $5, $6, 'unbonding', $7, $8, $9, $10, $11, CURRENT_TIMESTAMP)
+RETURNING id, user_id, token_id, amount, apy, start_date, end_date, status, auto_compound, rewards_claimed, created_at, updated_at, product_id, unbonding_until, withdrawn_at, penalty_amount, rewards_settled_at, parent_stake_id
+`
+
+type CreateSplitStakeParams struct {
+	UserID         pgtype.UUID      `json:"user_id"`
+	TokenID        pgtype.UUID      `json:"token_id"`
+	Amount         pgtype.Numeric   `json:"amount"`
+	Apy            pgtype.Numeric   `json:"apy"`
+	StartDate      pgtype.Timestamp `json:"start_date"`
+	EndDate        pgtype.Timestamp `json:"end_date"`
+	AutoCompound   pgtype.Bool      `json:"auto_compound"`
+	ProductID      pgtype.UUID      `json:"product_id"`
+	ParentStakeID  pgtype.UUID      `json:"parent_stake_id"`
+	PenaltyAmount  pgtype.Numeric   `json:"penalty_amount"`
+	UnbondingUntil pgtype.Timestamp `json:"unbonding_until"`
+}
+
+func (q *Queries) CreateSplitStake(ctx context.Context, arg CreateSplitStakeParams) (Stake, error) {
+	row := q.db.QueryRow(ctx, createSplitStake,
+		arg.UserID,
+		arg.TokenID,
+		arg.Amount,
+		arg.Apy,
+		arg.StartDate,
+		arg.EndDate,
+		arg.AutoCompound,
+		arg.ProductID,
+		arg.ParentStakeID,
+		arg.PenaltyAmount,
+		arg.UnbondingUntil,
+	)
+	var i Stake
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenID,
+		&i.Amount,
+		&i.Apy,
+		&i.StartDate,
+		&i.EndDate,
+		&i.Status,
+		&i.AutoCompound,
+		&i.RewardsClaimed,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ProductID,
+		&i.UnbondingUntil,
+		&i.WithdrawnAt,
+		&i.PenaltyAmount,
+		&i.RewardsSettledAt,
+		&i.ParentStakeID,
+	)
+	return i, err
+}
+
 const createStake = `-- name: CreateStake :one
-INSERT INTO stakes (user_id, token_id, amount, apy, end_date, auto_compound)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, user_id, token_id, amount, apy, start_date, end_date, status, auto_compound, rewards_claimed, created_at, updated_at
+INSERT INTO stakes (user_id, token_id, amount, apy, end_date, auto_compound, product_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, user_id, token_id, amount, apy, start_date, end_date, status, auto_compound, rewards_claimed, created_at, updated_at, product_id, unbonding_until, withdrawn_at, penalty_amount, rewards_settled_at, parent_stake_id
 `
 
 type CreateStakeParams struct {
@@ -24,6 +136,7 @@ type CreateStakeParams struct {
 	Apy          pgtype.Numeric   `json:"apy"`
 	EndDate      pgtype.Timestamp `json:"end_date"`
 	AutoCompound pgtype.Bool      `json:"auto_compound"`
+	ProductID    pgtype.UUID      `json:"product_id"`
 }
 
 // internal/db/queries/stakes.sql
@@ -35,6 +148,7 @@ func (q *Queries) CreateStake(ctx context.Context, arg CreateStakeParams) (Stake
 		arg.Apy,
 		arg.EndDate,
 		arg.AutoCompound,
+		arg.ProductID,
 	)
 	var i Stake
 	err := row.Scan(
@@ -50,32 +164,108 @@ func (q *Queries) CreateStake(ctx context.Context, arg CreateStakeParams) (Stake
 		&i.RewardsClaimed,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ProductID,
+		&i.UnbondingUntil,
+		&i.WithdrawnAt,
+		&i.PenaltyAmount,
+		&i.RewardsSettledAt,
+		&i.ParentStakeID,
 	)
 	return i, err
 }
 
+const createStakeHistory = `-- name: CreateStakeHistory :exec
+INSERT INTO stake_history (stake_id, user_id, event_type, amount, rewards_settled, balance_after, related_stake_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+`
+
+type CreateStakeHistoryParams struct {
+	StakeID        pgtype.UUID    `json:"stake_id"`
+	UserID         pgtype.UUID    `json:"user_id"`
+	EventType      string         `json:"event_type"`
+	Amount         pgtype.Numeric `json:"amount"`
+	RewardsSettled pgtype.Numeric `json:"rewards_settled"`
+	BalanceAfter   pgtype.Numeric `json:"balance_after"`
+	RelatedStakeID pgtype.UUID    `json:"related_stake_id"`
+}
+
+func (q *Queries) CreateStakeHistory(ctx context.Context, arg CreateStakeHistoryParams) error {
+	_, err := q.db.Exec(ctx, createStakeHistory,
+		arg.StakeID,
+		arg.UserID,
+		arg.EventType,
+		arg.Amount,
+		arg.RewardsSettled,
+		arg.BalanceAfter,
+		arg.RelatedStakeID,
+	)
+	return err
+}
+
+const getRewardSnapshotsAt = `-- name: GetRewardSnapshotsAt :many
+SELECT id, stake_id, user_id, snapshot_at, principal, apy, accrued_rewards, cumulative_rewards, rewards_claimed, created_at FROM stake_reward_snapshots
+WHERE snapshot_at = $1
+`
+
+func (q *Queries) GetRewardSnapshotsAt(ctx context.Context, snapshotAt pgtype.Timestamp) ([]StakeRewardSnapshot, error) {
+	rows, err := q.db.Query(ctx, getRewardSnapshotsAt, snapshotAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []StakeRewardSnapshot{}
+	for rows.Next() {
+		var i StakeRewardSnapshot
+		if err := rows.Scan(
+			&i.ID,
+			&i.StakeID,
+			&i.UserID,
+			&i.SnapshotAt,
+			&i.Principal,
+			&i.Apy,
+			&i.AccruedRewards,
+			&i.CumulativeRewards,
+			&i.RewardsClaimed,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getStakeByID = `-- name: GetStakeByID :one
-SELECT s.id, s.user_id, s.token_id, s.amount, s.apy, s.start_date, s.end_date, s.status, s.auto_compound, s.rewards_claimed, s.created_at, s.updated_at, t.symbol, t.name
+SELECT s.id, s.user_id, s.token_id, s.amount, s.apy, s.start_date, s.end_date, s.status, s.auto_compound, s.rewards_claimed, s.created_at, s.updated_at, s.product_id, s.unbonding_until, s.withdrawn_at, s.penalty_amount, s.rewards_settled_at, s.parent_stake_id, t.symbol, t.name
 FROM stakes s
 JOIN tokens t ON s.token_id = t.id
 WHERE s.id = $1
 `
 
 type GetStakeByIDRow struct {
-	ID             pgtype.UUID      `json:"id"`
-	UserID         pgtype.UUID      `json:"user_id"`
-	TokenID        pgtype.UUID      `json:"token_id"`
-	Amount         pgtype.Numeric   `json:"amount"`
-	Apy            pgtype.Numeric   `json:"apy"`
-	StartDate      pgtype.Timestamp `json:"start_date"`
-	EndDate        pgtype.Timestamp `json:"end_date"`
-	Status         pgtype.Text      `json:"status"`
-	AutoCompound   pgtype.Bool      `json:"auto_compound"`
-	RewardsClaimed pgtype.Numeric   `json:"rewards_claimed"`
-	CreatedAt      pgtype.Timestamp `json:"created_at"`
-	UpdatedAt      pgtype.Timestamp `json:"updated_at"`
-	Symbol         string           `json:"symbol"`
-	Name           string           `json:"name"`
+	ID               pgtype.UUID      `json:"id"`
+	UserID           pgtype.UUID      `json:"user_id"`
+	TokenID          pgtype.UUID      `json:"token_id"`
+	Amount           pgtype.Numeric   `json:"amount"`
+	Apy              pgtype.Numeric   `json:"apy"`
+	StartDate        pgtype.Timestamp `json:"start_date"`
+	EndDate          pgtype.Timestamp `json:"end_date"`
+	Status           pgtype.Text      `json:"status"`
+	AutoCompound     pgtype.Bool      `json:"auto_compound"`
+	RewardsClaimed   pgtype.Numeric   `json:"rewards_claimed"`
+	CreatedAt        pgtype.Timestamp `json:"created_at"`
+	UpdatedAt        pgtype.Timestamp `json:"updated_at"`
+	ProductID        pgtype.UUID      `json:"product_id"`
+	UnbondingUntil   pgtype.Timestamp `json:"unbonding_until"`
+	WithdrawnAt      pgtype.Timestamp `json:"withdrawn_at"`
+	PenaltyAmount    pgtype.Numeric   `json:"penalty_amount"`
+	RewardsSettledAt pgtype.Timestamp `json:"rewards_settled_at"`
+	ParentStakeID    pgtype.UUID      `json:"parent_stake_id"`
+	Symbol           string           `json:"symbol"`
+	Name             string           `json:"name"`
 }
 
 func (q *Queries) GetStakeByID(ctx context.Context, id pgtype.UUID) (GetStakeByIDRow, error) {
@@ -94,12 +284,335 @@ func (q *Queries) GetStakeByID(ctx context.Context, id pgtype.UUID) (GetStakeByI
 		&i.RewardsClaimed,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ProductID,
+		&i.UnbondingUntil,
+		&i.WithdrawnAt,
+		&i.PenaltyAmount,
+		&i.RewardsSettledAt,
+		&i.ParentStakeID,
 		&i.Symbol,
 		&i.Name,
 	)
 	return i, err
 }
 
+const getStakeForUpdate = `-- name: GetStakeForUpdate :one
+SELECT s.id, s.user_id, s.token_id, s.amount, s.apy, s.start_date, s.end_date, s.status, s.auto_compound, s.rewards_claimed, s.created_at, s.updated_at, s.product_id, s.unbonding_until, s.withdrawn_at, s.penalty_amount, s.rewards_settled_at, s.parent_stake_id, p.early_unstake_allowed, p.early_unstake_penalty, p.unbonding_days
+FROM stakes s
+LEFT JOIN staking_products p ON s.product_id = p.id
+WHERE s.id = $1
+FOR UPDATE OF s
+`
+
+type GetStakeForUpdateRow struct {
+	ID                  pgtype.UUID      `json:"id"`
+	UserID              pgtype.UUID      `json:"user_id"`
+	TokenID             pgtype.UUID      `json:"token_id"`
+	Amount              pgtype.Numeric   `json:"amount"`
+	Apy                 pgtype.Numeric   `json:"apy"`
+	StartDate           pgtype.Timestamp `json:"start_date"`
+	EndDate             pgtype.Timestamp `json:"end_date"`
+	Status              pgtype.Text      `json:"status"`
+	AutoCompound        pgtype.Bool      `json:"auto_compound"`
+	RewardsClaimed      pgtype.Numeric   `json:"rewards_claimed"`
+	CreatedAt           pgtype.Timestamp `json:"created_at"`
+	UpdatedAt           pgtype.Timestamp `json:"updated_at"`
+	ProductID           pgtype.UUID      `json:"product_id"`
+	UnbondingUntil      pgtype.Timestamp `json:"unbonding_until"`
+	WithdrawnAt         pgtype.Timestamp `json:"withdrawn_at"`
+	PenaltyAmount       pgtype.Numeric   `json:"penalty_amount"`
+	RewardsSettledAt    pgtype.Timestamp `json:"rewards_settled_at"`
+	ParentStakeID       pgtype.UUID      `json:"parent_stake_id"`
+	EarlyUnstakeAllowed pgtype.Bool      `json:"early_unstake_allowed"`
+	EarlyUnstakePenalty pgtype.Numeric   `json:"early_unstake_penalty"`
+	UnbondingDays       pgtype.Int4      `json:"unbonding_days"`
+}
+
+func (q *Queries) GetStakeForUpdate(ctx context.Context, id pgtype.UUID) (GetStakeForUpdateRow, error) {
+	row := q.db.QueryRow(ctx, getStakeForUpdate, id)
+	var i GetStakeForUpdateRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenID,
+		&i.Amount,
+		&i.Apy,
+		&i.StartDate,
+		&i.EndDate,
+		&i.Status,
+		&i.AutoCompound,
+		&i.RewardsClaimed,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ProductID,
+		&i.UnbondingUntil,
+		&i.WithdrawnAt,
+		&i.PenaltyAmount,
+		&i.RewardsSettledAt,
+		&i.ParentStakeID,
+		&i.EarlyUnstakeAllowed,
+		&i.EarlyUnstakePenalty,
+		&i.UnbondingDays,
+	)
+	return i, err
+}
+
+const getStakeHistory = `-- name: GetStakeHistory :many
+SELECT id, stake_id, user_id, event_type, amount, rewards_settled, balance_after, related_stake_id, created_at FROM stake_history
+WHERE stake_id = $1
+ORDER BY created_at ASC
+`
+
+func (q *Queries) GetStakeHistory(ctx context.Context, stakeID pgtype.UUID) ([]StakeHistory, error) {
+	rows, err := q.db.Query(ctx, getStakeHistory, stakeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []StakeHistory{}
+	for rows.Next() {
+		var i StakeHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.StakeID,
+			&i.UserID,
+			&i.EventType,
+			&i.Amount,
+			&i.RewardsSettled,
+			&i.BalanceAfter,
+			&i.RelatedStakeID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStakeLockTiers = `-- name: GetStakeLockTiers :many
+SELECT
+    (CASE
+        WHEN s.end_date - s.start_date < INTERVAL '30 days' THEN 'under_30d'
+        WHEN s.end_date - s.start_date < INTERVAL '90 days' THEN '30_89d'
+        WHEN s.end_date - s.start_date < INTERVAL '180 days' THEN '90_179d'
+        ELSE '180d_plus'
+    END)::text AS tier,
+    COUNT(*)::bigint AS stake_count,
+    COALESCE(SUM(s.amount * a.market_price), 0)::decimal AS total_value
+FROM stakes s
+LEFT JOIN assets a ON a.token_id = s.token_id
+WHERE s.status = 'active'
+  AND ($1::uuid IS NULL OR s.user_id = $1::uuid)
+GROUP BY 1
+ORDER BY MIN(s.end_date - s.start_date)
+`
+
+type GetStakeLockTiersRow struct {
+	Tier       string         `json:"tier"`
+	StakeCount int64          `json:"stake_count"`
+	TotalValue pgtype.Numeric `json:"total_value"`
+}
+
+func (q *Queries) GetStakeLockTiers(ctx context.Context, userID pgtype.UUID) ([]GetStakeLockTiersRow, error) {
+	rows, err := q.db.Query(ctx, getStakeLockTiers, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetStakeLockTiersRow{}
+	for rows.Next() {
+		var i GetStakeLockTiersRow
+		if err := rows.Scan(&i.Tier, &i.StakeCount, &i.TotalValue); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStakeRewardSeries = `-- name: GetStakeRewardSeries :many
+SELECT date_trunc($1::text, snapshot_at)::timestamp AS bucket_start,
+    MAX(principal)::decimal AS principal,
+    SUM(accrued_rewards)::decimal AS accrued_rewards,
+    MAX(cumulative_rewards)::decimal AS cumulative_rewards,
+    MAX(rewards_claimed)::decimal AS rewards_claimed
+FROM stake_reward_snapshots
+WHERE stake_id = $2::uuid
+  AND snapshot_at >= $3::timestamp
+  AND snapshot_at < $4::timestamp
+GROUP BY 1
+ORDER BY 1
+`
+
+type GetStakeRewardSeriesParams struct {
+	Bucket  string           `json:"bucket"`
+	StakeID pgtype.UUID      `json:"stake_id"`
+	FromAt  pgtype.Timestamp `json:"from_at"`
+	ToAt    pgtype.Timestamp `json:"to_at"`
+}
+
+type GetStakeRewardSeriesRow struct {
+	BucketStart       pgtype.Timestamp `json:"bucket_start"`
+	Principal         pgtype.Numeric   `json:"principal"`
+	AccruedRewards    pgtype.Numeric   `json:"accrued_rewards"`
+	CumulativeRewards pgtype.Numeric   `json:"cumulative_rewards"`
+	RewardsClaimed    pgtype.Numeric   `json:"rewards_claimed"`
+}
+
+func (q *Queries) GetStakeRewardSeries(ctx context.Context, arg GetStakeRewardSeriesParams) ([]GetStakeRewardSeriesRow, error) {
+	rows, err := q.db.Query(ctx, getStakeRewardSeries,
+		arg.Bucket,
+		arg.StakeID,
+		arg.FromAt,
+		arg.ToAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetStakeRewardSeriesRow{}
+	for rows.Next() {
+		var i GetStakeRewardSeriesRow
+		if err := rows.Scan(
+			&i.BucketStart,
+			&i.Principal,
+			&i.AccruedRewards,
+			&i.CumulativeRewards,
+			&i.RewardsClaimed,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStakeTotalsByToken = `-- name: GetStakeTotalsByToken :many
+SELECT t.symbol,
+    COALESCE(SUM(s.amount), 0)::decimal AS total_staked,
+    COALESCE(SUM(s.amount * a.market_price), 0)::decimal AS total_value,
+    COALESCE(SUM(s.amount * s.apy) / NULLIF(SUM(s.amount), 0), 0)::decimal AS weighted_apy,
+    COUNT(*)::bigint AS stake_count
+FROM stakes s
+JOIN tokens t ON s.token_id = t.id
+LEFT JOIN assets a ON a.token_id = s.token_id
+WHERE s.status = 'active'
+  AND ($1::uuid IS NULL OR s.user_id = $1::uuid)
+GROUP BY t.symbol
+ORDER BY t.symbol
+`
+
+type GetStakeTotalsByTokenRow struct {
+	Symbol      string         `json:"symbol"`
+	TotalStaked pgtype.Numeric `json:"total_staked"`
+	TotalValue  pgtype.Numeric `json:"total_value"`
+	WeightedApy pgtype.Numeric `json:"weighted_apy"`
+	StakeCount  int64          `json:"stake_count"`
+}
+
+func (q *Queries) GetStakeTotalsByToken(ctx context.Context, userID pgtype.UUID) ([]GetStakeTotalsByTokenRow, error) {
+	rows, err := q.db.Query(ctx, getStakeTotalsByToken, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetStakeTotalsByTokenRow{}
+	for rows.Next() {
+		var i GetStakeTotalsByTokenRow
+		if err := rows.Scan(
+			&i.Symbol,
+			&i.TotalStaked,
+			&i.TotalValue,
+			&i.WeightedApy,
+			&i.StakeCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStakingOverview = `-- name: GetStakingOverview :one
+SELECT
+    COUNT(DISTINCT s.user_id) FILTER (WHERE s.status = 'active')::bigint AS active_stakers,
+    COALESCE(SUM(s.rewards_claimed), 0)::decimal AS rewards_paid,
+    COUNT(*) FILTER (WHERE s.status = 'active' AND s.end_date > CURRENT_TIMESTAMP AND s.end_date <= CURRENT_TIMESTAMP + INTERVAL '7 days')::bigint AS maturing_7d_count,
+    COALESCE(SUM(s.amount * a.market_price) FILTER (WHERE s.status = 'active' AND s.end_date > CURRENT_TIMESTAMP AND s.end_date <= CURRENT_TIMESTAMP + INTERVAL '7 days'), 0)::decimal AS maturing_7d_value,
+    COUNT(*) FILTER (WHERE s.status = 'active' AND s.end_date > CURRENT_TIMESTAMP AND s.end_date <= CURRENT_TIMESTAMP + INTERVAL '30 days')::bigint AS maturing_30d_count,
+    COALESCE(SUM(s.amount * a.market_price) FILTER (WHERE s.status = 'active' AND s.end_date > CURRENT_TIMESTAMP AND s.end_date <= CURRENT_TIMESTAMP + INTERVAL '30 days'), 0)::decimal AS maturing_30d_value
+FROM stakes s
+LEFT JOIN assets a ON a.token_id = s.token_id
+WHERE $1::uuid IS NULL OR s.user_id = $1::uuid
+`
+
+type GetStakingOverviewRow struct {
+	ActiveStakers    int64          `json:"active_stakers"`
+	RewardsPaid      pgtype.Numeric `json:"rewards_paid"`
+	Maturing7dCount  int64          `json:"maturing_7d_count"`
+	Maturing7dValue  pgtype.Numeric `json:"maturing_7d_value"`
+	Maturing30dCount int64          `json:"maturing_30d_count"`
+	Maturing30dValue pgtype.Numeric `json:"maturing_30d_value"`
+}
+
+func (q *Queries) GetStakingOverview(ctx context.Context, userID pgtype.UUID) (GetStakingOverviewRow, error) {
+	row := q.db.QueryRow(ctx, getStakingOverview, userID)
+	var i GetStakingOverviewRow
+	err := row.Scan(
+		&i.ActiveStakers,
+		&i.RewardsPaid,
+		&i.Maturing7dCount,
+		&i.Maturing7dValue,
+		&i.Maturing30dCount,
+		&i.Maturing30dValue,
+	)
+	return i, err
+}
+
+const getStakingProductForDuration = `-- name: GetStakingProductForDuration :one
+SELECT id, token_id, name, min_duration_days, apy, early_unstake_allowed, early_unstake_penalty, unbonding_days, is_active, created_at, updated_at FROM staking_products
+WHERE token_id = $1 AND min_duration_days <= $2 AND is_active = true
+ORDER BY min_duration_days DESC
+LIMIT 1
+`
+
+type GetStakingProductForDurationParams struct {
+	TokenID         pgtype.UUID `json:"token_id"`
+	MinDurationDays int32       `json:"min_duration_days"`
+}
+
+func (q *Queries) GetStakingProductForDuration(ctx context.Context, arg GetStakingProductForDurationParams) (StakingProduct, error) {
+	row := q.db.QueryRow(ctx, getStakingProductForDuration, arg.TokenID, arg.MinDurationDays)
+	var i StakingProduct
+	err := row.Scan(
+		&i.ID,
+		&i.TokenID,
+		&i.Name,
+		&i.MinDurationDays,
+		&i.Apy,
+		&i.EarlyUnstakeAllowed,
+		&i.EarlyUnstakePenalty,
+		&i.UnbondingDays,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getTotalStakedValue = `-- name: GetTotalStakedValue :one
 SELECT COALESCE(SUM(s.amount * a.market_price), 0)::decimal as total_value
 FROM stakes s
@@ -115,28 +628,34 @@ func (q *Queries) GetTotalStakedValue(ctx context.Context) (pgtype.Numeric, erro
 }
 
 const getUserStakes = `-- name: GetUserStakes :many
-SELECT s.id, s.user_id, s.token_id, s.amount, s.apy, s.start_date, s.end_date, s.status, s.auto_compound, s.rewards_claimed, s.created_at, s.updated_at, t.symbol, t.name
+SELECT s.id, s.user_id, s.token_id, s.amount, s.apy, s.start_date, s.end_date, s.status, s.auto_compound, s.rewards_claimed, s.created_at, s.updated_at, s.product_id, s.unbonding_until, s.withdrawn_at, s.penalty_amount, s.rewards_settled_at, s.parent_stake_id, t.symbol, t.name
 FROM stakes s
 JOIN tokens t ON s.token_id = t.id
-WHERE s.user_id = $1 AND s.status = 'active'
+WHERE s.user_id = $1 AND s.status IN ('active', 'unbonding', 'withdrawable')
 ORDER BY s.created_at DESC
 `
 
 type GetUserStakesRow struct {
-	ID             pgtype.UUID      `json:"id"`
-	UserID         pgtype.UUID      `json:"user_id"`
-	TokenID        pgtype.UUID      `json:"token_id"`
-	Amount         pgtype.Numeric   `json:"amount"`
-	Apy            pgtype.Numeric   `json:"apy"`
-	StartDate      pgtype.Timestamp `json:"start_date"`
-	EndDate        pgtype.Timestamp `json:"end_date"`
-	Status         pgtype.Text      `json:"status"`
-	AutoCompound   pgtype.Bool      `json:"auto_compound"`
-	RewardsClaimed pgtype.Numeric   `json:"rewards_claimed"`
-	CreatedAt      pgtype.Timestamp `json:"created_at"`
-	UpdatedAt      pgtype.Timestamp `json:"updated_at"`
-	Symbol         string           `json:"symbol"`
-	Name           string           `json:"name"`
+	ID               pgtype.UUID      `json:"id"`
+	UserID           pgtype.UUID      `json:"user_id"`
+	TokenID          pgtype.UUID      `json:"token_id"`
+	Amount           pgtype.Numeric   `json:"amount"`
+	Apy              pgtype.Numeric   `json:"apy"`
+	StartDate        pgtype.Timestamp `json:"start_date"`
+	EndDate          pgtype.Timestamp `json:"end_date"`
+	Status           pgtype.Text      `json:"status"`
+	AutoCompound     pgtype.Bool      `json:"auto_compound"`
+	RewardsClaimed   pgtype.Numeric   `json:"rewards_claimed"`
+	CreatedAt        pgtype.Timestamp `json:"created_at"`
+	UpdatedAt        pgtype.Timestamp `json:"updated_at"`
+	ProductID        pgtype.UUID      `json:"product_id"`
+	UnbondingUntil   pgtype.Timestamp `json:"unbonding_until"`
+	WithdrawnAt      pgtype.Timestamp `json:"withdrawn_at"`
+	PenaltyAmount    pgtype.Numeric   `json:"penalty_amount"`
+	RewardsSettledAt pgtype.Timestamp `json:"rewards_settled_at"`
+	ParentStakeID    pgtype.UUID      `json:"parent_stake_id"`
+	Symbol           string           `json:"symbol"`
+	Name             string           `json:"name"`
 }
 
 func (q *Queries) GetUserStakes(ctx context.Context, userID pgtype.UUID) ([]GetUserStakesRow, error) {
@@ -161,6 +680,12 @@ func (q *Queries) GetUserStakes(ctx context.Context, userID pgtype.UUID) ([]GetU
 			&i.RewardsClaimed,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.ProductID,
+			&i.UnbondingUntil,
+			&i.WithdrawnAt,
+			&i.PenaltyAmount,
+			&i.RewardsSettledAt,
+			&i.ParentStakeID,
 			&i.Symbol,
 			&i.Name,
 		); err != nil {
@@ -174,25 +699,148 @@ func (q *Queries) GetUserStakes(ctx context.Context, userID pgtype.UUID) ([]GetU
 	return items, nil
 }
 
-const unstake = `-- name: Unstake :exec
-UPDATE stakes 
-SET status = 'unstaked', end_date = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
-WHERE id = $1 AND user_id = $2
+const listStakesDueForSnapshot = `-- name: ListStakesDueForSnapshot :many
+SELECT s.id, s.user_id, s.amount, s.apy, s.rewards_claimed,
+    COALESCE((SELECT MAX(r.snapshot_at) FROM stake_reward_snapshots r WHERE r.stake_id = s.id), s.start_date)::timestamp AS last_snapshot_at,
+    COALESCE((SELECT r.cumulative_rewards FROM stake_reward_snapshots r WHERE r.stake_id = s.id ORDER BY r.snapshot_at DESC LIMIT 1), 0)::decimal AS cumulative_rewards
+FROM stakes s
+WHERE s.status = 'active'
+  AND s.start_date < $1::timestamp
+  AND NOT EXISTS (
+    SELECT 1 FROM stake_reward_snapshots r
+    WHERE r.stake_id = s.id AND r.snapshot_at = $1::timestamp
+  )
+`
+
+type ListStakesDueForSnapshotRow struct {
+	ID                pgtype.UUID      `json:"id"`
+	UserID            pgtype.UUID      `json:"user_id"`
+	Amount            pgtype.Numeric   `json:"amount"`
+	Apy               pgtype.Numeric   `json:"apy"`
+	RewardsClaimed    pgtype.Numeric   `json:"rewards_claimed"`
+	LastSnapshotAt    pgtype.Timestamp `json:"last_snapshot_at"`
+	CumulativeRewards pgtype.Numeric   `json:"cumulative_rewards"`
+}
+
+func (q *Queries) ListStakesDueForSnapshot(ctx context.Context, snapshotAt pgtype.Timestamp) ([]ListStakesDueForSnapshotRow, error) {
+	rows, err := q.db.Query(ctx, listStakesDueForSnapshot, snapshotAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListStakesDueForSnapshotRow{}
+	for rows.Next() {
+		var i ListStakesDueForSnapshotRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Amount,
+			&i.Apy,
+			&i.RewardsClaimed,
+			&i.LastSnapshotAt,
+			&i.CumulativeRewards,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markStakeWithdrawn = `-- name: MarkStakeWithdrawn :exec
+UPDATE stakes
+SET status = 'withdrawn', withdrawn_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'withdrawable'
+`
+
+func (q *Queries) MarkStakeWithdrawn(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, markStakeWithdrawn, id)
+	return err
+}
+
+const releaseUnbondedStakes = `-- name: ReleaseUnbondedStakes :execrows
+UPDATE stakes
+SET status = 'withdrawable', updated_at = CURRENT_TIMESTAMP
+WHERE status = 'unbonding' AND unbonding_until <= CURRENT_TIMESTAMP
+`
+
+func (q *Queries) ReleaseUnbondedStakes(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, releaseUnbondedStakes)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setStakingProductAPY = `-- name: SetStakingProductAPY :execrows
+UPDATE staking_products
+SET apy = $2, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
 `
 
-type UnstakeParams struct {
-	ID     pgtype.UUID `json:"id"`
-	UserID pgtype.UUID `json:"user_id"`
+type SetStakingProductAPYParams struct {
+	ID  pgtype.UUID    `json:"id"`
+	Apy pgtype.Numeric `json:"apy"`
+}
+
+func (q *Queries) SetStakingProductAPY(ctx context.Context, arg SetStakingProductAPYParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setStakingProductAPY, arg.ID, arg.Apy)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
-func (q *Queries) Unstake(ctx context.Context, arg UnstakeParams) error {
-	_, err := q.db.Exec(ctx, unstake, arg.ID, arg.UserID)
+const startUnbondingMaturedStakes = `-- name: StartUnbondingMaturedStakes :execrows
+UPDATE stakes s
+SET status = 'unbonding',
+    unbonding_until = CURRENT_TIMESTAMP + make_interval(days => COALESCE(
+        (SELECT p.unbonding_days FROM staking_products p WHERE p.id = s.product_id),
+        $1::int
+    )),
+    updated_at = CURRENT_TIMESTAMP
+WHERE s.status = 'active'
+  AND s.end_date <= CURRENT_TIMESTAMP
+  AND s.auto_compound IS NOT TRUE
+`
+
+func (q *Queries) StartUnbondingMaturedStakes(ctx context.Context, defaultUnbondingDays int32) (int64, error) {
+	result, err := q.db.Exec(ctx, startUnbondingMaturedStakes, defaultUnbondingDays)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateStakePosition = `-- name: UpdateStakePosition :exec
+UPDATE stakes
+SET amount = $2, start_date = $3, end_date = $4, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'active'
+`
+
+type UpdateStakePositionParams struct {
+	ID        pgtype.UUID      `json:"id"`
+	Amount    pgtype.Numeric   `json:"amount"`
+	StartDate pgtype.Timestamp `json:"start_date"`
+	EndDate   pgtype.Timestamp `json:"end_date"`
+}
+
+func (q *Queries) UpdateStakePosition(ctx context.Context, arg UpdateStakePositionParams) error {
+	_, err := q.db.Exec(ctx, updateStakePosition,
+		arg.ID,
+		arg.Amount,
+		arg.StartDate,
+		arg.EndDate,
+	)
 	return err
 }
 
 const updateStakeRewards = `-- name: UpdateStakeRewards :exec
 UPDATE stakes 
-SET rewards_claimed = rewards_claimed + $2, updated_at = CURRENT_TIMESTAMP
+SET rewards_claimed = rewards_claimed + $2, rewards_settled_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
 WHERE id = $1
 `
 
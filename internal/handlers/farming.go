@@ -0,0 +1,165 @@
+// internal/handlers/farming.go
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jd7008911/aogeri-api/internal/auth"
+	"github.com/jd7008911/aogeri-api/internal/models"
+	"github.com/jd7008911/aogeri-api/pkg/web"
+)
+
+// ListFarms lists liquidity mining programs; ?include_ended=true also
+// returns finished ones.
+func (h *LiquidityHandler) ListFarms(w http.ResponseWriter, r *http.Request) {
+	var includeEnded bool
+	if v := r.URL.Query().Get("include_ended"); v != "" {
+		var err error
+		if includeEnded, err = strconv.ParseBool(v); err != nil {
+			web.Error(w, http.StatusBadRequest, "Invalid include_ended")
+			return
+		}
+	}
+
+	farms, err := h.svc.ListFarms(r.Context(), includeEnded)
+	if err != nil {
+		web.Error(w, http.StatusInternalServerError, "Failed to fetch farms")
+		return
+	}
+	web.Respond(w, http.StatusOK, farms)
+}
+
+func (h *LiquidityHandler) GetFarm(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid farm ID")
+		return
+	}
+
+	farm, err := h.svc.GetFarm(r.Context(), id)
+	if err != nil {
+		writeLiquidityError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, farm)
+}
+
+// CreateFarm lets an admin open a farm on a pool.
+func (h *LiquidityHandler) CreateFarm(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req models.CreateFarmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		web.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	farm, err := h.svc.CreateFarm(r.Context(), userID, req)
+	if err != nil {
+		writeLiquidityError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusCreated, farm)
+}
+
+// ListMyFarms returns the caller's farm stakes with their pending rewards.
+func (h *LiquidityHandler) ListMyFarms(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	stakes, err := h.svc.ListUserFarms(r.Context(), userID)
+	if err != nil {
+		web.Error(w, http.StatusInternalServerError, "Failed to fetch farm stakes")
+		return
+	}
+	web.Respond(w, http.StatusOK, stakes)
+}
+
+// StakeFarm locks LP shares of the caller's position in a farm.
+func (h *LiquidityHandler) StakeFarm(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid farm ID")
+		return
+	}
+
+	var req models.FarmStakeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		web.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stake, err := h.svc.StakeFarm(r.Context(), userID, id, req)
+	if err != nil {
+		writeLiquidityError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, stake)
+}
+
+// ClaimFarmRewards settles the rewards accrued on the caller's farm stake
+// and pays them out.
+func (h *LiquidityHandler) ClaimFarmRewards(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid farm ID")
+		return
+	}
+
+	claimed, err := h.svc.ClaimFarmRewards(r.Context(), userID, id)
+	if err != nil {
+		writeLiquidityError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, map[string]any{"claimed": claimed})
+}
+
+// ExitFarm claims the caller's rewards and unlocks all of their shares.
+func (h *LiquidityHandler) ExitFarm(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid farm ID")
+		return
+	}
+
+	exit, err := h.svc.ExitFarm(r.Context(), userID, id)
+	if err != nil {
+		writeLiquidityError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, exit)
+}
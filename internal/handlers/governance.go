@@ -2,30 +2,708 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/jd7008911/aogeri-api/internal/auth"
+	"github.com/jd7008911/aogeri-api/internal/models"
 	"github.com/jd7008911/aogeri-api/internal/services"
 	"github.com/jd7008911/aogeri-api/pkg/web"
 )
 
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
 type GovernanceHandler struct {
-	svc *services.GovernanceService
+	svc         *services.GovernanceService
+	authService *auth.AuthService
+	validate    *validator.Validate
 }
 
-func NewGovernanceHandler(_ any, svc *services.GovernanceService) *GovernanceHandler {
-	return &GovernanceHandler{svc: svc}
+func NewGovernanceHandler(svc *services.GovernanceService, authService *auth.AuthService) *GovernanceHandler {
+	return &GovernanceHandler{svc: svc, authService: authService, validate: validator.New()}
 }
 
 func (h *GovernanceHandler) RegisterRoutes(r chi.Router) {
-	r.Get("/proposals", h.ListProposals)
+	r.Route("/proposals", func(r chi.Router) {
+		r.Get("/", h.ListProposals)
+		r.Post("/", h.CreateProposal)
+		r.Get("/{id}", h.GetProposal)
+		r.Post("/{id}/votes", h.CastVote)
+		r.Get("/{id}/my-power", h.GetMyVotePower)
+		r.Get("/{id}/timeline", h.GetTimeline)
+		r.Get("/{id}/results", h.GetResults)
+		r.Get("/{id}/turnout", h.GetTurnout)
+		r.Get("/{id}/results/export", h.ExportResults)
+		r.Get("/{id}/comments", h.ListComments)
+		r.Post("/{id}/comments", h.CreateComment)
+		r.Patch("/{id}/comments/{commentID}", h.EditComment)
+		r.Get("/{id}/comments/{commentID}/history", h.CommentHistory)
+		r.Post("/{id}/comments/{commentID}/reactions", h.AddReaction)
+		r.Delete("/{id}/comments/{commentID}/reactions/{reaction}", h.RemoveReaction)
+		r.Group(func(r chi.Router) {
+			r.Use(h.authService.AdminMiddleware)
+			r.Post("/{id}/cancel", h.CancelProposal)
+			r.Post("/{id}/lock", h.LockDiscussion)
+			r.Post("/{id}/unlock", h.UnlockDiscussion)
+			r.Post("/{id}/comments/{commentID}/hide", h.HideComment)
+			r.Post("/{id}/comments/{commentID}/unhide", h.UnhideComment)
+		})
+	})
+	r.Route("/governance", func(r chi.Router) {
+		r.Post("/delegate", h.Delegate)
+		r.Post("/undelegate", h.Undelegate)
+		r.Get("/delegations", h.GetDelegations)
+		r.Get("/delegates", h.ListDelegates)
+		r.Get("/analytics/participation", h.GetParticipation)
+		r.Get("/analytics/top-voters", h.ListTopVoters)
+		r.Get("/me/history", h.GetVoteHistory)
+	})
 }
 
+// ListProposals lists proposals filtered by ?status= and ?type=, paginated
+// with ?limit= and ?offset=. The total match count is returned in the
+// X-Total-Count header.
 func (h *GovernanceHandler) ListProposals(w http.ResponseWriter, r *http.Request) {
-	list, err := h.svc.GetActiveProposals(r.Context())
+	q := r.URL.Query()
+	limit, offset, ok := pageParams(q.Get("limit"), q.Get("offset"))
+	if !ok {
+		web.Error(w, http.StatusBadRequest, "Invalid pagination parameters")
+		return
+	}
+
+	list, total, err := h.svc.ListProposals(r.Context(), q.Get("status"), q.Get("type"), limit, offset)
 	if err != nil {
 		web.Error(w, http.StatusInternalServerError, "Failed to fetch proposals")
 		return
 	}
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
 	web.Respond(w, http.StatusOK, list)
 }
+
+func (h *GovernanceHandler) GetProposal(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid proposal ID")
+		return
+	}
+
+	proposal, err := h.svc.GetProposal(r.Context(), id)
+	if err != nil {
+		writeGovernanceError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, proposal)
+}
+
+func (h *GovernanceHandler) CreateProposal(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req models.CreateProposalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		web.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	proposal, err := h.svc.CreateProposal(r.Context(), userID, req)
+	if err != nil {
+		writeGovernanceError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusCreated, proposal)
+}
+
+// CastVote casts or changes the caller's vote on a proposal.
+func (h *GovernanceHandler) CastVote(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid proposal ID")
+		return
+	}
+
+	var req models.VoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		web.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	receipt, err := h.svc.CastVote(r.Context(), id, userID, req)
+	if err != nil {
+		writeGovernanceError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, receipt)
+}
+
+// GetMyVotePower returns the caller's snapshotted vote power on a proposal.
+func (h *GovernanceHandler) GetMyVotePower(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid proposal ID")
+		return
+	}
+
+	power, err := h.svc.GetVotePower(r.Context(), id, userID)
+	if err != nil {
+		writeGovernanceError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, power)
+}
+
+// CancelProposal lets an admin cancel a passed proposal during its timelock.
+func (h *GovernanceHandler) CancelProposal(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid proposal ID")
+		return
+	}
+
+	proposal, err := h.svc.CancelProposal(r.Context(), id, userID)
+	if err != nil {
+		writeGovernanceError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, proposal)
+}
+
+// Delegate delegates the caller's vote power, globally or for one token.
+func (h *GovernanceHandler) Delegate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req models.DelegateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		web.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	delegation, err := h.svc.Delegate(r.Context(), userID, req)
+	if err != nil {
+		writeGovernanceError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, delegation)
+}
+
+// Undelegate removes one of the caller's delegations.
+func (h *GovernanceHandler) Undelegate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req models.UndelegateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		web.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.svc.Undelegate(r.Context(), userID, req.TokenSymbol); err != nil {
+		writeGovernanceError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, map[string]string{"message": "delegation removed"})
+}
+
+// GetDelegations lists the delegations the caller has made and received.
+func (h *GovernanceHandler) GetDelegations(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	delegations, err := h.svc.GetDelegations(r.Context(), userID)
+	if err != nil {
+		web.Error(w, http.StatusInternalServerError, "Failed to fetch delegations")
+		return
+	}
+	web.Respond(w, http.StatusOK, delegations)
+}
+
+// ListDelegates ranks delegates by received power for ?token=, paginated
+// with ?limit= and ?offset=.
+func (h *GovernanceHandler) ListDelegates(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, offset, ok := pageParams(q.Get("limit"), q.Get("offset"))
+	if !ok {
+		web.Error(w, http.StatusBadRequest, "Invalid pagination parameters")
+		return
+	}
+
+	delegates, err := h.svc.ListDelegates(r.Context(), q.Get("token"), limit, offset)
+	if err != nil {
+		web.Error(w, http.StatusInternalServerError, "Failed to fetch delegates")
+		return
+	}
+	web.Respond(w, http.StatusOK, delegates)
+}
+
+// GetParticipation reports turnout across finalized proposals, listing the
+// most recently closed ones with ?limit= and ?offset=.
+func (h *GovernanceHandler) GetParticipation(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, offset, ok := pageParams(q.Get("limit"), q.Get("offset"))
+	if !ok {
+		web.Error(w, http.StatusBadRequest, "Invalid pagination parameters")
+		return
+	}
+
+	report, err := h.svc.GetParticipation(r.Context(), limit, offset)
+	if err != nil {
+		web.Error(w, http.StatusInternalServerError, "Failed to fetch participation")
+		return
+	}
+	web.Respond(w, http.StatusOK, report)
+}
+
+// ListTopVoters ranks users by the votes they cast.
+func (h *GovernanceHandler) ListTopVoters(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, offset, ok := pageParams(q.Get("limit"), q.Get("offset"))
+	if !ok {
+		web.Error(w, http.StatusBadRequest, "Invalid pagination parameters")
+		return
+	}
+
+	voters, err := h.svc.ListTopVoters(r.Context(), limit, offset)
+	if err != nil {
+		web.Error(w, http.StatusInternalServerError, "Failed to fetch top voters")
+		return
+	}
+	web.Respond(w, http.StatusOK, voters)
+}
+
+// GetVoteHistory lists the caller's votes, newest first. The total count
+// is returned in the X-Total-Count header.
+func (h *GovernanceHandler) GetVoteHistory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+	q := r.URL.Query()
+	limit, offset, ok := pageParams(q.Get("limit"), q.Get("offset"))
+	if !ok {
+		web.Error(w, http.StatusBadRequest, "Invalid pagination parameters")
+		return
+	}
+
+	history, total, err := h.svc.GetVoteHistory(r.Context(), userID, limit, offset)
+	if err != nil {
+		web.Error(w, http.StatusInternalServerError, "Failed to fetch vote history")
+		return
+	}
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	web.Respond(w, http.StatusOK, history)
+}
+
+// GetTurnout returns a proposal's cumulative turnout per ?interval=hour
+// (the default) or day.
+func (h *GovernanceHandler) GetTurnout(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid proposal ID")
+		return
+	}
+
+	turnout, err := h.svc.GetTurnout(r.Context(), id, r.URL.Query().Get("interval"))
+	if err != nil {
+		writeGovernanceError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, turnout)
+}
+
+// GetResults returns a proposal's tallies, result and ballot root.
+func (h *GovernanceHandler) GetResults(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid proposal ID")
+		return
+	}
+
+	results, err := h.svc.GetResults(r.Context(), id)
+	if err != nil {
+		writeGovernanceError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, results)
+}
+
+// ExportResults downloads every ballot of a proposal as JSON, with the
+// results to check them against, or as CSV (?format=csv).
+func (h *GovernanceHandler) ExportResults(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid proposal ID")
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		web.Error(w, http.StatusBadRequest, "format must be json or csv")
+		return
+	}
+
+	export, err := h.svc.ExportBallots(r.Context(), id)
+	if err != nil {
+		writeGovernanceError(w, err)
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="proposal-%s-ballots.%s"`, id, format))
+	if format == "json" {
+		web.Respond(w, http.StatusOK, export)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	if export.BallotRoot != "" {
+		w.Header().Set("X-Ballot-Root", export.BallotRoot)
+	}
+	w.WriteHeader(http.StatusOK)
+	_ = services.WriteBallotsCSV(w, export.Ballots)
+}
+
+// GetTimeline returns a proposal's lifecycle events in order.
+func (h *GovernanceHandler) GetTimeline(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid proposal ID")
+		return
+	}
+
+	events, err := h.svc.GetTimeline(r.Context(), id)
+	if err != nil {
+		writeGovernanceError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, events)
+}
+
+// ListComments returns a proposal's comment thread.
+func (h *GovernanceHandler) ListComments(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid proposal ID")
+		return
+	}
+
+	discussion, err := h.svc.ListComments(r.Context(), id, userID)
+	if err != nil {
+		writeGovernanceError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, discussion)
+}
+
+// CreateComment posts a comment or a reply on a proposal.
+func (h *GovernanceHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid proposal ID")
+		return
+	}
+
+	var req models.CommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		web.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	comment, err := h.svc.CreateComment(r.Context(), id, userID, req)
+	if err != nil {
+		writeGovernanceError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusCreated, comment)
+}
+
+// EditComment replaces the body of the caller's comment.
+func (h *GovernanceHandler) EditComment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	id, commentID, ok := commentParams(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.EditCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		web.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	comment, err := h.svc.EditComment(r.Context(), id, commentID, userID, req.Body)
+	if err != nil {
+		writeGovernanceError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, comment)
+}
+
+// CommentHistory returns the earlier versions of a comment.
+func (h *GovernanceHandler) CommentHistory(w http.ResponseWriter, r *http.Request) {
+	id, commentID, ok := commentParams(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := h.svc.CommentHistory(r.Context(), id, commentID)
+	if err != nil {
+		writeGovernanceError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, history)
+}
+
+// AddReaction adds the caller's reaction to a comment.
+func (h *GovernanceHandler) AddReaction(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	id, commentID, ok := commentParams(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ReactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		web.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.svc.React(r.Context(), id, commentID, userID, req.Reaction, true); err != nil {
+		writeGovernanceError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, map[string]string{"message": "reaction added"})
+}
+
+// RemoveReaction removes the caller's reaction from a comment.
+func (h *GovernanceHandler) RemoveReaction(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	id, commentID, ok := commentParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.React(r.Context(), id, commentID, userID, chi.URLParam(r, "reaction"), false); err != nil {
+		writeGovernanceError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, map[string]string{"message": "reaction removed"})
+}
+
+// LockDiscussion stops new comments, edits and reactions on a proposal.
+func (h *GovernanceHandler) LockDiscussion(w http.ResponseWriter, r *http.Request) {
+	h.setDiscussionLocked(w, r, true)
+}
+
+// UnlockDiscussion reopens a locked discussion.
+func (h *GovernanceHandler) UnlockDiscussion(w http.ResponseWriter, r *http.Request) {
+	h.setDiscussionLocked(w, r, false)
+}
+
+func (h *GovernanceHandler) setDiscussionLocked(w http.ResponseWriter, r *http.Request, locked bool) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid proposal ID")
+		return
+	}
+
+	proposal, err := h.svc.SetDiscussionLocked(r.Context(), id, userID, locked)
+	if err != nil {
+		writeGovernanceError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, proposal)
+}
+
+// HideComment hides a comment's body from the thread.
+func (h *GovernanceHandler) HideComment(w http.ResponseWriter, r *http.Request) {
+	h.setCommentHidden(w, r, true)
+}
+
+// UnhideComment restores a hidden comment.
+func (h *GovernanceHandler) UnhideComment(w http.ResponseWriter, r *http.Request) {
+	h.setCommentHidden(w, r, false)
+}
+
+func (h *GovernanceHandler) setCommentHidden(w http.ResponseWriter, r *http.Request, hidden bool) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	id, commentID, ok := commentParams(w, r)
+	if !ok {
+		return
+	}
+
+	comment, err := h.svc.SetCommentHidden(r.Context(), id, commentID, userID, hidden)
+	if err != nil {
+		writeGovernanceError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, comment)
+}
+
+// commentParams parses the proposal and comment IDs from the URL, writing a
+// 400 when either is malformed.
+func commentParams(w http.ResponseWriter, r *http.Request) (proposalID, commentID uuid.UUID, ok bool) {
+	proposalID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid proposal ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	commentID, err = uuid.Parse(chi.URLParam(r, "commentID"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid comment ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return proposalID, commentID, true
+}
+
+// writeGovernanceError maps governance service errors to HTTP responses.
+func writeGovernanceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrProposalNotFound):
+		web.Error(w, http.StatusNotFound, "Proposal not found")
+	case errors.Is(err, services.ErrDelegateNotFound), errors.Is(err, services.ErrDelegationNotFound),
+		errors.Is(err, services.ErrCommentNotFound):
+		web.Error(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrInvalidProposal), errors.Is(err, services.ErrInvalidDelegation),
+		errors.Is(err, services.ErrInvalidComment), errors.Is(err, services.ErrInvalidReaction),
+		errors.Is(err, services.ErrInvalidBallot), errors.Is(err, services.ErrInvalidInterval):
+		web.Error(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrProposerIneligible), errors.Is(err, services.ErrNoVotingPower),
+		errors.Is(err, services.ErrNotCommentAuthor):
+		web.Error(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrTooManyOpenProposals), errors.Is(err, services.ErrVotingClosed),
+		errors.Is(err, services.ErrCannotCancel), errors.Is(err, services.ErrDelegationCycle),
+		errors.Is(err, services.ErrCommentHidden), errors.Is(err, services.ErrDiscussionLocked):
+		web.Error(w, http.StatusConflict, err.Error())
+	default:
+		web.Error(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// pageParams parses limit and offset query values, applying defaults and
+// capping limit.
+func pageParams(limitStr, offsetStr string) (limit, offset int, ok bool) {
+	limit, offset = defaultPageLimit, 0
+	if limitStr != "" {
+		v, err := strconv.Atoi(limitStr)
+		if err != nil || v <= 0 {
+			return 0, 0, false
+		}
+		limit = v
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	if offsetStr != "" {
+		v, err := strconv.Atoi(offsetStr)
+		if err != nil || v < 0 {
+			return 0, 0, false
+		}
+		offset = v
+	}
+	return limit, offset, true
+}
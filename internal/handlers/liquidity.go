@@ -2,22 +2,465 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/jd7008911/aogeri-api/internal/auth"
+	"github.com/jd7008911/aogeri-api/internal/models"
+	"github.com/jd7008911/aogeri-api/internal/services"
 	"github.com/jd7008911/aogeri-api/pkg/web"
 )
 
-type LiquidityHandler struct{}
+type LiquidityHandler struct {
+	svc         *services.LiquidityService
+	authService *auth.AuthService
+	validate    *validator.Validate
+}
 
-func NewLiquidityHandler() *LiquidityHandler {
-	return &LiquidityHandler{}
+func NewLiquidityHandler(svc *services.LiquidityService, authService *auth.AuthService) *LiquidityHandler {
+	return &LiquidityHandler{svc: svc, authService: authService, validate: validator.New()}
 }
 
 func (h *LiquidityHandler) RegisterRoutes(r chi.Router) {
-	r.Get("/liquidity", h.GetLiquidity)
+	r.Route("/pools", func(r chi.Router) {
+		r.Get("/", h.ListPools)
+		r.Get("/positions", h.GetPositions)
+		r.Get("/positions/{id}", h.GetPosition)
+		r.Get("/me/swaps", h.ListMySwaps)
+		r.Get("/route", h.QuoteRoute)
+		r.Post("/route/swap", h.SwapRoute)
+		r.Get("/{id}", h.GetPool)
+		r.Post("/{id}/deposit", h.AddLiquidity)
+		r.Post("/{id}/withdraw", h.RemoveLiquidity)
+		r.Get("/{id}/quote", h.Quote)
+		r.Get("/{id}/twap", h.TWAP)
+		r.Get("/{id}/history", h.PoolHistory)
+		r.Get("/{id}/depth", h.PoolDepth)
+		r.Post("/{id}/swap", h.Swap)
+		r.Get("/{id}/swaps", h.ListPoolSwaps)
+		r.Group(func(r chi.Router) {
+			r.Use(h.authService.AdminMiddleware)
+			r.Post("/", h.CreatePool)
+			r.Put("/{id}/fee", h.SetPoolFee)
+			r.Post("/{id}/pause", h.PausePool)
+			r.Post("/{id}/unpause", h.UnpausePool)
+			r.Post("/{id}/deprecate", h.DeprecatePool)
+			r.Get("/{id}/audit", h.ListPoolAudit)
+		})
+	})
+	r.Route("/farms", func(r chi.Router) {
+		r.Get("/", h.ListFarms)
+		r.Get("/me", h.ListMyFarms)
+		r.Get("/{id}", h.GetFarm)
+		r.Post("/{id}/stake", h.StakeFarm)
+		r.Post("/{id}/claim", h.ClaimFarmRewards)
+		r.Post("/{id}/exit", h.ExitFarm)
+		r.Group(func(r chi.Router) {
+			r.Use(h.authService.AdminMiddleware)
+			r.Post("/", h.CreateFarm)
+		})
+	})
+}
+
+// ListPools lists pools that are not deprecated, optionally only those
+// containing ?token=.
+func (h *LiquidityHandler) ListPools(w http.ResponseWriter, r *http.Request) {
+	pools, err := h.svc.ListPools(r.Context(), r.URL.Query().Get("token"))
+	if err != nil {
+		web.Error(w, http.StatusInternalServerError, "Failed to fetch pools")
+		return
+	}
+	web.Respond(w, http.StatusOK, pools)
+}
+
+func (h *LiquidityHandler) GetPool(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid pool ID")
+		return
+	}
+
+	pool, err := h.svc.GetPool(r.Context(), id)
+	if err != nil {
+		writeLiquidityError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, pool)
+}
+
+// GetPositions lists the caller's active liquidity positions.
+func (h *LiquidityHandler) GetPositions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	positions, err := h.svc.GetUserPositions(r.Context(), userID)
+	if err != nil {
+		web.Error(w, http.StatusInternalServerError, "Failed to fetch positions")
+		return
+	}
+	web.Respond(w, http.StatusOK, positions)
+}
+
+// GetPosition reports one of the caller's positions: fees earned, value
+// against holding the deposits, and impermanent loss.
+func (h *LiquidityHandler) GetPosition(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid position ID")
+		return
+	}
+
+	position, err := h.svc.GetPosition(r.Context(), userID, id)
+	if err != nil {
+		writeLiquidityError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, position)
+}
+
+// AddLiquidity deposits a token pair into a pool for LP shares.
+func (h *LiquidityHandler) AddLiquidity(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid pool ID")
+		return
+	}
+
+	var req models.AddLiquidityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		web.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	receipt, err := h.svc.AddLiquidity(r.Context(), userID, id, req)
+	if err != nil {
+		writeLiquidityError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, receipt)
+}
+
+// RemoveLiquidity burns LP shares for a pro-rata share of the reserves.
+func (h *LiquidityHandler) RemoveLiquidity(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid pool ID")
+		return
+	}
+
+	var req models.RemoveLiquidityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		web.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	receipt, err := h.svc.RemoveLiquidity(r.Context(), userID, id, req)
+	if err != nil {
+		writeLiquidityError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, receipt)
+}
+
+// Quote prices a swap of ?amount_in= of ?token_in= without executing it.
+// ?slippage_bps= overrides the tolerance used for minimum_received.
+func (h *LiquidityHandler) Quote(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid pool ID")
+		return
+	}
+	q := r.URL.Query()
+	var slippageBps int
+	if v := q.Get("slippage_bps"); v != "" {
+		if slippageBps, err = strconv.Atoi(v); err != nil || slippageBps <= 0 {
+			web.Error(w, http.StatusBadRequest, "Invalid slippage_bps")
+			return
+		}
+	}
+
+	quote, err := h.svc.Quote(r.Context(), id, q.Get("token_in"), q.Get("amount_in"), slippageBps)
+	if err != nil {
+		writeLiquidityError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, quote)
+}
+
+// TWAP returns a pool's time-weighted average price over ?window=, a Go
+// duration such as 30m or 24h.
+func (h *LiquidityHandler) TWAP(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid pool ID")
+		return
+	}
+	var window time.Duration
+	if v := r.URL.Query().Get("window"); v != "" {
+		if window, err = time.ParseDuration(v); err != nil || window <= 0 {
+			web.Error(w, http.StatusBadRequest, "Invalid window")
+			return
+		}
+	}
+
+	twap, err := h.svc.TWAP(r.Context(), id, window)
+	if err != nil {
+		writeLiquidityError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, twap)
+}
+
+// PoolHistory returns a pool's reserves, TVL, volume and fees over time.
+// ?interval= is 1h (default) or 1d; ?from= and ?to= accept RFC 3339
+// timestamps or dates and default to the interval's usual window.
+func (h *LiquidityHandler) PoolHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid pool ID")
+		return
+	}
+	q := r.URL.Query()
+	var from, to time.Time
+	if v := q.Get("from"); v != "" {
+		if from, err = parseTimeParam(v); err != nil {
+			web.Error(w, http.StatusBadRequest, "Invalid from parameter")
+			return
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if to, err = parseTimeParam(v); err != nil {
+			web.Error(w, http.StatusBadRequest, "Invalid to parameter")
+			return
+		}
+	}
+	interval := q.Get("interval")
+	if interval == "" {
+		interval = "1h"
+	}
+
+	points, err := h.svc.PoolHistory(r.Context(), id, interval, from, to)
+	if err != nil {
+		writeLiquidityError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, points)
+}
+
+// PoolDepth returns a depth chart of ?levels= price levels each side of a
+// pool's price, ?step_bps= apart.
+func (h *LiquidityHandler) PoolDepth(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid pool ID")
+		return
+	}
+	q := r.URL.Query()
+	var levels, stepBps int
+	if v := q.Get("levels"); v != "" {
+		if levels, err = strconv.Atoi(v); err != nil || levels <= 0 {
+			web.Error(w, http.StatusBadRequest, "Invalid levels")
+			return
+		}
+	}
+	if v := q.Get("step_bps"); v != "" {
+		if stepBps, err = strconv.Atoi(v); err != nil || stepBps <= 0 {
+			web.Error(w, http.StatusBadRequest, "Invalid step_bps")
+			return
+		}
+	}
+
+	depth, err := h.svc.PoolDepth(r.Context(), id, levels, stepBps)
+	if err != nil {
+		writeLiquidityError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, depth)
+}
+
+// Swap sells one token of a pool for the other.
+func (h *LiquidityHandler) Swap(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid pool ID")
+		return
+	}
+
+	var req models.SwapRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		web.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	receipt, err := h.svc.Swap(r.Context(), userID, id, req)
+	if err != nil {
+		writeLiquidityError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, receipt)
+}
+
+// QuoteRoute finds the best route for selling ?amount_in= of ?token_in=
+// for ?token_out=, through at most ?max_hops= pools.
+func (h *LiquidityHandler) QuoteRoute(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var slippageBps, maxHops int
+	var err error
+	if v := q.Get("slippage_bps"); v != "" {
+		if slippageBps, err = strconv.Atoi(v); err != nil || slippageBps <= 0 {
+			web.Error(w, http.StatusBadRequest, "Invalid slippage_bps")
+			return
+		}
+	}
+	if v := q.Get("max_hops"); v != "" {
+		if maxHops, err = strconv.Atoi(v); err != nil || maxHops <= 0 {
+			web.Error(w, http.StatusBadRequest, "Invalid max_hops")
+			return
+		}
+	}
+
+	quote, err := h.svc.QuoteRoute(r.Context(), q.Get("token_in"), q.Get("token_out"), q.Get("amount_in"), maxHops, slippageBps)
+	if err != nil {
+		writeLiquidityError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, quote)
+}
+
+// SwapRoute sells one token for another along the best route, executing
+// every hop or none.
+func (h *LiquidityHandler) SwapRoute(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req models.RouteSwapRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		web.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	receipt, err := h.svc.SwapRoute(r.Context(), userID, req)
+	if err != nil {
+		writeLiquidityError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, receipt)
+}
+
+// ListPoolSwaps returns a pool's swaps, newest first, paginated with
+// ?limit= and ?offset=.
+func (h *LiquidityHandler) ListPoolSwaps(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid pool ID")
+		return
+	}
+	q := r.URL.Query()
+	limit, offset, ok := pageParams(q.Get("limit"), q.Get("offset"))
+	if !ok {
+		web.Error(w, http.StatusBadRequest, "Invalid pagination parameters")
+		return
+	}
+
+	swaps, err := h.svc.ListPoolSwaps(r.Context(), id, limit, offset)
+	if err != nil {
+		web.Error(w, http.StatusInternalServerError, "Failed to fetch swaps")
+		return
+	}
+	web.Respond(w, http.StatusOK, swaps)
+}
+
+// ListMySwaps returns the caller's swaps across pools, newest first.
+func (h *LiquidityHandler) ListMySwaps(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+	q := r.URL.Query()
+	limit, offset, ok := pageParams(q.Get("limit"), q.Get("offset"))
+	if !ok {
+		web.Error(w, http.StatusBadRequest, "Invalid pagination parameters")
+		return
+	}
+
+	swaps, err := h.svc.ListUserSwaps(r.Context(), userID, limit, offset)
+	if err != nil {
+		web.Error(w, http.StatusInternalServerError, "Failed to fetch swaps")
+		return
+	}
+	web.Respond(w, http.StatusOK, swaps)
 }
 
-func (h *LiquidityHandler) GetLiquidity(w http.ResponseWriter, r *http.Request) {
-	web.Respond(w, http.StatusOK, map[string]any{"liquidity": 0})
+// writeLiquidityError maps liquidity service errors to HTTP responses.
+func writeLiquidityError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrPoolNotFound):
+		web.Error(w, http.StatusNotFound, "Pool not found")
+	case errors.Is(err, services.ErrFarmNotFound):
+		web.Error(w, http.StatusNotFound, "Farm not found")
+	case errors.Is(err, services.ErrPositionNotFound), errors.Is(err, services.ErrNoRoute),
+		errors.Is(err, services.ErrFarmStakeNotFound), errors.Is(err, services.ErrNoPrice):
+		web.Error(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrInvalidLiquidityAmount), errors.Is(err, services.ErrInsufficientShares),
+		errors.Is(err, services.ErrInvalidSwap), errors.Is(err, services.ErrInvalidFarm),
+		errors.Is(err, services.ErrInvalidPool), errors.Is(err, services.ErrInvalidRange),
+		errors.Is(err, services.ErrInvalidHistoryRange), errors.Is(err, services.ErrInvalidDepth):
+		web.Error(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrPoolInactive), errors.Is(err, services.ErrSlippageExceeded),
+		errors.Is(err, services.ErrInsufficientLiquidity), errors.Is(err, services.ErrDeadlineExceeded),
+		errors.Is(err, services.ErrFarmEnded), errors.Is(err, services.ErrSharesFarmed),
+		errors.Is(err, services.ErrPoolExists), errors.Is(err, services.ErrInvalidPoolStatus):
+		web.Error(w, http.StatusConflict, err.Error())
+	default:
+		web.Error(w, http.StatusInternalServerError, err.Error())
+	}
 }
@@ -0,0 +1,157 @@
+// internal/handlers/pool_admin.go
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jd7008911/aogeri-api/internal/auth"
+	"github.com/jd7008911/aogeri-api/internal/models"
+	"github.com/jd7008911/aogeri-api/pkg/web"
+)
+
+// auditContext identifies the admin making a change, for the audit log.
+func auditContext(r *http.Request) (models.AuditContext, bool) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		return models.AuditContext{}, false
+	}
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	return models.AuditContext{UserID: userID, IPAddress: ip, UserAgent: r.UserAgent()}, true
+}
+
+// CreatePool lets an admin open a pool for a token pair.
+func (h *LiquidityHandler) CreatePool(w http.ResponseWriter, r *http.Request) {
+	audit, ok := auditContext(r)
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req models.CreatePoolRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		web.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	pool, err := h.svc.CreatePool(r.Context(), audit, req)
+	if err != nil {
+		writeLiquidityError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusCreated, pool)
+}
+
+// SetPoolFee moves a pool to another fee tier.
+func (h *LiquidityHandler) SetPoolFee(w http.ResponseWriter, r *http.Request) {
+	audit, ok := auditContext(r)
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid pool ID")
+		return
+	}
+
+	var req models.SetPoolFeeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		web.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	pool, err := h.svc.SetPoolFee(r.Context(), audit, id, req.FeeBps)
+	if err != nil {
+		writeLiquidityError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, pool)
+}
+
+// PausePool stops deposits and swaps on a pool.
+func (h *LiquidityHandler) PausePool(w http.ResponseWriter, r *http.Request) {
+	h.changePoolStatus(w, r, h.svc.PausePool)
+}
+
+// UnpausePool reopens a paused pool.
+func (h *LiquidityHandler) UnpausePool(w http.ResponseWriter, r *http.Request) {
+	h.changePoolStatus(w, r, h.svc.UnpausePool)
+}
+
+// DeprecatePool puts a pool into withdraw-only mode for good.
+func (h *LiquidityHandler) DeprecatePool(w http.ResponseWriter, r *http.Request) {
+	h.changePoolStatus(w, r, h.svc.DeprecatePool)
+}
+
+// changePoolStatus runs a status change with the optional reason from the
+// request body.
+func (h *LiquidityHandler) changePoolStatus(w http.ResponseWriter, r *http.Request,
+	change func(ctx context.Context, audit models.AuditContext, poolID uuid.UUID, reason string) (*models.Pool, error)) {
+	audit, ok := auditContext(r)
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid pool ID")
+		return
+	}
+
+	var req models.PoolStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		web.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		web.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	pool, err := change(r.Context(), audit, id, req.Reason)
+	if err != nil {
+		writeLiquidityError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, pool)
+}
+
+// ListPoolAudit returns the admin changes made to a pool, newest first.
+func (h *LiquidityHandler) ListPoolAudit(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid pool ID")
+		return
+	}
+	q := r.URL.Query()
+	limit, offset, ok := pageParams(q.Get("limit"), q.Get("offset"))
+	if !ok {
+		web.Error(w, http.StatusBadRequest, "Invalid pagination parameters")
+		return
+	}
+
+	entries, err := h.svc.ListPoolAudit(r.Context(), id, limit, offset)
+	if err != nil {
+		writeLiquidityError(w, err)
+		return
+	}
+	web.Respond(w, http.StatusOK, entries)
+}
@@ -2,14 +2,15 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
-	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jd7008911/aogeri-api/internal/auth"
 	"github.com/jd7008911/aogeri-api/internal/db"
 	"github.com/jd7008911/aogeri-api/internal/models"
@@ -39,10 +40,15 @@ func (h *StakeHandler) RegisterRoutes(r chi.Router) {
 
 		r.Get("/", h.GetUserStakes)
 		r.Post("/", h.CreateStake)
+		r.Get("/stats", h.GetStakingStats)
 		r.Get("/{id}", h.GetStake)
 		r.Post("/{id}/unstake", h.Unstake)
+		r.Post("/{id}/release", h.Release)
+		r.Post("/{id}/withdraw", h.PartialWithdraw)
+		r.Post("/{id}/top-up", h.TopUp)
+		r.Get("/{id}/history", h.GetStakeHistory)
+		r.Get("/{id}/rewards", h.GetStakeRewards)
 		r.Post("/{id}/claim", h.ClaimRewards)
-		r.Get("/stats", h.GetStakingStats)
 	})
 }
 
@@ -66,6 +72,10 @@ func (h *StakeHandler) CreateStake(w http.ResponseWriter, r *http.Request) {
 
 	stake, err := h.stakeService.CreateStake(r.Context(), userID, req)
 	if err != nil {
+		if errors.Is(err, services.ErrRewardsBudgetExceeded) {
+			web.Error(w, http.StatusConflict, err.Error())
+			return
+		}
 		web.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -107,9 +117,9 @@ func (h *StakeHandler) GetStake(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Verify ownership
+	// Another user's stake is reported as missing so ownership is not leaked.
 	if stake.UserID != userID {
-		web.Error(w, http.StatusForbidden, "Access denied")
+		web.Error(w, http.StatusNotFound, "Stake not found")
 		return
 	}
 
@@ -130,19 +140,17 @@ func (h *StakeHandler) Unstake(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.stakeService.Unstake(r.Context(), stakeID, userID)
+	result, err := h.stakeService.Unstake(r.Context(), stakeID, userID)
 	if err != nil {
-		web.Error(w, http.StatusInternalServerError, err.Error())
+		writeStakeError(w, err)
 		return
 	}
 
-	web.Respond(w, http.StatusOK, map[string]string{
-		"message": "Stake unstaked successfully",
-	})
+	web.Respond(w, http.StatusOK, result)
 }
 
-// ClaimRewards placeholder
-func (h *StakeHandler) ClaimRewards(w http.ResponseWriter, r *http.Request) {
+// Release pays out a stake whose unbonding period has elapsed.
+func (h *StakeHandler) Release(w http.ResponseWriter, r *http.Request) {
 	userID, ok := auth.GetUserIDFromContext(r.Context())
 	if !ok {
 		web.Error(w, http.StatusUnauthorized, "User not authenticated")
@@ -156,61 +164,185 @@ func (h *StakeHandler) ClaimRewards(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Verify stake exists and ownership
-	stake, err := h.stakeService.GetStakeByID(r.Context(), stakeID)
+	result, err := h.stakeService.Release(r.Context(), stakeID, userID)
 	if err != nil {
-		web.Error(w, http.StatusNotFound, "Stake not found")
+		writeStakeError(w, err)
 		return
 	}
-	if stake.UserID != userID {
-		web.Error(w, http.StatusForbidden, "Access denied")
+
+	web.Respond(w, http.StatusOK, result)
+}
+
+// PartialWithdraw splits part of a stake into a new unbonding position.
+func (h *StakeHandler) PartialWithdraw(w http.ResponseWriter, r *http.Request) {
+	h.adjustStake(w, r, h.stakeService.PartialWithdraw)
+}
+
+// TopUp adds principal to an active stake.
+func (h *StakeHandler) TopUp(w http.ResponseWriter, r *http.Request) {
+	h.adjustStake(w, r, h.stakeService.TopUp)
+}
+
+func (h *StakeHandler) adjustStake(w http.ResponseWriter, r *http.Request, adjust func(ctx context.Context, stakeID, userID uuid.UUID, amount string) (*models.StakeAdjustment, error)) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
-	// Calculate rewards
-	rewardsStr, err := h.stakeService.CalculateRewards(r.Context(), stakeID)
+	stakeID, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid stake ID")
+		return
+	}
+
+	var req models.StakeAmountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
 		web.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// If zero, nothing to claim
-	if rewardsStr == "0" {
-		web.Respond(w, http.StatusOK, map[string]any{"claimed": "0"})
+	result, err := adjust(r.Context(), stakeID, userID, req.Amount)
+	if err != nil {
+		writeStakeError(w, err)
+		return
+	}
+
+	web.Respond(w, http.StatusOK, result)
+}
+
+func (h *StakeHandler) GetStakeHistory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
-	// Convert stakeID -> pgtype.UUID
-	var idPg pgtype.UUID
-	copy(idPg.Bytes[:], stakeID[:])
-	idPg.Valid = true
+	stakeID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid stake ID")
+		return
+	}
 
-	// Convert rewards string -> pgtype.Numeric
-	var rn pgtype.Numeric
-	if err := rn.Scan(rewardsStr); err != nil {
-		web.Error(w, http.StatusInternalServerError, "invalid rewards value")
+	history, err := h.stakeService.GetStakeHistory(r.Context(), stakeID, userID)
+	if err != nil {
+		writeStakeError(w, err)
 		return
 	}
 
-	// Update DB (increment rewards_claimed)
-	if err := h.queries.UpdateStakeRewards(r.Context(), db.UpdateStakeRewardsParams{
-		ID:             idPg,
-		RewardsClaimed: rn,
-	}); err != nil {
+	web.Respond(w, http.StatusOK, history)
+}
+
+// GetStakeRewards returns the reward history of a stake. from and to accept
+// RFC 3339 timestamps or dates and default to the last 30 days; interval is
+// day, week or month.
+func (h *StakeHandler) GetStakeRewards(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	stakeID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid stake ID")
+		return
+	}
+
+	q := r.URL.Query()
+	to := time.Now()
+	if v := q.Get("to"); v != "" {
+		if to, err = parseTimeParam(v); err != nil {
+			web.Error(w, http.StatusBadRequest, "Invalid to parameter")
+			return
+		}
+	}
+	from := to.AddDate(0, 0, -30)
+	if v := q.Get("from"); v != "" {
+		if from, err = parseTimeParam(v); err != nil {
+			web.Error(w, http.StatusBadRequest, "Invalid from parameter")
+			return
+		}
+	}
+	interval := q.Get("interval")
+	if interval == "" {
+		interval = "day"
+	}
+
+	points, err := h.stakeService.GetStakeRewards(r.Context(), stakeID, userID, from, to, interval)
+	if err != nil {
+		writeStakeError(w, err)
+		return
+	}
+
+	web.Respond(w, http.StatusOK, points)
+}
+
+// parseTimeParam accepts an RFC 3339 timestamp or a YYYY-MM-DD date.
+func parseTimeParam(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
+
+// writeStakeError maps staking service errors to HTTP responses.
+func writeStakeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrStakeNotFound):
+		web.Error(w, http.StatusNotFound, "Stake not found")
+	case errors.Is(err, services.ErrInvalidStakeAmount), errors.Is(err, services.ErrInvalidRewardRange):
+		web.Error(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrStakeInvalidState), errors.Is(err, services.ErrEarlyUnstakeForbidden),
+		errors.Is(err, services.ErrRewardsBudgetExceeded):
+		web.Error(w, http.StatusConflict, err.Error())
+	default:
 		web.Error(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// ClaimRewards settles the rewards accrued on a stake and pays them out.
+func (h *StakeHandler) ClaimRewards(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
-	// Return the claimed amount as number string
-	if _, err := strconv.ParseFloat(rewardsStr, 64); err != nil {
-		web.Respond(w, http.StatusOK, map[string]any{"claimed": rewardsStr})
+	stakeIDStr := chi.URLParam(r, "id")
+	stakeID, err := uuid.Parse(stakeIDStr)
+	if err != nil {
+		web.Error(w, http.StatusBadRequest, "Invalid stake ID")
 		return
 	}
 
-	web.Respond(w, http.StatusOK, map[string]any{"claimed": rewardsStr})
+	claimed, err := h.stakeService.ClaimRewards(r.Context(), stakeID, userID)
+	if err != nil {
+		writeStakeError(w, err)
+		return
+	}
+
+	web.Respond(w, http.StatusOK, map[string]any{"claimed": claimed})
 }
 
-// GetStakingStats placeholder
+// GetStakingStats returns the caller's staking statistics and the
+// protocol-wide totals.
 func (h *StakeHandler) GetStakingStats(w http.ResponseWriter, r *http.Request) {
-	web.Respond(w, http.StatusOK, map[string]any{"total_staked": "0"})
+	userID, ok := auth.GetUserIDFromContext(r.Context())
+	if !ok {
+		web.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	stats, err := h.stakeService.GetStakingStats(r.Context(), userID)
+	if err != nil {
+		web.Error(w, http.StatusInternalServerError, "Failed to fetch staking stats")
+		return
+	}
+
+	web.Respond(w, http.StatusOK, stats)
 }
@@ -2,6 +2,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -31,6 +32,87 @@ type Stake struct {
 	Status         string     `json:"status"`
 	AutoCompound   bool       `json:"auto_compound"`
 	RewardsClaimed string     `json:"rewards_claimed"`
+	UnbondingUntil *time.Time `json:"unbonding_until,omitempty"`
+	WithdrawnAt    *time.Time `json:"withdrawn_at,omitempty"`
+	PenaltyAmount  string     `json:"penalty_amount,omitempty"`
+}
+
+// UnstakeResult describes the outcome of an unstake or principal release.
+type UnstakeResult struct {
+	StakeID        uuid.UUID  `json:"stake_id"`
+	Status         string     `json:"status"`
+	Principal      string     `json:"principal"`
+	Penalty        string     `json:"penalty"`
+	Payout         string     `json:"payout"`
+	UnbondingUntil *time.Time `json:"unbonding_until,omitempty"`
+}
+
+// StakeAdjustment describes a stake after a partial withdrawal or top-up.
+type StakeAdjustment struct {
+	StakeID        uuid.UUID  `json:"stake_id"`
+	Amount         string     `json:"amount"`
+	RewardsSettled string     `json:"rewards_settled"`
+	SplitStakeID   *uuid.UUID `json:"split_stake_id,omitempty"`
+	Penalty        string     `json:"penalty,omitempty"`
+	StartDate      *time.Time `json:"start_date,omitempty"`
+	EndDate        *time.Time `json:"end_date,omitempty"`
+}
+
+type StakeHistoryEntry struct {
+	ID             uuid.UUID  `json:"id"`
+	EventType      string     `json:"event_type"`
+	Amount         string     `json:"amount"`
+	RewardsSettled string     `json:"rewards_settled"`
+	BalanceAfter   string     `json:"balance_after"`
+	RelatedStakeID *uuid.UUID `json:"related_stake_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// RewardPoint is one bucket of a stake's reward history.
+type RewardPoint struct {
+	Time              time.Time `json:"time"`
+	Principal         string    `json:"principal"`
+	AccruedRewards    string    `json:"accrued_rewards"`
+	CumulativeRewards string    `json:"cumulative_rewards"`
+	RewardsClaimed    string    `json:"rewards_claimed"`
+}
+
+// StakingStats reports staking totals for the caller and the whole protocol.
+type StakingStats struct {
+	User     StakingSummary `json:"user"`
+	Protocol StakingSummary `json:"protocol"`
+}
+
+// StakingSummary aggregates active stakes. Values are in the quote currency
+// of asset market prices.
+type StakingSummary struct {
+	Tokens              []TokenStakeStats `json:"tokens"`
+	TotalValue          string            `json:"total_value"`
+	WeightedAPY         float64           `json:"weighted_apy"`
+	ActiveStakers       int64             `json:"active_stakers"`
+	Maturing7Days       MaturityBucket    `json:"maturing_7_days"`
+	Maturing30Days      MaturityBucket    `json:"maturing_30_days"`
+	LifetimeRewardsPaid string            `json:"lifetime_rewards_paid"`
+	LockTiers           []LockTierStats   `json:"lock_tiers"`
+}
+
+type TokenStakeStats struct {
+	Symbol      string  `json:"symbol"`
+	TotalStaked string  `json:"total_staked"`
+	TotalValue  string  `json:"total_value"`
+	WeightedAPY float64 `json:"weighted_apy"`
+	StakeCount  int64   `json:"stake_count"`
+}
+
+type MaturityBucket struct {
+	Count int64  `json:"count"`
+	Value string `json:"value"`
+}
+
+type LockTierStats struct {
+	Tier       string `json:"tier"`
+	StakeCount int64  `json:"stake_count"`
+	TotalValue string `json:"total_value"`
 }
 
 type Proposal struct {
@@ -47,6 +129,95 @@ type Proposal struct {
 	ForVotes     string     `json:"for_votes"`
 	AgainstVotes string     `json:"against_votes"`
 	AbstainVotes string     `json:"abstain_votes"`
+
+	EligiblePower string          `json:"eligible_power"`
+	SnapshotAt    *time.Time      `json:"snapshot_at,omitempty"`
+	Result        *ProposalResult `json:"result,omitempty"`
+
+	Payload        *ProposalPayload `json:"payload,omitempty"`
+	ExecutableAt   *time.Time       `json:"executable_at,omitempty"`
+	ExecutedAt     *time.Time       `json:"executed_at,omitempty"`
+	ExecutionError string           `json:"execution_error,omitempty"`
+	CancelledAt    *time.Time       `json:"cancelled_at,omitempty"`
+
+	DiscussionLocked bool `json:"discussion_locked"`
+
+	VotingMethod string   `json:"voting_method"`
+	Options      []string `json:"options,omitempty"`
+	OptionVotes  []string `json:"option_votes,omitempty"`
+}
+
+// ProposalPayload is the change a proposal applies once it has passed and
+// its timelock has elapsed. Params holds the action's parameters, one of
+// the *Params types below.
+type ProposalPayload struct {
+	Action string          `json:"action"`
+	Params json.RawMessage `json:"params"`
+}
+
+// SetProductAPYParams changes the APY of a staking product.
+type SetProductAPYParams struct {
+	ProductID uuid.UUID `json:"product_id"`
+	APY       float64   `json:"apy"`
+}
+
+// AddTokenParams lists a new token, or reactivates and updates an existing
+// one with the same symbol.
+type AddTokenParams struct {
+	Symbol          string `json:"symbol"`
+	Name            string `json:"name"`
+	ContractAddress string `json:"contract_address,omitempty"`
+	Decimals        int    `json:"decimals"`
+}
+
+// SetTokenActiveParams activates or deactivates a listed token.
+type SetTokenActiveParams struct {
+	Symbol string `json:"symbol"`
+	Active bool   `json:"active"`
+}
+
+// SetSecurityThresholdParams overrides a security threshold.
+type SetSecurityThresholdParams struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+}
+
+// SetPoolFeeParams moves a liquidity pool to another fee tier.
+type SetPoolFeeParams struct {
+	PoolID uuid.UUID `json:"pool_id"`
+	FeeBps int       `json:"fee_bps"`
+}
+
+// ProposalResult summarises a finalized proposal. Turnout and ForShare are
+// percentages.
+type ProposalResult struct {
+	Outcome       string    `json:"outcome"`
+	QuorumMet     bool      `json:"quorum_met"`
+	ThresholdMet  bool      `json:"threshold_met"`
+	Turnout       float64   `json:"turnout"`
+	ForShare      float64   `json:"for_share"`
+	EligiblePower string    `json:"eligible_power"`
+	TotalVotes    string    `json:"total_votes"`
+	ForVotes      string    `json:"for_votes"`
+	AgainstVotes  string    `json:"against_votes"`
+	AbstainVotes  string    `json:"abstain_votes"`
+	FinalizedAt   time.Time `json:"finalized_at"`
+
+	// VotingMethod is the tally method used. For ranked_choice proposals
+	// ForShare is the winner's share of the final round.
+	VotingMethod  string        `json:"voting_method,omitempty"`
+	WinningOption *int          `json:"winning_option,omitempty"`
+	Winner        string        `json:"winner,omitempty"`
+	Rounds        []RunoffRound `json:"rounds,omitempty"`
+}
+
+// RunoffRound is one instant-runoff round: the votes each option held and
+// the option eliminated at the end of it, if any. Exhausted is the power
+// of ballots with no remaining preference.
+type RunoffRound struct {
+	Votes      []string `json:"votes"`
+	Exhausted  string   `json:"exhausted"`
+	Eliminated *int     `json:"eliminated,omitempty"`
 }
 
 type Asset struct {
@@ -67,6 +238,24 @@ type DashboardStats struct {
 	TotalRewards        string  `json:"total_rewards"`
 	SecurityScore       float64 `json:"security_score"`
 	GovernanceProposals int32   `json:"governance_proposals"`
+
+	RewardPools []RewardPoolStats `json:"reward_pools"`
+}
+
+// RewardPoolStats reports the budget and runway of a staking rewards pool.
+// RunwayDays is how long the remaining budget covers the current daily
+// reward liability of active stakes; it is omitted when nothing accrues.
+type RewardPoolStats struct {
+	TokenSymbol    string   `json:"token_symbol"`
+	Schedule       string   `json:"schedule"`
+	Budget         string   `json:"budget"`
+	Distributed    string   `json:"distributed"`
+	Remaining      string   `json:"remaining"`
+	DailyEmission  string   `json:"daily_emission"`
+	TotalStaked    string   `json:"total_staked"`
+	DailyLiability string   `json:"daily_liability"`
+	SustainableAPY float64  `json:"sustainable_apy"`
+	RunwayDays     *float64 `json:"runway_days,omitempty"`
 }
 
 // Request/Response types
@@ -96,8 +285,666 @@ type StakeRequest struct {
 	DurationDays int    `json:"duration_days" validate:"min=30"`
 }
 
+type StakeAmountRequest struct {
+	Amount string `json:"amount" validate:"required,numeric"`
+}
+
+// CreateProposalRequest submits a governance proposal. VotingStart defaults
+// to now; Quorum and Threshold default to the proposal type's values.
+type CreateProposalRequest struct {
+	Title        string     `json:"title" validate:"required,max=255"`
+	Description  string     `json:"description" validate:"required,max=10000"`
+	ProposalType string     `json:"proposal_type" validate:"required,max=50"`
+	VotingStart  *time.Time `json:"voting_start,omitempty"`
+	VotingEnd    time.Time  `json:"voting_end" validate:"required"`
+	Quorum       *float64   `json:"quorum,omitempty"`
+	Threshold    *float64   `json:"threshold,omitempty"`
+
+	Payload *ProposalPayload `json:"payload,omitempty"`
+
+	// Options are the choices of a ranked_choice proposal.
+	Options []string `json:"options,omitempty" validate:"omitempty,max=10,dive,required,max=100"`
+}
+
+// VoteRequest casts or changes a vote. Vote power is derived server-side
+// from the voter's active stakes. Ranked-choice proposals take Ranking, the
+// option indices in order of preference, instead of VoteChoice.
 type VoteRequest struct {
-	ProposalID uuid.UUID `json:"proposal_id" validate:"required"`
-	VoteChoice string    `json:"vote_choice" validate:"required,oneof=for against abstain"`
-	VotePower  string    `json:"vote_power" validate:"required,numeric"`
+	VoteChoice string `json:"vote_choice,omitempty" validate:"omitempty,oneof=for against abstain"`
+	Ranking    []int  `json:"ranking,omitempty" validate:"omitempty,max=10"`
+}
+
+// Vote is a cast vote. VotePower includes power delegated to the voter by
+// users who have not voted themselves.
+type Vote struct {
+	ProposalID     uuid.UUID `json:"proposal_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	VoteChoice     string    `json:"vote_choice"`
+	Ranking        []int     `json:"ranking,omitempty"`
+	VotePower      string    `json:"vote_power"`
+	DelegatedPower string    `json:"delegated_power"`
+	VotedAt        time.Time `json:"voted_at"`
+}
+
+// VotePower is a user's snapshotted vote power on a proposal. SnapshotAt is
+// nil until the proposal becomes active. DelegatedPower is the power
+// delegated to the user by voters who have not voted directly.
+type VotePower struct {
+	ProposalID     uuid.UUID  `json:"proposal_id"`
+	StakedAmount   string     `json:"staked_amount"`
+	VotePower      string     `json:"vote_power"`
+	DelegatedPower string     `json:"delegated_power"`
+	DelegatedTo    *uuid.UUID `json:"delegated_to,omitempty"`
+	SnapshotAt     *time.Time `json:"snapshot_at,omitempty"`
+}
+
+// DelegateRequest delegates the caller's vote power. An empty TokenSymbol
+// delegates for every governance token.
+type DelegateRequest struct {
+	DelegateID  uuid.UUID `json:"delegate_id" validate:"required"`
+	TokenSymbol string    `json:"token_symbol,omitempty" validate:"omitempty,alphanum,max=10"`
+}
+
+// UndelegateRequest removes the caller's delegation for TokenSymbol, or the
+// global delegation when it is empty.
+type UndelegateRequest struct {
+	TokenSymbol string `json:"token_symbol,omitempty" validate:"omitempty,alphanum,max=10"`
+}
+
+// Delegation hands DelegatorID's vote power to DelegateID. TokenSymbol is
+// empty for a global delegation.
+type Delegation struct {
+	DelegatorID uuid.UUID `json:"delegator_id"`
+	DelegateID  uuid.UUID `json:"delegate_id"`
+	TokenSymbol string    `json:"token_symbol,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Delegations lists the delegations a user has made and received.
+type Delegations struct {
+	Delegating []Delegation `json:"delegating"`
+	Received   []Delegation `json:"received"`
+}
+
+// Delegate ranks a user by the staked power delegated to them, directly or
+// through a chain of delegations.
+type Delegate struct {
+	DelegateID     uuid.UUID `json:"delegate_id"`
+	DelegatorCount int64     `json:"delegator_count"`
+	ReceivedPower  string    `json:"received_power"`
+}
+
+// CommentRequest posts a comment on a proposal, or a reply when ParentID
+// is set.
+type CommentRequest struct {
+	Body     string     `json:"body" validate:"required,max=5000"`
+	ParentID *uuid.UUID `json:"parent_id,omitempty"`
+}
+
+// EditCommentRequest replaces the body of the caller's comment.
+type EditCommentRequest struct {
+	Body string `json:"body" validate:"required,max=5000"`
+}
+
+// ReactionRequest adds a reaction to a comment.
+type ReactionRequest struct {
+	Reaction string `json:"reaction" validate:"required,max=20"`
+}
+
+// Comment is a proposal comment with its replies. Hidden comments keep
+// their place in the thread but their body is withheld.
+type Comment struct {
+	ID          uuid.UUID        `json:"id"`
+	ProposalID  uuid.UUID        `json:"proposal_id"`
+	AuthorID    uuid.UUID        `json:"author_id"`
+	ParentID    *uuid.UUID       `json:"parent_id,omitempty"`
+	Body        string           `json:"body"`
+	Hidden      bool             `json:"hidden"`
+	EditedAt    *time.Time       `json:"edited_at,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	Reactions   map[string]int64 `json:"reactions"`
+	MyReactions []string         `json:"my_reactions"`
+	Replies     []Comment        `json:"replies"`
+}
+
+// Discussion is the comment thread of a proposal.
+type Discussion struct {
+	ProposalID uuid.UUID `json:"proposal_id"`
+	Locked     bool      `json:"locked"`
+	Comments   []Comment `json:"comments"`
+}
+
+// CommentEdit is an earlier version of an edited comment.
+type CommentEdit struct {
+	PreviousBody string    `json:"previous_body"`
+	EditedAt     time.Time `json:"edited_at"`
+}
+
+// ProposalEvent is one entry of a proposal's timeline.
+type ProposalEvent struct {
+	Type      string          `json:"type"`
+	ActorID   *uuid.UUID      `json:"actor_id,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// ProposalResults summarises a proposal's tallies. BallotRoot is the Merkle
+// root of the ballot set, fixed when the proposal is finalized.
+type ProposalResults struct {
+	ProposalID    uuid.UUID       `json:"proposal_id"`
+	Status        string          `json:"status"`
+	VotingMethod  string          `json:"voting_method"`
+	Options       []string        `json:"options,omitempty"`
+	Quorum        float64         `json:"quorum"`
+	Threshold     float64         `json:"threshold"`
+	EligiblePower string          `json:"eligible_power"`
+	Tally         ResultsTally    `json:"tally"`
+	Result        *ProposalResult `json:"result,omitempty"`
+	BallotRoot    string          `json:"ballot_root,omitempty"`
+	BallotCount   int             `json:"ballot_count,omitempty"`
+}
+
+// ResultsTally is the stored tally of a proposal.
+type ResultsTally struct {
+	ForVotes     string   `json:"for_votes"`
+	AgainstVotes string   `json:"against_votes"`
+	AbstainVotes string   `json:"abstain_votes"`
+	TotalVotes   string   `json:"total_votes"`
+	OptionVotes  []string `json:"option_votes,omitempty"`
+}
+
+// Ballot is one snapshot holder of a proposal: their snapshotted power,
+// the delegate it was frozen to and, if they voted directly, their vote.
+type Ballot struct {
+	Voter         uuid.UUID  `json:"voter"`
+	SnapshotPower string     `json:"snapshot_power"`
+	DelegateID    *uuid.UUID `json:"delegate_id,omitempty"`
+	VoteChoice    string     `json:"vote_choice,omitempty"`
+	Ranking       []int      `json:"ranking,omitempty"`
+	VotedAt       *time.Time `json:"voted_at,omitempty"`
+}
+
+// BallotExport is the full ballot set of a proposal with its results, from
+// which the tally and ballot root can be recomputed.
+type BallotExport struct {
+	ProposalResults
+	Ballots []Ballot `json:"ballots"`
+}
+
+// ProposalTurnout is how a proposal's turnout built up over its voting
+// window.
+type ProposalTurnout struct {
+	ProposalID    uuid.UUID      `json:"proposal_id"`
+	Interval      string         `json:"interval"`
+	EligiblePower string         `json:"eligible_power"`
+	Points        []TurnoutPoint `json:"points"`
+}
+
+// TurnoutPoint is the cumulative turnout at the end of a time bucket.
+// Turnout is PowerCast as a percentage of the eligible power.
+type TurnoutPoint struct {
+	Time      time.Time `json:"time"`
+	Voters    int64     `json:"voters"`
+	PowerCast string    `json:"power_cast"`
+	Turnout   float64   `json:"turnout"`
+}
+
+// ParticipationReport summarises turnout over finalized proposals.
+type ParticipationReport struct {
+	Proposals      int64                   `json:"proposals"`
+	AverageTurnout float64                 `json:"average_turnout"`
+	UniqueVoters   int64                   `json:"unique_voters"`
+	Recent         []ProposalParticipation `json:"recent"`
+}
+
+// ProposalParticipation is the turnout of one finalized proposal: power
+// cast against eligible power, and direct voters against holders with
+// power in its snapshot.
+type ProposalParticipation struct {
+	ProposalID    uuid.UUID `json:"proposal_id"`
+	Title         string    `json:"title"`
+	Status        string    `json:"status"`
+	VotingMethod  string    `json:"voting_method"`
+	EligiblePower string    `json:"eligible_power"`
+	TotalVotes    string    `json:"total_votes"`
+	Turnout       float64   `json:"turnout"`
+	Voters        int64     `json:"voters"`
+	Holders       int64     `json:"holders"`
+	FinalizedAt   time.Time `json:"finalized_at"`
+}
+
+// TopVoter ranks a user by the votes they cast. ParticipationRate is the
+// percentage of proposals they held power in that they voted on.
+type TopVoter struct {
+	UserID            uuid.UUID  `json:"user_id"`
+	WalletAddress     string     `json:"wallet_address,omitempty"`
+	VotesCast         int64      `json:"votes_cast"`
+	EligibleProposals int64      `json:"eligible_proposals"`
+	ParticipationRate float64    `json:"participation_rate"`
+	PowerCast         string     `json:"power_cast"`
+	LastVotedAt       *time.Time `json:"last_voted_at,omitempty"`
+}
+
+// VoteHistoryEntry is one of a user's votes with the proposal's outcome.
+// WithMajority is set once the proposal is finalized and the vote took a
+// side: for or against, or a first preference on a ranked ballot.
+type VoteHistoryEntry struct {
+	ProposalID   uuid.UUID `json:"proposal_id"`
+	Title        string    `json:"title"`
+	Status       string    `json:"status"`
+	VotingMethod string    `json:"voting_method"`
+	VoteChoice   string    `json:"vote_choice"`
+	Ranking      []int     `json:"ranking,omitempty"`
+	VotePower    string    `json:"vote_power"`
+	VotedAt      time.Time `json:"voted_at"`
+	Outcome      string    `json:"outcome,omitempty"`
+	WithMajority *bool     `json:"with_majority,omitempty"`
+}
+
+// VoteReceipt is returned after voting, with the proposal's updated tallies.
+type VoteReceipt struct {
+	Vote     Vote     `json:"vote"`
+	Proposal Proposal `json:"proposal"`
+}
+
+// Pool is a liquidity pool for a pair of tokens. TVL is the value of the
+// liquidity it holds and APR the annual return paid to providers, in
+// percent.
+type Pool struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Token0    PoolToken `json:"token0"`
+	Token1    PoolToken `json:"token1"`
+	TVL       string    `json:"tvl"`
+	APR       float64   `json:"apr"`
+	Providers int64     `json:"providers"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// FeeBps is the pool's swap fee tier. Status is active, paused (no
+	// deposits or swaps) or deprecated (withdrawals only, for good).
+	FeeBps int    `json:"fee_bps"`
+	Status string `json:"status"`
+
+	// Reserve0 and Reserve1 are the tokens held, TotalShares the LP shares
+	// issued against them and Price the pool's price of token0 in token1.
+	Reserve0    string  `json:"reserve0"`
+	Reserve1    string  `json:"reserve1"`
+	TotalShares string  `json:"total_shares"`
+	Price       float64 `json:"price"`
+
+	// Type is constant_product or concentrated. A concentrated pool also
+	// reports its tick spacing, the tick holding its price and the
+	// liquidity of the positions whose range covers that price.
+	Type            string `json:"type"`
+	TickSpacing     int    `json:"tick_spacing,omitempty"`
+	Tick            *int   `json:"tick,omitempty"`
+	ActiveLiquidity string `json:"active_liquidity,omitempty"`
+
+	// Fees is the pool's recent fee income, included on the pool detail.
+	Fees *PoolFees `json:"fees,omitempty"`
+}
+
+// PoolTWAP is a pool's time-weighted average price from From to To.
+// Price0 is token0 priced in token1 and Price1 the inverse, each averaged
+// on its own. From is later than the window asked for when the pool has
+// less price history.
+type PoolTWAP struct {
+	PoolID    uuid.UUID `json:"pool_id"`
+	Token0    string    `json:"token0"`
+	Token1    string    `json:"token1"`
+	Window    string    `json:"window"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Price0    float64   `json:"price0"`
+	Price1    float64   `json:"price1"`
+	SpotPrice float64   `json:"spot_price"`
+}
+
+// PoolHistoryPoint is one interval of a pool's history starting at Time.
+// Reserves, TVL, Price and Providers are as of the interval's last
+// snapshot and PriceLow and PriceHigh the range its snapshots saw; Swaps,
+// volume and fees are summed over the interval, volume counted on the
+// input side of each swap.
+type PoolHistoryPoint struct {
+	Time        time.Time `json:"time"`
+	Reserve0    string    `json:"reserve0"`
+	Reserve1    string    `json:"reserve1"`
+	TotalShares string    `json:"total_shares"`
+	Price       float64   `json:"price"`
+	PriceLow    float64   `json:"price_low"`
+	PriceHigh   float64   `json:"price_high"`
+	TVL         string    `json:"tvl"`
+	Providers   int       `json:"providers"`
+	Swaps       int64     `json:"swaps"`
+	Volume0     string    `json:"volume0"`
+	Volume1     string    `json:"volume1"`
+	VolumeValue string    `json:"volume_value"`
+	Fees0       string    `json:"fees0"`
+	Fees1       string    `json:"fees1"`
+	FeesValue   string    `json:"fees_value"`
+}
+
+// PoolDepth is an order book read off a pool's curve. Each ask is the
+// token0 a buyer takes out, and the token1 paid for it, to push the
+// price up to that level; each bid the token0 sold in, and the token1
+// received, to push it down. Amounts are cumulative from the current
+// price and exclude fees.
+type PoolDepth struct {
+	PoolID  uuid.UUID    `json:"pool_id"`
+	Token0  string       `json:"token0"`
+	Token1  string       `json:"token1"`
+	Price   float64      `json:"price"`
+	StepBps int          `json:"step_bps"`
+	Bids    []DepthLevel `json:"bids"`
+	Asks    []DepthLevel `json:"asks"`
+}
+
+// DepthLevel is one price level of a PoolDepth.
+type DepthLevel struct {
+	Price   float64 `json:"price"`
+	Amount0 string  `json:"amount0"`
+	Amount1 string  `json:"amount1"`
+}
+
+// PoolFees is the value of the swap fees a pool earned over the trailing 7
+// and 30 days, annualized against its current TVL.
+type PoolFees struct {
+	Fees7d  string  `json:"fees_7d"`
+	Fees30d string  `json:"fees_30d"`
+	APR7d   float64 `json:"apr_7d"`
+	APR30d  float64 `json:"apr_30d"`
+}
+
+// PoolToken is one side of a pool's pair.
+type PoolToken struct {
+	ID     uuid.UUID `json:"id"`
+	Symbol string    `json:"symbol"`
+}
+
+// CreatePoolRequest opens a pool for a token pair. FeeBps must be one of
+// the configured fee tiers and defaults to the standard swap fee; Name
+// defaults to "TOKEN0/TOKEN1".
+type CreatePoolRequest struct {
+	Token0 string `json:"token0" validate:"required"`
+	Token1 string `json:"token1" validate:"required"`
+	FeeBps int    `json:"fee_bps,omitempty" validate:"omitempty,min=1"`
+	Name   string `json:"name,omitempty" validate:"omitempty,max=100"`
+
+	// Type defaults to constant_product. A concentrated pool needs its
+	// InitialPrice, token0 in token1; TickSpacing defaults to 10.
+	Type         string  `json:"type,omitempty" validate:"omitempty,oneof=constant_product concentrated"`
+	TickSpacing  int     `json:"tick_spacing,omitempty" validate:"omitempty,min=1,max=1000"`
+	InitialPrice float64 `json:"initial_price,omitempty" validate:"omitempty,gt=0"`
+}
+
+// SetPoolFeeRequest moves a pool to another fee tier.
+type SetPoolFeeRequest struct {
+	FeeBps int `json:"fee_bps" validate:"required,min=1"`
+}
+
+// PoolStatusRequest pauses, unpauses or deprecates a pool. Reason is kept
+// in the audit log.
+type PoolStatusRequest struct {
+	Reason string `json:"reason,omitempty" validate:"omitempty,max=500"`
+}
+
+// AuditContext identifies who made an admin change and from where.
+type AuditContext struct {
+	UserID    uuid.UUID
+	IPAddress string
+	UserAgent string
+}
+
+// AuditLog is one recorded admin change to a resource.
+type AuditLog struct {
+	ID        uuid.UUID       `json:"id"`
+	UserID    *uuid.UUID      `json:"user_id,omitempty"`
+	UserEmail string          `json:"user_email,omitempty"`
+	Action    string          `json:"action"`
+	Details   json.RawMessage `json:"details"`
+	IPAddress string          `json:"ip_address,omitempty"`
+	UserAgent string          `json:"user_agent,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// LiquidityPosition is a user's stake in a pool. PoolShare is the
+// percentage of the pool's shares held, and Value that share of its TVL.
+// FeesEarned0 and FeesEarned1 are the swap fees its shares have earned;
+// APREarned annualizes their value against the deposited amounts.
+type LiquidityPosition struct {
+	ID           uuid.UUID `json:"id"`
+	PoolID       uuid.UUID `json:"pool_id"`
+	PoolName     string    `json:"pool_name"`
+	Token0Symbol string    `json:"token0_symbol"`
+	Token1Symbol string    `json:"token1_symbol"`
+	Amount0      string    `json:"amount0"`
+	Amount1      string    `json:"amount1"`
+	Shares       string    `json:"shares"`
+	PoolShare    float64   `json:"pool_share"`
+	Value        string    `json:"value"`
+	FeesEarned0  string    `json:"fees_earned0"`
+	FeesEarned1  string    `json:"fees_earned1"`
+	FeesValue    string    `json:"fees_value"`
+	APREarned    float64   `json:"apr_earned"`
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+
+	// NftID numbers every position uniquely. Positions in concentrated
+	// pools also report their price range.
+	NftID int64          `json:"nft_id"`
+	Range *PositionRange `json:"range,omitempty"`
+}
+
+// PositionRange is the price range of a concentrated-liquidity position.
+// The position earns fees only while the pool's price is inside it;
+// Status is in_range, below_range (the position holds only token0) or
+// above_range (only token1).
+type PositionRange struct {
+	TickLower  int     `json:"tick_lower"`
+	TickUpper  int     `json:"tick_upper"`
+	PriceLower float64 `json:"price_lower"`
+	PriceUpper float64 `json:"price_upper"`
+	Status     string  `json:"status"`
+}
+
+// PositionDetail compares a position with holding its deposited amounts.
+// Current0 and Current1 are the tokens its shares redeem for now, and
+// HodlValue what the deposits would be worth had they been held.
+// ImpermanentLoss is the percentage, zero or negative, lost to the pool's
+// price moving since deposit, fees excluded; VsHodl is Value minus
+// HodlValue, fees included.
+type PositionDetail struct {
+	LiquidityPosition
+	Current0        string  `json:"current0"`
+	Current1        string  `json:"current1"`
+	HodlValue       string  `json:"hodl_value"`
+	ImpermanentLoss float64 `json:"impermanent_loss"`
+	VsHodl          string  `json:"vs_hodl"`
+}
+
+// AddLiquidityRequest deposits a pair of amounts into a pool. Deposits
+// into a funded pool are matched to its ratio and the excess refunded; the
+// deposit fails if fewer than MinShares would be minted. Deposits into a
+// concentrated pool open a new position between TickLower and TickUpper,
+// and one of the amounts may be zero when the range is away from the
+// price.
+type AddLiquidityRequest struct {
+	Amount0   string `json:"amount0" validate:"required,numeric"`
+	Amount1   string `json:"amount1" validate:"required,numeric"`
+	MinShares string `json:"min_shares,omitempty" validate:"omitempty,numeric"`
+	TickLower *int   `json:"tick_lower,omitempty"`
+	TickUpper *int   `json:"tick_upper,omitempty"`
+}
+
+// RemoveLiquidityRequest burns LP shares for their pro-rata share of the
+// reserves, failing if either amount would fall below its minimum. In a
+// concentrated pool it burns liquidity from PositionID and also pays out
+// the position's uncollected fees.
+type RemoveLiquidityRequest struct {
+	Shares     string     `json:"shares" validate:"required,numeric"`
+	MinAmount0 string     `json:"min_amount0,omitempty" validate:"omitempty,numeric"`
+	MinAmount1 string     `json:"min_amount1,omitempty" validate:"omitempty,numeric"`
+	PositionID *uuid.UUID `json:"position_id,omitempty"`
+}
+
+// LiquidityReceipt is returned after a deposit or withdrawal: the amounts
+// that moved, any refunded excess, the shares minted or burned and the
+// resulting position and pool.
+type LiquidityReceipt struct {
+	Amount0  string            `json:"amount0"`
+	Amount1  string            `json:"amount1"`
+	Refund0  string            `json:"refund0,omitempty"`
+	Refund1  string            `json:"refund1,omitempty"`
+	Shares   string            `json:"shares"`
+	Position LiquidityPosition `json:"position"`
+	Pool     Pool              `json:"pool"`
+}
+
+// SwapQuote prices a swap against a pool's current reserves. PriceImpact
+// is how far, in percent, the execution price falls short of the spot
+// price, fee excluded; MinimumReceived applies SlippageBps to AmountOut.
+type SwapQuote struct {
+	PoolID          uuid.UUID `json:"pool_id"`
+	TokenIn         string    `json:"token_in"`
+	TokenOut        string    `json:"token_out"`
+	AmountIn        string    `json:"amount_in"`
+	AmountOut       string    `json:"amount_out"`
+	Fee             string    `json:"fee"`
+	FeeBps          int       `json:"fee_bps"`
+	SpotPrice       float64   `json:"spot_price"`
+	ExecutionPrice  float64   `json:"execution_price"`
+	PriceImpact     float64   `json:"price_impact"`
+	SlippageBps     int       `json:"slippage_bps"`
+	MinimumReceived string    `json:"minimum_received"`
+}
+
+// SwapRequest sells AmountIn of TokenIn into a pool. The swap fails if it
+// would return less than MinAmountOut or runs after Deadline.
+type SwapRequest struct {
+	TokenIn      string     `json:"token_in" validate:"required"`
+	AmountIn     string     `json:"amount_in" validate:"required,numeric"`
+	MinAmountOut string     `json:"min_amount_out" validate:"required,numeric"`
+	Deadline     *time.Time `json:"deadline,omitempty"`
+}
+
+// Swap is an executed swap.
+type Swap struct {
+	ID          uuid.UUID  `json:"id"`
+	PoolID      uuid.UUID  `json:"pool_id"`
+	UserID      *uuid.UUID `json:"user_id,omitempty"`
+	TokenIn     string     `json:"token_in"`
+	TokenOut    string     `json:"token_out"`
+	AmountIn    string     `json:"amount_in"`
+	AmountOut   string     `json:"amount_out"`
+	Fee         string     `json:"fee"`
+	PriceImpact float64    `json:"price_impact"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// SwapReceipt is returned after a swap with the pool's new state.
+type SwapReceipt struct {
+	Swap Swap `json:"swap"`
+	Pool Pool `json:"pool"`
+}
+
+// Farm is a liquidity mining program paying RewardPerDay of RewardToken,
+// between StartsAt and EndsAt, to the LP shares staked in it. APR values
+// a year of rewards against the staked shares' part of the pool's TVL.
+type Farm struct {
+	ID           uuid.UUID `json:"id"`
+	PoolID       uuid.UUID `json:"pool_id"`
+	PoolName     string    `json:"pool_name"`
+	RewardToken  string    `json:"reward_token"`
+	RewardPerDay string    `json:"reward_per_day"`
+	StartsAt     time.Time `json:"starts_at"`
+	EndsAt       time.Time `json:"ends_at"`
+	TotalStaked  string    `json:"total_staked"`
+	RewardsPaid  string    `json:"rewards_paid"`
+	APR          float64   `json:"apr"`
+	IsLive       bool      `json:"is_live"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// CreateFarmRequest opens a farm on a pool. RewardToken defaults to AOG
+// and StartsAt to now.
+type CreateFarmRequest struct {
+	PoolID       uuid.UUID  `json:"pool_id" validate:"required"`
+	RewardToken  string     `json:"reward_token,omitempty"`
+	RewardPerDay string     `json:"reward_per_day" validate:"required,numeric"`
+	StartsAt     *time.Time `json:"starts_at,omitempty"`
+	EndsAt       time.Time  `json:"ends_at" validate:"required"`
+}
+
+// FarmStakeRequest locks Shares of the caller's position in the farm's
+// pool.
+type FarmStakeRequest struct {
+	Shares string `json:"shares" validate:"required,numeric"`
+}
+
+// FarmStake is LP shares a user has locked in a farm. PendingRewards have
+// accrued but not been claimed.
+type FarmStake struct {
+	ID             uuid.UUID `json:"id"`
+	FarmID         uuid.UUID `json:"farm_id"`
+	PositionID     uuid.UUID `json:"position_id"`
+	PoolID         uuid.UUID `json:"pool_id"`
+	PoolName       string    `json:"pool_name,omitempty"`
+	RewardToken    string    `json:"reward_token,omitempty"`
+	Shares         string    `json:"shares"`
+	PendingRewards string    `json:"pending_rewards"`
+	RewardsClaimed string    `json:"rewards_claimed"`
+	Status         string    `json:"status"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// FarmExit is returned when a user leaves a farm: the shares unlocked and
+// the rewards paid out with them.
+type FarmExit struct {
+	Shares  string    `json:"shares"`
+	Claimed string    `json:"claimed"`
+	Stake   FarmStake `json:"stake"`
+}
+
+// RouteHop is one pool of a multi-hop route.
+type RouteHop struct {
+	PoolID      uuid.UUID `json:"pool_id"`
+	PoolName    string    `json:"pool_name"`
+	TokenIn     string    `json:"token_in"`
+	TokenOut    string    `json:"token_out"`
+	AmountIn    string    `json:"amount_in"`
+	AmountOut   string    `json:"amount_out"`
+	Fee         string    `json:"fee"`
+	PriceImpact float64   `json:"price_impact"`
+}
+
+// RouteQuote is the best path found from TokenIn to TokenOut. Each hop's
+// output is the next hop's input; PriceImpact compounds the hops' impacts.
+type RouteQuote struct {
+	TokenIn         string     `json:"token_in"`
+	TokenOut        string     `json:"token_out"`
+	AmountIn        string     `json:"amount_in"`
+	AmountOut       string     `json:"amount_out"`
+	PriceImpact     float64    `json:"price_impact"`
+	SlippageBps     int        `json:"slippage_bps"`
+	MinimumReceived string     `json:"minimum_received"`
+	Hops            []RouteHop `json:"hops"`
+}
+
+// RouteSwapRequest sells AmountIn of TokenIn for TokenOut along the best
+// route of at most MaxHops pools, defaulting to the configured limit.
+type RouteSwapRequest struct {
+	TokenIn      string     `json:"token_in" validate:"required"`
+	TokenOut     string     `json:"token_out" validate:"required"`
+	AmountIn     string     `json:"amount_in" validate:"required,numeric"`
+	MinAmountOut string     `json:"min_amount_out" validate:"required,numeric"`
+	MaxHops      int        `json:"max_hops,omitempty" validate:"omitempty,min=1"`
+	Deadline     *time.Time `json:"deadline,omitempty"`
+}
+
+// RouteReceipt is returned after a routed swap, with one swap per hop.
+type RouteReceipt struct {
+	Route RouteQuote `json:"route"`
+	Swaps []Swap     `json:"swaps"`
 }
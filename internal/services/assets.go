@@ -2,13 +2,18 @@ package services
 
 import (
 	"context"
+	"errors"
 	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jd7008911/aogeri-api/internal/db"
 	"github.com/jd7008911/aogeri-api/internal/models"
 )
 
+var ErrTokenNotFound = errors.New("token not found")
+
 // AssetsService provides asset listing and metrics.
 type AssetsService struct {
 	queries *db.Queries
@@ -57,3 +62,31 @@ func (s *AssetsService) GetAssets(ctx context.Context) ([]models.Asset, error) {
 
 	return out, nil
 }
+
+// AddToken lists a token, or updates and reactivates an existing token with
+// the same symbol.
+func (s *AssetsService) AddToken(ctx context.Context, p models.AddTokenParams) error {
+	_, err := s.queries.UpsertToken(ctx, db.UpsertTokenParams{
+		Symbol:          strings.ToUpper(p.Symbol),
+		Name:            p.Name,
+		ContractAddress: pgtype.Text{String: p.ContractAddress, Valid: p.ContractAddress != ""},
+		Decimals:        pgtype.Int4{Int32: int32(p.Decimals), Valid: true},
+	})
+	return err
+}
+
+// SetTokenActive activates or deactivates a listed token. Inactive tokens
+// are hidden from listings and cannot be staked.
+func (s *AssetsService) SetTokenActive(ctx context.Context, symbol string, active bool) error {
+	rows, err := s.queries.SetTokenActive(ctx, db.SetTokenActiveParams{
+		Symbol:   strings.ToUpper(symbol),
+		IsActive: pgtype.Bool{Bool: active, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrTokenNotFound
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+// internal/services/cache.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/jd7008911/aogeri-api/internal/auth"
+)
+
+// cached returns the JSON value stored under key, or calls load and stores
+// its result for ttl. Cache failures fall through to load so a Redis outage
+// only costs latency.
+func cached[T any](ctx context.Context, store auth.Store, key string, ttl time.Duration, load func() (T, error)) (T, error) {
+	if store == nil || ttl <= 0 {
+		return load()
+	}
+	if raw, err := store.Get(ctx, key); err == nil && raw != "" {
+		var v T
+		if err := json.Unmarshal([]byte(raw), &v); err == nil {
+			return v, nil
+		}
+	}
+	v, err := load()
+	if err != nil {
+		return v, err
+	}
+	if raw, err := json.Marshal(v); err == nil {
+		if err := store.Set(ctx, key, string(raw), ttl); err != nil {
+			log.Printf("cache set %s: %v", key, err)
+		}
+	}
+	return v, nil
+}
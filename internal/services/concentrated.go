@@ -0,0 +1,581 @@
+// internal/services/concentrated.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jd7008911/aogeri-api/internal/db"
+	"github.com/jd7008911/aogeri-api/internal/models"
+)
+
+var ErrInvalidRange = errors.New("invalid price range")
+
+// Pool types. Constant-product pools spread liquidity over every price;
+// concentrated pools let each position choose a tick range.
+const (
+	poolConstantProduct = "constant_product"
+	poolConcentrated    = "concentrated"
+)
+
+const (
+	minTick            = -887272
+	maxTick            = 887272
+	tickBase           = 1.0001
+	defaultTickSpacing = 10
+)
+
+// tickSqrtPrice is the square root of the price at tick, 1.0001^tick.
+func tickSqrtPrice(tick int) float64 {
+	return math.Pow(tickBase, float64(tick)/2)
+}
+
+// priceTick is the tick whose range holds price: the highest tick whose
+// price is at most price.
+func priceTick(price float64) int {
+	return int(math.Floor(math.Log(price) / math.Log(tickBase)))
+}
+
+// liquidityForAmounts is the most liquidity amount0 and amount1 can back
+// between sqrtA and sqrtB at the price sqrtP. Below the range a position
+// holds only token0, above it only token1.
+func liquidityForAmounts(sqrtP, sqrtA, sqrtB, amount0, amount1 float64) float64 {
+	switch {
+	case sqrtP <= sqrtA:
+		return amount0 * sqrtA * sqrtB / (sqrtB - sqrtA)
+	case sqrtP >= sqrtB:
+		return amount1 / (sqrtB - sqrtA)
+	}
+	return math.Min(amount0*sqrtP*sqrtB/(sqrtB-sqrtP), amount1/(sqrtP-sqrtA))
+}
+
+// amountsForLiquidity is what liquidity between sqrtA and sqrtB holds at
+// the price sqrtP.
+func amountsForLiquidity(sqrtP, sqrtA, sqrtB, liquidity float64) (amount0, amount1 float64) {
+	switch {
+	case sqrtP <= sqrtA:
+		return liquidity * (sqrtB - sqrtA) / (sqrtA * sqrtB), 0
+	case sqrtP >= sqrtB:
+		return 0, liquidity * (sqrtB - sqrtA)
+	}
+	return liquidity * (sqrtB - sqrtP) / (sqrtP * sqrtB), liquidity * (sqrtP - sqrtA)
+}
+
+// growthInside is the fee growth a range earned while the price was
+// inside it, from the fee growth outside its two ticks.
+func growthInside(lower, upper, current int, outsideLower, outsideUpper, global float64) float64 {
+	below := outsideLower
+	if current < lower {
+		below = global - outsideLower
+	}
+	above := outsideUpper
+	if current >= upper {
+		above = global - outsideUpper
+	}
+	return global - below - above
+}
+
+// tickState is an initialized tick of a concentrated pool.
+type tickState struct {
+	tick               int
+	liquidityNet       float64
+	outside0, outside1 float64
+}
+
+func tickStates(rows []db.PoolTick) []tickState {
+	out := make([]tickState, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, tickState{
+			tick:         int(r.Tick),
+			liquidityNet: numericFloat(r.LiquidityNet),
+			outside0:     numericFloat(r.FeeGrowthOutside0),
+			outside1:     numericFloat(r.FeeGrowthOutside1),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].tick < out[j].tick })
+	return out
+}
+
+// curve is the pricing state of a pool. Reserves and shares price a
+// constant-product pool; the square-root price, tick, in-range liquidity
+// and ticks price a concentrated one.
+type curve struct {
+	concentrated           bool
+	feeBps                 int
+	reserve0, reserve1     float64
+	totalShares            float64
+	sqrtPrice              float64
+	tick                   int
+	liquidity              float64
+	feeGrowth0, feeGrowth1 float64
+	ticks                  []tickState
+}
+
+func lockedCurve(p db.LiquidityPool, ticks []db.PoolTick) curve {
+	return curve{
+		concentrated: p.PoolType == poolConcentrated,
+		feeBps:       int(p.FeeBps),
+		reserve0:     numericFloat(p.Reserve0),
+		reserve1:     numericFloat(p.Reserve1),
+		totalShares:  numericFloat(p.TotalShares),
+		sqrtPrice:    numericFloat(p.SqrtPrice),
+		tick:         int(p.Tick),
+		liquidity:    numericFloat(p.Liquidity),
+		feeGrowth0:   numericFloat(p.FeeGrowth0),
+		feeGrowth1:   numericFloat(p.FeeGrowth1),
+		ticks:        tickStates(ticks),
+	}
+}
+
+// rowCurve prices quotes from a listed pool. It lacks the pool's fee
+// growth, so its swaps must not be applied.
+func rowCurve(p db.GetPoolRow, ticks []db.PoolTick) curve {
+	return curve{
+		concentrated: p.PoolType == poolConcentrated,
+		feeBps:       int(p.FeeBps),
+		reserve0:     numericFloat(p.Reserve0),
+		reserve1:     numericFloat(p.Reserve1),
+		totalShares:  numericFloat(p.TotalShares),
+		sqrtPrice:    numericFloat(p.SqrtPrice),
+		tick:         int(p.Tick),
+		liquidity:    numericFloat(p.Liquidity),
+		ticks:        tickStates(ticks),
+	}
+}
+
+// price is the pool's marginal price of token0 in token1.
+func (c curve) price() float64 {
+	if c.concentrated {
+		return c.sqrtPrice * c.sqrtPrice
+	}
+	return poolPrice(c.reserve0, c.reserve1)
+}
+
+// spot is the marginal price of side's output token in its input token.
+func (c curve) spot(side swapSide) float64 {
+	p := c.price()
+	if side.zeroForOne || p == 0 {
+		return p
+	}
+	return 1 / p
+}
+
+// tickCrossing records the pool's fee growth as a swap crossed a tick.
+type tickCrossing struct {
+	tick             int
+	growth0, growth1 float64
+}
+
+// swapResult is a swap priced through one pool. growth is the fee growth
+// it adds for the input token, per share or per unit of in-range
+// liquidity. A concentrated pool's price state after the swap, and the
+// ticks crossed on the way, are set too.
+type swapResult struct {
+	out, fee, impact float64
+	growth           float64
+	sqrtPrice        float64
+	tick             int
+	liquidity        float64
+	crossed          []tickCrossing
+}
+
+// priceSwap prices selling in through a pool's curve.
+func priceSwap(c curve, side swapSide, in float64) (swapResult, error) {
+	if c.concentrated {
+		return concentratedSwap(c, side, in)
+	}
+	reserveIn, reserveOut := side.reserves(c.reserve0, c.reserve1)
+	if reserveIn <= 0 || reserveOut <= 0 {
+		return swapResult{}, ErrInsufficientLiquidity
+	}
+	out, fee, impact := swapAmounts(reserveIn, reserveOut, in, c.feeBps)
+	res := swapResult{out: out, fee: fee, impact: impact}
+	if c.totalShares > 0 {
+		res.growth = fee / c.totalShares
+	}
+	return res, nil
+}
+
+// concentratedSwap walks a concentrated pool's price through its ticks
+// until the input is spent. Between two initialized ticks the in-range
+// liquidity is constant and the pool behaves like a constant-product pool
+// of that liquidity; crossing a tick adds or removes the liquidity of the
+// positions bounded by it. Each step's fee accrues to the liquidity
+// active during the step.
+func concentratedSwap(c curve, side swapSide, in float64) (swapResult, error) {
+	if c.sqrtPrice <= 0 {
+		return swapResult{}, ErrInsufficientLiquidity
+	}
+	feeRate := float64(c.feeBps) / bpsDenominator
+	sqrtP, liquidity, tick := c.sqrtPrice, c.liquidity, c.tick
+	globalIn := c.feeGrowth1
+	if side.zeroForOne {
+		globalIn = c.feeGrowth0
+	}
+	res := swapResult{}
+	remaining := in
+
+	for remaining > in*1e-12 {
+		next, ok := nextTick(c.ticks, tick, side.zeroForOne)
+		if !ok && liquidity <= 0 {
+			break
+		}
+		target := tickSqrtPrice(minTick)
+		if !side.zeroForOne {
+			target = tickSqrtPrice(maxTick)
+		}
+		if ok {
+			target = tickSqrtPrice(next.tick)
+		}
+
+		if liquidity > 0 {
+			net := remaining * (1 - feeRate)
+			reach := liquidity * (target - sqrtP)
+			if side.zeroForOne {
+				reach = liquidity * (1/target - 1/sqrtP)
+			}
+			if net < reach {
+				var moved float64
+				if side.zeroForOne {
+					moved = liquidity * sqrtP / (liquidity + net*sqrtP)
+					res.out += liquidity * (sqrtP - moved)
+				} else {
+					moved = sqrtP + net/liquidity
+					res.out += liquidity * (1/sqrtP - 1/moved)
+				}
+				stepFee := remaining - net
+				res.fee += stepFee
+				res.growth += stepFee / liquidity
+				tick = clampTick(priceTick(moved*moved), tick, next, ok, side.zeroForOne)
+				sqrtP, remaining = moved, 0
+				break
+			}
+			gross := reach / (1 - feeRate)
+			if side.zeroForOne {
+				res.out += liquidity * (sqrtP - target)
+			} else {
+				res.out += liquidity * (1/sqrtP - 1/target)
+			}
+			stepFee := gross - reach
+			res.fee += stepFee
+			res.growth += stepFee / liquidity
+			remaining -= gross
+		}
+		if !ok {
+			sqrtP = target
+			break
+		}
+
+		sqrtP = target
+		crossing := tickCrossing{tick: next.tick, growth0: c.feeGrowth0, growth1: c.feeGrowth1}
+		if side.zeroForOne {
+			crossing.growth0 = globalIn + res.growth
+			liquidity -= next.liquidityNet
+			tick = next.tick - 1
+		} else {
+			crossing.growth1 = globalIn + res.growth
+			liquidity += next.liquidityNet
+			tick = next.tick
+		}
+		res.crossed = append(res.crossed, crossing)
+	}
+	if remaining > in*1e-12 {
+		return swapResult{}, ErrInsufficientLiquidity
+	}
+
+	res.sqrtPrice, res.tick, res.liquidity = sqrtP, tick, math.Max(liquidity, 0)
+	if net := in - res.fee; net > 0 {
+		res.impact = math.Max(0, (1-res.out/(net*c.spot(side)))*100)
+	}
+	return res, nil
+}
+
+// nextTick is the next initialized tick the price reaches moving down
+// (the highest at or below tick) or up (the lowest above it).
+func nextTick(ticks []tickState, tick int, down bool) (tickState, bool) {
+	i := sort.Search(len(ticks), func(i int) bool { return ticks[i].tick > tick })
+	if down {
+		if i == 0 {
+			return tickState{}, false
+		}
+		return ticks[i-1], true
+	}
+	if i == len(ticks) {
+		return tickState{}, false
+	}
+	return ticks[i], true
+}
+
+// clampTick keeps the tick computed from a price that stopped short of
+// the next initialized tick on the near side of it, guarding against
+// rounding in the logarithm.
+func clampTick(t, current int, next tickState, hasNext, down bool) int {
+	if down {
+		if hasNext && t < next.tick {
+			t = next.tick
+		}
+		return min(t, current)
+	}
+	if hasNext && t >= next.tick {
+		t = next.tick - 1
+	}
+	return max(t, current)
+}
+
+// checkRange validates a position's ticks against a pool's spacing.
+func checkRange(lower, upper, spacing int) error {
+	if lower >= upper {
+		return fmt.Errorf("%w: tick_lower must be below tick_upper", ErrInvalidRange)
+	}
+	if lower < minTick || upper > maxTick {
+		return fmt.Errorf("%w: ticks must be within [%d, %d]", ErrInvalidRange, minTick, maxTick)
+	}
+	if spacing > 0 && (lower%spacing != 0 || upper%spacing != 0) {
+		return fmt.Errorf("%w: ticks must be multiples of the pool's tick spacing %d", ErrInvalidRange, spacing)
+	}
+	return nil
+}
+
+func findTick(ticks []tickState, tick int) (tickState, bool) {
+	for _, t := range ticks {
+		if t.tick == tick {
+			return t, true
+		}
+	}
+	return tickState{}, false
+}
+
+// updateTick adds liquidity to one of a position's ticks. A tick being
+// initialized starts with all fee growth counted outside it when it is at
+// or below the current tick, as if it had always been crossed.
+func updateTick(ctx context.Context, q liquidityQuerier, pool db.LiquidityPool, tick int, gross, net float64) error {
+	params := db.UpdateTickParams{PoolID: pool.ID, Tick: int32(tick)}
+	var outside0, outside1 float64
+	if tick <= int(pool.Tick) {
+		outside0, outside1 = numericFloat(pool.FeeGrowth0), numericFloat(pool.FeeGrowth1)
+	}
+	var err error
+	if params.FeeGrowthOutside0, err = floatNumeric(outside0); err != nil {
+		return err
+	}
+	if params.FeeGrowthOutside1, err = floatNumeric(outside1); err != nil {
+		return err
+	}
+	if params.GrossDelta, err = floatNumeric(gross); err != nil {
+		return err
+	}
+	if params.NetDelta, err = floatNumeric(net); err != nil {
+		return err
+	}
+	return q.UpdateTick(ctx, params)
+}
+
+// rangeGrowth is the fee growth inside a position's range, per token.
+func rangeGrowth(ctx context.Context, q liquidityQuerier, pool db.LiquidityPool, lower, upper int) (float64, float64, error) {
+	rows, err := q.ListPoolTicks(ctx, pool.ID)
+	if err != nil {
+		return 0, 0, err
+	}
+	ticks := tickStates(rows)
+	lo, _ := findTick(ticks, lower)
+	hi, _ := findTick(ticks, upper)
+	current := int(pool.Tick)
+	return growthInside(lower, upper, current, lo.outside0, hi.outside0, numericFloat(pool.FeeGrowth0)),
+		growthInside(lower, upper, current, lo.outside1, hi.outside1, numericFloat(pool.FeeGrowth1)), nil
+}
+
+// crossTicks stores a concentrated pool's price after a swap and flips
+// the fee growth outside every tick the swap crossed.
+func crossTicks(ctx context.Context, q liquidityQuerier, pool db.LiquidityPool, res swapResult) error {
+	for _, c := range res.crossed {
+		params := db.CrossTickParams{PoolID: pool.ID, Tick: int32(c.tick)}
+		var err error
+		if params.FeeGrowth0, err = floatNumeric(c.growth0); err != nil {
+			return err
+		}
+		if params.FeeGrowth1, err = floatNumeric(c.growth1); err != nil {
+			return err
+		}
+		if err := q.CrossTick(ctx, params); err != nil {
+			return err
+		}
+	}
+	return setCurve(ctx, q, pool, res.sqrtPrice, res.tick, res.liquidity)
+}
+
+func setCurve(ctx context.Context, q liquidityQuerier, pool db.LiquidityPool, sqrtPrice float64, tick int, liquidity float64) error {
+	params := db.SetPoolCurveParams{ID: pool.ID, Tick: int32(tick)}
+	var err error
+	if params.SqrtPrice, err = floatNumeric(sqrtPrice); err != nil {
+		return err
+	}
+	if params.Liquidity, err = floatNumeric(liquidity); err != nil {
+		return err
+	}
+	return q.SetPoolCurve(ctx, params)
+}
+
+// addRangeLiquidity opens a position between two ticks of a locked
+// concentrated pool. It mints the most liquidity the amounts back at the
+// current price and refunds the rest; the liquidity is the position's
+// shares.
+func (s *LiquidityService) addRangeLiquidity(ctx context.Context, q liquidityQuerier, pool db.LiquidityPool, userID uuid.UUID, lower, upper int, amount0, amount1, minShares float64) (*models.LiquidityReceipt, error) {
+	if err := checkRange(lower, upper, int(pool.TickSpacing)); err != nil {
+		return nil, err
+	}
+	sqrtP, sqrtA, sqrtB := numericFloat(pool.SqrtPrice), tickSqrtPrice(lower), tickSqrtPrice(upper)
+	liquidity := liquidityForAmounts(sqrtP, sqrtA, sqrtB, amount0, amount1)
+	if liquidity <= 0 || math.IsInf(liquidity, 0) || math.IsNaN(liquidity) {
+		return nil, ErrInvalidLiquidityAmount
+	}
+	if liquidity < minShares {
+		return nil, fmt.Errorf("%w: %s liquidity minted, at least %s required", ErrSlippageExceeded, formatAmount(liquidity), formatAmount(minShares))
+	}
+	used0, used1 := amountsForLiquidity(sqrtP, sqrtA, sqrtB, liquidity)
+
+	if err := updateTick(ctx, q, pool, lower, liquidity, liquidity); err != nil {
+		return nil, err
+	}
+	if err := updateTick(ctx, q, pool, upper, liquidity, -liquidity); err != nil {
+		return nil, err
+	}
+	growth0, growth1, err := rangeGrowth(ctx, q, pool, lower, upper)
+	if err != nil {
+		return nil, err
+	}
+	if current := int(pool.Tick); lower <= current && current < upper {
+		if err := setCurve(ctx, q, pool, sqrtP, current, numericFloat(pool.Liquidity)+liquidity); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.setReserves(ctx, q, pool, numericFloat(pool.Reserve0)+used0, numericFloat(pool.Reserve1)+used1, numericFloat(pool.TotalShares)+liquidity); err != nil {
+		return nil, err
+	}
+
+	params := db.CreateRangePositionParams{
+		UserID:    toPgUUID(userID),
+		PoolID:    pool.ID,
+		TickLower: int32(lower),
+		TickUpper: int32(upper),
+	}
+	if params.Amount0, err = floatNumeric(used0); err != nil {
+		return nil, err
+	}
+	if params.Amount1, err = floatNumeric(used1); err != nil {
+		return nil, err
+	}
+	if params.Shares, err = floatNumeric(liquidity); err != nil {
+		return nil, err
+	}
+	if params.FeeGrowth0Last, err = floatNumeric(growth0); err != nil {
+		return nil, err
+	}
+	if params.FeeGrowth1Last, err = floatNumeric(growth1); err != nil {
+		return nil, err
+	}
+	position, err := q.CreateRangePosition(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	receipt, err := liquidityReceipt(ctx, q, position, used0, used1, liquidity)
+	if err != nil {
+		return nil, err
+	}
+	if refund := amount0 - used0; refund > amount0*1e-12 {
+		receipt.Refund0 = formatAmount(refund)
+	}
+	if refund := amount1 - used1; refund > amount1*1e-12 {
+		receipt.Refund1 = formatAmount(refund)
+	}
+	return receipt, nil
+}
+
+// removeRangeLiquidity burns liquidity from one of the user's range
+// positions in a locked concentrated pool. It pays out the tokens the
+// liquidity holds at the current price plus every fee the position has
+// earned and not yet collected.
+func (s *LiquidityService) removeRangeLiquidity(ctx context.Context, q liquidityQuerier, pool db.LiquidityPool, userID uuid.UUID, positionID *uuid.UUID, burn, min0, min1 float64) (*models.LiquidityReceipt, error) {
+	if positionID == nil {
+		return nil, fmt.Errorf("%w: position_id is required for a concentrated pool", ErrInvalidRange)
+	}
+	position, err := q.GetPositionForUpdate(ctx, db.GetPositionForUpdateParams{ID: toPgUUID(*positionID), UserID: toPgUUID(userID)})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPositionNotFound
+		}
+		return nil, err
+	}
+	if position.PoolID != pool.ID || position.Status.String != "active" || !position.TickLower.Valid {
+		return nil, ErrPositionNotFound
+	}
+	held := numericFloat(position.Shares)
+	if burn > held {
+		return nil, ErrInsufficientShares
+	}
+	lower, upper := int(position.TickLower.Int32), int(position.TickUpper.Int32)
+
+	growth0, growth1, err := rangeGrowth(ctx, q, pool, lower, upper)
+	if err != nil {
+		return nil, err
+	}
+	fees0 := numericFloat(position.Fees0) + held*(growth0-numericFloat(position.FeeGrowth0Last)) - numericFloat(position.FeesCollected0)
+	fees1 := numericFloat(position.Fees1) + held*(growth1-numericFloat(position.FeeGrowth1Last)) - numericFloat(position.FeesCollected1)
+	sqrtP := numericFloat(pool.SqrtPrice)
+	principal0, principal1 := amountsForLiquidity(sqrtP, tickSqrtPrice(lower), tickSqrtPrice(upper), burn)
+	out0, out1 := principal0+math.Max(fees0, 0), principal1+math.Max(fees1, 0)
+	if out0 < min0 || out1 < min1 {
+		return nil, fmt.Errorf("%w: would receive %s and %s", ErrSlippageExceeded, formatAmount(out0), formatAmount(out1))
+	}
+
+	keep := 1 - burn/held
+	params := db.UpdatePositionParams{ID: position.ID, Status: "active"}
+	if keep <= 0 {
+		keep = 0
+		params.Status = "withdrawn"
+	}
+	if params.Amount0, err = floatNumeric(numericFloat(position.Amount0) * keep); err != nil {
+		return nil, err
+	}
+	if params.Amount1, err = floatNumeric(numericFloat(position.Amount1) * keep); err != nil {
+		return nil, err
+	}
+	if params.Shares, err = floatNumeric(held - burn); err != nil {
+		return nil, err
+	}
+	if params.FeeGrowth0, err = floatNumeric(growth0); err != nil {
+		return nil, err
+	}
+	if params.FeeGrowth1, err = floatNumeric(growth1); err != nil {
+		return nil, err
+	}
+	updated, err := q.UpdatePosition(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.CollectPositionFees(ctx, position.ID); err != nil {
+		return nil, err
+	}
+
+	if err := updateTick(ctx, q, pool, lower, -burn, -burn); err != nil {
+		return nil, err
+	}
+	if err := updateTick(ctx, q, pool, upper, -burn, burn); err != nil {
+		return nil, err
+	}
+	if current := int(pool.Tick); lower <= current && current < upper {
+		if err := setCurve(ctx, q, pool, sqrtP, current, math.Max(numericFloat(pool.Liquidity)-burn, 0)); err != nil {
+			return nil, err
+		}
+	}
+	reserve0 := math.Max(numericFloat(pool.Reserve0)-out0, 0)
+	reserve1 := math.Max(numericFloat(pool.Reserve1)-out1, 0)
+	if err := s.setReserves(ctx, q, pool, reserve0, reserve1, math.Max(numericFloat(pool.TotalShares)-burn, 0)); err != nil {
+		return nil, err
+	}
+	return liquidityReceipt(ctx, q, updated, out0, out1, burn)
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -17,10 +18,19 @@ import (
 type DashboardService struct {
 	queries *db.Queries
 	auth    *auth.AuthService
+	pools   *RewardsPoolService
+	prices  PriceSource
 }
 
 func NewDashboardService(queries *db.Queries, a *auth.AuthService) *DashboardService {
-	return &DashboardService{queries: queries, auth: a}
+	return &DashboardService{queries: queries, auth: a, pools: NewRewardsPoolService(queries)}
+}
+
+// WithPriceSource values staked tokens and TVL at the source's prices,
+// falling back to the stored market price for tokens it cannot price.
+func (d *DashboardService) WithPriceSource(prices PriceSource) *DashboardService {
+	d.prices = prices
+	return d
 }
 
 // AuthMiddleware proxies to auth service middleware so handlers can use it.
@@ -38,32 +48,16 @@ func (d *DashboardService) GetStats(ctx context.Context) (models.DashboardStats,
 		return out, err
 	}
 
-	// Convert TotalTvl (driver returns various types) to string
-	totalTvlStr := "0"
-	switch v := am.TotalTvl.(type) {
-	case nil:
-		totalTvlStr = "0"
-	case float64:
-		totalTvlStr = strconv.FormatFloat(v, 'f', -1, 64)
-	case string:
-		totalTvlStr = v
-	case []byte:
-		totalTvlStr = string(v)
-	default:
-		totalTvlStr = fmt.Sprintf("%v", v)
+	totalTvlStr, err := d.totalValueLocked(ctx, am.TotalTvl)
+	if err != nil {
+		return out, err
 	}
 
-	// Total staked value (explicit query)
-	totalStaked, err := d.queries.GetTotalStakedValue(ctx)
+	// Total staked value
+	totalStakedStr, err := d.totalStakedValue(ctx)
 	if err != nil {
 		return out, err
 	}
-	totalStakedStr := "0"
-	if totalStaked.Valid {
-		if fv, err := totalStaked.Float64Value(); err == nil {
-			totalStakedStr = strconv.FormatFloat(fv.Float64, 'f', -1, 64)
-		}
-	}
 
 	// Active governance proposals count
 	props, err := d.queries.GetActiveProposals(ctx)
@@ -71,6 +65,12 @@ func (d *DashboardService) GetStats(ctx context.Context) (models.DashboardStats,
 		return out, err
 	}
 
+	// Rewards pool budgets and runway
+	pools, err := d.pools.PoolStats(ctx)
+	if err != nil {
+		return out, err
+	}
+
 	out = models.DashboardStats{
 		TotalValueLocked:    totalTvlStr,
 		ActiveMonitors:      int32(am.ActiveTokens),
@@ -79,11 +79,85 @@ func (d *DashboardService) GetStats(ctx context.Context) (models.DashboardStats,
 		TotalRewards:        totalStakedStr,
 		SecurityScore:       100.0,
 		GovernanceProposals: int32(len(props)),
+		RewardPools:         pools,
 	}
 
 	return out, nil
 }
 
+// totalValueLocked revalues each token's stored TVL at the price source's
+// price when one is set. Tokens it cannot price, or without a stored market
+// price to recover the locked amount from, keep their stored TVL.
+func (d *DashboardService) totalValueLocked(ctx context.Context, stored any) (string, error) {
+	if d.prices == nil {
+		// Convert TotalTvl (driver returns various types) to string
+		switch v := stored.(type) {
+		case nil:
+			return "0", nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case string:
+			return v, nil
+		case []byte:
+			return string(v), nil
+		default:
+			return fmt.Sprintf("%v", v), nil
+		}
+	}
+
+	rows, err := d.queries.GetAssetTVLByToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	var value float64
+	for _, r := range rows {
+		if !r.LockedAmount.Valid {
+			value += numericFloat(r.TotalValueLocked)
+			continue
+		}
+		price, err := d.prices.TokenPrice(ctx, r.Symbol)
+		switch {
+		case errors.Is(err, ErrNoPrice):
+			value += numericFloat(r.TotalValueLocked)
+		case err != nil:
+			return "", err
+		default:
+			value += numericFloat(r.LockedAmount) * price
+		}
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64), nil
+}
+
+// totalStakedValue values active stakes at the price source's prices when
+// one is set, otherwise at the stored market prices.
+func (d *DashboardService) totalStakedValue(ctx context.Context) (string, error) {
+	if d.prices == nil {
+		totalStaked, err := d.queries.GetTotalStakedValue(ctx)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatFloat(numericFloat(totalStaked), 'f', -1, 64), nil
+	}
+
+	totals, err := d.queries.GetStakeTotalsByToken(ctx, pgtype.UUID{})
+	if err != nil {
+		return "", err
+	}
+	var value float64
+	for _, t := range totals {
+		price, err := d.prices.TokenPrice(ctx, t.Symbol)
+		switch {
+		case errors.Is(err, ErrNoPrice):
+			value += numericFloat(t.TotalValue)
+		case err != nil:
+			return "", err
+		default:
+			value += numericFloat(t.TotalStaked) * price
+		}
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64), nil
+}
+
 // GetUserOverview returns a minimal overview for now.
 func (d *DashboardService) GetUserOverview(ctx context.Context, userID uuid.UUID) (any, error) {
 	// Convert uuid.UUID -> pgtype.UUID
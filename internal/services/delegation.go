@@ -0,0 +1,211 @@
+// internal/services/delegation.go
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jd7008911/aogeri-api/internal/db"
+	"github.com/jd7008911/aogeri-api/internal/models"
+)
+
+var (
+	ErrInvalidDelegation  = errors.New("cannot delegate to yourself")
+	ErrDelegateNotFound   = errors.New("delegate not found")
+	ErrDelegationNotFound = errors.New("delegation not found")
+	ErrDelegationCycle    = errors.New("delegation would create a cycle")
+)
+
+// maxDelegationDepth bounds delegation chains. The tally queries use the
+// same limit.
+const maxDelegationDepth = 32
+
+type delegationQuerier interface {
+	AdvisoryLock(ctx context.Context, lockKey int64) error
+	GetUserByID(ctx context.Context, id pgtype.UUID) (db.User, error)
+	UpsertDelegation(ctx context.Context, arg db.UpsertDelegationParams) (db.VoteDelegation, error)
+	DeleteDelegation(ctx context.Context, arg db.DeleteDelegationParams) (int64, error)
+	ListDelegationsByDelegator(ctx context.Context, delegatorID pgtype.UUID) ([]db.VoteDelegation, error)
+	ListDelegationsByDelegate(ctx context.Context, delegateID pgtype.UUID) ([]db.VoteDelegation, error)
+	GetEffectiveDelegate(ctx context.Context, arg db.GetEffectiveDelegateParams) (pgtype.UUID, error)
+	ListDelegationSymbols(ctx context.Context) ([]string, error)
+	ListDelegates(ctx context.Context, arg db.ListDelegatesParams) ([]db.ListDelegatesRow, error)
+}
+
+// Delegate hands delegatorID's vote power to req.DelegateID, replacing any
+// delegation with the same scope. Delegations apply to proposals that
+// become active afterwards; delegators can still vote directly to override
+// their delegate on a proposal.
+func (g *GovernanceService) Delegate(ctx context.Context, delegatorID uuid.UUID, req models.DelegateRequest) (*models.Delegation, error) {
+	if req.DelegateID == delegatorID {
+		return nil, ErrInvalidDelegation
+	}
+	symbol := strings.ToUpper(req.TokenSymbol)
+
+	var row db.VoteDelegation
+	err := g.inTx(ctx, func(q governanceQuerier) error {
+		// Serialise delegation changes so concurrent requests cannot close a
+		// cycle between them.
+		if err := q.AdvisoryLock(ctx, lockKeyDelegations); err != nil {
+			return err
+		}
+		if _, err := q.GetUserByID(ctx, toPgUUID(req.DelegateID)); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrDelegateNotFound
+			}
+			return err
+		}
+
+		scopes := []string{symbol}
+		if symbol == "" {
+			// A global delegation applies under every token scope in which
+			// the delegator has no token delegation of its own.
+			symbols, err := q.ListDelegationSymbols(ctx)
+			if err != nil {
+				return err
+			}
+			existing, err := q.ListDelegationsByDelegator(ctx, toPgUUID(delegatorID))
+			if err != nil {
+				return err
+			}
+			overridden := map[string]bool{}
+			for _, d := range existing {
+				if d.TokenSymbol.Valid {
+					overridden[d.TokenSymbol.String] = true
+				}
+			}
+			for _, sym := range symbols {
+				if !overridden[sym] {
+					scopes = append(scopes, sym)
+				}
+			}
+		}
+		for _, scope := range scopes {
+			if err := checkDelegationCycle(ctx, q, delegatorID, req.DelegateID, scope); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		row, err = q.UpsertDelegation(ctx, db.UpsertDelegationParams{
+			DelegatorID: toPgUUID(delegatorID),
+			DelegateID:  toPgUUID(req.DelegateID),
+			TokenSymbol: pgtype.Text{String: symbol, Valid: symbol != ""},
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	d := delegationModel(row)
+	return &d, nil
+}
+
+// checkDelegationCycle follows the effective delegations under scope from
+// delegate and fails if they lead back to delegator. Scope "" follows
+// global delegations only.
+func checkDelegationCycle(ctx context.Context, q governanceQuerier, delegator, delegate uuid.UUID, scope string) error {
+	cur := toPgUUID(delegate)
+	for i := 0; i < maxDelegationDepth; i++ {
+		if cur == toPgUUID(delegator) {
+			return ErrDelegationCycle
+		}
+		next, err := q.GetEffectiveDelegate(ctx, db.GetEffectiveDelegateParams{
+			DelegatorID: cur,
+			TokenSymbol: pgtype.Text{String: scope, Valid: true},
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		cur = next
+	}
+	return ErrDelegationCycle
+}
+
+// Undelegate removes delegatorID's delegation for symbol, or the global
+// delegation when symbol is empty.
+func (g *GovernanceService) Undelegate(ctx context.Context, delegatorID uuid.UUID, symbol string) error {
+	symbol = strings.ToUpper(symbol)
+	rows, err := g.queries.DeleteDelegation(ctx, db.DeleteDelegationParams{
+		DelegatorID: toPgUUID(delegatorID),
+		TokenSymbol: pgtype.Text{String: symbol, Valid: symbol != ""},
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrDelegationNotFound
+	}
+	return nil
+}
+
+// GetDelegations lists the delegations userID has made and received.
+func (g *GovernanceService) GetDelegations(ctx context.Context, userID uuid.UUID) (*models.Delegations, error) {
+	out, err := g.queries.ListDelegationsByDelegator(ctx, toPgUUID(userID))
+	if err != nil {
+		return nil, err
+	}
+	in, err := g.queries.ListDelegationsByDelegate(ctx, toPgUUID(userID))
+	if err != nil {
+		return nil, err
+	}
+	res := &models.Delegations{
+		Delegating: make([]models.Delegation, 0, len(out)),
+		Received:   make([]models.Delegation, 0, len(in)),
+	}
+	for _, d := range out {
+		res.Delegating = append(res.Delegating, delegationModel(d))
+	}
+	for _, d := range in {
+		res.Received = append(res.Received, delegationModel(d))
+	}
+	return res, nil
+}
+
+// ListDelegates ranks delegates by the vote power in active stake of symbol
+// delegated to them, directly or through a chain. Power is lock-weighted
+// the same way as the vote snapshot when GOVERNANCE_LOCK_WEIGHTING is set.
+// symbol defaults to the governance token.
+func (g *GovernanceService) ListDelegates(ctx context.Context, symbol string, limit, offset int) ([]models.Delegate, error) {
+	if symbol == "" {
+		symbol = g.cfg.Token
+	}
+	rows, err := g.queries.ListDelegates(ctx, db.ListDelegatesParams{
+		Symbol:        strings.ToUpper(symbol),
+		LockWeighting: g.cfg.LockWeighting,
+		PageLimit:     int32(limit),
+		PageOffset:    int32(offset),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]models.Delegate, 0, len(rows))
+	for _, r := range rows {
+		id, _ := pgToUUID(r.DelegateID)
+		out = append(out, models.Delegate{
+			DelegateID:     id,
+			DelegatorCount: r.DelegatorCount,
+			ReceivedPower:  numericString(r.ReceivedPower),
+		})
+	}
+	return out, nil
+}
+
+func delegationModel(d db.VoteDelegation) models.Delegation {
+	delegator, _ := pgToUUID(d.DelegatorID)
+	delegate, _ := pgToUUID(d.DelegateID)
+	return models.Delegation{
+		DelegatorID: delegator,
+		DelegateID:  delegate,
+		TokenSymbol: d.TokenSymbol.String,
+		CreatedAt:   d.CreatedAt.Time,
+		UpdatedAt:   d.UpdatedAt.Time,
+	}
+}
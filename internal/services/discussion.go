@@ -0,0 +1,336 @@
+// internal/services/discussion.go
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jd7008911/aogeri-api/internal/db"
+	"github.com/jd7008911/aogeri-api/internal/models"
+)
+
+var (
+	ErrCommentNotFound  = errors.New("comment not found")
+	ErrInvalidComment   = errors.New("invalid comment")
+	ErrInvalidReaction  = errors.New("unknown reaction")
+	ErrNotCommentAuthor = errors.New("only the author can edit a comment")
+	ErrCommentHidden    = errors.New("comment has been hidden by a moderator")
+	ErrDiscussionLocked = errors.New("discussion is locked")
+)
+
+// commentReactions are the reactions a comment accepts.
+var commentReactions = map[string]bool{
+	"thumbs_up":   true,
+	"thumbs_down": true,
+	"heart":       true,
+	"laugh":       true,
+	"rocket":      true,
+	"eyes":        true,
+}
+
+type discussionQuerier interface {
+	CreateComment(ctx context.Context, arg db.CreateCommentParams) (db.ProposalComment, error)
+	GetComment(ctx context.Context, id pgtype.UUID) (db.ProposalComment, error)
+	GetCommentForUpdate(ctx context.Context, id pgtype.UUID) (db.ProposalComment, error)
+	ListProposalComments(ctx context.Context, proposalID pgtype.UUID) ([]db.ProposalComment, error)
+	UpdateCommentBody(ctx context.Context, arg db.UpdateCommentBodyParams) (db.ProposalComment, error)
+	CreateCommentEdit(ctx context.Context, arg db.CreateCommentEditParams) error
+	ListCommentEdits(ctx context.Context, commentID pgtype.UUID) ([]db.ProposalCommentEdit, error)
+	SetCommentHidden(ctx context.Context, arg db.SetCommentHiddenParams) (db.ProposalComment, error)
+	AddCommentReaction(ctx context.Context, arg db.AddCommentReactionParams) (int64, error)
+	RemoveCommentReaction(ctx context.Context, arg db.RemoveCommentReactionParams) (int64, error)
+	ListProposalReactions(ctx context.Context, arg db.ListProposalReactionsParams) ([]db.ListProposalReactionsRow, error)
+	SetDiscussionLock(ctx context.Context, arg db.SetDiscussionLockParams) (db.GovernanceProposal, error)
+}
+
+// ListComments returns the comment thread of a proposal as a tree, with
+// reaction counts and the reactions viewerID has added.
+func (g *GovernanceService) ListComments(ctx context.Context, proposalID, viewerID uuid.UUID) (*models.Discussion, error) {
+	p, err := proposalByID(ctx, g.queries, toPgUUID(proposalID))
+	if err != nil {
+		return nil, err
+	}
+	rows, err := g.queries.ListProposalComments(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	reactions, err := g.queries.ListProposalReactions(ctx, db.ListProposalReactionsParams{
+		UserID:     toPgUUID(viewerID),
+		ProposalID: p.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[pgtype.UUID]map[string]int64{}
+	mine := map[pgtype.UUID][]string{}
+	for _, r := range reactions {
+		if counts[r.CommentID] == nil {
+			counts[r.CommentID] = map[string]int64{}
+		}
+		counts[r.CommentID][r.Reaction] = r.ReactionCount
+		if r.Reacted {
+			mine[r.CommentID] = append(mine[r.CommentID], r.Reaction)
+		}
+	}
+	children := map[pgtype.UUID][]db.ProposalComment{}
+	for _, r := range rows {
+		// Roots are keyed by the zero UUID.
+		children[r.ParentID] = append(children[r.ParentID], r)
+	}
+	var build func(parent pgtype.UUID) []models.Comment
+	build = func(parent pgtype.UUID) []models.Comment {
+		out := make([]models.Comment, 0, len(children[parent]))
+		for _, r := range children[parent] {
+			c := commentModel(r)
+			if n := counts[r.ID]; n != nil {
+				c.Reactions = n
+			}
+			if m := mine[r.ID]; m != nil {
+				c.MyReactions = m
+			}
+			c.Replies = build(r.ID)
+			out = append(out, c)
+		}
+		return out
+	}
+
+	return &models.Discussion{
+		ProposalID: proposalID,
+		Locked:     p.DiscussionLockedAt.Valid,
+		Comments:   build(pgtype.UUID{}),
+	}, nil
+}
+
+// CreateComment posts a comment on a proposal, or a reply to req.ParentID.
+func (g *GovernanceService) CreateComment(ctx context.Context, proposalID, authorID uuid.UUID, req models.CommentRequest) (*models.Comment, error) {
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return nil, ErrInvalidComment
+	}
+
+	var row db.ProposalComment
+	err := g.inTx(ctx, func(q governanceQuerier) error {
+		p, err := proposalByID(ctx, q, toPgUUID(proposalID))
+		if err != nil {
+			return err
+		}
+		if p.DiscussionLockedAt.Valid {
+			return ErrDiscussionLocked
+		}
+		params := db.CreateCommentParams{ProposalID: p.ID, AuthorID: toPgUUID(authorID), Body: body}
+		if req.ParentID != nil {
+			parent, err := commentInProposal(ctx, q.GetComment, p.ID, toPgUUID(*req.ParentID))
+			if err != nil {
+				return err
+			}
+			if parent.Hidden {
+				return ErrCommentHidden
+			}
+			params.ParentID = parent.ID
+		}
+		row, err = q.CreateComment(ctx, params)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	c := commentModel(row)
+	return &c, nil
+}
+
+// EditComment replaces the body of one of authorID's comments, keeping the
+// previous body in the comment's history.
+func (g *GovernanceService) EditComment(ctx context.Context, proposalID, commentID, authorID uuid.UUID, body string) (*models.Comment, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return nil, ErrInvalidComment
+	}
+
+	var row db.ProposalComment
+	err := g.inTx(ctx, func(q governanceQuerier) error {
+		p, err := proposalByID(ctx, q, toPgUUID(proposalID))
+		if err != nil {
+			return err
+		}
+		row, err = commentInProposal(ctx, q.GetCommentForUpdate, p.ID, toPgUUID(commentID))
+		if err != nil {
+			return err
+		}
+		if row.AuthorID != toPgUUID(authorID) {
+			return ErrNotCommentAuthor
+		}
+		if row.Hidden {
+			return ErrCommentHidden
+		}
+		if p.DiscussionLockedAt.Valid {
+			return ErrDiscussionLocked
+		}
+		if row.Body == body {
+			return nil
+		}
+		if err := q.CreateCommentEdit(ctx, db.CreateCommentEditParams{CommentID: row.ID, PreviousBody: row.Body}); err != nil {
+			return err
+		}
+		row, err = q.UpdateCommentBody(ctx, db.UpdateCommentBodyParams{ID: row.ID, Body: body})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	c := commentModel(row)
+	return &c, nil
+}
+
+// CommentHistory returns the earlier versions of a comment, newest first.
+func (g *GovernanceService) CommentHistory(ctx context.Context, proposalID, commentID uuid.UUID) ([]models.CommentEdit, error) {
+	c, err := commentInProposal(ctx, g.queries.GetComment, toPgUUID(proposalID), toPgUUID(commentID))
+	if err != nil {
+		return nil, err
+	}
+	if c.Hidden {
+		return nil, ErrCommentHidden
+	}
+	rows, err := g.queries.ListCommentEdits(ctx, c.ID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]models.CommentEdit, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, models.CommentEdit{PreviousBody: r.PreviousBody, EditedAt: r.EditedAt.Time})
+	}
+	return out, nil
+}
+
+// React adds (add true) or removes userID's reaction on a comment. Both are
+// idempotent.
+func (g *GovernanceService) React(ctx context.Context, proposalID, commentID, userID uuid.UUID, reaction string, add bool) error {
+	if !commentReactions[reaction] {
+		return ErrInvalidReaction
+	}
+	p, err := proposalByID(ctx, g.queries, toPgUUID(proposalID))
+	if err != nil {
+		return err
+	}
+	c, err := commentInProposal(ctx, g.queries.GetComment, p.ID, toPgUUID(commentID))
+	if err != nil {
+		return err
+	}
+	if !add {
+		_, err = g.queries.RemoveCommentReaction(ctx, db.RemoveCommentReactionParams{
+			CommentID: c.ID,
+			UserID:    toPgUUID(userID),
+			Reaction:  reaction,
+		})
+		return err
+	}
+	if c.Hidden {
+		return ErrCommentHidden
+	}
+	if p.DiscussionLockedAt.Valid {
+		return ErrDiscussionLocked
+	}
+	_, err = g.queries.AddCommentReaction(ctx, db.AddCommentReactionParams{
+		CommentID: c.ID,
+		UserID:    toPgUUID(userID),
+		Reaction:  reaction,
+	})
+	return err
+}
+
+// SetCommentHidden lets a moderator hide a comment, or restore it.
+func (g *GovernanceService) SetCommentHidden(ctx context.Context, proposalID, commentID, moderatorID uuid.UUID, hidden bool) (*models.Comment, error) {
+	c, err := commentInProposal(ctx, g.queries.GetComment, toPgUUID(proposalID), toPgUUID(commentID))
+	if err != nil {
+		return nil, err
+	}
+	params := db.SetCommentHiddenParams{ID: c.ID, Hidden: hidden}
+	if hidden {
+		params.HiddenBy = toPgUUID(moderatorID)
+	}
+	row, err := g.queries.SetCommentHidden(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	out := commentModel(row)
+	return &out, nil
+}
+
+// SetDiscussionLocked lets a moderator lock a proposal's discussion, which
+// stops new comments, edits and reactions, or unlock it again.
+func (g *GovernanceService) SetDiscussionLocked(ctx context.Context, proposalID, moderatorID uuid.UUID, locked bool) (*models.Proposal, error) {
+	params := db.SetDiscussionLockParams{ID: toPgUUID(proposalID), Locked: locked}
+	if locked {
+		params.LockedBy = toPgUUID(moderatorID)
+	}
+	row, err := g.queries.SetDiscussionLock(ctx, params)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrProposalNotFound
+		}
+		return nil, err
+	}
+	p := proposalModel(row)
+	return &p, nil
+}
+
+// proposalByID loads a proposal, mapping a missing row to
+// ErrProposalNotFound.
+func proposalByID(ctx context.Context, q governanceQuerier, id pgtype.UUID) (db.GovernanceProposal, error) {
+	p, err := q.GetProposalByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return p, ErrProposalNotFound
+	}
+	return p, err
+}
+
+// commentInProposal loads a comment with get and checks that it belongs to
+// proposalID.
+func commentInProposal(ctx context.Context, get func(context.Context, pgtype.UUID) (db.ProposalComment, error), proposalID, commentID pgtype.UUID) (db.ProposalComment, error) {
+	c, err := get(ctx, commentID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c, ErrCommentNotFound
+		}
+		return c, err
+	}
+	if c.ProposalID != proposalID {
+		return db.ProposalComment{}, ErrCommentNotFound
+	}
+	return c, nil
+}
+
+// commentModel converts a comment row, withholding the body of hidden
+// comments.
+func commentModel(r db.ProposalComment) models.Comment {
+	id, _ := pgToUUID(r.ID)
+	proposal, _ := pgToUUID(r.ProposalID)
+	author, _ := pgToUUID(r.AuthorID)
+	c := models.Comment{
+		ID:          id,
+		ProposalID:  proposal,
+		AuthorID:    author,
+		Body:        r.Body,
+		Hidden:      r.Hidden,
+		CreatedAt:   r.CreatedAt.Time,
+		Reactions:   map[string]int64{},
+		MyReactions: []string{},
+		Replies:     []models.Comment{},
+	}
+	if r.Hidden {
+		c.Body = ""
+	}
+	if r.ParentID.Valid {
+		parent, _ := pgToUUID(r.ParentID)
+		c.ParentID = &parent
+	}
+	if r.EditedAt.Valid {
+		t := r.EditedAt.Time
+		c.EditedAt = &t
+	}
+	return c
+}
@@ -0,0 +1,388 @@
+// internal/services/farming.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jd7008911/aogeri-api/internal/db"
+	"github.com/jd7008911/aogeri-api/internal/models"
+)
+
+var (
+	ErrFarmNotFound      = errors.New("farm not found")
+	ErrFarmStakeNotFound = errors.New("no shares staked in this farm")
+	ErrInvalidFarm       = errors.New("invalid farm")
+	ErrFarmEnded         = errors.New("farm has ended")
+	ErrSharesFarmed      = errors.New("shares are locked in a farm")
+)
+
+const defaultFarmRewardToken = "AOG"
+
+// rewardPerShare advances a farm's accumulator from its last update to
+// now. Rewards only flow inside the farm's window and while shares are
+// staked; emission with nothing staked is not paid to anyone.
+func rewardPerShare(acc, staked, perDay float64, start, end, last, now time.Time) float64 {
+	from, to := last, now
+	if start.After(from) {
+		from = start
+	}
+	if end.Before(to) {
+		to = end
+	}
+	if staked <= 0 || !to.After(from) {
+		return acc
+	}
+	return acc + perDay*to.Sub(from).Hours()/24/staked
+}
+
+// earnedRewards is what a stake has accrued up to the accumulator acc.
+func earnedRewards(shares, acc, paid, pending float64) float64 {
+	return pending + shares*(acc-paid)
+}
+
+// CreateFarm opens a liquidity mining program on a pool.
+func (s *LiquidityService) CreateFarm(ctx context.Context, adminID uuid.UUID, req models.CreateFarmRequest) (*models.Farm, error) {
+	perDay, ok := parsePositiveAmount(req.RewardPerDay)
+	if !ok {
+		return nil, fmt.Errorf("%w: reward_per_day must be positive", ErrInvalidFarm)
+	}
+	start := time.Now()
+	if req.StartsAt != nil {
+		start = *req.StartsAt
+	}
+	if !req.EndsAt.After(start) || !req.EndsAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: ends_at must be in the future and after starts_at", ErrInvalidFarm)
+	}
+	token := strings.ToUpper(req.RewardToken)
+	if token == "" {
+		token = defaultFarmRewardToken
+	}
+	pool, err := s.queries.GetPool(ctx, toPgUUID(req.PoolID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPoolNotFound
+		}
+		return nil, err
+	}
+	if pool.PoolType == poolConcentrated {
+		return nil, fmt.Errorf("%w: concentrated pools have no shares to farm", ErrInvalidFarm)
+	}
+
+	params := db.CreateFarmParams{
+		PoolID:      toPgUUID(req.PoolID),
+		StartsAt:    pgtype.Timestamp{Time: start, Valid: true},
+		EndsAt:      pgtype.Timestamp{Time: req.EndsAt, Valid: true},
+		CreatedBy:   toPgUUID(adminID),
+		RewardToken: token,
+	}
+	if params.RewardPerDay, err = floatNumeric(perDay); err != nil {
+		return nil, err
+	}
+	farm, err := s.queries.CreateFarm(ctx, params)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: unknown reward token %s", ErrInvalidFarm, token)
+		}
+		return nil, err
+	}
+	id, _ := pgToUUID(farm.ID)
+	return s.GetFarm(ctx, id)
+}
+
+// ListFarms returns farms newest first, leaving out ended ones unless
+// includeEnded.
+func (s *LiquidityService) ListFarms(ctx context.Context, includeEnded bool) ([]models.Farm, error) {
+	rows, err := s.queries.ListFarms(ctx, includeEnded)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	out := make([]models.Farm, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, farmModel(db.GetFarmRow(r), now))
+	}
+	return out, nil
+}
+
+// GetFarm returns a farm by ID.
+func (s *LiquidityService) GetFarm(ctx context.Context, id uuid.UUID) (*models.Farm, error) {
+	row, err := s.queries.GetFarm(ctx, toPgUUID(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrFarmNotFound
+		}
+		return nil, err
+	}
+	f := farmModel(row, time.Now())
+	return &f, nil
+}
+
+func farmModel(r db.GetFarmRow, now time.Time) models.Farm {
+	id, _ := pgToUUID(r.ID)
+	poolID, _ := pgToUUID(r.PoolID)
+	f := models.Farm{
+		ID:           id,
+		PoolID:       poolID,
+		PoolName:     r.PoolName,
+		RewardToken:  r.RewardToken,
+		RewardPerDay: numericString(r.RewardPerDay),
+		StartsAt:     r.StartsAt.Time,
+		EndsAt:       r.EndsAt.Time,
+		TotalStaked:  numericString(r.TotalStaked),
+		RewardsPaid:  numericString(r.RewardsPaid),
+		IsLive:       !now.Before(r.StartsAt.Time) && now.Before(r.EndsAt.Time),
+		CreatedAt:    r.CreatedAt.Time,
+	}
+	if poolShares := numericFloat(r.PoolShares); poolShares > 0 {
+		staked := numericFloat(r.TotalStaked) / poolShares * numericFloat(r.PoolTvl)
+		if staked > 0 {
+			f.APR = numericFloat(r.RewardPerDay) * numericFloat(r.RewardPrice) * 365 / staked * 100
+		}
+	}
+	return f
+}
+
+// ListUserFarms returns the user's open farm stakes with the rewards they
+// have accrued so far.
+func (s *LiquidityService) ListUserFarms(ctx context.Context, userID uuid.UUID) ([]models.FarmStake, error) {
+	rows, err := s.queries.ListUserFarmStakes(ctx, toPgUUID(userID))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	out := make([]models.FarmStake, 0, len(rows))
+	for _, r := range rows {
+		acc := rewardPerShare(numericFloat(r.RewardPerShare), numericFloat(r.TotalStaked), numericFloat(r.RewardPerDay),
+			r.StartsAt.Time, r.EndsAt.Time, r.LastUpdateAt.Time, now)
+		id, _ := pgToUUID(r.ID)
+		farmID, _ := pgToUUID(r.FarmID)
+		positionID, _ := pgToUUID(r.PositionID)
+		poolID, _ := pgToUUID(r.PoolID)
+		out = append(out, models.FarmStake{
+			ID:             id,
+			FarmID:         farmID,
+			PositionID:     positionID,
+			PoolID:         poolID,
+			PoolName:       r.PoolName,
+			RewardToken:    r.RewardToken,
+			Shares:         numericString(r.Shares),
+			PendingRewards: formatAmount(earnedRewards(numericFloat(r.Shares), acc, numericFloat(r.RewardPerSharePaid), numericFloat(r.RewardsPending))),
+			RewardsClaimed: numericString(r.RewardsClaimed),
+			Status:         r.Status,
+			CreatedAt:      r.CreatedAt.Time,
+		})
+	}
+	return out, nil
+}
+
+// StakeFarm locks shares of the user's position in the farm's pool. Adding
+// to an existing stake settles its rewards first, so earlier shares keep
+// what they earned at the old rate.
+func (s *LiquidityService) StakeFarm(ctx context.Context, userID, farmID uuid.UUID, req models.FarmStakeRequest) (*models.FarmStake, error) {
+	amount, ok := parsePositiveAmount(req.Shares)
+	if !ok {
+		return nil, ErrInvalidLiquidityAmount
+	}
+
+	var stake *models.FarmStake
+	err := s.inTx(ctx, func(q liquidityQuerier) error {
+		now := time.Now()
+		farm, acc, err := lockFarm(ctx, q, farmID, now)
+		if err != nil {
+			return err
+		}
+		if !now.Before(farm.EndsAt.Time) {
+			return ErrFarmEnded
+		}
+		position, err := q.GetOpenPositionForUpdate(ctx, db.GetOpenPositionForUpdateParams{UserID: toPgUUID(userID), PoolID: farm.PoolID})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrPositionNotFound
+			}
+			return err
+		}
+		if amount > numericFloat(position.Shares)-numericFloat(position.FarmedShares) {
+			return ErrInsufficientShares
+		}
+
+		row, err := q.GetOpenFarmStakeForUpdate(ctx, db.GetOpenFarmStakeForUpdateParams{FarmID: farm.ID, UserID: toPgUUID(userID)})
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			params := db.CreateFarmStakeParams{FarmID: farm.ID, UserID: toPgUUID(userID), PositionID: position.ID}
+			if params.Shares, err = floatNumeric(amount); err != nil {
+				return err
+			}
+			if params.RewardPerSharePaid, err = floatNumeric(acc); err != nil {
+				return err
+			}
+			if row, err = q.CreateFarmStake(ctx, params); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			shares := numericFloat(row.Shares)
+			pending := earnedRewards(shares, acc, numericFloat(row.RewardPerSharePaid), numericFloat(row.RewardsPending))
+			if row, err = updateFarmStake(ctx, q, row, shares+amount, acc, pending, numericFloat(row.RewardsClaimed), "active"); err != nil {
+				return err
+			}
+		}
+
+		delta, err := floatNumeric(amount)
+		if err != nil {
+			return err
+		}
+		if err := q.AddFarmedShares(ctx, db.AddFarmedSharesParams{ID: position.ID, Delta: delta}); err != nil {
+			return err
+		}
+		if err := updateFarm(ctx, q, farm, acc, now, numericFloat(farm.TotalStaked)+amount, numericFloat(farm.RewardsPaid)); err != nil {
+			return err
+		}
+		m := farmStakeModel(row, farm, acc)
+		stake = &m
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stake, nil
+}
+
+// ClaimFarmRewards settles the rewards accrued on the user's stake in a
+// farm and pays them out.
+func (s *LiquidityService) ClaimFarmRewards(ctx context.Context, userID, farmID uuid.UUID) (string, error) {
+	exit, err := s.settleFarmStake(ctx, userID, farmID, false)
+	if err != nil {
+		return "0", err
+	}
+	return exit.Claimed, nil
+}
+
+// ExitFarm claims the user's rewards from a farm and unlocks all of their
+// shares.
+func (s *LiquidityService) ExitFarm(ctx context.Context, userID, farmID uuid.UUID) (*models.FarmExit, error) {
+	return s.settleFarmStake(ctx, userID, farmID, true)
+}
+
+func (s *LiquidityService) settleFarmStake(ctx context.Context, userID, farmID uuid.UUID, exit bool) (*models.FarmExit, error) {
+	var result *models.FarmExit
+	err := s.inTx(ctx, func(q liquidityQuerier) error {
+		now := time.Now()
+		farm, acc, err := lockFarm(ctx, q, farmID, now)
+		if err != nil {
+			return err
+		}
+		row, err := q.GetOpenFarmStakeForUpdate(ctx, db.GetOpenFarmStakeForUpdateParams{FarmID: farm.ID, UserID: toPgUUID(userID)})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrFarmStakeNotFound
+			}
+			return err
+		}
+
+		shares := numericFloat(row.Shares)
+		claimed := earnedRewards(shares, acc, numericFloat(row.RewardPerSharePaid), numericFloat(row.RewardsPending))
+		remaining, status, released := shares, "active", 0.0
+		if exit {
+			remaining, status, released = 0, "exited", shares
+		}
+		if row, err = updateFarmStake(ctx, q, row, remaining, acc, 0, numericFloat(row.RewardsClaimed)+claimed, status); err != nil {
+			return err
+		}
+		if released > 0 {
+			delta, err := floatNumeric(-released)
+			if err != nil {
+				return err
+			}
+			if err := q.AddFarmedShares(ctx, db.AddFarmedSharesParams{ID: row.PositionID, Delta: delta}); err != nil {
+				return err
+			}
+		}
+		if err := updateFarm(ctx, q, farm, acc, now, numericFloat(farm.TotalStaked)-released, numericFloat(farm.RewardsPaid)+claimed); err != nil {
+			return err
+		}
+		result = &models.FarmExit{
+			Shares:  formatAmount(released),
+			Claimed: formatAmount(claimed),
+			Stake:   farmStakeModel(row, farm, acc),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// lockFarm locks a farm and returns it with its accumulator advanced to
+// now.
+func lockFarm(ctx context.Context, q liquidityQuerier, farmID uuid.UUID, now time.Time) (db.LiquidityFarm, float64, error) {
+	farm, err := q.GetFarmForUpdate(ctx, toPgUUID(farmID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return farm, 0, ErrFarmNotFound
+		}
+		return farm, 0, err
+	}
+	acc := rewardPerShare(numericFloat(farm.RewardPerShare), numericFloat(farm.TotalStaked), numericFloat(farm.RewardPerDay),
+		farm.StartsAt.Time, farm.EndsAt.Time, farm.LastUpdateAt.Time, now)
+	return farm, acc, nil
+}
+
+func updateFarm(ctx context.Context, q liquidityQuerier, farm db.LiquidityFarm, acc float64, now time.Time, staked, paid float64) error {
+	params := db.UpdateFarmParams{ID: farm.ID, LastUpdateAt: pgtype.Timestamp{Time: now, Valid: true}}
+	var err error
+	if params.RewardPerShare, err = floatNumeric(acc); err != nil {
+		return err
+	}
+	if params.TotalStaked, err = floatNumeric(staked); err != nil {
+		return err
+	}
+	if params.RewardsPaid, err = floatNumeric(paid); err != nil {
+		return err
+	}
+	return q.UpdateFarm(ctx, params)
+}
+
+func updateFarmStake(ctx context.Context, q liquidityQuerier, row db.FarmStake, shares, acc, pending, claimed float64, status string) (db.FarmStake, error) {
+	params := db.UpdateFarmStakeParams{ID: row.ID, Status: status}
+	var err error
+	if params.Shares, err = floatNumeric(shares); err != nil {
+		return row, err
+	}
+	if params.RewardPerSharePaid, err = floatNumeric(acc); err != nil {
+		return row, err
+	}
+	if params.RewardsPending, err = floatNumeric(pending); err != nil {
+		return row, err
+	}
+	if params.RewardsClaimed, err = floatNumeric(claimed); err != nil {
+		return row, err
+	}
+	return q.UpdateFarmStake(ctx, params)
+}
+
+func farmStakeModel(row db.FarmStake, farm db.LiquidityFarm, acc float64) models.FarmStake {
+	id, _ := pgToUUID(row.ID)
+	farmID, _ := pgToUUID(row.FarmID)
+	positionID, _ := pgToUUID(row.PositionID)
+	poolID, _ := pgToUUID(farm.PoolID)
+	return models.FarmStake{
+		ID:             id,
+		FarmID:         farmID,
+		PositionID:     positionID,
+		PoolID:         poolID,
+		Shares:         numericString(row.Shares),
+		PendingRewards: formatAmount(earnedRewards(numericFloat(row.Shares), acc, numericFloat(row.RewardPerSharePaid), numericFloat(row.RewardsPending))),
+		RewardsClaimed: numericString(row.RewardsClaimed),
+		Status:         row.Status,
+		CreatedAt:      row.CreatedAt.Time,
+	}
+}
@@ -3,22 +3,149 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jd7008911/aogeri-api/internal/config"
 	"github.com/jd7008911/aogeri-api/internal/db"
 	"github.com/jd7008911/aogeri-api/internal/models"
 )
 
+var (
+	ErrProposalNotFound     = errors.New("proposal not found")
+	ErrInvalidProposal      = errors.New("invalid proposal")
+	ErrProposerIneligible   = errors.New("insufficient staked balance to create a proposal")
+	ErrTooManyOpenProposals = errors.New("too many open proposals")
+	ErrVotingClosed         = errors.New("proposal is not open for voting")
+	ErrNoVotingPower        = errors.New("no active stake to vote with")
+	ErrCannotCancel         = errors.New("only passed proposals still in their timelock can be cancelled")
+)
+
+// ProposalType describes a kind of proposal that can be submitted, the
+// quorum and threshold it gets when the proposer does not set them and the
+// payload actions it may carry.
+type ProposalType struct {
+	Name             string   `json:"name"`
+	Description      string   `json:"description"`
+	DefaultQuorum    float64  `json:"default_quorum"`
+	DefaultThreshold float64  `json:"default_threshold"`
+	VotingMethod     string   `json:"voting_method"`
+	Actions          []string `json:"actions,omitempty"`
+}
+
+// proposalTypes is the registry of accepted proposal_type values.
+var proposalTypes = map[string]ProposalType{
+	"fee_change": {Name: "fee_change", Description: "Change protocol or pool fees", DefaultQuorum: 20, DefaultThreshold: 50,
+		VotingMethod: MethodTokenWeighted, Actions: []string{ActionSetPoolFee}},
+	"parameter_change": {Name: "parameter_change", Description: "Change a protocol parameter", DefaultQuorum: 20, DefaultThreshold: 50,
+		VotingMethod: MethodTokenWeighted, Actions: []string{ActionSetSecurityThreshold, ActionSetProductAPY}},
+	"protocol_upgrade": {Name: "protocol_upgrade", Description: "Upgrade protocol contracts or services", DefaultQuorum: 40, DefaultThreshold: 66,
+		VotingMethod: MethodTokenWeighted, Actions: []string{ActionAddToken, ActionSetTokenActive}},
+	"treasury_spend": {Name: "treasury_spend", Description: "Spend funds from the treasury", DefaultQuorum: 30, DefaultThreshold: 60,
+		VotingMethod: MethodTokenWeighted},
+	"staking_product": {Name: "staking_product", Description: "Add or change a staking product", DefaultQuorum: 20, DefaultThreshold: 50,
+		VotingMethod: MethodTokenWeighted, Actions: []string{ActionSetProductAPY}},
+	"text": {Name: "text", Description: "Non-binding signalling proposal", DefaultQuorum: 10, DefaultThreshold: 50,
+		VotingMethod: MethodTokenWeighted},
+	"community_signal": {Name: "community_signal", Description: "Non-binding signal counted by quadratic voting", DefaultQuorum: 10, DefaultThreshold: 50,
+		VotingMethod: MethodQuadratic},
+	"multi_option": {Name: "multi_option", Description: "Choose between several options by ranked-choice vote", DefaultQuorum: 10, DefaultThreshold: 50,
+		VotingMethod: MethodRankedChoice},
+}
+
+// ProposalTypes lists the registered proposal types by name.
+func ProposalTypes() []ProposalType {
+	out := make([]ProposalType, 0, len(proposalTypes))
+	for _, t := range proposalTypes {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+type governanceQuerier interface {
+	delegationQuerier
+	discussionQuerier
+	analyticsQuerier
+	GetActiveProposals(ctx context.Context) ([]db.GovernanceProposal, error)
+	GetProposalByID(ctx context.Context, id pgtype.UUID) (db.GovernanceProposal, error)
+	ListProposals(ctx context.Context, arg db.ListProposalsParams) ([]db.GovernanceProposal, error)
+	CountProposals(ctx context.Context, arg db.CountProposalsParams) (int64, error)
+	CountOpenProposalsByProposer(ctx context.Context, proposerID pgtype.UUID) (int64, error)
+	GetUserStakedBalance(ctx context.Context, arg db.GetUserStakedBalanceParams) (pgtype.Numeric, error)
+	CreateProposal(ctx context.Context, arg db.CreateProposalParams) (db.GovernanceProposal, error)
+	GetProposalForUpdate(ctx context.Context, id pgtype.UUID) (db.GovernanceProposal, error)
+	CreateVoteSnapshot(ctx context.Context, arg db.CreateVoteSnapshotParams) (int64, error)
+	ActivateProposal(ctx context.Context, id pgtype.UUID) (db.GovernanceProposal, error)
+	GetVoteSnapshot(ctx context.Context, arg db.GetVoteSnapshotParams) (db.GovernanceSnapshot, error)
+	TryAdvisoryLock(ctx context.Context, lockKey int64) (bool, error)
+	AdvisoryLockSubject(ctx context.Context, arg db.AdvisoryLockSubjectParams) error
+	ListProposalsToActivate(ctx context.Context) ([]pgtype.UUID, error)
+	ListProposalsToFinalize(ctx context.Context) ([]pgtype.UUID, error)
+	FinalizeProposal(ctx context.Context, arg db.FinalizeProposalParams) (db.GovernanceProposal, error)
+	ListProposalsToExecute(ctx context.Context) ([]pgtype.UUID, error)
+	MarkProposalExecuted(ctx context.Context, arg db.MarkProposalExecutedParams) (db.GovernanceProposal, error)
+	CancelProposal(ctx context.Context, arg db.CancelProposalParams) (db.GovernanceProposal, error)
+	CastVote(ctx context.Context, arg db.CastVoteParams) (db.UserVote, error)
+	ListEffectiveVotes(ctx context.Context, proposalID pgtype.UUID) ([]db.ListEffectiveVotesRow, error)
+	ListProposalBallots(ctx context.Context, proposalID pgtype.UUID) ([]db.ListProposalBallotsRow, error)
+	SetProposalTallies(ctx context.Context, arg db.SetProposalTalliesParams) (db.GovernanceProposal, error)
+	SnapshotDelegations(ctx context.Context, arg db.SnapshotDelegationsParams) error
+	SnapshotDelegates(ctx context.Context, proposalID pgtype.UUID) error
+	CountSnapshotDelegators(ctx context.Context, arg db.CountSnapshotDelegatorsParams) (int64, error)
+	GetDelegatedVotePower(ctx context.Context, arg db.GetDelegatedVotePowerParams) (pgtype.Numeric, error)
+	RecordProposalEvent(ctx context.Context, arg db.RecordProposalEventParams) error
+	ListProposalEvents(ctx context.Context, proposalID pgtype.UUID) ([]db.ProposalEvent, error)
+}
+
 type GovernanceService struct {
-	queries *db.Queries
+	queries   governanceQuerier
+	tx        TxRunner
+	cfg       config.GovernanceConfig
+	executors ProposalExecutors
 }
 
-func NewGovernanceService(queries *db.Queries, _ any) *GovernanceService {
+func NewGovernanceService(queries governanceQuerier, _ any) *GovernanceService {
 	return &GovernanceService{queries: queries}
 }
 
+// WithTx makes voting run inside a single transaction.
+func (g *GovernanceService) WithTx(tx TxRunner) *GovernanceService {
+	g.tx = tx
+	return g
+}
+
+// WithConfig sets the proposal eligibility rules and bounds.
+func (g *GovernanceService) WithConfig(cfg config.GovernanceConfig) *GovernanceService {
+	g.cfg = cfg
+	return g
+}
+
+// WithExecutors sets the services that passed proposals apply their
+// payloads through.
+func (g *GovernanceService) WithExecutors(e ProposalExecutors) *GovernanceService {
+	g.executors = e
+	return g
+}
+
+// inTx runs fn in a transaction when a TxRunner is configured, otherwise
+// directly against the service queries.
+func (g *GovernanceService) inTx(ctx context.Context, fn func(q governanceQuerier) error) error {
+	if g.tx == nil {
+		return fn(g.queries)
+	}
+	return g.tx.ExecTx(ctx, func(q *db.Queries) error { return fn(q) })
+}
+
 func (g *GovernanceService) GetActiveProposals(ctx context.Context) ([]models.Proposal, error) {
 	rows, err := g.queries.GetActiveProposals(ctx)
 	if err != nil {
@@ -26,66 +153,792 @@ func (g *GovernanceService) GetActiveProposals(ctx context.Context) ([]models.Pr
 	}
 	out := make([]models.Proposal, 0, len(rows))
 	for _, r := range rows {
-		var id uuid.UUID
-		if r.ID.Valid {
-			id, _ = uuid.FromBytes(r.ID.Bytes[:])
+		out = append(out, proposalModel(r))
+	}
+	return out, nil
+}
+
+// ListProposals returns a page of proposals filtered by status and type
+// (empty means any) together with the total number of matches.
+func (g *GovernanceService) ListProposals(ctx context.Context, status, proposalType string, limit, offset int) ([]models.Proposal, int64, error) {
+	filter := db.CountProposalsParams{
+		Status:       pgtype.Text{String: status, Valid: status != ""},
+		ProposalType: pgtype.Text{String: proposalType, Valid: proposalType != ""},
+	}
+	rows, err := g.queries.ListProposals(ctx, db.ListProposalsParams{
+		Status:       filter.Status,
+		ProposalType: filter.ProposalType,
+		PageLimit:    int32(limit),
+		PageOffset:   int32(offset),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := g.queries.CountProposals(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	out := make([]models.Proposal, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, proposalModel(r))
+	}
+	return out, total, nil
+}
+
+func (g *GovernanceService) GetProposal(ctx context.Context, id uuid.UUID) (models.Proposal, error) {
+	r, err := g.queries.GetProposalByID(ctx, toPgUUID(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Proposal{}, ErrProposalNotFound
+		}
+		return models.Proposal{}, err
+	}
+	return proposalModel(r), nil
+}
+
+// CreateProposal validates req against the type registry and the configured
+// bounds, checks the proposer's eligibility and stores the proposal. It is
+// activated straight away when its voting window has already opened.
+func (g *GovernanceService) CreateProposal(ctx context.Context, proposerID uuid.UUID, req models.CreateProposalRequest) (*models.Proposal, error) {
+	now := time.Now()
+	params, err := g.proposalParams(req, now)
+	if err != nil {
+		return nil, err
+	}
+	params.ProposerID = toPgUUID(proposerID)
+
+	var row db.GovernanceProposal
+	err = g.inTx(ctx, func(q governanceQuerier) error {
+		staked, err := q.GetUserStakedBalance(ctx, db.GetUserStakedBalanceParams{
+			UserID: params.ProposerID,
+			Symbol: g.cfg.Token,
+		})
+		if err != nil {
+			return err
 		}
-		var proposer uuid.UUID
-		if r.ProposerID.Valid {
-			proposer, _ = uuid.FromBytes(r.ProposerID.Bytes[:])
+		if numericFloat(staked) < g.cfg.MinProposerStake {
+			return ErrProposerIneligible
 		}
 
-		quorum := 0.0
-		if r.Quorum.Valid {
-			if fv, err := r.Quorum.Float64Value(); err == nil {
-				quorum = fv.Float64
+		// Serialise the proposer's submissions so concurrent requests cannot
+		// both pass the open-proposal cap.
+		if err := q.AdvisoryLockSubject(ctx, db.AdvisoryLockSubjectParams{
+			LockClass: lockClassProposer,
+			Subject:   proposerID.String(),
+		}); err != nil {
+			return err
+		}
+		open, err := q.CountOpenProposalsByProposer(ctx, params.ProposerID)
+		if err != nil {
+			return err
+		}
+		if g.cfg.MaxOpenProposals > 0 && open >= int64(g.cfg.MaxOpenProposals) {
+			return ErrTooManyOpenProposals
+		}
+
+		row, err = q.CreateProposal(ctx, params)
+		if err != nil {
+			return err
+		}
+		if err := recordProposalEvent(ctx, q, row.ID, EventCreated, params.ProposerID, nil); err != nil {
+			return err
+		}
+		if params.VotingStart.Time.After(now) {
+			return nil
+		}
+		row, err = g.activateProposal(ctx, q, row.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	p := proposalModel(row)
+	return &p, nil
+}
+
+// activateProposal snapshots the vote power of every eligible staker and
+// the delegations in force, then moves a pending proposal to active.
+func (g *GovernanceService) activateProposal(ctx context.Context, q governanceQuerier, id pgtype.UUID) (db.GovernanceProposal, error) {
+	if _, err := q.CreateVoteSnapshot(ctx, db.CreateVoteSnapshotParams{
+		ProposalID:    id,
+		LockWeighting: g.cfg.LockWeighting,
+		Symbol:        g.cfg.Token,
+	}); err != nil {
+		return db.GovernanceProposal{}, err
+	}
+	if err := q.SnapshotDelegations(ctx, db.SnapshotDelegationsParams{ProposalID: id, Symbol: g.cfg.Token}); err != nil {
+		return db.GovernanceProposal{}, err
+	}
+	// Delegates without stake of their own still need a snapshot row to vote.
+	if err := q.SnapshotDelegates(ctx, id); err != nil {
+		return db.GovernanceProposal{}, err
+	}
+	p, err := q.ActivateProposal(ctx, id)
+	if err != nil {
+		return p, err
+	}
+	err = recordProposalEvent(ctx, q, id, EventActivated, pgtype.UUID{}, map[string]string{
+		"eligible_power": numericString(p.EligiblePower),
+	})
+	return p, err
+}
+
+// recomputeProposalVotes retallies a proposal from its snapshot with its
+// voting method, following delegation chains to the first holder who voted
+// directly.
+func recomputeProposalVotes(ctx context.Context, q governanceQuerier, p db.GovernanceProposal) (db.GovernanceProposal, voteTally, error) {
+	method, ok := tallyMethods[p.VotingMethod]
+	if !ok {
+		return p, voteTally{}, fmt.Errorf("unknown voting method %q", p.VotingMethod)
+	}
+	rows, err := q.ListEffectiveVotes(ctx, p.ID)
+	if err != nil {
+		return p, voteTally{}, err
+	}
+	ballots, err := ballotsFromRows(rows)
+	if err != nil {
+		return p, voteTally{}, err
+	}
+	t := method.tally(len(proposalOptions(p.Options)), ballots)
+
+	params := db.SetProposalTalliesParams{ID: p.ID}
+	for _, v := range []struct {
+		dst *pgtype.Numeric
+		val float64
+	}{
+		{&params.ForVotes, t.forVotes},
+		{&params.AgainstVotes, t.againstVotes},
+		{&params.AbstainVotes, t.abstainVotes},
+		{&params.TotalVotes, t.participation},
+	} {
+		if *v.dst, err = floatNumeric(v.val); err != nil {
+			return p, t, err
+		}
+	}
+	if method.ranked {
+		votes := make([]string, len(t.optionVotes))
+		for i, v := range t.optionVotes {
+			votes[i] = formatAmount(v)
+		}
+		if params.OptionVotes, err = json.Marshal(votes); err != nil {
+			return p, t, err
+		}
+	}
+	p, err = q.SetProposalTallies(ctx, params)
+	return p, t, err
+}
+
+// CastVote records userID's vote on a proposal, replacing any earlier vote,
+// and recomputes the proposal tallies. Vote power comes from the snapshot
+// taken when the proposal became active, plus any power delegated to userID
+// by holders who have not voted themselves.
+func (g *GovernanceService) CastVote(ctx context.Context, proposalID, userID uuid.UUID, req models.VoteRequest) (*models.VoteReceipt, error) {
+	var receipt models.VoteReceipt
+	err := g.inTx(ctx, func(q governanceQuerier) error {
+		p, err := q.GetProposalForUpdate(ctx, toPgUUID(proposalID))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrProposalNotFound
 			}
+			return err
 		}
-		threshold := 0.0
-		if r.Threshold.Valid {
-			if fv, err := r.Threshold.Float64Value(); err == nil {
-				threshold = fv.Float64
+		now := time.Now()
+		if !votingOpen(p, now) {
+			return ErrVotingClosed
+		}
+		if p.Status.String == "pending" {
+			// The window opened before the scheduler got to it.
+			if p, err = g.activateProposal(ctx, q, p.ID); err != nil {
+				return err
 			}
 		}
 
-		forVotes := "0"
-		if r.ForVotes.Valid {
-			if fv, err := r.ForVotes.Float64Value(); err == nil {
-				forVotes = strconv.FormatFloat(fv.Float64, 'f', -1, 64)
+		method, ok := tallyMethods[p.VotingMethod]
+		if !ok {
+			return fmt.Errorf("unknown voting method %q", p.VotingMethod)
+		}
+		if err := validateBallot(method, len(proposalOptions(p.Options)), req); err != nil {
+			return err
+		}
+
+		snap, err := q.GetVoteSnapshot(ctx, db.GetVoteSnapshotParams{ProposalID: p.ID, UserID: toPgUUID(userID)})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrNoVotingPower
 			}
+			return err
 		}
-		againstVotes := "0"
-		if r.AgainstVotes.Valid {
-			if fv, err := r.AgainstVotes.Float64Value(); err == nil {
-				againstVotes = strconv.FormatFloat(fv.Float64, 'f', -1, 64)
+		if numericFloat(snap.VotePower) <= 0 {
+			delegators, err := q.CountSnapshotDelegators(ctx, db.CountSnapshotDelegatorsParams{
+				ProposalID: p.ID,
+				DelegateID: toPgUUID(userID),
+			})
+			if err != nil {
+				return err
 			}
+			if delegators == 0 {
+				return ErrNoVotingPower
+			}
+		}
+
+		params := db.CastVoteParams{
+			UserID:     toPgUUID(userID),
+			ProposalID: p.ID,
+			VotePower:  snap.VotePower,
+			VoteChoice: req.VoteChoice,
 		}
-		abstainVotes := "0"
-		if r.AbstainVotes.Valid {
-			if fv, err := r.AbstainVotes.Float64Value(); err == nil {
-				abstainVotes = strconv.FormatFloat(fv.Float64, 'f', -1, 64)
+		if method.ranked {
+			params.VoteChoice = "ranked"
+			if params.Ballot, err = json.Marshal(req.Ranking); err != nil {
+				return err
 			}
 		}
+		vote, err := q.CastVote(ctx, params)
+		if err != nil {
+			return err
+		}
+		updated, _, err := recomputeProposalVotes(ctx, q, p)
+		if err != nil {
+			return err
+		}
+		if err := recordVoteMilestones(ctx, q, updated); err != nil {
+			return err
+		}
+		delegated, err := q.GetDelegatedVotePower(ctx, db.GetDelegatedVotePowerParams{
+			ProposalID: p.ID,
+			UserID:     toPgUUID(userID),
+		})
+		if err != nil {
+			return err
+		}
+
+		receipt = models.VoteReceipt{
+			Vote: models.Vote{
+				ProposalID:     proposalID,
+				UserID:         userID,
+				VoteChoice:     vote.VoteChoice,
+				Ranking:        req.Ranking,
+				VotePower:      formatAmount(numericFloat(vote.VotePower) + numericFloat(delegated)),
+				DelegatedPower: numericString(delegated),
+				VotedAt:        vote.VotedAt.Time,
+			},
+			Proposal: proposalModel(updated),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &receipt, nil
+}
+
+// ProcessProposals activates pending proposals whose voting window has
+// opened, finalizes active proposals whose window has closed and executes
+// passed ones whose timelock has elapsed. Only the replica holding the
+// governance advisory lock does the work. The lock is held by an outer
+// transaction while each proposal moves in a transaction of its own, so a
+// proposal that fails is logged and retried on the next run without
+// rolling back or holding up the others. Transitions are conditional on
+// the current status so reruns are no-ops.
+func (g *GovernanceService) ProcessProposals(ctx context.Context) error {
+	return g.inTx(ctx, func(lock governanceQuerier) error {
+		leader, err := lock.TryAdvisoryLock(ctx, lockKeyGovernance)
+		if err != nil || !leader {
+			return err
+		}
+
+		toActivate, err := lock.ListProposalsToActivate(ctx)
+		if err != nil {
+			return err
+		}
+		activated := g.processEach(ctx, "activate", toActivate, func(q governanceQuerier, id pgtype.UUID) error {
+			if _, err := g.activateProposal(ctx, q, id); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				return err
+			}
+			return nil
+		})
+
+		toFinalize, err := lock.ListProposalsToFinalize(ctx)
+		if err != nil {
+			return err
+		}
+		finalized := g.processEach(ctx, "finalize", toFinalize, func(q governanceQuerier, id pgtype.UUID) error {
+			return g.finalizeProposal(ctx, q, id)
+		})
+
+		toExecute, err := lock.ListProposalsToExecute(ctx)
+		if err != nil {
+			return err
+		}
+		executed := g.processEach(ctx, "execute", toExecute, func(q governanceQuerier, id pgtype.UUID) error {
+			return g.executeProposal(ctx, q, id)
+		})
+		if activated > 0 || finalized > 0 || executed > 0 {
+			log.Printf("governance: %d proposals activated, %d finalized, %d executed",
+				activated, finalized, executed)
+		}
+		return ctx.Err()
+	})
+}
+
+// processEach runs step for each proposal in its own transaction, logging
+// and skipping those that fail. It returns how many succeeded.
+func (g *GovernanceService) processEach(ctx context.Context, stage string, ids []pgtype.UUID, step func(q governanceQuerier, id pgtype.UUID) error) int {
+	done := 0
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := g.inTx(ctx, func(q governanceQuerier) error { return step(q, id) }); err != nil {
+			log.Printf("governance: %s proposal %s failed: %v", stage, uuid.UUID(id.Bytes), err)
+			continue
+		}
+		done++
+	}
+	return done
+}
+
+// finalizeProposal recomputes the tallies of a closed proposal and records
+// whether it passed, along with the Merkle root of its ballots so exports
+// can be checked against it. A passed proposal with a payload becomes executable
+// once the timelock has elapsed.
+func (g *GovernanceService) finalizeProposal(ctx context.Context, q governanceQuerier, id pgtype.UUID) error {
+	p, err := q.GetProposalForUpdate(ctx, id)
+	if err != nil {
+		return err
+	}
+	p, tally, err := recomputeProposalVotes(ctx, q, p)
+	if err != nil {
+		return err
+	}
+	result := proposalResult(p, tally)
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	ballots, err := listBallots(ctx, q, id)
+	if err != nil {
+		return err
+	}
+	var executableAt pgtype.Timestamp
+	if result.Outcome == "passed" && len(p.Payload) > 0 {
+		executableAt = pgtype.Timestamp{Time: time.Now().Add(g.cfg.Timelock), Valid: true}
+	}
+	_, err = q.FinalizeProposal(ctx, db.FinalizeProposalParams{
+		ID:           id,
+		Status:       pgtype.Text{String: result.Outcome, Valid: true},
+		Result:       raw,
+		ExecutableAt: executableAt,
+		BallotRoot:   pgtype.Text{String: BallotRoot(ballots), Valid: true},
+		BallotCount:  pgtype.Int4{Int32: int32(len(ballots)), Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	return recordProposalEvent(ctx, q, id, EventClosed, pgtype.UUID{}, result)
+}
+
+// executeProposal applies the payload of a passed proposal whose timelock
+// has elapsed and marks it executed, or failed when the payload is
+// rejected. It runs in the proposal's own transaction, with the status
+// update as its last step, and any other error is returned so the
+// proposal stays passed and is retried on the next run. Executors write
+// outside that transaction, so payload actions are idempotent and a run
+// interrupted before the status update is safe to repeat.
+func (g *GovernanceService) executeProposal(ctx context.Context, q governanceQuerier, id pgtype.UUID) error {
+	p, err := q.GetProposalForUpdate(ctx, id)
+	if err != nil {
+		return err
+	}
+	if p.Status.String != "passed" {
+		return nil
+	}
 
-		var votingEnd time.Time
-		if r.VotingEnd.Valid {
-			votingEnd = r.VotingEnd.Time
-		}
-
-		out = append(out, models.Proposal{
-			ID:           id,
-			Title:        r.Title,
-			Description:  r.Description,
-			ProposerID:   proposer,
-			Type:         r.ProposalType,
-			Status:       r.Status.String,
-			VotingEnd:    votingEnd,
-			Quorum:       quorum,
-			Threshold:    threshold,
-			ForVotes:     forVotes,
-			AgainstVotes: againstVotes,
-			AbstainVotes: abstainVotes,
+	params := db.MarkProposalExecutedParams{ID: id, Status: pgtype.Text{String: "executed", Valid: true}}
+	if err := g.executePayload(ctx, p); err != nil {
+		if errors.Is(err, errExecutorUnavailable) {
+			// Leave it passed so it runs once the executor is wired up.
+			log.Printf("governance: proposal %s not executed: %v", uuid.UUID(id.Bytes), err)
+			return nil
+		}
+		if !payloadRejected(err) {
+			return err
+		}
+		params.Status.String = "failed"
+		params.ExecutionError = pgtype.Text{String: err.Error(), Valid: true}
+	}
+	if _, err = q.MarkProposalExecuted(ctx, params); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	if params.ExecutionError.Valid {
+		return recordProposalEvent(ctx, q, id, EventExecutionFailed, pgtype.UUID{}, map[string]string{
+			"error": params.ExecutionError.String,
 		})
 	}
+	return recordProposalEvent(ctx, q, id, EventExecuted, pgtype.UUID{}, nil)
+}
+
+// CancelProposal cancels a passed proposal during its timelock so that its
+// payload is never applied.
+func (g *GovernanceService) CancelProposal(ctx context.Context, proposalID, adminID uuid.UUID) (*models.Proposal, error) {
+	if _, err := g.queries.GetProposalByID(ctx, toPgUUID(proposalID)); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrProposalNotFound
+		}
+		return nil, err
+	}
+	var row db.GovernanceProposal
+	err := g.inTx(ctx, func(q governanceQuerier) error {
+		var err error
+		row, err = q.CancelProposal(ctx, db.CancelProposalParams{
+			ID:          toPgUUID(proposalID),
+			CancelledBy: toPgUUID(adminID),
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrCannotCancel
+			}
+			return err
+		}
+		return recordProposalEvent(ctx, q, row.ID, EventCancelled, toPgUUID(adminID), nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	p := proposalModel(row)
+	return &p, nil
+}
+
+// proposalResult evaluates quorum against the eligible vote power and the
+// threshold against the tally. For/against methods need more for than
+// against votes and a for share of the two at or above the threshold;
+// abstentions count toward quorum only. Ranked-choice proposals need a
+// runoff winner whose final-round share meets the threshold.
+func proposalResult(p db.GovernanceProposal, t voteTally) models.ProposalResult {
+	eligible := numericFloat(p.EligiblePower)
+
+	r := models.ProposalResult{
+		Outcome:       "rejected",
+		EligiblePower: formatAmount(eligible),
+		TotalVotes:    formatAmount(t.participation),
+		ForVotes:      formatAmount(t.forVotes),
+		AgainstVotes:  formatAmount(t.againstVotes),
+		AbstainVotes:  formatAmount(t.abstainVotes),
+		VotingMethod:  p.VotingMethod,
+	}
+	if eligible > 0 {
+		r.Turnout = t.participation / eligible * 100
+	}
+	r.QuorumMet = eligible > 0 && r.Turnout >= numericFloat(p.Quorum)
+
+	if tallyMethods[p.VotingMethod].ranked {
+		r.Rounds = t.rounds
+		if t.winner >= 0 {
+			winner := t.winner
+			r.WinningOption = &winner
+			if options := proposalOptions(p.Options); winner < len(options) {
+				r.Winner = options[winner]
+			}
+			r.ForShare = t.winnerShare
+		}
+		r.ThresholdMet = t.winner >= 0 && r.ForShare >= numericFloat(p.Threshold)
+	} else {
+		if t.forVotes+t.againstVotes > 0 {
+			r.ForShare = t.forVotes / (t.forVotes + t.againstVotes) * 100
+		}
+		r.ThresholdMet = t.forVotes > t.againstVotes && r.ForShare >= numericFloat(p.Threshold)
+	}
+	if r.QuorumMet && r.ThresholdMet {
+		r.Outcome = "passed"
+	}
+	return r
+}
+
+// votingOpen reports whether p accepts votes at now.
+func votingOpen(p db.GovernanceProposal, now time.Time) bool {
+	if !p.Status.Valid || (p.Status.String != "active" && p.Status.String != "pending") {
+		return false
+	}
+	if p.VotingStart.Valid && now.Before(p.VotingStart.Time) {
+		return false
+	}
+	return p.VotingEnd.Valid && now.Before(p.VotingEnd.Time)
+}
+
+// GetVotePower returns userID's snapshotted vote power on a proposal. Before
+// the proposal is active no snapshot exists and the power is reported as 0.
+func (g *GovernanceService) GetVotePower(ctx context.Context, proposalID, userID uuid.UUID) (*models.VotePower, error) {
+	p, err := g.queries.GetProposalByID(ctx, toPgUUID(proposalID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrProposalNotFound
+		}
+		return nil, err
+	}
+	out := &models.VotePower{ProposalID: proposalID, StakedAmount: "0", VotePower: "0", DelegatedPower: "0"}
+	if !p.SnapshotAt.Valid {
+		return out, nil
+	}
+	t := p.SnapshotAt.Time
+	out.SnapshotAt = &t
+
+	snap, err := g.queries.GetVoteSnapshot(ctx, db.GetVoteSnapshotParams{ProposalID: p.ID, UserID: toPgUUID(userID)})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return out, nil
+		}
+		return nil, err
+	}
+	out.StakedAmount = numericString(snap.StakedAmount)
+	out.VotePower = numericString(snap.VotePower)
+	if snap.DelegateID.Valid {
+		to, _ := pgToUUID(snap.DelegateID)
+		out.DelegatedTo = &to
+	}
+	delegated, err := g.queries.GetDelegatedVotePower(ctx, db.GetDelegatedVotePowerParams{
+		ProposalID: p.ID,
+		UserID:     toPgUUID(userID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out.DelegatedPower = numericString(delegated)
 	return out, nil
 }
+
+// proposalParams checks the request fields that do not need the database.
+func (g *GovernanceService) proposalParams(req models.CreateProposalRequest, now time.Time) (db.CreateProposalParams, error) {
+	var params db.CreateProposalParams
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrInvalidProposal, fmt.Sprintf(format, args...))
+	}
+
+	title := strings.TrimSpace(req.Title)
+	description := strings.TrimSpace(req.Description)
+	if title == "" || description == "" {
+		return params, invalid("title and description are required")
+	}
+	pt, ok := proposalTypes[req.ProposalType]
+	if !ok {
+		return params, invalid("unknown proposal_type %q", req.ProposalType)
+	}
+
+	start := now
+	if req.VotingStart != nil {
+		if req.VotingStart.Before(now.Add(-time.Minute)) {
+			return params, invalid("voting_start is in the past")
+		}
+		if g.cfg.MaxStartDelay > 0 && req.VotingStart.After(now.Add(g.cfg.MaxStartDelay)) {
+			return params, invalid("voting_start is more than %s away", g.cfg.MaxStartDelay)
+		}
+		if req.VotingStart.After(now) {
+			start = *req.VotingStart
+		}
+	}
+	period := req.VotingEnd.Sub(start)
+	if period < g.cfg.MinVotingPeriod {
+		return params, invalid("voting period must be at least %s", g.cfg.MinVotingPeriod)
+	}
+	if g.cfg.MaxVotingPeriod > 0 && period > g.cfg.MaxVotingPeriod {
+		return params, invalid("voting period must be at most %s", g.cfg.MaxVotingPeriod)
+	}
+
+	quorum := pt.DefaultQuorum
+	if req.Quorum != nil {
+		quorum = *req.Quorum
+	}
+	if quorum < g.cfg.MinQuorum || quorum > 100 {
+		return params, invalid("quorum must be between %s and 100", formatAmount(g.cfg.MinQuorum))
+	}
+	threshold := pt.DefaultThreshold
+	if req.Threshold != nil {
+		threshold = *req.Threshold
+	}
+	if threshold < g.cfg.MinThreshold || threshold > 100 {
+		return params, invalid("threshold must be between %s and 100", formatAmount(g.cfg.MinThreshold))
+	}
+
+	var options []byte
+	if tallyMethods[pt.VotingMethod].ranked {
+		if len(req.Options) < minProposalOptions || len(req.Options) > maxProposalOptions {
+			return params, invalid("%s proposals need between %d and %d options", pt.Name, minProposalOptions, maxProposalOptions)
+		}
+		seen := make(map[string]bool, len(req.Options))
+		cleaned := make([]string, 0, len(req.Options))
+		for _, o := range req.Options {
+			o = strings.TrimSpace(o)
+			if o == "" {
+				return params, invalid("options must not be blank")
+			}
+			if seen[strings.ToLower(o)] {
+				return params, invalid("option %q is listed twice", o)
+			}
+			seen[strings.ToLower(o)] = true
+			cleaned = append(cleaned, o)
+		}
+		var err error
+		if options, err = json.Marshal(cleaned); err != nil {
+			return params, err
+		}
+	} else if len(req.Options) > 0 {
+		return params, invalid("proposal_type %q does not take options", pt.Name)
+	}
+
+	var payload []byte
+	if req.Payload != nil {
+		if err := validatePayload(pt, req.Payload); err != nil {
+			return params, invalid("%v", err)
+		}
+		var err error
+		if payload, err = json.Marshal(req.Payload); err != nil {
+			return params, err
+		}
+	}
+
+	quorumN, err := floatNumeric(quorum)
+	if err != nil {
+		return params, err
+	}
+	thresholdN, err := floatNumeric(threshold)
+	if err != nil {
+		return params, err
+	}
+	return db.CreateProposalParams{
+		Title:        title,
+		Description:  description,
+		ProposalType: pt.Name,
+		VotingStart:  pgtype.Timestamp{Time: start, Valid: true},
+		VotingEnd:    pgtype.Timestamp{Time: req.VotingEnd, Valid: true},
+		Quorum:       quorumN,
+		Threshold:    thresholdN,
+		Status:       pgtype.Text{String: "pending", Valid: true},
+		Payload:      payload,
+		VotingMethod: pt.VotingMethod,
+		Options:      options,
+	}, nil
+}
+
+// proposalModel converts a proposal row into the API model.
+func proposalModel(r db.GovernanceProposal) models.Proposal {
+	var id uuid.UUID
+	if r.ID.Valid {
+		id, _ = uuid.FromBytes(r.ID.Bytes[:])
+	}
+	var proposer uuid.UUID
+	if r.ProposerID.Valid {
+		proposer, _ = uuid.FromBytes(r.ProposerID.Bytes[:])
+	}
+
+	quorum := 0.0
+	if r.Quorum.Valid {
+		if fv, err := r.Quorum.Float64Value(); err == nil {
+			quorum = fv.Float64
+		}
+	}
+	threshold := 0.0
+	if r.Threshold.Valid {
+		if fv, err := r.Threshold.Float64Value(); err == nil {
+			threshold = fv.Float64
+		}
+	}
+
+	forVotes := "0"
+	if r.ForVotes.Valid {
+		if fv, err := r.ForVotes.Float64Value(); err == nil {
+			forVotes = strconv.FormatFloat(fv.Float64, 'f', -1, 64)
+		}
+	}
+	againstVotes := "0"
+	if r.AgainstVotes.Valid {
+		if fv, err := r.AgainstVotes.Float64Value(); err == nil {
+			againstVotes = strconv.FormatFloat(fv.Float64, 'f', -1, 64)
+		}
+	}
+	abstainVotes := "0"
+	if r.AbstainVotes.Valid {
+		if fv, err := r.AbstainVotes.Float64Value(); err == nil {
+			abstainVotes = strconv.FormatFloat(fv.Float64, 'f', -1, 64)
+		}
+	}
+
+	var votingStart *time.Time
+	if r.VotingStart.Valid {
+		t := r.VotingStart.Time
+		votingStart = &t
+	}
+	var votingEnd time.Time
+	if r.VotingEnd.Valid {
+		votingEnd = r.VotingEnd.Time
+	}
+	var snapshotAt *time.Time
+	if r.SnapshotAt.Valid {
+		t := r.SnapshotAt.Time
+		snapshotAt = &t
+	}
+	timeOrNil := func(ts pgtype.Timestamp) *time.Time {
+		if !ts.Valid {
+			return nil
+		}
+		t := ts.Time
+		return &t
+	}
+	var payload *models.ProposalPayload
+	if len(r.Payload) > 0 {
+		var pl models.ProposalPayload
+		if err := json.Unmarshal(r.Payload, &pl); err == nil {
+			payload = &pl
+		}
+	}
+	var optionVotes []string
+	if len(r.OptionVotes) > 0 {
+		_ = json.Unmarshal(r.OptionVotes, &optionVotes)
+	}
+	var result *models.ProposalResult
+	if len(r.Result) > 0 {
+		var res models.ProposalResult
+		if err := json.Unmarshal(r.Result, &res); err == nil {
+			if r.FinalizedAt.Valid {
+				res.FinalizedAt = r.FinalizedAt.Time
+			}
+			result = &res
+		}
+	}
+
+	return models.Proposal{
+		ID:           id,
+		Title:        r.Title,
+		Description:  r.Description,
+		ProposerID:   proposer,
+		Type:         r.ProposalType,
+		Status:       r.Status.String,
+		VotingStart:  votingStart,
+		VotingEnd:    votingEnd,
+		Quorum:       quorum,
+		Threshold:    threshold,
+		ForVotes:     forVotes,
+		AgainstVotes: againstVotes,
+		AbstainVotes: abstainVotes,
+
+		EligiblePower: numericString(r.EligiblePower),
+		SnapshotAt:    snapshotAt,
+		Result:        result,
+
+		Payload:        payload,
+		ExecutableAt:   timeOrNil(r.ExecutableAt),
+		ExecutedAt:     timeOrNil(r.ExecutedAt),
+		ExecutionError: r.ExecutionError.String,
+		CancelledAt:    timeOrNil(r.CancelledAt),
+
+		DiscussionLocked: r.DiscussionLockedAt.Valid,
+
+		VotingMethod: r.VotingMethod,
+		Options:      proposalOptions(r.Options),
+		OptionVotes:  optionVotes,
+	}
+}
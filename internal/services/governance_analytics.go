@@ -0,0 +1,206 @@
+// internal/services/governance_analytics.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jd7008911/aogeri-api/internal/db"
+	"github.com/jd7008911/aogeri-api/internal/models"
+)
+
+// ErrInvalidInterval is returned for a turnout interval other than hour or
+// day.
+var ErrInvalidInterval = errors.New("interval must be hour or day")
+
+type analyticsQuerier interface {
+	GetUserVotes(ctx context.Context, arg db.GetUserVotesParams) ([]db.GetUserVotesRow, error)
+	CountUserVotes(ctx context.Context, userID pgtype.UUID) (int64, error)
+	ListProposalTurnout(ctx context.Context, arg db.ListProposalTurnoutParams) ([]db.ListProposalTurnoutRow, error)
+	ListProposalParticipation(ctx context.Context, arg db.ListProposalParticipationParams) ([]db.ListProposalParticipationRow, error)
+	GetParticipationSummary(ctx context.Context) (db.GetParticipationSummaryRow, error)
+	ListTopVoters(ctx context.Context, arg db.ListTopVotersParams) ([]db.ListTopVotersRow, error)
+}
+
+// GetTurnout returns the cumulative turnout of a proposal per hour or day
+// of its voting window. Power delegated to a voter counts from the time of
+// their latest vote.
+func (g *GovernanceService) GetTurnout(ctx context.Context, proposalID uuid.UUID, interval string) (*models.ProposalTurnout, error) {
+	if interval == "" {
+		interval = "hour"
+	}
+	if interval != "hour" && interval != "day" {
+		return nil, ErrInvalidInterval
+	}
+	p, err := proposalByID(ctx, g.queries, toPgUUID(proposalID))
+	if err != nil {
+		return nil, err
+	}
+	rows, err := g.queries.ListProposalTurnout(ctx, db.ListProposalTurnoutParams{ProposalID: p.ID, Bucket: interval})
+	if err != nil {
+		return nil, err
+	}
+
+	eligible := numericFloat(p.EligiblePower)
+	out := &models.ProposalTurnout{
+		ProposalID:    proposalID,
+		Interval:      interval,
+		EligiblePower: numericString(p.EligiblePower),
+		Points:        make([]models.TurnoutPoint, 0, len(rows)),
+	}
+	var voters int64
+	var power float64
+	for _, r := range rows {
+		voters += r.Voters
+		power += numericFloat(r.Power)
+		pt := models.TurnoutPoint{Time: r.Bucket.Time, Voters: voters, PowerCast: formatAmount(power)}
+		if eligible > 0 {
+			pt.Turnout = power / eligible * 100
+		}
+		out.Points = append(out.Points, pt)
+	}
+	return out, nil
+}
+
+// GetParticipation summarises turnout over all finalized proposals and
+// lists the most recently closed ones.
+func (g *GovernanceService) GetParticipation(ctx context.Context, limit, offset int) (*models.ParticipationReport, error) {
+	summary, err := g.queries.GetParticipationSummary(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := g.queries.ListProposalParticipation(ctx, db.ListProposalParticipationParams{
+		PageLimit:  int32(limit),
+		PageOffset: int32(offset),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := &models.ParticipationReport{
+		Proposals:      summary.Proposals,
+		AverageTurnout: numericFloat(summary.AverageTurnout),
+		UniqueVoters:   summary.UniqueVoters,
+		Recent:         make([]models.ProposalParticipation, 0, len(rows)),
+	}
+	for _, r := range rows {
+		id, _ := pgToUUID(r.ID)
+		pp := models.ProposalParticipation{
+			ProposalID:    id,
+			Title:         r.Title,
+			Status:        r.Status,
+			VotingMethod:  r.VotingMethod,
+			EligiblePower: numericString(r.EligiblePower),
+			TotalVotes:    numericString(r.TotalVotes),
+			Voters:        r.Voters,
+			Holders:       r.Holders,
+			FinalizedAt:   r.FinalizedAt.Time,
+		}
+		if eligible := numericFloat(r.EligiblePower); eligible > 0 {
+			pp.Turnout = numericFloat(r.TotalVotes) / eligible * 100
+		}
+		out.Recent = append(out.Recent, pp)
+	}
+	return out, nil
+}
+
+// ListTopVoters ranks users by the number of votes they cast.
+func (g *GovernanceService) ListTopVoters(ctx context.Context, limit, offset int) ([]models.TopVoter, error) {
+	rows, err := g.queries.ListTopVoters(ctx, db.ListTopVotersParams{PageLimit: int32(limit), PageOffset: int32(offset)})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]models.TopVoter, 0, len(rows))
+	for _, r := range rows {
+		id, _ := pgToUUID(r.UserID)
+		v := models.TopVoter{
+			UserID:            id,
+			WalletAddress:     r.WalletAddress,
+			VotesCast:         r.VotesCast,
+			EligibleProposals: r.EligibleProposals,
+			PowerCast:         numericString(r.PowerCast),
+		}
+		if r.EligibleProposals > 0 {
+			v.ParticipationRate = float64(r.VotesCast) / float64(r.EligibleProposals) * 100
+		}
+		if r.LastVotedAt.Valid {
+			t := r.LastVotedAt.Time
+			v.LastVotedAt = &t
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// GetVoteHistory lists a user's votes, newest first, with the outcome of
+// each finalized proposal and whether the user sided with the majority.
+func (g *GovernanceService) GetVoteHistory(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.VoteHistoryEntry, int64, error) {
+	uid := toPgUUID(userID)
+	total, err := g.queries.CountUserVotes(ctx, uid)
+	if err != nil {
+		return nil, 0, err
+	}
+	rows, err := g.queries.GetUserVotes(ctx, db.GetUserVotesParams{
+		UserID:     uid,
+		PageLimit:  int32(limit),
+		PageOffset: int32(offset),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	out := make([]models.VoteHistoryEntry, 0, len(rows))
+	for _, r := range rows {
+		id, _ := pgToUUID(r.ProposalID)
+		e := models.VoteHistoryEntry{
+			ProposalID:   id,
+			Title:        r.Title,
+			Status:       r.Status,
+			VotingMethod: r.VotingMethod,
+			VoteChoice:   r.VoteChoice,
+			VotePower:    numericString(r.VotePower),
+			VotedAt:      r.VotedAt.Time,
+		}
+		if len(r.Ballot) > 0 {
+			if err := json.Unmarshal(r.Ballot, &e.Ranking); err != nil {
+				return nil, 0, err
+			}
+		}
+		if len(r.Result) > 0 {
+			var res models.ProposalResult
+			if err := json.Unmarshal(r.Result, &res); err != nil {
+				return nil, 0, err
+			}
+			e.Outcome = res.Outcome
+			e.WithMajority = withMajority(e, res)
+		}
+		out = append(out, e)
+	}
+	return out, total, nil
+}
+
+// withMajority reports whether a vote sided with the larger of for and
+// against, or ranked the winning option first. It is nil for abstentions
+// and for/against ties.
+func withMajority(v models.VoteHistoryEntry, r models.ProposalResult) *bool {
+	var with bool
+	switch {
+	case len(v.Ranking) > 0:
+		if r.WinningOption == nil {
+			return nil
+		}
+		with = v.Ranking[0] == *r.WinningOption
+	case v.VoteChoice == "for" || v.VoteChoice == "against":
+		forVotes, _ := strconv.ParseFloat(r.ForVotes, 64)
+		againstVotes, _ := strconv.ParseFloat(r.AgainstVotes, 64)
+		if tied(forVotes, againstVotes) {
+			return nil
+		}
+		with = (forVotes > againstVotes) == (v.VoteChoice == "for")
+	default:
+		return nil
+	}
+	return &with
+}
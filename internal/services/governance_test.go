@@ -0,0 +1,1636 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jd7008911/aogeri-api/internal/config"
+	"github.com/jd7008911/aogeri-api/internal/db"
+	"github.com/jd7008911/aogeri-api/internal/models"
+)
+
+type fakeGovQueries struct {
+	staked string
+	open   int64
+	// subjectLocks are the per-subject advisory locks taken; countLocked
+	// records whether one was held when open proposals were counted.
+	subjectLocks []db.AdvisoryLockSubjectParams
+	countLocked  bool
+	proposals    map[pgtype.UUID]db.GovernanceProposal
+	created      *db.CreateProposalParams
+	power        map[pgtype.UUID]string
+	snapshots    map[pgtype.UUID]map[pgtype.UUID]string
+	votes        map[pgtype.UUID]db.CastVoteParams
+	follower     bool
+	// failFinalize makes FinalizeProposal fail for these proposals.
+	failFinalize map[pgtype.UUID]bool
+
+	missingUsers  map[pgtype.UUID]bool
+	delegations   map[string]db.VoteDelegation
+	snapDelegates map[pgtype.UUID]map[pgtype.UUID]pgtype.UUID
+	delegatesArg  db.ListDelegatesParams
+
+	comments  []db.ProposalComment
+	edits     []db.ProposalCommentEdit
+	reactions map[db.AddCommentReactionParams]bool
+	events    []db.ProposalEvent
+
+	turnout       []db.ListProposalTurnoutRow
+	participation db.GetParticipationSummaryRow
+	topVoters     []db.ListTopVotersRow
+}
+
+func (f *fakeGovQueries) GetActiveProposals(ctx context.Context) ([]db.GovernanceProposal, error) {
+	return nil, nil
+}
+func (f *fakeGovQueries) GetProposalByID(ctx context.Context, id pgtype.UUID) (db.GovernanceProposal, error) {
+	p, ok := f.proposals[id]
+	if !ok {
+		return p, pgx.ErrNoRows
+	}
+	return p, nil
+}
+func (f *fakeGovQueries) ListProposals(ctx context.Context, arg db.ListProposalsParams) ([]db.GovernanceProposal, error) {
+	return nil, nil
+}
+func (f *fakeGovQueries) CountProposals(ctx context.Context, arg db.CountProposalsParams) (int64, error) {
+	return 0, nil
+}
+func (f *fakeGovQueries) CountOpenProposalsByProposer(ctx context.Context, proposerID pgtype.UUID) (int64, error) {
+	f.countLocked = len(f.subjectLocks) > 0
+	return f.open, nil
+}
+func (f *fakeGovQueries) AdvisoryLockSubject(ctx context.Context, arg db.AdvisoryLockSubjectParams) error {
+	f.subjectLocks = append(f.subjectLocks, arg)
+	return nil
+}
+func (f *fakeGovQueries) GetUserStakedBalance(ctx context.Context, arg db.GetUserStakedBalanceParams) (pgtype.Numeric, error) {
+	var n pgtype.Numeric
+	err := n.Scan(f.staked)
+	return n, err
+}
+func (f *fakeGovQueries) CreateProposal(ctx context.Context, arg db.CreateProposalParams) (db.GovernanceProposal, error) {
+	f.created = &arg
+	if f.proposals == nil {
+		f.proposals = map[pgtype.UUID]db.GovernanceProposal{}
+	}
+	p := db.GovernanceProposal{
+		ID:           toPgUUID(uuid.New()),
+		Title:        arg.Title,
+		Description:  arg.Description,
+		ProposerID:   arg.ProposerID,
+		ProposalType: arg.ProposalType,
+		Status:       arg.Status,
+		VotingStart:  arg.VotingStart,
+		VotingEnd:    arg.VotingEnd,
+		Quorum:       arg.Quorum,
+		Threshold:    arg.Threshold,
+		Payload:      arg.Payload,
+		VotingMethod: arg.VotingMethod,
+		Options:      arg.Options,
+	}
+	f.proposals[p.ID] = p
+	return p, nil
+}
+
+func (f *fakeGovQueries) GetProposalForUpdate(ctx context.Context, id pgtype.UUID) (db.GovernanceProposal, error) {
+	return f.GetProposalByID(ctx, id)
+}
+func (f *fakeGovQueries) CreateVoteSnapshot(ctx context.Context, arg db.CreateVoteSnapshotParams) (int64, error) {
+	if f.snapshots == nil {
+		f.snapshots = map[pgtype.UUID]map[pgtype.UUID]string{}
+	}
+	if _, ok := f.snapshots[arg.ProposalID]; ok {
+		return 0, nil
+	}
+	snap := map[pgtype.UUID]string{}
+	for user, power := range f.power {
+		snap[user] = power
+	}
+	f.snapshots[arg.ProposalID] = snap
+	return int64(len(snap)), nil
+}
+func (f *fakeGovQueries) ActivateProposal(ctx context.Context, id pgtype.UUID) (db.GovernanceProposal, error) {
+	p, ok := f.proposals[id]
+	if !ok {
+		p = db.GovernanceProposal{ID: id}
+	}
+	p.Status = pgtype.Text{String: "active", Valid: true}
+	p.SnapshotAt = pgtype.Timestamp{Time: time.Now(), Valid: true}
+	total := 0.0
+	for _, v := range f.snapshots[id] {
+		n, _ := strconv.ParseFloat(v, 64)
+		total += n
+	}
+	p.EligiblePower, _ = floatNumeric(total)
+	if f.proposals != nil {
+		f.proposals[id] = p
+	}
+	return p, nil
+}
+func (f *fakeGovQueries) GetVoteSnapshot(ctx context.Context, arg db.GetVoteSnapshotParams) (db.GovernanceSnapshot, error) {
+	v, ok := f.snapshots[arg.ProposalID][arg.UserID]
+	if !ok {
+		return db.GovernanceSnapshot{}, pgx.ErrNoRows
+	}
+	var n pgtype.Numeric
+	_ = n.Scan(v)
+	return db.GovernanceSnapshot{
+		ProposalID:   arg.ProposalID,
+		UserID:       arg.UserID,
+		StakedAmount: n,
+		VotePower:    n,
+		DelegateID:   f.snapDelegates[arg.ProposalID][arg.UserID],
+	}, nil
+}
+func (f *fakeGovQueries) CastVote(ctx context.Context, arg db.CastVoteParams) (db.UserVote, error) {
+	if f.votes == nil {
+		f.votes = map[pgtype.UUID]db.CastVoteParams{}
+	}
+	f.votes[arg.UserID] = arg
+	return db.UserVote{UserID: arg.UserID, ProposalID: arg.ProposalID, VotePower: arg.VotePower, VoteChoice: arg.VoteChoice}, nil
+}
+
+// receiver follows holder's snapshotted delegation chain to the first user
+// who voted directly, mirroring the SQL tally.
+func (f *fakeGovQueries) receiver(proposalID, holder pgtype.UUID) (pgtype.UUID, bool) {
+	cur := holder
+	for depth := 0; depth <= maxDelegationDepth; depth++ {
+		if v, ok := f.votes[cur]; ok && v.ProposalID == proposalID {
+			return cur, true
+		}
+		next, ok := f.snapDelegates[proposalID][cur]
+		if !ok || depth == maxDelegationDepth {
+			break
+		}
+		cur = next
+	}
+	return pgtype.UUID{}, false
+}
+func (f *fakeGovQueries) ListEffectiveVotes(ctx context.Context, proposalID pgtype.UUID) ([]db.ListEffectiveVotesRow, error) {
+	power := map[pgtype.UUID]float64{}
+	roots := map[pgtype.UUID]float64{}
+	for holder, p := range f.snapshots[proposalID] {
+		to, ok := f.receiver(proposalID, holder)
+		if !ok {
+			continue
+		}
+		n, _ := strconv.ParseFloat(p, 64)
+		power[to] += n
+		roots[to] += math.Sqrt(n)
+	}
+	var out []db.ListEffectiveVotesRow
+	for user, p := range power {
+		v := f.votes[user]
+		row := db.ListEffectiveVotesRow{UserID: user, VoteChoice: v.VoteChoice, Ballot: v.Ballot}
+		row.VotePower, _ = floatNumeric(p)
+		row.RootPower, _ = floatNumeric(roots[user])
+		out = append(out, row)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return bytes.Compare(out[i].UserID.Bytes[:], out[j].UserID.Bytes[:]) < 0
+	})
+	return out, nil
+}
+func (f *fakeGovQueries) ListProposalBallots(ctx context.Context, proposalID pgtype.UUID) ([]db.ListProposalBallotsRow, error) {
+	var out []db.ListProposalBallotsRow
+	for holder, p := range f.snapshots[proposalID] {
+		row := db.ListProposalBallotsRow{Voter: holder, DelegateID: f.snapDelegates[proposalID][holder]}
+		_ = row.SnapshotPower.Scan(p)
+		if v, ok := f.votes[holder]; ok && v.ProposalID == proposalID {
+			row.VoteChoice = v.VoteChoice
+			row.Ballot = v.Ballot
+			row.VotedAt = pgtype.Timestamp{Time: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), Valid: true}
+		}
+		out = append(out, row)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return bytes.Compare(out[i].Voter.Bytes[:], out[j].Voter.Bytes[:]) < 0
+	})
+	return out, nil
+}
+func (f *fakeGovQueries) GetUserVotes(ctx context.Context, arg db.GetUserVotesParams) ([]db.GetUserVotesRow, error) {
+	var out []db.GetUserVotesRow
+	if v, ok := f.votes[arg.UserID]; ok && arg.PageOffset == 0 {
+		p := f.proposals[v.ProposalID]
+		out = append(out, db.GetUserVotesRow{
+			ProposalID:   v.ProposalID,
+			Title:        p.Title,
+			Status:       p.Status.String,
+			VotingMethod: p.VotingMethod,
+			VoteChoice:   v.VoteChoice,
+			Ballot:       v.Ballot,
+			VotePower:    v.VotePower,
+			VotedAt:      pgtype.Timestamp{Time: time.Now(), Valid: true},
+			Result:       p.Result,
+		})
+	}
+	return out, nil
+}
+func (f *fakeGovQueries) CountUserVotes(ctx context.Context, userID pgtype.UUID) (int64, error) {
+	if _, ok := f.votes[userID]; ok {
+		return 1, nil
+	}
+	return 0, nil
+}
+func (f *fakeGovQueries) ListProposalTurnout(ctx context.Context, arg db.ListProposalTurnoutParams) ([]db.ListProposalTurnoutRow, error) {
+	return f.turnout, nil
+}
+func (f *fakeGovQueries) ListProposalParticipation(ctx context.Context, arg db.ListProposalParticipationParams) ([]db.ListProposalParticipationRow, error) {
+	var out []db.ListProposalParticipationRow
+	for id, p := range f.proposals {
+		if !p.FinalizedAt.Valid {
+			continue
+		}
+		out = append(out, db.ListProposalParticipationRow{
+			ID: id, Title: p.Title, Status: p.Status.String, VotingMethod: p.VotingMethod,
+			EligiblePower: p.EligiblePower, TotalVotes: p.TotalVotes, FinalizedAt: p.FinalizedAt,
+		})
+	}
+	return out, nil
+}
+func (f *fakeGovQueries) GetParticipationSummary(ctx context.Context) (db.GetParticipationSummaryRow, error) {
+	return f.participation, nil
+}
+func (f *fakeGovQueries) ListTopVoters(ctx context.Context, arg db.ListTopVotersParams) ([]db.ListTopVotersRow, error) {
+	return f.topVoters, nil
+}
+func (f *fakeGovQueries) SetProposalTallies(ctx context.Context, arg db.SetProposalTalliesParams) (db.GovernanceProposal, error) {
+	p := f.proposals[arg.ID]
+	p.ForVotes = arg.ForVotes
+	p.AgainstVotes = arg.AgainstVotes
+	p.AbstainVotes = arg.AbstainVotes
+	p.TotalVotes = arg.TotalVotes
+	p.OptionVotes = arg.OptionVotes
+	f.proposals[arg.ID] = p
+	return p, nil
+}
+func (f *fakeGovQueries) SnapshotDelegations(ctx context.Context, arg db.SnapshotDelegationsParams) error {
+	if f.snapDelegates == nil {
+		f.snapDelegates = map[pgtype.UUID]map[pgtype.UUID]pgtype.UUID{}
+	}
+	snap := map[pgtype.UUID]pgtype.UUID{}
+	for _, d := range f.delegations {
+		if d.TokenSymbol.Valid && d.TokenSymbol.String != arg.Symbol {
+			continue
+		}
+		if _, ok := snap[d.DelegatorID]; ok && !d.TokenSymbol.Valid {
+			continue
+		}
+		snap[d.DelegatorID] = d.DelegateID
+		if _, ok := f.snapshots[arg.ProposalID][d.DelegatorID]; !ok {
+			f.snapshots[arg.ProposalID][d.DelegatorID] = "0"
+		}
+	}
+	f.snapDelegates[arg.ProposalID] = snap
+	return nil
+}
+func (f *fakeGovQueries) SnapshotDelegates(ctx context.Context, proposalID pgtype.UUID) error {
+	for _, to := range f.snapDelegates[proposalID] {
+		if _, ok := f.snapshots[proposalID][to]; !ok {
+			f.snapshots[proposalID][to] = "0"
+		}
+	}
+	return nil
+}
+func (f *fakeGovQueries) CountSnapshotDelegators(ctx context.Context, arg db.CountSnapshotDelegatorsParams) (int64, error) {
+	var n int64
+	for _, to := range f.snapDelegates[arg.ProposalID] {
+		if to == arg.DelegateID {
+			n++
+		}
+	}
+	return n, nil
+}
+func (f *fakeGovQueries) GetDelegatedVotePower(ctx context.Context, arg db.GetDelegatedVotePowerParams) (pgtype.Numeric, error) {
+	total := 0.0
+	for holder, power := range f.snapshots[arg.ProposalID] {
+		if holder == arg.UserID {
+			continue
+		}
+		if to, ok := f.receiver(arg.ProposalID, holder); ok && to == arg.UserID {
+			n, _ := strconv.ParseFloat(power, 64)
+			total += n
+		}
+	}
+	return floatNumeric(total)
+}
+func (f *fakeGovQueries) AdvisoryLock(ctx context.Context, lockKey int64) error {
+	return nil
+}
+func (f *fakeGovQueries) GetUserByID(ctx context.Context, id pgtype.UUID) (db.User, error) {
+	if f.missingUsers[id] {
+		return db.User{}, pgx.ErrNoRows
+	}
+	return db.User{ID: id}, nil
+}
+func delegationKey(delegator pgtype.UUID, symbol pgtype.Text) string {
+	id, _ := pgToUUID(delegator)
+	return id.String() + "/" + symbol.String
+}
+func (f *fakeGovQueries) UpsertDelegation(ctx context.Context, arg db.UpsertDelegationParams) (db.VoteDelegation, error) {
+	if f.delegations == nil {
+		f.delegations = map[string]db.VoteDelegation{}
+	}
+	d := db.VoteDelegation{DelegatorID: arg.DelegatorID, DelegateID: arg.DelegateID, TokenSymbol: arg.TokenSymbol}
+	f.delegations[delegationKey(arg.DelegatorID, arg.TokenSymbol)] = d
+	return d, nil
+}
+func (f *fakeGovQueries) DeleteDelegation(ctx context.Context, arg db.DeleteDelegationParams) (int64, error) {
+	key := delegationKey(arg.DelegatorID, arg.TokenSymbol)
+	if _, ok := f.delegations[key]; !ok {
+		return 0, nil
+	}
+	delete(f.delegations, key)
+	return 1, nil
+}
+func (f *fakeGovQueries) ListDelegationsByDelegator(ctx context.Context, delegatorID pgtype.UUID) ([]db.VoteDelegation, error) {
+	var out []db.VoteDelegation
+	for _, d := range f.delegations {
+		if d.DelegatorID == delegatorID {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+func (f *fakeGovQueries) ListDelegationsByDelegate(ctx context.Context, delegateID pgtype.UUID) ([]db.VoteDelegation, error) {
+	var out []db.VoteDelegation
+	for _, d := range f.delegations {
+		if d.DelegateID == delegateID {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+func (f *fakeGovQueries) GetEffectiveDelegate(ctx context.Context, arg db.GetEffectiveDelegateParams) (pgtype.UUID, error) {
+	if d, ok := f.delegations[delegationKey(arg.DelegatorID, arg.TokenSymbol)]; ok {
+		return d.DelegateID, nil
+	}
+	if d, ok := f.delegations[delegationKey(arg.DelegatorID, pgtype.Text{})]; ok {
+		return d.DelegateID, nil
+	}
+	return pgtype.UUID{}, pgx.ErrNoRows
+}
+func (f *fakeGovQueries) ListDelegationSymbols(ctx context.Context) ([]string, error) {
+	var out []string
+	for _, d := range f.delegations {
+		if d.TokenSymbol.Valid {
+			out = append(out, d.TokenSymbol.String)
+		}
+	}
+	return out, nil
+}
+func (f *fakeGovQueries) ListDelegates(ctx context.Context, arg db.ListDelegatesParams) ([]db.ListDelegatesRow, error) {
+	f.delegatesArg = arg
+	return nil, nil
+}
+func (f *fakeGovQueries) TryAdvisoryLock(ctx context.Context, lockKey int64) (bool, error) {
+	return !f.follower, nil
+}
+func (f *fakeGovQueries) listDue(status string, at func(db.GovernanceProposal) time.Time) []pgtype.UUID {
+	var ids []pgtype.UUID
+	for id, p := range f.proposals {
+		if p.Status.String == status && !at(p).After(time.Now()) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+func (f *fakeGovQueries) ListProposalsToActivate(ctx context.Context) ([]pgtype.UUID, error) {
+	return f.listDue("pending", func(p db.GovernanceProposal) time.Time { return p.VotingStart.Time }), nil
+}
+func (f *fakeGovQueries) ListProposalsToFinalize(ctx context.Context) ([]pgtype.UUID, error) {
+	return f.listDue("active", func(p db.GovernanceProposal) time.Time { return p.VotingEnd.Time }), nil
+}
+func (f *fakeGovQueries) FinalizeProposal(ctx context.Context, arg db.FinalizeProposalParams) (db.GovernanceProposal, error) {
+	if f.failFinalize[arg.ID] {
+		return db.GovernanceProposal{}, errors.New("finalize failed")
+	}
+	p, ok := f.proposals[arg.ID]
+	if !ok || p.Status.String != "active" {
+		return db.GovernanceProposal{}, pgx.ErrNoRows
+	}
+	p.Status = arg.Status
+	p.Result = arg.Result
+	p.ExecutableAt = arg.ExecutableAt
+	p.BallotRoot = arg.BallotRoot
+	p.BallotCount = arg.BallotCount
+	p.FinalizedAt = pgtype.Timestamp{Time: time.Now(), Valid: true}
+	f.proposals[arg.ID] = p
+	return p, nil
+}
+func (f *fakeGovQueries) ListProposalsToExecute(ctx context.Context) ([]pgtype.UUID, error) {
+	var ids []pgtype.UUID
+	for id, p := range f.proposals {
+		if p.Status.String == "passed" && p.ExecutableAt.Valid && !p.ExecutableAt.Time.After(time.Now()) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+func (f *fakeGovQueries) MarkProposalExecuted(ctx context.Context, arg db.MarkProposalExecutedParams) (db.GovernanceProposal, error) {
+	p, ok := f.proposals[arg.ID]
+	if !ok || p.Status.String != "passed" {
+		return db.GovernanceProposal{}, pgx.ErrNoRows
+	}
+	p.Status = arg.Status
+	p.ExecutionError = arg.ExecutionError
+	p.ExecutedAt = pgtype.Timestamp{Time: time.Now(), Valid: true}
+	f.proposals[arg.ID] = p
+	return p, nil
+}
+func (f *fakeGovQueries) CancelProposal(ctx context.Context, arg db.CancelProposalParams) (db.GovernanceProposal, error) {
+	p, ok := f.proposals[arg.ID]
+	if !ok || p.Status.String != "passed" || !p.ExecutableAt.Valid || !p.ExecutableAt.Time.After(time.Now()) {
+		return db.GovernanceProposal{}, pgx.ErrNoRows
+	}
+	p.Status = pgtype.Text{String: "cancelled", Valid: true}
+	p.CancelledAt = pgtype.Timestamp{Time: time.Now(), Valid: true}
+	p.CancelledBy = arg.CancelledBy
+	f.proposals[arg.ID] = p
+	return p, nil
+}
+
+func (f *fakeGovQueries) RecordProposalEvent(ctx context.Context, arg db.RecordProposalEventParams) error {
+	for _, e := range f.events {
+		if e.ProposalID == arg.ProposalID && e.EventType == arg.EventType {
+			return nil
+		}
+	}
+	f.events = append(f.events, db.ProposalEvent{
+		ID:         toPgUUID(uuid.New()),
+		ProposalID: arg.ProposalID,
+		EventType:  arg.EventType,
+		ActorID:    arg.ActorID,
+		Data:       arg.Data,
+		CreatedAt:  pgtype.Timestamp{Time: time.Now(), Valid: true},
+	})
+	return nil
+}
+func (f *fakeGovQueries) ListProposalEvents(ctx context.Context, proposalID pgtype.UUID) ([]db.ProposalEvent, error) {
+	var out []db.ProposalEvent
+	for _, e := range f.events {
+		if e.ProposalID == proposalID {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+func (f *fakeGovQueries) CreateComment(ctx context.Context, arg db.CreateCommentParams) (db.ProposalComment, error) {
+	c := db.ProposalComment{
+		ID:         toPgUUID(uuid.New()),
+		ProposalID: arg.ProposalID,
+		AuthorID:   arg.AuthorID,
+		ParentID:   arg.ParentID,
+		Body:       arg.Body,
+		CreatedAt:  pgtype.Timestamp{Time: time.Now(), Valid: true},
+	}
+	f.comments = append(f.comments, c)
+	return c, nil
+}
+func (f *fakeGovQueries) GetComment(ctx context.Context, id pgtype.UUID) (db.ProposalComment, error) {
+	for _, c := range f.comments {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return db.ProposalComment{}, pgx.ErrNoRows
+}
+func (f *fakeGovQueries) GetCommentForUpdate(ctx context.Context, id pgtype.UUID) (db.ProposalComment, error) {
+	return f.GetComment(ctx, id)
+}
+func (f *fakeGovQueries) ListProposalComments(ctx context.Context, proposalID pgtype.UUID) ([]db.ProposalComment, error) {
+	var out []db.ProposalComment
+	for _, c := range f.comments {
+		if c.ProposalID == proposalID {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+func (f *fakeGovQueries) updateComment(id pgtype.UUID, fn func(c *db.ProposalComment)) (db.ProposalComment, error) {
+	for i := range f.comments {
+		if f.comments[i].ID == id {
+			fn(&f.comments[i])
+			return f.comments[i], nil
+		}
+	}
+	return db.ProposalComment{}, pgx.ErrNoRows
+}
+func (f *fakeGovQueries) UpdateCommentBody(ctx context.Context, arg db.UpdateCommentBodyParams) (db.ProposalComment, error) {
+	return f.updateComment(arg.ID, func(c *db.ProposalComment) {
+		c.Body = arg.Body
+		c.EditedAt = pgtype.Timestamp{Time: time.Now(), Valid: true}
+	})
+}
+func (f *fakeGovQueries) CreateCommentEdit(ctx context.Context, arg db.CreateCommentEditParams) error {
+	f.edits = append(f.edits, db.ProposalCommentEdit{
+		CommentID:    arg.CommentID,
+		PreviousBody: arg.PreviousBody,
+		EditedAt:     pgtype.Timestamp{Time: time.Now(), Valid: true},
+	})
+	return nil
+}
+func (f *fakeGovQueries) ListCommentEdits(ctx context.Context, commentID pgtype.UUID) ([]db.ProposalCommentEdit, error) {
+	var out []db.ProposalCommentEdit
+	for i := len(f.edits) - 1; i >= 0; i-- {
+		if f.edits[i].CommentID == commentID {
+			out = append(out, f.edits[i])
+		}
+	}
+	return out, nil
+}
+func (f *fakeGovQueries) SetCommentHidden(ctx context.Context, arg db.SetCommentHiddenParams) (db.ProposalComment, error) {
+	return f.updateComment(arg.ID, func(c *db.ProposalComment) {
+		c.Hidden = arg.Hidden
+		c.HiddenBy = arg.HiddenBy
+	})
+}
+func (f *fakeGovQueries) AddCommentReaction(ctx context.Context, arg db.AddCommentReactionParams) (int64, error) {
+	if f.reactions == nil {
+		f.reactions = map[db.AddCommentReactionParams]bool{}
+	}
+	if f.reactions[arg] {
+		return 0, nil
+	}
+	f.reactions[arg] = true
+	return 1, nil
+}
+func (f *fakeGovQueries) RemoveCommentReaction(ctx context.Context, arg db.RemoveCommentReactionParams) (int64, error) {
+	key := db.AddCommentReactionParams(arg)
+	if !f.reactions[key] {
+		return 0, nil
+	}
+	delete(f.reactions, key)
+	return 1, nil
+}
+func (f *fakeGovQueries) ListProposalReactions(ctx context.Context, arg db.ListProposalReactionsParams) ([]db.ListProposalReactionsRow, error) {
+	type group struct {
+		comment  pgtype.UUID
+		reaction string
+	}
+	rows := map[group]*db.ListProposalReactionsRow{}
+	for r := range f.reactions {
+		c, err := f.GetComment(ctx, r.CommentID)
+		if err != nil || c.ProposalID != arg.ProposalID {
+			continue
+		}
+		k := group{r.CommentID, r.Reaction}
+		if rows[k] == nil {
+			rows[k] = &db.ListProposalReactionsRow{CommentID: r.CommentID, Reaction: r.Reaction}
+		}
+		rows[k].ReactionCount++
+		rows[k].Reacted = rows[k].Reacted || r.UserID == arg.UserID
+	}
+	var out []db.ListProposalReactionsRow
+	for _, r := range rows {
+		out = append(out, *r)
+	}
+	return out, nil
+}
+func (f *fakeGovQueries) SetDiscussionLock(ctx context.Context, arg db.SetDiscussionLockParams) (db.GovernanceProposal, error) {
+	p, ok := f.proposals[arg.ID]
+	if !ok {
+		return p, pgx.ErrNoRows
+	}
+	p.DiscussionLockedAt = pgtype.Timestamp{Time: time.Now(), Valid: arg.Locked}
+	p.DiscussionLockedBy = arg.LockedBy
+	f.proposals[arg.ID] = p
+	return p, nil
+}
+
+type fakeProducts struct {
+	apy map[uuid.UUID]float64
+	err error
+}
+
+func (f *fakeProducts) SetProductAPY(ctx context.Context, productID uuid.UUID, apy float64) error {
+	if f.err != nil {
+		return f.err
+	}
+	if _, ok := f.apy[productID]; !ok {
+		return ErrProductNotFound
+	}
+	f.apy[productID] = apy
+	return nil
+}
+
+type fakePools struct {
+	fees  map[uuid.UUID]int
+	tiers []int
+	audit models.AuditContext
+}
+
+func (f *fakePools) SetPoolFee(ctx context.Context, audit models.AuditContext, poolID uuid.UUID, feeBps int) (*models.Pool, error) {
+	if !slices.Contains(f.tiers, feeBps) {
+		return nil, fmt.Errorf("%w: fee_bps must be one of %v", ErrInvalidPool, f.tiers)
+	}
+	if _, ok := f.fees[poolID]; !ok {
+		return nil, ErrPoolNotFound
+	}
+	f.fees[poolID] = feeBps
+	f.audit = audit
+	return &models.Pool{ID: poolID}, nil
+}
+
+func testGovernanceConfig() config.GovernanceConfig {
+	return config.GovernanceConfig{
+		Token:            "AOG",
+		MinProposerStake: 1000,
+		MaxOpenProposals: 2,
+		MinVotingPeriod:  24 * time.Hour,
+		MaxVotingPeriod:  30 * 24 * time.Hour,
+		MaxStartDelay:    14 * 24 * time.Hour,
+		MinQuorum:        10,
+		MinThreshold:     50,
+		Timelock:         48 * time.Hour,
+	}
+}
+
+func TestCreateProposal_DefaultsFromRegistry(t *testing.T) {
+	fq := &fakeGovQueries{staked: "1500"}
+	g := NewGovernanceService(fq, nil).WithConfig(testGovernanceConfig())
+	proposer := uuid.New()
+
+	p, err := g.CreateProposal(context.Background(), proposer, models.CreateProposalRequest{
+		Title:        "Raise swap fee",
+		Description:  "Raise the swap fee to 0.35%",
+		ProposalType: "fee_change",
+		VotingEnd:    time.Now().Add(72 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Status != "active" || p.Quorum != 20 || p.Threshold != 50 {
+		t.Fatalf("unexpected proposal: %+v", p)
+	}
+	want := db.AdvisoryLockSubjectParams{LockClass: lockClassProposer, Subject: proposer.String()}
+	if len(fq.subjectLocks) != 1 || fq.subjectLocks[0] != want || !fq.countLocked {
+		t.Fatalf("expected the proposer lock before counting open proposals, got %+v", fq.subjectLocks)
+	}
+}
+
+func TestCreateProposal_Rules(t *testing.T) {
+	now := time.Now()
+	valid := func() models.CreateProposalRequest {
+		return models.CreateProposalRequest{
+			Title:        "Upgrade",
+			Description:  "Upgrade the staking contracts",
+			ProposalType: "protocol_upgrade",
+			VotingEnd:    now.Add(72 * time.Hour),
+		}
+	}
+	low := 5.0
+	later := now.Add(60 * time.Hour)
+
+	cases := []struct {
+		name   string
+		staked string
+		open   int64
+		mutate func(*models.CreateProposalRequest)
+		want   error
+	}{
+		{"unknown type", "1500", 0, func(r *models.CreateProposalRequest) { r.ProposalType = "airdrop" }, ErrInvalidProposal},
+		{"window too short", "1500", 0, func(r *models.CreateProposalRequest) { r.VotingEnd = now.Add(time.Hour) }, ErrInvalidProposal},
+		{"window too long", "1500", 0, func(r *models.CreateProposalRequest) { r.VotingEnd = now.Add(60 * 24 * time.Hour) }, ErrInvalidProposal},
+		{"window measured from start", "1500", 0, func(r *models.CreateProposalRequest) { r.VotingStart = &later }, ErrInvalidProposal},
+		{"quorum below minimum", "1500", 0, func(r *models.CreateProposalRequest) { r.Quorum = &low }, ErrInvalidProposal},
+		{"options on a for/against type", "1500", 0, func(r *models.CreateProposalRequest) { r.Options = []string{"a", "b"} }, ErrInvalidProposal},
+		{"ranked choice needs two options", "1500", 0, func(r *models.CreateProposalRequest) {
+			r.ProposalType, r.Options = "multi_option", []string{"only"}
+		}, ErrInvalidProposal},
+		{"ranked choice duplicate options", "1500", 0, func(r *models.CreateProposalRequest) {
+			r.ProposalType, r.Options = "multi_option", []string{"Blue", " blue "}
+		}, ErrInvalidProposal},
+		{"insufficient stake", "999", 0, nil, ErrProposerIneligible},
+		{"open proposal cap", "1500", 2, nil, ErrTooManyOpenProposals},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fq := &fakeGovQueries{staked: tc.staked, open: tc.open}
+			g := NewGovernanceService(fq, nil).WithConfig(testGovernanceConfig())
+			req := valid()
+			if tc.mutate != nil {
+				tc.mutate(&req)
+			}
+			if _, err := g.CreateProposal(context.Background(), uuid.New(), req); !errors.Is(err, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+			if fq.created != nil {
+				t.Fatalf("proposal must not be created")
+			}
+		})
+	}
+}
+
+func TestCreateProposal_FutureStartIsPending(t *testing.T) {
+	fq := &fakeGovQueries{staked: "1500"}
+	g := NewGovernanceService(fq, nil).WithConfig(testGovernanceConfig())
+	start := time.Now().Add(24 * time.Hour)
+
+	p, err := g.CreateProposal(context.Background(), uuid.New(), models.CreateProposalRequest{
+		Title:        "Treasury grant",
+		Description:  "Fund the audit",
+		ProposalType: "treasury_spend",
+		VotingStart:  &start,
+		VotingEnd:    start.Add(7 * 24 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Status != "pending" || p.VotingStart == nil || !p.VotingStart.Equal(start) {
+		t.Fatalf("unexpected proposal: %+v", p)
+	}
+}
+
+func TestGetProposal_NotFound(t *testing.T) {
+	g := NewGovernanceService(&fakeGovQueries{}, nil)
+	if _, err := g.GetProposal(context.Background(), uuid.New()); !errors.Is(err, ErrProposalNotFound) {
+		t.Fatalf("expected ErrProposalNotFound, got %v", err)
+	}
+}
+
+func openProposal(fq *fakeGovQueries, start, end time.Time, status string) uuid.UUID {
+	id := uuid.New()
+	if fq.proposals == nil {
+		fq.proposals = map[pgtype.UUID]db.GovernanceProposal{}
+	}
+	fq.proposals[toPgUUID(id)] = db.GovernanceProposal{
+		ID:           toPgUUID(id),
+		Status:       pgtype.Text{String: status, Valid: true},
+		VotingStart:  pgtype.Timestamp{Time: start, Valid: true},
+		VotingEnd:    pgtype.Timestamp{Time: end, Valid: true},
+		VotingMethod: MethodTokenWeighted,
+	}
+	return id
+}
+
+func TestCastVote_UsesSnapshotAndRevote(t *testing.T) {
+	now := time.Now()
+	voter := uuid.New()
+	fq := &fakeGovQueries{power: map[pgtype.UUID]string{toPgUUID(voter): "200"}}
+	g := NewGovernanceService(fq, nil).WithConfig(testGovernanceConfig())
+	// Pending with an open window: voting activates it and takes the snapshot.
+	pid := openProposal(fq, now.Add(-time.Hour), now.Add(time.Hour), "pending")
+
+	receipt, err := g.CastVote(context.Background(), pid, voter, models.VoteRequest{VoteChoice: "for"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if receipt.Vote.VotePower != "200" || receipt.Proposal.ForVotes != "200" || receipt.Proposal.Status != "active" {
+		t.Fatalf("unexpected receipt: %+v", receipt)
+	}
+
+	// Stake changes after the snapshot do not move vote power.
+	fq.power[toPgUUID(voter)] = "5000"
+	receipt, err = g.CastVote(context.Background(), pid, voter, models.VoteRequest{VoteChoice: "against"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if receipt.Proposal.ForVotes != "0" || receipt.Proposal.AgainstVotes != "200" {
+		t.Fatalf("vote change not reflected in tallies: %+v", receipt.Proposal)
+	}
+
+	// Users who staked after the snapshot cannot vote.
+	late := uuid.New()
+	fq.power[toPgUUID(late)] = "100"
+	if _, err := g.CastVote(context.Background(), pid, late, models.VoteRequest{VoteChoice: "for"}); !errors.Is(err, ErrNoVotingPower) {
+		t.Fatalf("expected ErrNoVotingPower, got %v", err)
+	}
+}
+
+func TestCastVote_Rejections(t *testing.T) {
+	now := time.Now()
+	fq := &fakeGovQueries{}
+	g := NewGovernanceService(fq, nil).WithConfig(testGovernanceConfig())
+	ended := openProposal(fq, now.Add(-2*time.Hour), now.Add(-time.Hour), "active")
+	if _, err := g.CastVote(context.Background(), ended, uuid.New(), models.VoteRequest{VoteChoice: "for"}); !errors.Is(err, ErrVotingClosed) {
+		t.Fatalf("expected ErrVotingClosed after voting_end, got %v", err)
+	}
+	notStarted := openProposal(fq, now.Add(time.Hour), now.Add(2*time.Hour), "pending")
+	if _, err := g.CastVote(context.Background(), notStarted, uuid.New(), models.VoteRequest{VoteChoice: "for"}); !errors.Is(err, ErrVotingClosed) {
+		t.Fatalf("expected ErrVotingClosed before voting_start, got %v", err)
+	}
+	if _, err := g.CastVote(context.Background(), uuid.New(), uuid.New(), models.VoteRequest{VoteChoice: "for"}); !errors.Is(err, ErrProposalNotFound) {
+		t.Fatalf("expected ErrProposalNotFound, got %v", err)
+	}
+}
+
+func TestGetVotePower(t *testing.T) {
+	now := time.Now()
+	voter := uuid.New()
+	fq := &fakeGovQueries{power: map[pgtype.UUID]string{toPgUUID(voter): "150"}}
+	g := NewGovernanceService(fq, nil).WithConfig(testGovernanceConfig())
+	pid := openProposal(fq, now.Add(time.Hour), now.Add(48*time.Hour), "pending")
+
+	power, err := g.GetVotePower(context.Background(), pid, voter)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if power.VotePower != "0" || power.SnapshotAt != nil {
+		t.Fatalf("expected no power before activation: %+v", power)
+	}
+
+	if _, err := g.activateProposal(context.Background(), fq, toPgUUID(pid)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	power, err = g.GetVotePower(context.Background(), pid, voter)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if power.VotePower != "150" || power.SnapshotAt == nil {
+		t.Fatalf("unexpected power: %+v", power)
+	}
+}
+
+func TestProcessProposals(t *testing.T) {
+	now := time.Now()
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	power := map[pgtype.UUID]string{toPgUUID(a): "600", toPgUUID(b): "300", toPgUUID(c): "100"}
+
+	cases := []struct {
+		name    string
+		quorum  float64
+		votes   map[uuid.UUID]string
+		outcome string
+	}{
+		{"passes", 10, map[uuid.UUID]string{a: "for", b: "against"}, "passed"},
+		{"quorum not met", 50, map[uuid.UUID]string{c: "for"}, "rejected"},
+		{"threshold not met", 10, map[uuid.UUID]string{a: "against", b: "for", c: "abstain"}, "rejected"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fq := &fakeGovQueries{power: power}
+			g := NewGovernanceService(fq, nil).WithConfig(testGovernanceConfig())
+			pid := openProposal(fq, now.Add(-time.Hour), now.Add(time.Hour), "pending")
+			p := fq.proposals[toPgUUID(pid)]
+			p.Quorum, _ = floatNumeric(tc.quorum)
+			p.Threshold, _ = floatNumeric(50)
+			fq.proposals[toPgUUID(pid)] = p
+
+			if err := g.ProcessProposals(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := fq.proposals[toPgUUID(pid)].Status.String; got != "active" {
+				t.Fatalf("expected active after start, got %s", got)
+			}
+			for voter, choice := range tc.votes {
+				if _, err := g.CastVote(context.Background(), pid, voter, models.VoteRequest{VoteChoice: choice}); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			p = fq.proposals[toPgUUID(pid)]
+			p.VotingEnd = pgtype.Timestamp{Time: now.Add(-time.Minute), Valid: true}
+			fq.proposals[toPgUUID(pid)] = p
+			if err := g.ProcessProposals(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got, err := g.GetProposal(context.Background(), pid)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Status != tc.outcome || got.Result == nil || got.Result.Outcome != tc.outcome {
+				t.Fatalf("expected %s, got %+v", tc.outcome, got)
+			}
+		})
+	}
+}
+
+func TestProcessProposals_SkipsWithoutLock(t *testing.T) {
+	now := time.Now()
+	fq := &fakeGovQueries{follower: true}
+	g := NewGovernanceService(fq, nil).WithConfig(testGovernanceConfig())
+	pid := openProposal(fq, now.Add(-2*time.Hour), now.Add(-time.Hour), "active")
+
+	if err := g.ProcessProposals(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := fq.proposals[toPgUUID(pid)].Status.String; got != "active" {
+		t.Fatalf("follower must not finalize, got %s", got)
+	}
+}
+
+func TestProcessProposals_IsolatesFailures(t *testing.T) {
+	now := time.Now()
+	fq := &fakeGovQueries{}
+	g := NewGovernanceService(fq, nil).WithConfig(testGovernanceConfig())
+	stuck := openProposal(fq, now.Add(-2*time.Hour), now.Add(-time.Hour), "active")
+	other := openProposal(fq, now.Add(-2*time.Hour), now.Add(-time.Hour), "active")
+	fq.failFinalize = map[pgtype.UUID]bool{toPgUUID(stuck): true}
+
+	if err := g.ProcessProposals(context.Background()); err != nil {
+		t.Fatalf("a failing proposal must not fail the run, got %v", err)
+	}
+	if got := fq.proposals[toPgUUID(other)].Status.String; got == "active" {
+		t.Fatalf("expected the other proposal to be finalized, got %s", got)
+	}
+	if got := fq.proposals[toPgUUID(stuck)].Status.String; got != "active" {
+		t.Fatalf("expected the failing proposal to stay active, got %s", got)
+	}
+
+	// It is retried on the next run.
+	fq.failFinalize = nil
+	if err := g.ProcessProposals(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := fq.proposals[toPgUUID(stuck)].Status.String; got == "active" {
+		t.Fatalf("expected the retried proposal to be finalized, got %s", got)
+	}
+}
+
+func apyPayload(productID uuid.UUID, apy float64) *models.ProposalPayload {
+	params, _ := json.Marshal(models.SetProductAPYParams{ProductID: productID, APY: apy})
+	return &models.ProposalPayload{Action: ActionSetProductAPY, Params: params}
+}
+
+func TestCreateProposal_PayloadValidation(t *testing.T) {
+	product := uuid.New()
+	cases := []struct {
+		name    string
+		typ     string
+		payload *models.ProposalPayload
+		ok      bool
+	}{
+		{"valid", "staking_product", apyPayload(product, 12), true},
+		{"action not allowed for type", "protocol_upgrade", apyPayload(product, 12), false},
+		{"text takes no payload", "text", apyPayload(product, 12), false},
+		{"apy out of range", "staking_product", apyPayload(product, 5000), false},
+		{"unknown params", "staking_product", &models.ProposalPayload{Action: ActionSetProductAPY, Params: json.RawMessage(`{"product":"x"}`)}, false},
+		{"fee tier out of range", "fee_change", poolFeePayload(uuid.New(), 0), false},
+		{"pool fee needs a pool", "fee_change", poolFeePayload(uuid.Nil, 30), false},
+		{"unknown threshold", "parameter_change", &models.ProposalPayload{Action: ActionSetSecurityThreshold, Params: json.RawMessage(`{"name":"nope","value":1}`)}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fq := &fakeGovQueries{staked: "1500"}
+			g := NewGovernanceService(fq, nil).WithConfig(testGovernanceConfig())
+			p, err := g.CreateProposal(context.Background(), uuid.New(), models.CreateProposalRequest{
+				Title:        "Change APY",
+				Description:  "Change the staking product APY",
+				ProposalType: tc.typ,
+				VotingEnd:    time.Now().Add(72 * time.Hour),
+				Payload:      tc.payload,
+			})
+			if tc.ok {
+				if err != nil || p.Payload == nil || p.Payload.Action != ActionSetProductAPY {
+					t.Fatalf("unexpected result: %+v, %v", p, err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidProposal) {
+				t.Fatalf("expected ErrInvalidProposal, got %v", err)
+			}
+		})
+	}
+}
+
+// passedProposal stores a passed proposal with payload whose timelock ends
+// at executableAt.
+func passedProposal(fq *fakeGovQueries, payload *models.ProposalPayload, executableAt time.Time) uuid.UUID {
+	now := time.Now()
+	id := openProposal(fq, now.Add(-72*time.Hour), now.Add(-time.Hour), "passed")
+	p := fq.proposals[toPgUUID(id)]
+	p.Payload, _ = json.Marshal(payload)
+	p.ExecutableAt = pgtype.Timestamp{Time: executableAt, Valid: true}
+	fq.proposals[toPgUUID(id)] = p
+	return id
+}
+
+func TestProcessProposals_TimelockAndExecution(t *testing.T) {
+	now := time.Now()
+	product := uuid.New()
+	voter := uuid.New()
+	products := &fakeProducts{apy: map[uuid.UUID]float64{product: 8}}
+	fq := &fakeGovQueries{power: map[pgtype.UUID]string{toPgUUID(voter): "100"}}
+	g := NewGovernanceService(fq, nil).
+		WithConfig(testGovernanceConfig()).
+		WithExecutors(ProposalExecutors{Products: products})
+
+	// Passing starts the timelock rather than executing straight away.
+	pid := openProposal(fq, now.Add(-time.Hour), now.Add(time.Hour), "active")
+	p := fq.proposals[toPgUUID(pid)]
+	p.Payload, _ = json.Marshal(apyPayload(product, 12))
+	p.Quorum, _ = floatNumeric(10)
+	p.Threshold, _ = floatNumeric(50)
+	p.EligiblePower, _ = floatNumeric(100)
+	fq.proposals[toPgUUID(pid)] = p
+	fq.snapshots = map[pgtype.UUID]map[pgtype.UUID]string{toPgUUID(pid): {toPgUUID(voter): "100"}}
+	if _, err := g.CastVote(context.Background(), pid, voter, models.VoteRequest{VoteChoice: "for"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p = fq.proposals[toPgUUID(pid)]
+	p.VotingEnd = pgtype.Timestamp{Time: now.Add(-time.Minute), Valid: true}
+	fq.proposals[toPgUUID(pid)] = p
+	if err := g.ProcessProposals(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := fq.proposals[toPgUUID(pid)]
+	if got.Status.String != "passed" || !got.ExecutableAt.Valid || got.ExecutableAt.Time.Before(now.Add(47*time.Hour)) {
+		t.Fatalf("expected passed with a 48h timelock, got %+v", got)
+	}
+	if products.apy[product] != 8 {
+		t.Fatalf("payload applied during timelock")
+	}
+
+	// Once the timelock has elapsed the payload is applied.
+	due := passedProposal(fq, apyPayload(product, 12), now.Add(-time.Minute))
+	missing := passedProposal(fq, apyPayload(uuid.New(), 5), now.Add(-time.Minute))
+	if err := g.ProcessProposals(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if products.apy[product] != 12 || fq.proposals[toPgUUID(due)].Status.String != "executed" {
+		t.Fatalf("expected executed proposal and new APY, got %v %+v", products.apy[product], fq.proposals[toPgUUID(due)])
+	}
+	failed := fq.proposals[toPgUUID(missing)]
+	if failed.Status.String != "failed" || failed.ExecutionError.String == "" {
+		t.Fatalf("expected failed execution, got %+v", failed)
+	}
+	if fq.proposals[toPgUUID(pid)].Status.String != "passed" {
+		t.Fatalf("timelocked proposal must stay passed")
+	}
+}
+
+func poolFeePayload(poolID uuid.UUID, feeBps int) *models.ProposalPayload {
+	params, _ := json.Marshal(models.SetPoolFeeParams{PoolID: poolID, FeeBps: feeBps})
+	return &models.ProposalPayload{Action: ActionSetPoolFee, Params: params}
+}
+
+func TestProcessProposals_SetPoolFee(t *testing.T) {
+	pool := uuid.New()
+	proposer := uuid.New()
+	pools := &fakePools{fees: map[uuid.UUID]int{pool: 30}, tiers: []int{5, 30, 100}}
+	fq := &fakeGovQueries{}
+	g := NewGovernanceService(fq, nil).
+		WithConfig(testGovernanceConfig()).
+		WithExecutors(ProposalExecutors{Pools: pools})
+	due := passedProposal(fq, poolFeePayload(pool, 100), time.Now().Add(-time.Minute))
+	p := fq.proposals[toPgUUID(due)]
+	p.ProposerID = toPgUUID(proposer)
+	fq.proposals[toPgUUID(due)] = p
+	untiered := passedProposal(fq, poolFeePayload(pool, 42), time.Now().Add(-time.Minute))
+
+	if err := g.ProcessProposals(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pools.fees[pool] != 100 || fq.proposals[toPgUUID(due)].Status.String != "executed" {
+		t.Fatalf("expected executed proposal and new fee, got %d %+v", pools.fees[pool], fq.proposals[toPgUUID(due)])
+	}
+	if pools.audit.UserID != proposer {
+		t.Fatalf("fee change audited as %v; want the proposer %v", pools.audit.UserID, proposer)
+	}
+	// A fee that is not a configured tier can never apply.
+	if got := fq.proposals[toPgUUID(untiered)]; got.Status.String != "failed" || got.ExecutionError.String == "" {
+		t.Fatalf("expected failed execution, got %+v", got)
+	}
+}
+
+func TestProcessProposals_TransientExecutionErrorRetries(t *testing.T) {
+	product := uuid.New()
+	products := &fakeProducts{apy: map[uuid.UUID]float64{product: 8}, err: errors.New("connection reset")}
+	fq := &fakeGovQueries{}
+	g := NewGovernanceService(fq, nil).
+		WithConfig(testGovernanceConfig()).
+		WithExecutors(ProposalExecutors{Products: products})
+	pid := passedProposal(fq, apyPayload(product, 12), time.Now().Add(-time.Minute))
+
+	if err := g.ProcessProposals(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := fq.proposals[toPgUUID(pid)]; got.Status.String != "passed" || got.ExecutionError.Valid {
+		t.Fatalf("a transient error must leave the proposal passed, got %+v", got)
+	}
+
+	products.err = nil
+	if err := g.ProcessProposals(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := fq.proposals[toPgUUID(pid)].Status.String; got != "executed" || products.apy[product] != 12 {
+		t.Fatalf("expected the retry to execute, got %s with apy %v", got, products.apy[product])
+	}
+}
+
+func TestProcessProposals_MissingExecutorLeavesPassed(t *testing.T) {
+	fq := &fakeGovQueries{}
+	g := NewGovernanceService(fq, nil).WithConfig(testGovernanceConfig())
+	pid := passedProposal(fq, apyPayload(uuid.New(), 12), time.Now().Add(-time.Minute))
+
+	if err := g.ProcessProposals(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := fq.proposals[toPgUUID(pid)].Status.String; got != "passed" {
+		t.Fatalf("expected passed, got %s", got)
+	}
+}
+
+func TestCancelProposal(t *testing.T) {
+	now := time.Now()
+	fq := &fakeGovQueries{}
+	g := NewGovernanceService(fq, nil).WithConfig(testGovernanceConfig())
+	locked := passedProposal(fq, apyPayload(uuid.New(), 12), now.Add(time.Hour))
+	expired := passedProposal(fq, apyPayload(uuid.New(), 12), now.Add(-time.Hour))
+
+	p, err := g.CancelProposal(context.Background(), locked, uuid.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Status != "cancelled" || p.CancelledAt == nil {
+		t.Fatalf("unexpected proposal: %+v", p)
+	}
+	if _, err := g.CancelProposal(context.Background(), expired, uuid.New()); !errors.Is(err, ErrCannotCancel) {
+		t.Fatalf("expected ErrCannotCancel after the timelock, got %v", err)
+	}
+	if _, err := g.CancelProposal(context.Background(), uuid.New(), uuid.New()); !errors.Is(err, ErrProposalNotFound) {
+		t.Fatalf("expected ErrProposalNotFound, got %v", err)
+	}
+}
+
+func TestCastVote_DelegatedPower(t *testing.T) {
+	now := time.Now()
+	alice, bob, carol := uuid.New(), uuid.New(), uuid.New()
+	fq := &fakeGovQueries{power: map[pgtype.UUID]string{
+		toPgUUID(alice): "100",
+		toPgUUID(bob):   "50",
+	}}
+	g := NewGovernanceService(fq, nil).WithConfig(testGovernanceConfig())
+	ctx := context.Background()
+
+	// alice -> bob -> carol; carol holds no stake.
+	if _, err := g.Delegate(ctx, alice, models.DelegateRequest{DelegateID: bob}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := g.Delegate(ctx, bob, models.DelegateRequest{DelegateID: carol, TokenSymbol: "aog"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pid := openProposal(fq, now.Add(-time.Hour), now.Add(time.Hour), "pending")
+
+	receipt, err := g.CastVote(ctx, pid, carol, models.VoteRequest{VoteChoice: "for"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if receipt.Vote.VotePower != "150" || receipt.Vote.DelegatedPower != "150" || receipt.Proposal.ForVotes != "150" {
+		t.Fatalf("expected the whole chain to count for carol: %+v", receipt)
+	}
+
+	// A direct vote by alice overrides her delegation on this proposal.
+	receipt, err = g.CastVote(ctx, pid, alice, models.VoteRequest{VoteChoice: "against"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if receipt.Proposal.ForVotes != "50" || receipt.Proposal.AgainstVotes != "100" {
+		t.Fatalf("direct vote did not override delegation: %+v", receipt.Proposal)
+	}
+
+	power, err := g.GetVotePower(ctx, pid, bob)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if power.VotePower != "50" || power.DelegatedPower != "0" || power.DelegatedTo == nil || *power.DelegatedTo != carol {
+		t.Fatalf("unexpected power for bob: %+v", power)
+	}
+}
+
+func TestDelegate_Rejections(t *testing.T) {
+	alice, bob, carol := uuid.New(), uuid.New(), uuid.New()
+	fq := &fakeGovQueries{missingUsers: map[pgtype.UUID]bool{toPgUUID(carol): true}}
+	g := NewGovernanceService(fq, nil).WithConfig(testGovernanceConfig())
+	ctx := context.Background()
+
+	if _, err := g.Delegate(ctx, alice, models.DelegateRequest{DelegateID: alice}); !errors.Is(err, ErrInvalidDelegation) {
+		t.Fatalf("expected ErrInvalidDelegation, got %v", err)
+	}
+	if _, err := g.Delegate(ctx, alice, models.DelegateRequest{DelegateID: carol}); !errors.Is(err, ErrDelegateNotFound) {
+		t.Fatalf("expected ErrDelegateNotFound, got %v", err)
+	}
+	if _, err := g.Delegate(ctx, alice, models.DelegateRequest{DelegateID: bob, TokenSymbol: "AOG"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// bob's global delegation would apply to AOG and close the loop.
+	if _, err := g.Delegate(ctx, bob, models.DelegateRequest{DelegateID: alice}); !errors.Is(err, ErrDelegationCycle) {
+		t.Fatalf("expected ErrDelegationCycle, got %v", err)
+	}
+	// Under another token alice has no delegation, so bob -> alice is fine.
+	if _, err := g.Delegate(ctx, bob, models.DelegateRequest{DelegateID: alice, TokenSymbol: "ETH"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := g.Undelegate(ctx, alice, "aog"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := g.Undelegate(ctx, alice, "aog"); !errors.Is(err, ErrDelegationNotFound) {
+		t.Fatalf("expected ErrDelegationNotFound, got %v", err)
+	}
+}
+
+func TestListDelegates_WeightsLikeSnapshots(t *testing.T) {
+	fq := &fakeGovQueries{}
+	cfg := testGovernanceConfig()
+	cfg.LockWeighting = true
+	g := NewGovernanceService(fq, nil).WithConfig(cfg)
+
+	if _, err := g.ListDelegates(context.Background(), "", 20, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := fq.delegatesArg; got.Symbol != "AOG" || !got.LockWeighting {
+		t.Fatalf("delegates ranked with %+v; want AOG with lock weighting", got)
+	}
+}
+
+func TestTimeline_LifecycleAndMilestones(t *testing.T) {
+	now := time.Now()
+	small, large := uuid.New(), uuid.New()
+	fq := &fakeGovQueries{power: map[pgtype.UUID]string{
+		toPgUUID(small): "30",
+		toPgUUID(large): "70",
+	}}
+	g := NewGovernanceService(fq, nil).WithConfig(testGovernanceConfig())
+	ctx := context.Background()
+	pid := openProposal(fq, now.Add(-time.Hour), now.Add(time.Hour), "pending")
+	p := fq.proposals[toPgUUID(pid)]
+	p.Quorum, _ = floatNumeric(40)
+	p.Threshold, _ = floatNumeric(50)
+	fq.proposals[toPgUUID(pid)] = p
+
+	for _, v := range []struct {
+		user   uuid.UUID
+		choice string
+	}{{small, "for"}, {large, "for"}, {small, "against"}} {
+		if _, err := g.CastVote(ctx, pid, v.user, models.VoteRequest{VoteChoice: v.choice}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	p = fq.proposals[toPgUUID(pid)]
+	p.VotingEnd = pgtype.Timestamp{Time: now.Add(-time.Minute), Valid: true}
+	fq.proposals[toPgUUID(pid)] = p
+	if err := g.ProcessProposals(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events, err := g.GetTimeline(ctx, pid)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	want := []string{EventActivated, EventFirstVote, "turnout_25", "turnout_50", "turnout_75", EventQuorumReached, EventClosed}
+	if len(types) != len(want) {
+		t.Fatalf("timeline = %v, want %v", types, want)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Fatalf("timeline = %v, want %v", types, want)
+		}
+	}
+
+	if _, err := g.GetTimeline(ctx, uuid.New()); !errors.Is(err, ErrProposalNotFound) {
+		t.Fatalf("expected ErrProposalNotFound, got %v", err)
+	}
+}
+
+func TestComments_ThreadsEditsAndReactions(t *testing.T) {
+	now := time.Now()
+	author, other := uuid.New(), uuid.New()
+	fq := &fakeGovQueries{}
+	g := NewGovernanceService(fq, nil).WithConfig(testGovernanceConfig())
+	ctx := context.Background()
+	pid := openProposal(fq, now.Add(-time.Hour), now.Add(time.Hour), "active")
+
+	root, err := g.CreateComment(ctx, pid, author, models.CommentRequest{Body: " Looks good "})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reply, err := g.CreateComment(ctx, pid, other, models.CommentRequest{Body: "Agreed", ParentID: &root.ID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := g.CreateComment(ctx, pid, other, models.CommentRequest{Body: "   "}); !errors.Is(err, ErrInvalidComment) {
+		t.Fatalf("expected ErrInvalidComment, got %v", err)
+	}
+	elsewhere := openProposal(fq, now.Add(-time.Hour), now.Add(time.Hour), "active")
+	if _, err := g.CreateComment(ctx, elsewhere, other, models.CommentRequest{Body: "x", ParentID: &root.ID}); !errors.Is(err, ErrCommentNotFound) {
+		t.Fatalf("expected ErrCommentNotFound for a parent on another proposal, got %v", err)
+	}
+
+	if _, err := g.EditComment(ctx, pid, root.ID, other, "hijack"); !errors.Is(err, ErrNotCommentAuthor) {
+		t.Fatalf("expected ErrNotCommentAuthor, got %v", err)
+	}
+	edited, err := g.EditComment(ctx, pid, root.ID, author, "Looks good to me")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if edited.Body != "Looks good to me" || edited.EditedAt == nil {
+		t.Fatalf("unexpected edited comment: %+v", edited)
+	}
+	history, err := g.CommentHistory(ctx, pid, root.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history) != 1 || history[0].PreviousBody != "Looks good" {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+
+	if err := g.React(ctx, pid, root.ID, other, "rocket", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := g.React(ctx, pid, root.ID, other, "rocket", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := g.React(ctx, pid, root.ID, author, "rocket", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := g.React(ctx, pid, root.ID, other, "sparkles", true); !errors.Is(err, ErrInvalidReaction) {
+		t.Fatalf("expected ErrInvalidReaction, got %v", err)
+	}
+
+	d, err := g.ListComments(ctx, pid, other)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(d.Comments) != 1 || len(d.Comments[0].Replies) != 1 || d.Comments[0].Replies[0].ID != reply.ID {
+		t.Fatalf("unexpected thread: %+v", d.Comments)
+	}
+	c := d.Comments[0]
+	if c.Reactions["rocket"] != 2 || len(c.MyReactions) != 1 || c.MyReactions[0] != "rocket" {
+		t.Fatalf("unexpected reactions: %+v %+v", c.Reactions, c.MyReactions)
+	}
+}
+
+func TestComments_Moderation(t *testing.T) {
+	now := time.Now()
+	author, admin := uuid.New(), uuid.New()
+	fq := &fakeGovQueries{}
+	g := NewGovernanceService(fq, nil).WithConfig(testGovernanceConfig())
+	ctx := context.Background()
+	pid := openProposal(fq, now.Add(-time.Hour), now.Add(time.Hour), "active")
+
+	c, err := g.CreateComment(ctx, pid, author, models.CommentRequest{Body: "spam"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := g.SetCommentHidden(ctx, pid, c.ID, admin, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d, err := g.ListComments(ctx, pid, author)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !d.Comments[0].Hidden || d.Comments[0].Body != "" {
+		t.Fatalf("hidden comment body must be withheld: %+v", d.Comments[0])
+	}
+	if _, err := g.EditComment(ctx, pid, c.ID, author, "not spam"); !errors.Is(err, ErrCommentHidden) {
+		t.Fatalf("expected ErrCommentHidden, got %v", err)
+	}
+	if _, err := g.CreateComment(ctx, pid, author, models.CommentRequest{Body: "reply", ParentID: &c.ID}); !errors.Is(err, ErrCommentHidden) {
+		t.Fatalf("expected ErrCommentHidden, got %v", err)
+	}
+
+	p, err := g.SetDiscussionLocked(ctx, pid, admin, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !p.DiscussionLocked {
+		t.Fatalf("expected locked discussion: %+v", p)
+	}
+	if _, err := g.CreateComment(ctx, pid, author, models.CommentRequest{Body: "hello"}); !errors.Is(err, ErrDiscussionLocked) {
+		t.Fatalf("expected ErrDiscussionLocked, got %v", err)
+	}
+	if _, err := g.SetDiscussionLocked(ctx, pid, admin, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := g.CreateComment(ctx, pid, author, models.CommentRequest{Body: "hello"}); err != nil {
+		t.Fatalf("unexpected error after unlock: %v", err)
+	}
+}
+
+func TestRankedChoiceProposal(t *testing.T) {
+	now := time.Now()
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	fq := &fakeGovQueries{staked: "1500", power: map[pgtype.UUID]string{
+		toPgUUID(a): "40",
+		toPgUUID(b): "35",
+		toPgUUID(c): "25",
+	}}
+	g := NewGovernanceService(fq, nil).WithConfig(testGovernanceConfig())
+	ctx := context.Background()
+
+	created, err := g.CreateProposal(ctx, uuid.New(), models.CreateProposalRequest{
+		Title:        "Treasury allocation",
+		Description:  "Pick the next grant round focus",
+		ProposalType: "multi_option",
+		VotingEnd:    now.Add(72 * time.Hour),
+		Options:      []string{" Audits ", "Tooling", "Education"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created.VotingMethod != MethodRankedChoice || len(created.Options) != 3 || created.Options[0] != "Audits" {
+		t.Fatalf("unexpected proposal: %+v", created)
+	}
+
+	if _, err := g.CastVote(ctx, created.ID, a, models.VoteRequest{VoteChoice: "for"}); !errors.Is(err, ErrInvalidBallot) {
+		t.Fatalf("expected ErrInvalidBallot, got %v", err)
+	}
+	for _, v := range []struct {
+		user    uuid.UUID
+		ranking []int
+	}{{a, []int{0}}, {b, []int{1}}, {c, []int{2, 1}}} {
+		if _, err := g.CastVote(ctx, created.ID, v.user, models.VoteRequest{Ranking: v.ranking}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	p := fq.proposals[toPgUUID(created.ID)]
+	if live := proposalModel(p).OptionVotes; len(live) != 3 || live[0] != "40" || live[2] != "25" {
+		t.Fatalf("unexpected live first preferences: %v", live)
+	}
+
+	p.VotingEnd = pgtype.Timestamp{Time: now.Add(-time.Minute), Valid: true}
+	fq.proposals[p.ID] = p
+	if err := g.ProcessProposals(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := proposalModel(fq.proposals[p.ID])
+	if got.Status != "passed" || got.Result == nil {
+		t.Fatalf("expected passed proposal, got %+v", got)
+	}
+	r := got.Result
+	if r.Winner != "Tooling" || r.WinningOption == nil || *r.WinningOption != 1 || len(r.Rounds) != 2 || r.ForShare != 60 {
+		t.Fatalf("unexpected result: %+v", r)
+	}
+}
+
+func TestQuadraticProposalResult(t *testing.T) {
+	now := time.Now()
+	whale := uuid.New()
+	power := map[pgtype.UUID]string{toPgUUID(whale): "900"}
+	var small []uuid.UUID
+	for i := 0; i < 4; i++ {
+		u := uuid.New()
+		small = append(small, u)
+		power[toPgUUID(u)] = "100"
+	}
+	fq := &fakeGovQueries{power: power}
+	g := NewGovernanceService(fq, nil).WithConfig(testGovernanceConfig())
+	ctx := context.Background()
+	pid := openProposal(fq, now.Add(-time.Hour), now.Add(time.Hour), "pending")
+	p := fq.proposals[toPgUUID(pid)]
+	p.VotingMethod = MethodQuadratic
+	p.Quorum, _ = floatNumeric(10)
+	p.Threshold, _ = floatNumeric(50)
+	fq.proposals[p.ID] = p
+
+	if _, err := g.CastVote(ctx, pid, whale, models.VoteRequest{VoteChoice: "for"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, u := range small {
+		if _, err := g.CastVote(ctx, pid, u, models.VoteRequest{VoteChoice: "against"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	p = fq.proposals[p.ID]
+	p.VotingEnd = pgtype.Timestamp{Time: now.Add(-time.Minute), Valid: true}
+	fq.proposals[p.ID] = p
+	if err := g.ProcessProposals(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := proposalModel(fq.proposals[p.ID])
+	if got.Status != "rejected" || got.Result.ForVotes != "30" || got.Result.AgainstVotes != "40" || got.Result.TotalVotes != "1300" {
+		t.Fatalf("expected quadratic weighting to reject, got %+v", got.Result)
+	}
+}
+
+func TestQuadraticProposalResult_DelegationKeepsRoots(t *testing.T) {
+	now := time.Now()
+	whale := uuid.New()
+	power := map[pgtype.UUID]string{toPgUUID(whale): "900"}
+	var small []uuid.UUID
+	for i := 0; i < 4; i++ {
+		u := uuid.New()
+		small = append(small, u)
+		power[toPgUUID(u)] = "100"
+	}
+	fq := &fakeGovQueries{power: power}
+	g := NewGovernanceService(fq, nil).WithConfig(testGovernanceConfig())
+	ctx := context.Background()
+	// Three small holders delegate to the fourth, who votes for all of them.
+	for _, u := range small[1:] {
+		if _, err := g.Delegate(ctx, u, models.DelegateRequest{DelegateID: small[0]}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	pid := openProposal(fq, now.Add(-time.Hour), now.Add(time.Hour), "pending")
+	p := fq.proposals[toPgUUID(pid)]
+	p.VotingMethod = MethodQuadratic
+	p.Quorum, _ = floatNumeric(10)
+	p.Threshold, _ = floatNumeric(50)
+	fq.proposals[p.ID] = p
+
+	if _, err := g.CastVote(ctx, pid, whale, models.VoteRequest{VoteChoice: "for"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := g.CastVote(ctx, pid, small[0], models.VoteRequest{VoteChoice: "against"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p = fq.proposals[p.ID]
+	p.VotingEnd = pgtype.Timestamp{Time: now.Add(-time.Minute), Valid: true}
+	fq.proposals[p.ID] = p
+	if err := g.ProcessProposals(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 4 * sqrt(100) = 40 against, as if each had voted, not sqrt(400) = 20.
+	got := proposalModel(fq.proposals[p.ID])
+	if got.Status != "rejected" || got.Result.ForVotes != "30" || got.Result.AgainstVotes != "40" || got.Result.TotalVotes != "1300" {
+		t.Fatalf("expected delegated quadratic power to keep per-holder roots, got %+v", got.Result)
+	}
+}
+
+func TestGetTurnout_Cumulative(t *testing.T) {
+	now := time.Now()
+	fq := &fakeGovQueries{}
+	pid := openProposal(fq, now.Add(-time.Hour), now.Add(time.Hour), "active")
+	p := fq.proposals[toPgUUID(pid)]
+	p.EligiblePower, _ = floatNumeric(400)
+	fq.proposals[p.ID] = p
+	start := now.Truncate(time.Hour)
+	for i, power := range []float64{100, 50, 150} {
+		row := db.ListProposalTurnoutRow{Bucket: pgtype.Timestamp{Time: start.Add(time.Duration(i) * time.Hour), Valid: true}, Voters: 2}
+		row.Power, _ = floatNumeric(power)
+		fq.turnout = append(fq.turnout, row)
+	}
+	g := NewGovernanceService(fq, nil).WithConfig(testGovernanceConfig())
+
+	got, err := g.GetTurnout(context.Background(), pid, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Interval != "hour" || len(got.Points) != 3 {
+		t.Fatalf("unexpected turnout: %+v", got)
+	}
+	last := got.Points[2]
+	if got.Points[1].PowerCast != "150" || last.Voters != 6 || last.PowerCast != "300" || last.Turnout != 75 {
+		t.Fatalf("expected cumulative points, got %+v", got.Points)
+	}
+	if _, err := g.GetTurnout(context.Background(), pid, "week"); !errors.Is(err, ErrInvalidInterval) {
+		t.Fatalf("expected ErrInvalidInterval, got %v", err)
+	}
+}
+
+func TestGetVoteHistory_Majority(t *testing.T) {
+	now := time.Now()
+	alice, bob, carol := uuid.New(), uuid.New(), uuid.New()
+	fq := &fakeGovQueries{power: map[pgtype.UUID]string{
+		toPgUUID(alice): "100",
+		toPgUUID(bob):   "50",
+		toPgUUID(carol): "10",
+	}}
+	g := NewGovernanceService(fq, nil).WithConfig(testGovernanceConfig())
+	ctx := context.Background()
+	pid := openProposal(fq, now.Add(-time.Hour), now.Add(time.Hour), "pending")
+	for user, choice := range map[uuid.UUID]string{alice: "for", bob: "against", carol: "abstain"} {
+		if _, err := g.CastVote(ctx, pid, user, models.VoteRequest{VoteChoice: choice}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// Before finalization there is no outcome to compare against.
+	history, total, err := g.GetVoteHistory(ctx, alice, 20, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if total != 1 || len(history) != 1 || history[0].Outcome != "" || history[0].WithMajority != nil {
+		t.Fatalf("unexpected open history: %+v", history)
+	}
+
+	p := fq.proposals[toPgUUID(pid)]
+	p.VotingEnd = pgtype.Timestamp{Time: now.Add(-time.Minute), Valid: true}
+	fq.proposals[p.ID] = p
+	if err := g.ProcessProposals(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for user, want := range map[uuid.UUID]*bool{alice: ptr(true), bob: ptr(false), carol: nil} {
+		history, _, err := g.GetVoteHistory(ctx, user, 20, 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got := history[0]
+		if got.Outcome != "passed" || (want == nil) != (got.WithMajority == nil) || (want != nil && *want != *got.WithMajority) {
+			t.Fatalf("unexpected history entry for %s: %+v", got.VoteChoice, got)
+		}
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
@@ -0,0 +1,16 @@
+// internal/services/leader.go
+package services
+
+// Advisory lock keys. Background jobs that must run on a single replica take
+// their lock with pg_try_advisory_xact_lock inside their transaction and skip
+// the run when another replica holds it; writers that must be serialised
+// wait for it with pg_advisory_xact_lock.
+const (
+	lockKeyGovernance  int64 = 0x676f7601 // "gov" 1
+	lockKeyDelegations int64 = 0x676f7602 // "gov" 2
+)
+
+// Advisory lock classes, locked per subject with AdvisoryLockSubject.
+const (
+	lockClassProposer int32 = 0x676f7603 // "gov" 3, per proposer
+)
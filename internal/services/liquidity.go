@@ -0,0 +1,488 @@
+// internal/services/liquidity.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jd7008911/aogeri-api/internal/config"
+	"github.com/jd7008911/aogeri-api/internal/db"
+	"github.com/jd7008911/aogeri-api/internal/models"
+)
+
+var (
+	ErrPoolNotFound           = errors.New("pool not found")
+	ErrPoolInactive           = errors.New("pool is not active")
+	ErrPositionNotFound       = errors.New("liquidity position not found")
+	ErrInvalidLiquidityAmount = errors.New("invalid liquidity amount")
+	ErrInsufficientShares     = errors.New("position does not hold that many shares")
+	ErrSlippageExceeded       = errors.New("slippage bound exceeded")
+	ErrInvalidSwap            = errors.New("invalid swap")
+	ErrInsufficientLiquidity  = errors.New("pool has insufficient liquidity")
+	ErrDeadlineExceeded       = errors.New("deadline has passed")
+)
+
+type liquidityQuerier interface {
+	ListPools(ctx context.Context, symbol pgtype.Text) ([]db.ListPoolsRow, error)
+	GetPool(ctx context.Context, id pgtype.UUID) (db.GetPoolRow, error)
+	ListUserPositions(ctx context.Context, userID pgtype.UUID) ([]db.ListUserPositionsRow, error)
+	GetUserPosition(ctx context.Context, arg db.GetUserPositionParams) (db.GetUserPositionRow, error)
+	GetPoolForUpdate(ctx context.Context, id pgtype.UUID) (db.LiquidityPool, error)
+	SetPoolReserves(ctx context.Context, arg db.SetPoolReservesParams) (db.LiquidityPool, error)
+	GetOpenPositionForUpdate(ctx context.Context, arg db.GetOpenPositionForUpdateParams) (db.UserLiquidity, error)
+	AddToPosition(ctx context.Context, arg db.AddToPositionParams) (db.UserLiquidity, error)
+	UpdatePosition(ctx context.Context, arg db.UpdatePositionParams) (db.UserLiquidity, error)
+	CreateSwap(ctx context.Context, arg db.CreateSwapParams) (db.PoolSwap, error)
+	ListPoolSwaps(ctx context.Context, arg db.ListPoolSwapsParams) ([]db.ListPoolSwapsRow, error)
+	ListUserSwaps(ctx context.Context, arg db.ListUserSwapsParams) ([]db.ListUserSwapsRow, error)
+	RecordPoolFees(ctx context.Context, arg db.RecordPoolFeesParams) error
+	GetPoolFeeIncome(ctx context.Context, id pgtype.UUID) (db.GetPoolFeeIncomeRow, error)
+	AccruePoolFees(ctx context.Context, arg db.AccruePoolFeesParams) error
+	CreateFarm(ctx context.Context, arg db.CreateFarmParams) (db.LiquidityFarm, error)
+	ListFarms(ctx context.Context, includeEnded bool) ([]db.ListFarmsRow, error)
+	GetFarm(ctx context.Context, id pgtype.UUID) (db.GetFarmRow, error)
+	GetFarmForUpdate(ctx context.Context, id pgtype.UUID) (db.LiquidityFarm, error)
+	UpdateFarm(ctx context.Context, arg db.UpdateFarmParams) error
+	GetOpenFarmStakeForUpdate(ctx context.Context, arg db.GetOpenFarmStakeForUpdateParams) (db.FarmStake, error)
+	CreateFarmStake(ctx context.Context, arg db.CreateFarmStakeParams) (db.FarmStake, error)
+	UpdateFarmStake(ctx context.Context, arg db.UpdateFarmStakeParams) (db.FarmStake, error)
+	AddFarmedShares(ctx context.Context, arg db.AddFarmedSharesParams) error
+	ListUserFarmStakes(ctx context.Context, userID pgtype.UUID) ([]db.ListUserFarmStakesRow, error)
+	GetTokenBySymbol(ctx context.Context, symbol string) (db.GetTokenBySymbolRow, error)
+	PoolPairExists(ctx context.Context, arg db.PoolPairExistsParams) (bool, error)
+	CreatePool(ctx context.Context, arg db.CreatePoolParams) (db.LiquidityPool, error)
+	SetPoolFee(ctx context.Context, arg db.SetPoolFeeParams) error
+	SetPoolStatus(ctx context.Context, arg db.SetPoolStatusParams) error
+	CreateAuditLog(ctx context.Context, arg db.CreateAuditLogParams) error
+	ListAuditLogs(ctx context.Context, arg db.ListAuditLogsParams) ([]db.ListAuditLogsRow, error)
+	GetPoolState(ctx context.Context, id pgtype.UUID) (db.LiquidityPool, error)
+	RecordPriceObservation(ctx context.Context, arg db.RecordPriceObservationParams) error
+	GetPriceObservation(ctx context.Context, arg db.GetPriceObservationParams) (db.PoolPriceObservation, error)
+	GetFirstPriceObservation(ctx context.Context, poolID pgtype.UUID) (db.PoolPriceObservation, error)
+	GetPositionForUpdate(ctx context.Context, arg db.GetPositionForUpdateParams) (db.UserLiquidity, error)
+	CreateRangePosition(ctx context.Context, arg db.CreateRangePositionParams) (db.UserLiquidity, error)
+	CollectPositionFees(ctx context.Context, id pgtype.UUID) error
+	ListPoolTicks(ctx context.Context, poolID pgtype.UUID) ([]db.PoolTick, error)
+	UpdateTick(ctx context.Context, arg db.UpdateTickParams) error
+	CrossTick(ctx context.Context, arg db.CrossTickParams) error
+	SetPoolCurve(ctx context.Context, arg db.SetPoolCurveParams) error
+	CreatePoolSnapshots(ctx context.Context, arg db.CreatePoolSnapshotsParams) (int64, error)
+	GetPoolHistory(ctx context.Context, arg db.GetPoolHistoryParams) ([]db.GetPoolHistoryRow, error)
+}
+
+// LiquidityService exposes liquidity pools and providers' positions.
+type LiquidityService struct {
+	queries liquidityQuerier
+	tx      TxRunner
+	cfg     config.LiquidityConfig
+}
+
+func NewLiquidityService(queries liquidityQuerier) *LiquidityService {
+	return &LiquidityService{queries: queries}
+}
+
+// WithTx makes deposits and withdrawals run inside a single transaction.
+func (s *LiquidityService) WithTx(tx TxRunner) *LiquidityService {
+	s.tx = tx
+	return s
+}
+
+// WithConfig sets the default fee, fee tiers, slippage tolerance and price
+// oracle.
+func (s *LiquidityService) WithConfig(cfg config.LiquidityConfig) *LiquidityService {
+	s.cfg = cfg
+	return s
+}
+
+func (s *LiquidityService) inTx(ctx context.Context, fn func(q liquidityQuerier) error) error {
+	if s.tx == nil {
+		return fn(s.queries)
+	}
+	return s.tx.ExecTx(ctx, func(q *db.Queries) error { return fn(q) })
+}
+
+// ListPools returns pools that are not deprecated, largest first. A non-empty symbol keeps
+// only pools with that token on either side.
+func (s *LiquidityService) ListPools(ctx context.Context, symbol string) ([]models.Pool, error) {
+	symbol = strings.ToUpper(symbol)
+	rows, err := s.queries.ListPools(ctx, pgtype.Text{String: symbol, Valid: symbol != ""})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]models.Pool, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, poolModel(db.GetPoolRow(r)))
+	}
+	return out, nil
+}
+
+// GetPool returns a pool by ID, including inactive ones, with its recent
+// fee income.
+func (s *LiquidityService) GetPool(ctx context.Context, id uuid.UUID) (*models.Pool, error) {
+	row, err := s.queries.GetPool(ctx, toPgUUID(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPoolNotFound
+		}
+		return nil, err
+	}
+	p := poolModel(row)
+	if p.Fees, err = s.poolFees(ctx, row.ID); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetUserPositions returns a user's active positions, newest first.
+func (s *LiquidityService) GetUserPositions(ctx context.Context, userID uuid.UUID) ([]models.LiquidityPosition, error) {
+	rows, err := s.queries.ListUserPositions(ctx, toPgUUID(userID))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	out := make([]models.LiquidityPosition, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, positionReport(db.GetUserPositionRow(r), now).LiquidityPosition)
+	}
+	return out, nil
+}
+
+func poolModel(r db.GetPoolRow) models.Pool {
+	id, _ := pgToUUID(r.ID)
+	t0, _ := pgToUUID(r.Token0ID)
+	t1, _ := pgToUUID(r.Token1ID)
+	p := models.Pool{
+		ID:        id,
+		Name:      r.Name,
+		Token0:    models.PoolToken{ID: t0, Symbol: r.Token0Symbol},
+		Token1:    models.PoolToken{ID: t1, Symbol: r.Token1Symbol},
+		TVL:       numericString(r.TotalLiquidity),
+		APR:       numericFloat(r.Apr),
+		Providers: r.Providers,
+		IsActive:  r.IsActive,
+		CreatedAt: r.CreatedAt.Time,
+		FeeBps:    int(r.FeeBps),
+		Status:    r.Status,
+
+		Reserve0:    numericString(r.Reserve0),
+		Reserve1:    numericString(r.Reserve1),
+		TotalShares: numericString(r.TotalShares),
+		Price:       rowCurve(r, nil).price(),
+		Type:        r.PoolType,
+	}
+	if r.PoolType == poolConcentrated {
+		tick := int(r.Tick)
+		p.TickSpacing, p.Tick = int(r.TickSpacing), &tick
+		p.ActiveLiquidity = numericString(r.Liquidity)
+	}
+	return p
+}
+
+// poolPrice is the marginal price of token0 in token1, or 0 for an empty
+// pool.
+func poolPrice(reserve0, reserve1 float64) float64 {
+	if reserve0 <= 0 {
+		return 0
+	}
+	return reserve1 / reserve0
+}
+
+// AddLiquidity deposits into a pool using constant-product accounting. The
+// first deposit sets the price and mints sqrt(amount0 * amount1) shares.
+// Later deposits are matched to the pool's ratio, the excess of one side
+// refunded, and mint shares in proportion to the reserves they add.
+// Deposits into a concentrated pool open a range position instead.
+func (s *LiquidityService) AddLiquidity(ctx context.Context, userID, poolID uuid.UUID, req models.AddLiquidityRequest) (*models.LiquidityReceipt, error) {
+	amount0, err := parseMinAmount(req.Amount0)
+	if err != nil {
+		return nil, err
+	}
+	amount1, err := parseMinAmount(req.Amount1)
+	if err != nil {
+		return nil, err
+	}
+	if amount0 <= 0 && amount1 <= 0 {
+		return nil, ErrInvalidLiquidityAmount
+	}
+	minShares, err := parseMinAmount(req.MinShares)
+	if err != nil {
+		return nil, err
+	}
+	ranged := req.TickLower != nil || req.TickUpper != nil
+	if ranged && (req.TickLower == nil || req.TickUpper == nil) {
+		return nil, fmt.Errorf("%w: tick_lower and tick_upper go together", ErrInvalidRange)
+	}
+
+	var receipt *models.LiquidityReceipt
+	err = s.inTx(ctx, func(q liquidityQuerier) error {
+		pool, err := lockActivePool(ctx, q, poolID)
+		if err != nil {
+			return err
+		}
+		if pool.PoolType == poolConcentrated {
+			if !ranged {
+				return fmt.Errorf("%w: a concentrated pool needs tick_lower and tick_upper", ErrInvalidRange)
+			}
+			receipt, err = s.addRangeLiquidity(ctx, q, pool, userID, *req.TickLower, *req.TickUpper, amount0, amount1, minShares)
+			return err
+		}
+		if ranged {
+			return fmt.Errorf("%w: constant-product pools take no price range", ErrInvalidRange)
+		}
+		if amount0 <= 0 || amount1 <= 0 {
+			return ErrInvalidLiquidityAmount
+		}
+		reserve0, reserve1 := numericFloat(pool.Reserve0), numericFloat(pool.Reserve1)
+		totalShares := numericFloat(pool.TotalShares)
+
+		used0, used1, shares := depositAmounts(reserve0, reserve1, totalShares, amount0, amount1)
+		if shares <= 0 {
+			return ErrInvalidLiquidityAmount
+		}
+		if shares < minShares {
+			return fmt.Errorf("%w: %s shares minted, at least %s required", ErrSlippageExceeded, formatAmount(shares), formatAmount(minShares))
+		}
+
+		if err := s.setReserves(ctx, q, pool, reserve0+used0, reserve1+used1, totalShares+shares); err != nil {
+			return err
+		}
+		params := db.AddToPositionParams{
+			UserID:         toPgUUID(userID),
+			PoolID:         pool.ID,
+			FeeGrowth0Last: pool.FeeGrowth0,
+			FeeGrowth1Last: pool.FeeGrowth1,
+		}
+		if params.Amount0, err = floatNumeric(used0); err != nil {
+			return err
+		}
+		if params.Amount1, err = floatNumeric(used1); err != nil {
+			return err
+		}
+		if params.Shares, err = floatNumeric(shares); err != nil {
+			return err
+		}
+		position, err := q.AddToPosition(ctx, params)
+		if err != nil {
+			return err
+		}
+		receipt, err = liquidityReceipt(ctx, q, position, used0, used1, shares)
+		if err != nil {
+			return err
+		}
+		if refund := amount0 - used0; refund > 0 {
+			receipt.Refund0 = formatAmount(refund)
+		}
+		if refund := amount1 - used1; refund > 0 {
+			receipt.Refund1 = formatAmount(refund)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// depositAmounts returns how much of a deposit a pool takes and the shares
+// it mints.
+func depositAmounts(reserve0, reserve1, totalShares, amount0, amount1 float64) (used0, used1, shares float64) {
+	if totalShares <= 0 || reserve0 <= 0 || reserve1 <= 0 {
+		return amount0, amount1, math.Sqrt(amount0 * amount1)
+	}
+	used0, used1 = amount0, amount0*reserve1/reserve0
+	if used1 > amount1 {
+		used0, used1 = amount1*reserve0/reserve1, amount1
+	}
+	return used0, used1, used0 / reserve0 * totalShares
+}
+
+// RemoveLiquidity burns shares of the caller's position for the same
+// fraction of each reserve. Burning every share closes the position. In a
+// concentrated pool it burns liquidity from the given range position.
+func (s *LiquidityService) RemoveLiquidity(ctx context.Context, userID, poolID uuid.UUID, req models.RemoveLiquidityRequest) (*models.LiquidityReceipt, error) {
+	burn, ok := parsePositiveAmount(req.Shares)
+	if !ok {
+		return nil, ErrInvalidLiquidityAmount
+	}
+	min0, err := parseMinAmount(req.MinAmount0)
+	if err != nil {
+		return nil, err
+	}
+	min1, err := parseMinAmount(req.MinAmount1)
+	if err != nil {
+		return nil, err
+	}
+
+	var receipt *models.LiquidityReceipt
+	err = s.inTx(ctx, func(q liquidityQuerier) error {
+		// Withdrawals stay open on paused and deprecated pools.
+		pool, err := lockPool(ctx, q, poolID)
+		if err != nil {
+			return err
+		}
+		if pool.PoolType == poolConcentrated {
+			receipt, err = s.removeRangeLiquidity(ctx, q, pool, userID, req.PositionID, burn, min0, min1)
+			return err
+		}
+		position, err := q.GetOpenPositionForUpdate(ctx, db.GetOpenPositionForUpdateParams{UserID: toPgUUID(userID), PoolID: pool.ID})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrPositionNotFound
+			}
+			return err
+		}
+		held := numericFloat(position.Shares)
+		if burn > held {
+			return ErrInsufficientShares
+		}
+		if burn > held-numericFloat(position.FarmedShares) {
+			return fmt.Errorf("%w: exit the farm before withdrawing them", ErrSharesFarmed)
+		}
+		reserve0, reserve1 := numericFloat(pool.Reserve0), numericFloat(pool.Reserve1)
+		totalShares := numericFloat(pool.TotalShares)
+		if totalShares <= 0 {
+			return ErrInsufficientShares
+		}
+
+		fraction := burn / totalShares
+		out0, out1 := reserve0*fraction, reserve1*fraction
+		if out0 < min0 || out1 < min1 {
+			return fmt.Errorf("%w: would receive %s and %s", ErrSlippageExceeded, formatAmount(out0), formatAmount(out1))
+		}
+
+		remaining := totalShares - burn
+		if remaining <= 0 {
+			// The last provider out takes the reserves with them.
+			out0, out1, remaining = reserve0, reserve1, 0
+		}
+		if err := s.setReserves(ctx, q, pool, reserve0-out0, reserve1-out1, remaining); err != nil {
+			return err
+		}
+
+		// The deposited amounts are the position's cost basis and shrink
+		// in proportion to the shares burned.
+		keep := 1 - burn/held
+		params := db.UpdatePositionParams{
+			ID:         position.ID,
+			Status:     "active",
+			FeeGrowth0: pool.FeeGrowth0,
+			FeeGrowth1: pool.FeeGrowth1,
+		}
+		if keep <= 0 {
+			keep = 0
+			params.Status = "withdrawn"
+		}
+		if params.Amount0, err = floatNumeric(numericFloat(position.Amount0) * keep); err != nil {
+			return err
+		}
+		if params.Amount1, err = floatNumeric(numericFloat(position.Amount1) * keep); err != nil {
+			return err
+		}
+		if params.Shares, err = floatNumeric(held - burn); err != nil {
+			return err
+		}
+		updated, err := q.UpdatePosition(ctx, params)
+		if err != nil {
+			return err
+		}
+		receipt, err = liquidityReceipt(ctx, q, updated, out0, out1, burn)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// lockActivePool locks a pool that takes deposits and swaps, that is one
+// neither paused nor deprecated.
+func lockActivePool(ctx context.Context, q liquidityQuerier, poolID uuid.UUID) (db.LiquidityPool, error) {
+	pool, err := lockPool(ctx, q, poolID)
+	if err != nil {
+		return pool, err
+	}
+	if pool.Status != poolActive {
+		return pool, fmt.Errorf("%w: pool is %s", ErrPoolInactive, pool.Status)
+	}
+	return pool, nil
+}
+
+// setReserves stores a locked pool's new reserves and share supply. The
+// price accumulators are first advanced at the outgoing price, and their
+// new values observed for TWAPs. TVL is valued at the oracle's prices.
+func (s *LiquidityService) setReserves(ctx context.Context, q liquidityQuerier, pool db.LiquidityPool, reserve0, reserve1, totalShares float64) error {
+	now := time.Now()
+	cum0, cum1 := accumulatePrices(numericFloat(pool.Price0Cumulative), numericFloat(pool.Price1Cumulative),
+		spotPrice(pool), pool.PriceUpdatedAt.Time, now)
+	params := db.SetPoolReservesParams{ID: pool.ID, PriceUpdatedAt: pgtype.Timestamp{Time: now, Valid: true}}
+	var err error
+	if params.Price0, params.Price1, err = s.poolPrices(ctx, q, pool.ID); err != nil {
+		return err
+	}
+	if params.Reserve0, err = floatNumeric(reserve0); err != nil {
+		return err
+	}
+	if params.Reserve1, err = floatNumeric(reserve1); err != nil {
+		return err
+	}
+	if params.TotalShares, err = floatNumeric(totalShares); err != nil {
+		return err
+	}
+	if params.Price0Cumulative, err = floatNumeric(cum0); err != nil {
+		return err
+	}
+	if params.Price1Cumulative, err = floatNumeric(cum1); err != nil {
+		return err
+	}
+	if _, err = q.SetPoolReserves(ctx, params); err != nil {
+		return err
+	}
+	return q.RecordPriceObservation(ctx, db.RecordPriceObservationParams{
+		PoolID:           pool.ID,
+		ObservedAt:       params.PriceUpdatedAt,
+		Price0Cumulative: params.Price0Cumulative,
+		Price1Cumulative: params.Price1Cumulative,
+	})
+}
+
+// liquidityReceipt reads back the pool after a deposit or withdrawal and
+// describes the position against it.
+func liquidityReceipt(ctx context.Context, q liquidityQuerier, position db.UserLiquidity, amount0, amount1, shares float64) (*models.LiquidityReceipt, error) {
+	row, err := q.GetPool(ctx, position.PoolID)
+	if err != nil {
+		return nil, err
+	}
+	pos, err := q.GetUserPosition(ctx, db.GetUserPositionParams{ID: position.ID, UserID: position.UserID})
+	if err != nil {
+		return nil, err
+	}
+	return &models.LiquidityReceipt{
+		Amount0:  formatAmount(amount0),
+		Amount1:  formatAmount(amount1),
+		Shares:   formatAmount(shares),
+		Position: positionReport(pos, time.Now()).LiquidityPosition,
+		Pool:     poolModel(row),
+	}, nil
+}
+
+// parseMinAmount parses an optional non-negative amount, such as a deposit
+// side or a slippage bound. It must be finite: a NaN bound compares false
+// against everything and would switch the slippage check off.
+func parseMinAmount(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) || v < 0 {
+		return 0, ErrInvalidLiquidityAmount
+	}
+	return v, nil
+}
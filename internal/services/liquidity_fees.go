@@ -0,0 +1,203 @@
+// internal/services/liquidity_fees.go
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jd7008911/aogeri-api/internal/db"
+	"github.com/jd7008911/aogeri-api/internal/models"
+)
+
+// feeAPR annualizes the fees a pool earned over days against its TVL, in
+// percent.
+func feeAPR(fees float64, days int, tvl float64) float64 {
+	if tvl <= 0 || days <= 0 {
+		return 0
+	}
+	return fees / float64(days) * 365 / tvl * 100
+}
+
+// impermanentLoss is the percentage by which a constant-product position
+// trails holding its tokens after the pool price moved from entry to now,
+// fees excluded. It is zero when the price is unchanged and negative
+// otherwise.
+func impermanentLoss(entry, now float64) float64 {
+	if entry <= 0 || now <= 0 {
+		return 0
+	}
+	k := now / entry
+	return (2*math.Sqrt(k)/(1+k) - 1) * 100
+}
+
+// recordSwapFees books a swap's volume and fee against the pool's day,
+// adds the fee growth it priced and refreshes the pool's stored 7-day APR.
+// It runs after the swap's reserves are stored, so the APR uses the new
+// TVL.
+func recordSwapFees(ctx context.Context, q liquidityQuerier, pool db.LiquidityPool, side swapSide, in, fee, growth float64) error {
+	var volume0, volume1, fee0, fee1, growth0, growth1 float64
+	if side.zeroForOne {
+		volume0, fee0, growth0 = in, fee, growth
+	} else {
+		volume1, fee1, growth1 = in, fee, growth
+	}
+	day := db.RecordPoolFeesParams{PoolID: pool.ID}
+	var err error
+	if day.Volume0, err = floatNumeric(volume0); err != nil {
+		return err
+	}
+	if day.Volume1, err = floatNumeric(volume1); err != nil {
+		return err
+	}
+	if day.Fees0, err = floatNumeric(fee0); err != nil {
+		return err
+	}
+	if day.Fees1, err = floatNumeric(fee1); err != nil {
+		return err
+	}
+	if err := q.RecordPoolFees(ctx, day); err != nil {
+		return err
+	}
+
+	income, err := q.GetPoolFeeIncome(ctx, pool.ID)
+	if err != nil {
+		return err
+	}
+	accrue := db.AccruePoolFeesParams{ID: pool.ID}
+	if accrue.Growth0, err = floatNumeric(growth0); err != nil {
+		return err
+	}
+	if accrue.Growth1, err = floatNumeric(growth1); err != nil {
+		return err
+	}
+	if accrue.Apr, err = floatNumeric(feeAPR(numericFloat(income.Fees7d), 7, numericFloat(income.Tvl))); err != nil {
+		return err
+	}
+	return q.AccruePoolFees(ctx, accrue)
+}
+
+func (s *LiquidityService) poolFees(ctx context.Context, id pgtype.UUID) (*models.PoolFees, error) {
+	income, err := s.queries.GetPoolFeeIncome(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	tvl := numericFloat(income.Tvl)
+	return &models.PoolFees{
+		Fees7d:  numericString(income.Fees7d),
+		Fees30d: numericString(income.Fees30d),
+		APR7d:   feeAPR(numericFloat(income.Fees7d), 7, tvl),
+		APR30d:  feeAPR(numericFloat(income.Fees30d), 30, tvl),
+	}, nil
+}
+
+// GetPosition reports one of the user's positions, open or withdrawn,
+// against holding its deposits.
+func (s *LiquidityService) GetPosition(ctx context.Context, userID, positionID uuid.UUID) (*models.PositionDetail, error) {
+	row, err := s.queries.GetUserPosition(ctx, db.GetUserPositionParams{ID: toPgUUID(positionID), UserID: toPgUUID(userID)})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPositionNotFound
+		}
+		return nil, err
+	}
+	detail := positionReport(row, time.Now())
+	return &detail, nil
+}
+
+// positionReport values a position at current token prices. The deposited
+// amounts are its cost basis: their ratio is the entry price for
+// impermanent loss, and their value the HODL comparison.
+func positionReport(r db.GetUserPositionRow, now time.Time) models.PositionDetail {
+	id, _ := pgToUUID(r.ID)
+	poolID, _ := pgToUUID(r.PoolID)
+	amount0, amount1 := numericFloat(r.Amount0), numericFloat(r.Amount1)
+	price0, price1 := numericFloat(r.Price0), numericFloat(r.Price1)
+	fees0, fees1 := numericFloat(r.Fees0), numericFloat(r.Fees1)
+
+	var current0, current1, share float64
+	var span *models.PositionRange
+	if r.TickLower.Valid {
+		span, current0, current1, share = positionRange(r)
+	} else if total := numericFloat(r.PoolShares); total > 0 {
+		share = numericFloat(r.Shares) / total
+		current0, current1 = share*numericFloat(r.Reserve0), share*numericFloat(r.Reserve1)
+	}
+	value := current0*price0 + current1*price1
+	hodl := amount0*price0 + amount1*price1
+	feesValue := fees0*price0 + fees1*price1
+
+	pos := models.LiquidityPosition{
+		ID:           id,
+		PoolID:       poolID,
+		PoolName:     r.PoolName,
+		Token0Symbol: r.Token0Symbol,
+		Token1Symbol: r.Token1Symbol,
+		Amount0:      numericString(r.Amount0),
+		Amount1:      numericString(r.Amount1),
+		Shares:       numericString(r.Shares),
+		PoolShare:    share * 100,
+		Value:        formatAmount(value),
+		FeesEarned0:  formatAmount(fees0),
+		FeesEarned1:  formatAmount(fees1),
+		FeesValue:    formatAmount(feesValue),
+		Status:       r.Status,
+		CreatedAt:    r.CreatedAt.Time,
+		NftID:        r.NftID,
+		Range:        span,
+	}
+	if held := now.Sub(r.CreatedAt.Time).Hours() / 24; r.CreatedAt.Valid && hodl > 0 {
+		pos.APREarned = feesValue / hodl * 365 / math.Max(held, 1) * 100
+	}
+	il := impermanentLoss(poolPrice(amount0, amount1), poolPrice(numericFloat(r.Reserve0), numericFloat(r.Reserve1)))
+	if span != nil {
+		// A range position's loss depends on its range, so it is read off
+		// its holdings against the deposit, both valued at the pool price.
+		il = 0
+		price := numericFloat(r.SqrtPrice) * numericFloat(r.SqrtPrice)
+		if held := amount0*price + amount1; held > 0 {
+			il = math.Min(0, ((current0*price+current1)/held-1)*100)
+		}
+	}
+	return models.PositionDetail{
+		LiquidityPosition: pos,
+		Current0:          formatAmount(current0),
+		Current1:          formatAmount(current1),
+		HodlValue:         formatAmount(hodl),
+		ImpermanentLoss:   il,
+		VsHodl:            formatAmount(value - hodl),
+	}
+}
+
+// positionRange describes a concentrated-liquidity position's range
+// against its pool's price, with the tokens its liquidity holds there and
+// its share of the pool's in-range liquidity.
+func positionRange(r db.GetUserPositionRow) (span *models.PositionRange, current0, current1, share float64) {
+	lower, upper := int(r.TickLower.Int32), int(r.TickUpper.Int32)
+	sqrtA, sqrtB := tickSqrtPrice(lower), tickSqrtPrice(upper)
+	liquidity := numericFloat(r.Shares)
+	current0, current1 = amountsForLiquidity(numericFloat(r.SqrtPrice), sqrtA, sqrtB, liquidity)
+
+	span = &models.PositionRange{
+		TickLower:  lower,
+		TickUpper:  upper,
+		PriceLower: sqrtA * sqrtA,
+		PriceUpper: sqrtB * sqrtB,
+		Status:     "in_range",
+	}
+	switch tick := int(r.PoolTick); {
+	case tick < lower:
+		span.Status = "below_range"
+	case tick >= upper:
+		span.Status = "above_range"
+	default:
+		if active := numericFloat(r.PoolLiquidity); active > 0 {
+			share = liquidity / active
+		}
+	}
+	return span, current0, current1, share
+}
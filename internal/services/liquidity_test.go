@@ -0,0 +1,1578 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jd7008911/aogeri-api/internal/config"
+	"github.com/jd7008911/aogeri-api/internal/db"
+	"github.com/jd7008911/aogeri-api/internal/models"
+)
+
+type fakeLiquidityQueries struct {
+	pools     map[pgtype.UUID]db.LiquidityPool
+	symbols   map[pgtype.UUID][2]string
+	positions map[pgtype.UUID]db.UserLiquidity
+	listed    []db.ListUserPositionsRow
+	symbol    pgtype.Text
+	swaps     []db.PoolSwap
+	prices    map[string]float64
+	feeValues map[pgtype.UUID]float64
+
+	farms      map[pgtype.UUID]db.LiquidityFarm
+	farmTokens map[pgtype.UUID]string
+	farmStakes map[pgtype.UUID]db.FarmStake
+
+	tokens map[string]db.GetTokenBySymbolRow
+	audit  []db.CreateAuditLogParams
+
+	observations map[pgtype.UUID][]db.PoolPriceObservation
+
+	ticks   map[pgtype.UUID]map[int32]db.PoolTick
+	nextNft int64
+
+	snapshots []db.CreatePoolSnapshotsParams
+	history   []db.GetPoolHistoryRow
+	historyAt db.GetPoolHistoryParams
+}
+
+// addPool registers an active pool with the given reserves and shares.
+func (f *fakeLiquidityQueries) addPool(sym0, sym1 string, reserve0, reserve1, shares float64) uuid.UUID {
+	id := uuid.New()
+	if f.pools == nil {
+		f.pools = map[pgtype.UUID]db.LiquidityPool{}
+		f.symbols = map[pgtype.UUID][2]string{}
+	}
+	f.pools[toPgUUID(id)] = db.LiquidityPool{
+		ID:          toPgUUID(id),
+		Name:        sym0 + "-" + sym1,
+		Token0ID:    toPgUUID(uuid.New()),
+		Token1ID:    toPgUUID(uuid.New()),
+		Reserve0:    numeric(reserve0),
+		Reserve1:    numeric(reserve1),
+		TotalShares: numeric(shares),
+		FeeBps:      30,
+		Status:      "active",
+		IsActive:    pgtype.Bool{Bool: true, Valid: true},
+		PoolType:    "constant_product",
+		TickSpacing: 1,
+	}
+	f.symbols[toPgUUID(id)] = [2]string{sym0, sym1}
+	return id
+}
+
+func (f *fakeLiquidityQueries) poolRow(p db.LiquidityPool) db.GetPoolRow {
+	sym := f.symbols[p.ID]
+	return db.GetPoolRow{
+		ID: p.ID, Name: p.Name,
+		Token0ID: p.Token0ID, Token0Symbol: sym[0],
+		Token1ID: p.Token1ID, Token1Symbol: sym[1],
+		TotalLiquidity: p.TotalLiquidity, Apr: p.Apr,
+		Reserve0: p.Reserve0, Reserve1: p.Reserve1, TotalShares: p.TotalShares,
+		IsActive: p.IsActive.Bool, FeeBps: p.FeeBps, Status: p.Status,
+		PoolType: p.PoolType, TickSpacing: p.TickSpacing, SqrtPrice: p.SqrtPrice, Tick: p.Tick, Liquidity: p.Liquidity,
+	}
+}
+
+func (f *fakeLiquidityQueries) ListPools(ctx context.Context, symbol pgtype.Text) ([]db.ListPoolsRow, error) {
+	f.symbol = symbol
+	var out []db.ListPoolsRow
+	for _, p := range f.pools {
+		if p.Status != "deprecated" {
+			out = append(out, db.ListPoolsRow(f.poolRow(p)))
+		}
+	}
+	return out, nil
+}
+func (f *fakeLiquidityQueries) GetPool(ctx context.Context, id pgtype.UUID) (db.GetPoolRow, error) {
+	p, ok := f.pools[id]
+	if !ok {
+		return db.GetPoolRow{}, pgx.ErrNoRows
+	}
+	return f.poolRow(p), nil
+}
+func (f *fakeLiquidityQueries) ListUserPositions(ctx context.Context, userID pgtype.UUID) ([]db.ListUserPositionsRow, error) {
+	return f.listed, nil
+}
+func (f *fakeLiquidityQueries) GetPoolForUpdate(ctx context.Context, id pgtype.UUID) (db.LiquidityPool, error) {
+	p, ok := f.pools[id]
+	if !ok {
+		return p, pgx.ErrNoRows
+	}
+	return p, nil
+}
+func (f *fakeLiquidityQueries) SetPoolReserves(ctx context.Context, arg db.SetPoolReservesParams) (db.LiquidityPool, error) {
+	p := f.pools[arg.ID]
+	sym := f.symbols[p.ID]
+	p.Reserve0, p.Reserve1, p.TotalShares = arg.Reserve0, arg.Reserve1, arg.TotalShares
+	p.Price0Cumulative, p.Price1Cumulative, p.PriceUpdatedAt = arg.Price0Cumulative, arg.Price1Cumulative, arg.PriceUpdatedAt
+	price := func(given pgtype.Numeric, symbol string) float64 {
+		if given.Valid {
+			return numericFloat(given)
+		}
+		return f.prices[symbol]
+	}
+	p.TotalLiquidity = numeric(numericFloat(arg.Reserve0)*price(arg.Price0, sym[0]) + numericFloat(arg.Reserve1)*price(arg.Price1, sym[1]))
+	f.pools[arg.ID] = p
+	return p, nil
+}
+func (f *fakeLiquidityQueries) GetUserPosition(ctx context.Context, arg db.GetUserPositionParams) (db.GetUserPositionRow, error) {
+	ul, ok := f.positions[arg.ID]
+	if !ok || ul.UserID != arg.UserID {
+		return db.GetUserPositionRow{}, pgx.ErrNoRows
+	}
+	p := f.pools[ul.PoolID]
+	sym := f.symbols[p.ID]
+	shares := numericFloat(ul.Shares)
+	growth0, growth1 := numericFloat(p.FeeGrowth0), numericFloat(p.FeeGrowth1)
+	if ul.TickLower.Valid {
+		lo, hi := f.ticks[p.ID][ul.TickLower.Int32], f.ticks[p.ID][ul.TickUpper.Int32]
+		lower, upper, current := int(ul.TickLower.Int32), int(ul.TickUpper.Int32), int(p.Tick)
+		growth0 = growthInside(lower, upper, current, numericFloat(lo.FeeGrowthOutside0), numericFloat(hi.FeeGrowthOutside0), growth0)
+		growth1 = growthInside(lower, upper, current, numericFloat(lo.FeeGrowthOutside1), numericFloat(hi.FeeGrowthOutside1), growth1)
+	}
+	return db.GetUserPositionRow{
+		ID: ul.ID, PoolID: p.ID, PoolName: p.Name, Token0Symbol: sym[0], Token1Symbol: sym[1],
+		Amount0: ul.Amount0, Amount1: ul.Amount1, Shares: ul.Shares, Status: ul.Status.String, CreatedAt: ul.CreatedAt,
+		Reserve0: p.Reserve0, Reserve1: p.Reserve1, PoolShares: p.TotalShares,
+		Price0: numeric(f.prices[sym[0]]), Price1: numeric(f.prices[sym[1]]),
+		Fees0: numeric(numericFloat(ul.Fees0) + shares*(growth0-numericFloat(ul.FeeGrowth0Last))),
+		Fees1: numeric(numericFloat(ul.Fees1) + shares*(growth1-numericFloat(ul.FeeGrowth1Last))),
+		NftID: ul.NftID, TickLower: ul.TickLower, TickUpper: ul.TickUpper,
+		PoolType: p.PoolType, SqrtPrice: p.SqrtPrice, PoolTick: p.Tick, PoolLiquidity: p.Liquidity,
+	}, nil
+}
+func (f *fakeLiquidityQueries) GetOpenPositionForUpdate(ctx context.Context, arg db.GetOpenPositionForUpdateParams) (db.UserLiquidity, error) {
+	for _, p := range f.positions {
+		if p.UserID == arg.UserID && p.PoolID == arg.PoolID && p.Status.String == "active" && !p.TickLower.Valid {
+			return p, nil
+		}
+	}
+	return db.UserLiquidity{}, pgx.ErrNoRows
+}
+func (f *fakeLiquidityQueries) AddToPosition(ctx context.Context, arg db.AddToPositionParams) (db.UserLiquidity, error) {
+	if f.positions == nil {
+		f.positions = map[pgtype.UUID]db.UserLiquidity{}
+	}
+	p, err := f.GetOpenPositionForUpdate(ctx, db.GetOpenPositionForUpdateParams{UserID: arg.UserID, PoolID: arg.PoolID})
+	if err != nil {
+		f.nextNft++
+		p = db.UserLiquidity{
+			ID: toPgUUID(uuid.New()), UserID: arg.UserID, PoolID: arg.PoolID, NftID: f.nextNft,
+			Status:         pgtype.Text{String: "active", Valid: true},
+			FeeGrowth0Last: arg.FeeGrowth0Last, FeeGrowth1Last: arg.FeeGrowth1Last,
+		}
+	}
+	f.settle(&p, arg.FeeGrowth0Last, arg.FeeGrowth1Last)
+	p.Amount0 = numeric(numericFloat(p.Amount0) + numericFloat(arg.Amount0))
+	p.Amount1 = numeric(numericFloat(p.Amount1) + numericFloat(arg.Amount1))
+	p.Shares = numeric(numericFloat(p.Shares) + numericFloat(arg.Shares))
+	f.positions[p.ID] = p
+	return p, nil
+}
+func (f *fakeLiquidityQueries) UpdatePosition(ctx context.Context, arg db.UpdatePositionParams) (db.UserLiquidity, error) {
+	p := f.positions[arg.ID]
+	f.settle(&p, arg.FeeGrowth0, arg.FeeGrowth1)
+	p.Amount0, p.Amount1, p.Shares = arg.Amount0, arg.Amount1, arg.Shares
+	p.Status = pgtype.Text{String: arg.Status, Valid: true}
+	f.positions[arg.ID] = p
+	return p, nil
+}
+
+// settle mirrors the queries' fee settlement: the position's shares earn
+// the fee growth since its checkpoint, which then moves to growth0/1.
+func (f *fakeLiquidityQueries) settle(p *db.UserLiquidity, growth0, growth1 pgtype.Numeric) {
+	shares := numericFloat(p.Shares)
+	p.Fees0 = numeric(numericFloat(p.Fees0) + shares*(numericFloat(growth0)-numericFloat(p.FeeGrowth0Last)))
+	p.Fees1 = numeric(numericFloat(p.Fees1) + shares*(numericFloat(growth1)-numericFloat(p.FeeGrowth1Last)))
+	p.FeeGrowth0Last, p.FeeGrowth1Last = growth0, growth1
+}
+
+func (f *fakeLiquidityQueries) CreateSwap(ctx context.Context, arg db.CreateSwapParams) (db.PoolSwap, error) {
+	swap := db.PoolSwap{
+		ID: toPgUUID(uuid.New()), PoolID: arg.PoolID, UserID: arg.UserID,
+		TokenInID: arg.TokenInID, TokenOutID: arg.TokenOutID,
+		AmountIn: arg.AmountIn, AmountOut: arg.AmountOut, Fee: arg.Fee, PriceImpact: arg.PriceImpact,
+		Reserve0After: arg.Reserve0After, Reserve1After: arg.Reserve1After,
+		CreatedAt: pgtype.Timestamp{Time: time.Now(), Valid: true},
+	}
+	f.swaps = append(f.swaps, swap)
+	return swap, nil
+}
+func (f *fakeLiquidityQueries) swapRow(s db.PoolSwap) db.ListUserSwapsRow {
+	symbol := func(token pgtype.UUID) string {
+		p := f.pools[s.PoolID]
+		if token == p.Token0ID {
+			return f.symbols[p.ID][0]
+		}
+		return f.symbols[p.ID][1]
+	}
+	return db.ListUserSwapsRow{
+		ID: s.ID, PoolID: s.PoolID, UserID: s.UserID,
+		TokenIn: symbol(s.TokenInID), TokenOut: symbol(s.TokenOutID),
+		AmountIn: s.AmountIn, AmountOut: s.AmountOut, Fee: s.Fee, PriceImpact: s.PriceImpact,
+		CreatedAt: s.CreatedAt,
+	}
+}
+func (f *fakeLiquidityQueries) ListPoolSwaps(ctx context.Context, arg db.ListPoolSwapsParams) ([]db.ListPoolSwapsRow, error) {
+	var out []db.ListPoolSwapsRow
+	for i := len(f.swaps) - 1; i >= 0; i-- {
+		if f.swaps[i].PoolID == arg.PoolID {
+			out = append(out, db.ListPoolSwapsRow(f.swapRow(f.swaps[i])))
+		}
+	}
+	return out, nil
+}
+func (f *fakeLiquidityQueries) ListUserSwaps(ctx context.Context, arg db.ListUserSwapsParams) ([]db.ListUserSwapsRow, error) {
+	var out []db.ListUserSwapsRow
+	for i := len(f.swaps) - 1; i >= 0; i-- {
+		if f.swaps[i].UserID == arg.UserID {
+			out = append(out, f.swapRow(f.swaps[i]))
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeLiquidityQueries) RecordPoolFees(ctx context.Context, arg db.RecordPoolFeesParams) error {
+	if f.feeValues == nil {
+		f.feeValues = map[pgtype.UUID]float64{}
+	}
+	sym := f.symbols[arg.PoolID]
+	f.feeValues[arg.PoolID] += numericFloat(arg.Fees0)*f.prices[sym[0]] + numericFloat(arg.Fees1)*f.prices[sym[1]]
+	return nil
+}
+func (f *fakeLiquidityQueries) GetPoolFeeIncome(ctx context.Context, id pgtype.UUID) (db.GetPoolFeeIncomeRow, error) {
+	fees := numeric(f.feeValues[id])
+	return db.GetPoolFeeIncomeRow{Tvl: f.pools[id].TotalLiquidity, Fees7d: fees, Fees30d: fees}, nil
+}
+func (f *fakeLiquidityQueries) AccruePoolFees(ctx context.Context, arg db.AccruePoolFeesParams) error {
+	p := f.pools[arg.ID]
+	p.FeeGrowth0 = numeric(numericFloat(p.FeeGrowth0) + numericFloat(arg.Growth0))
+	p.FeeGrowth1 = numeric(numericFloat(p.FeeGrowth1) + numericFloat(arg.Growth1))
+	p.Apr = arg.Apr
+	f.pools[arg.ID] = p
+	return nil
+}
+
+func (f *fakeLiquidityQueries) CreateFarm(ctx context.Context, arg db.CreateFarmParams) (db.LiquidityFarm, error) {
+	if _, ok := f.prices[arg.RewardToken]; !ok {
+		return db.LiquidityFarm{}, pgx.ErrNoRows
+	}
+	if f.farms == nil {
+		f.farms = map[pgtype.UUID]db.LiquidityFarm{}
+		f.farmTokens = map[pgtype.UUID]string{}
+		f.farmStakes = map[pgtype.UUID]db.FarmStake{}
+	}
+	farm := db.LiquidityFarm{
+		ID: toPgUUID(uuid.New()), PoolID: arg.PoolID, RewardPerDay: arg.RewardPerDay,
+		StartsAt: arg.StartsAt, EndsAt: arg.EndsAt, LastUpdateAt: arg.StartsAt, CreatedBy: arg.CreatedBy,
+		TotalStaked: numeric(0), RewardPerShare: numeric(0), RewardsPaid: numeric(0),
+	}
+	f.farms[farm.ID] = farm
+	f.farmTokens[farm.ID] = arg.RewardToken
+	return farm, nil
+}
+func (f *fakeLiquidityQueries) GetFarm(ctx context.Context, id pgtype.UUID) (db.GetFarmRow, error) {
+	farm, ok := f.farms[id]
+	if !ok {
+		return db.GetFarmRow{}, pgx.ErrNoRows
+	}
+	pool := f.pools[farm.PoolID]
+	return db.GetFarmRow{
+		ID: farm.ID, PoolID: farm.PoolID, PoolName: pool.Name,
+		RewardToken: f.farmTokens[id], RewardPrice: numeric(f.prices[f.farmTokens[id]]),
+		RewardPerDay: farm.RewardPerDay, StartsAt: farm.StartsAt, EndsAt: farm.EndsAt,
+		TotalStaked: farm.TotalStaked, RewardPerShare: farm.RewardPerShare,
+		LastUpdateAt: farm.LastUpdateAt, RewardsPaid: farm.RewardsPaid,
+		PoolShares: pool.TotalShares, PoolTvl: pool.TotalLiquidity,
+	}, nil
+}
+func (f *fakeLiquidityQueries) ListFarms(ctx context.Context, includeEnded bool) ([]db.ListFarmsRow, error) {
+	var out []db.ListFarmsRow
+	for id, farm := range f.farms {
+		if includeEnded || farm.EndsAt.Time.After(time.Now()) {
+			row, _ := f.GetFarm(ctx, id)
+			out = append(out, db.ListFarmsRow(row))
+		}
+	}
+	return out, nil
+}
+func (f *fakeLiquidityQueries) GetFarmForUpdate(ctx context.Context, id pgtype.UUID) (db.LiquidityFarm, error) {
+	farm, ok := f.farms[id]
+	if !ok {
+		return farm, pgx.ErrNoRows
+	}
+	return farm, nil
+}
+func (f *fakeLiquidityQueries) UpdateFarm(ctx context.Context, arg db.UpdateFarmParams) error {
+	farm := f.farms[arg.ID]
+	farm.RewardPerShare, farm.LastUpdateAt, farm.TotalStaked, farm.RewardsPaid = arg.RewardPerShare, arg.LastUpdateAt, arg.TotalStaked, arg.RewardsPaid
+	f.farms[arg.ID] = farm
+	return nil
+}
+func (f *fakeLiquidityQueries) GetOpenFarmStakeForUpdate(ctx context.Context, arg db.GetOpenFarmStakeForUpdateParams) (db.FarmStake, error) {
+	for _, st := range f.farmStakes {
+		if st.FarmID == arg.FarmID && st.UserID == arg.UserID && st.Status == "active" {
+			return st, nil
+		}
+	}
+	return db.FarmStake{}, pgx.ErrNoRows
+}
+func (f *fakeLiquidityQueries) CreateFarmStake(ctx context.Context, arg db.CreateFarmStakeParams) (db.FarmStake, error) {
+	st := db.FarmStake{
+		ID: toPgUUID(uuid.New()), FarmID: arg.FarmID, UserID: arg.UserID, PositionID: arg.PositionID,
+		Shares: arg.Shares, RewardPerSharePaid: arg.RewardPerSharePaid,
+		RewardsPending: numeric(0), RewardsClaimed: numeric(0), Status: "active",
+	}
+	f.farmStakes[st.ID] = st
+	return st, nil
+}
+func (f *fakeLiquidityQueries) UpdateFarmStake(ctx context.Context, arg db.UpdateFarmStakeParams) (db.FarmStake, error) {
+	st := f.farmStakes[arg.ID]
+	st.Shares, st.RewardPerSharePaid, st.RewardsPending, st.RewardsClaimed, st.Status = arg.Shares, arg.RewardPerSharePaid, arg.RewardsPending, arg.RewardsClaimed, arg.Status
+	f.farmStakes[arg.ID] = st
+	return st, nil
+}
+func (f *fakeLiquidityQueries) AddFarmedShares(ctx context.Context, arg db.AddFarmedSharesParams) error {
+	p := f.positions[arg.ID]
+	p.FarmedShares = numeric(numericFloat(p.FarmedShares) + numericFloat(arg.Delta))
+	f.positions[arg.ID] = p
+	return nil
+}
+func (f *fakeLiquidityQueries) ListUserFarmStakes(ctx context.Context, userID pgtype.UUID) ([]db.ListUserFarmStakesRow, error) {
+	var out []db.ListUserFarmStakesRow
+	for _, st := range f.farmStakes {
+		if st.UserID != userID || st.Status != "active" {
+			continue
+		}
+		farm := f.farms[st.FarmID]
+		out = append(out, db.ListUserFarmStakesRow{
+			ID: st.ID, FarmID: st.FarmID, PositionID: st.PositionID, PoolID: farm.PoolID,
+			PoolName: f.pools[farm.PoolID].Name, RewardToken: f.farmTokens[farm.ID],
+			Shares: st.Shares, RewardPerSharePaid: st.RewardPerSharePaid,
+			RewardsPending: st.RewardsPending, RewardsClaimed: st.RewardsClaimed, Status: st.Status,
+			RewardPerDay: farm.RewardPerDay, StartsAt: farm.StartsAt, EndsAt: farm.EndsAt,
+			TotalStaked: farm.TotalStaked, RewardPerShare: farm.RewardPerShare, LastUpdateAt: farm.LastUpdateAt,
+		})
+	}
+	return out, nil
+}
+
+// addToken registers a token that pools can be created for.
+func (f *fakeLiquidityQueries) addToken(symbol string, active bool) {
+	if f.tokens == nil {
+		f.tokens = map[string]db.GetTokenBySymbolRow{}
+	}
+	f.tokens[symbol] = db.GetTokenBySymbolRow{ID: toPgUUID(uuid.New()), Symbol: symbol, IsActive: active}
+}
+
+func (f *fakeLiquidityQueries) GetTokenBySymbol(ctx context.Context, symbol string) (db.GetTokenBySymbolRow, error) {
+	t, ok := f.tokens[symbol]
+	if !ok {
+		return t, pgx.ErrNoRows
+	}
+	return t, nil
+}
+func (f *fakeLiquidityQueries) PoolPairExists(ctx context.Context, arg db.PoolPairExistsParams) (bool, error) {
+	for _, p := range f.pools {
+		same := (p.Token0ID == arg.TokenA && p.Token1ID == arg.TokenB) || (p.Token0ID == arg.TokenB && p.Token1ID == arg.TokenA)
+		if same && p.FeeBps == arg.FeeBps && p.Status != "deprecated" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+func (f *fakeLiquidityQueries) CreatePool(ctx context.Context, arg db.CreatePoolParams) (db.LiquidityPool, error) {
+	id := f.addPool("", "", 0, 0, 0)
+	p := f.pools[toPgUUID(id)]
+	p.Name, p.Token0ID, p.Token1ID, p.FeeBps = arg.Name, arg.Token0ID, arg.Token1ID, arg.FeeBps
+	p.PoolType, p.TickSpacing, p.SqrtPrice, p.Tick = arg.PoolType, arg.TickSpacing, arg.SqrtPrice, arg.Tick
+	f.pools[p.ID] = p
+	var sym [2]string
+	for _, t := range f.tokens {
+		if t.ID == arg.Token0ID {
+			sym[0] = t.Symbol
+		}
+		if t.ID == arg.Token1ID {
+			sym[1] = t.Symbol
+		}
+	}
+	f.symbols[p.ID] = sym
+	return p, nil
+}
+func (f *fakeLiquidityQueries) SetPoolFee(ctx context.Context, arg db.SetPoolFeeParams) error {
+	p := f.pools[arg.ID]
+	p.FeeBps = arg.FeeBps
+	f.pools[p.ID] = p
+	return nil
+}
+func (f *fakeLiquidityQueries) SetPoolStatus(ctx context.Context, arg db.SetPoolStatusParams) error {
+	p := f.pools[arg.ID]
+	p.Status, p.IsActive = arg.Status, pgtype.Bool{Bool: arg.Status == "active", Valid: true}
+	f.pools[p.ID] = p
+	return nil
+}
+func (f *fakeLiquidityQueries) CreateAuditLog(ctx context.Context, arg db.CreateAuditLogParams) error {
+	f.audit = append(f.audit, arg)
+	return nil
+}
+func (f *fakeLiquidityQueries) ListAuditLogs(ctx context.Context, arg db.ListAuditLogsParams) ([]db.ListAuditLogsRow, error) {
+	var out []db.ListAuditLogsRow
+	for i := len(f.audit) - 1; i >= 0; i-- {
+		a := f.audit[i]
+		if a.ResourceType == arg.ResourceType && a.ResourceID == arg.ResourceID {
+			row := db.ListAuditLogsRow{ID: toPgUUID(uuid.New()), UserID: a.UserID, Action: a.Action, Details: a.Details, UserAgent: a.UserAgent}
+			if a.IpAddress != nil {
+				row.IpAddress = a.IpAddress.String()
+			}
+			out = append(out, row)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeLiquidityQueries) GetPoolState(ctx context.Context, id pgtype.UUID) (db.LiquidityPool, error) {
+	return f.GetPoolForUpdate(ctx, id)
+}
+func (f *fakeLiquidityQueries) RecordPriceObservation(ctx context.Context, arg db.RecordPriceObservationParams) error {
+	if f.observations == nil {
+		f.observations = map[pgtype.UUID][]db.PoolPriceObservation{}
+	}
+	f.observations[arg.PoolID] = append(f.observations[arg.PoolID], db.PoolPriceObservation(arg))
+	return nil
+}
+func (f *fakeLiquidityQueries) GetPriceObservation(ctx context.Context, arg db.GetPriceObservationParams) (db.PoolPriceObservation, error) {
+	var before *db.PoolPriceObservation
+	for i, o := range f.observations[arg.PoolID] {
+		if !o.ObservedAt.Time.After(arg.At.Time) && (before == nil || o.ObservedAt.Time.After(before.ObservedAt.Time)) {
+			before = &f.observations[arg.PoolID][i]
+		}
+	}
+	if before == nil {
+		return db.PoolPriceObservation{}, pgx.ErrNoRows
+	}
+	return *before, nil
+}
+func (f *fakeLiquidityQueries) GetFirstPriceObservation(ctx context.Context, poolID pgtype.UUID) (db.PoolPriceObservation, error) {
+	var first *db.PoolPriceObservation
+	for i, o := range f.observations[poolID] {
+		if first == nil || o.ObservedAt.Time.Before(first.ObservedAt.Time) {
+			first = &f.observations[poolID][i]
+		}
+	}
+	if first == nil {
+		return db.PoolPriceObservation{}, pgx.ErrNoRows
+	}
+	return *first, nil
+}
+
+func (f *fakeLiquidityQueries) GetPositionForUpdate(ctx context.Context, arg db.GetPositionForUpdateParams) (db.UserLiquidity, error) {
+	p, ok := f.positions[arg.ID]
+	if !ok || p.UserID != arg.UserID {
+		return p, pgx.ErrNoRows
+	}
+	return p, nil
+}
+func (f *fakeLiquidityQueries) CreateRangePosition(ctx context.Context, arg db.CreateRangePositionParams) (db.UserLiquidity, error) {
+	if f.positions == nil {
+		f.positions = map[pgtype.UUID]db.UserLiquidity{}
+	}
+	f.nextNft++
+	p := db.UserLiquidity{
+		ID: toPgUUID(uuid.New()), UserID: arg.UserID, PoolID: arg.PoolID, NftID: f.nextNft,
+		Amount0: arg.Amount0, Amount1: arg.Amount1, Shares: arg.Shares,
+		Status:    pgtype.Text{String: "active", Valid: true},
+		TickLower: pgtype.Int4{Int32: arg.TickLower, Valid: true}, TickUpper: pgtype.Int4{Int32: arg.TickUpper, Valid: true},
+		FeeGrowth0Last: arg.FeeGrowth0Last, FeeGrowth1Last: arg.FeeGrowth1Last,
+	}
+	f.positions[p.ID] = p
+	return p, nil
+}
+func (f *fakeLiquidityQueries) CollectPositionFees(ctx context.Context, id pgtype.UUID) error {
+	p := f.positions[id]
+	p.FeesCollected0, p.FeesCollected1 = p.Fees0, p.Fees1
+	f.positions[id] = p
+	return nil
+}
+func (f *fakeLiquidityQueries) ListPoolTicks(ctx context.Context, poolID pgtype.UUID) ([]db.PoolTick, error) {
+	var out []db.PoolTick
+	for _, t := range f.ticks[poolID] {
+		if numericFloat(t.LiquidityGross) > 0 {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+func (f *fakeLiquidityQueries) UpdateTick(ctx context.Context, arg db.UpdateTickParams) error {
+	if f.ticks == nil {
+		f.ticks = map[pgtype.UUID]map[int32]db.PoolTick{}
+	}
+	if f.ticks[arg.PoolID] == nil {
+		f.ticks[arg.PoolID] = map[int32]db.PoolTick{}
+	}
+	t, ok := f.ticks[arg.PoolID][arg.Tick]
+	if !ok || numericFloat(t.LiquidityGross) <= 0 {
+		t = db.PoolTick{PoolID: arg.PoolID, Tick: arg.Tick, FeeGrowthOutside0: arg.FeeGrowthOutside0, FeeGrowthOutside1: arg.FeeGrowthOutside1}
+	}
+	t.LiquidityGross = numeric(numericFloat(t.LiquidityGross) + numericFloat(arg.GrossDelta))
+	t.LiquidityNet = numeric(numericFloat(t.LiquidityNet) + numericFloat(arg.NetDelta))
+	f.ticks[arg.PoolID][arg.Tick] = t
+	return nil
+}
+func (f *fakeLiquidityQueries) CrossTick(ctx context.Context, arg db.CrossTickParams) error {
+	t := f.ticks[arg.PoolID][arg.Tick]
+	t.FeeGrowthOutside0 = numeric(numericFloat(arg.FeeGrowth0) - numericFloat(t.FeeGrowthOutside0))
+	t.FeeGrowthOutside1 = numeric(numericFloat(arg.FeeGrowth1) - numericFloat(t.FeeGrowthOutside1))
+	f.ticks[arg.PoolID][arg.Tick] = t
+	return nil
+}
+func (f *fakeLiquidityQueries) SetPoolCurve(ctx context.Context, arg db.SetPoolCurveParams) error {
+	p := f.pools[arg.ID]
+	p.SqrtPrice, p.Tick, p.Liquidity = arg.SqrtPrice, arg.Tick, arg.Liquidity
+	f.pools[p.ID] = p
+	return nil
+}
+func (f *fakeLiquidityQueries) CreatePoolSnapshots(ctx context.Context, arg db.CreatePoolSnapshotsParams) (int64, error) {
+	f.snapshots = append(f.snapshots, arg)
+	var n int64
+	for _, p := range f.pools {
+		if p.Status != "deprecated" {
+			n++
+		}
+	}
+	return n, nil
+}
+func (f *fakeLiquidityQueries) GetPoolHistory(ctx context.Context, arg db.GetPoolHistoryParams) ([]db.GetPoolHistoryRow, error) {
+	f.historyAt = arg
+	return f.history, nil
+}
+
+// agePool moves a pool's price history back by d, as if d had passed since
+// its reserves last changed.
+func (f *fakeLiquidityQueries) agePool(id uuid.UUID, d time.Duration) {
+	p := f.pools[toPgUUID(id)]
+	p.PriceUpdatedAt.Time = p.PriceUpdatedAt.Time.Add(-d)
+	f.pools[p.ID] = p
+	for i := range f.observations[p.ID] {
+		f.observations[p.ID][i].ObservedAt.Time = f.observations[p.ID][i].ObservedAt.Time.Add(-d)
+	}
+}
+
+// advanceFarm moves a farm's last update back by d, as if d had passed
+// since it was last accrued.
+func (f *fakeLiquidityQueries) advanceFarm(id uuid.UUID, d time.Duration) {
+	farm := f.farms[toPgUUID(id)]
+	farm.LastUpdateAt.Time = farm.LastUpdateAt.Time.Add(-d)
+	f.farms[farm.ID] = farm
+}
+
+func numeric(f float64) pgtype.Numeric {
+	n, _ := floatNumeric(f)
+	return n
+}
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-9*math.Max(1, math.Abs(b)) }
+
+func TestLiquidity_PoolsAndPositions(t *testing.T) {
+	fq := &fakeLiquidityQueries{}
+	poolID := fq.addPool("AOG", "BNB", 1000, 4, 63.245553203367585)
+	p := fq.pools[toPgUUID(poolID)]
+	p.TotalLiquidity, p.Apr = numeric(500000), numeric(12)
+	fq.pools[p.ID] = p
+	fq.listed = []db.ListUserPositionsRow{{
+		ID: toPgUUID(uuid.New()), PoolID: toPgUUID(poolID), PoolName: "AOG-BNB",
+		Amount0: numeric(100), Amount1: numeric(2), Shares: numeric(25), Status: "active",
+		Reserve0: numeric(1000), Reserve1: numeric(4), PoolShares: numeric(100),
+		Price0: numeric(250), Price1: numeric(62500),
+	}}
+	s := NewLiquidityService(fq)
+	ctx := context.Background()
+
+	pools, err := s.ListPools(ctx, "aog")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fq.symbol.String != "AOG" || len(pools) != 1 || pools[0].TVL != "500000" || pools[0].APR != 12 ||
+		pools[0].Token1.Symbol != "BNB" || pools[0].Price != 0.004 {
+		t.Fatalf("unexpected pools: %+v (symbol %q)", pools, fq.symbol.String)
+	}
+
+	positions, err := s.GetUserPositions(ctx, uuid.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(positions) != 1 || positions[0].PoolShare != 25 || positions[0].Value != "125000" {
+		t.Fatalf("unexpected positions: %+v", positions)
+	}
+
+	if _, err := s.GetPool(ctx, uuid.New()); !errors.Is(err, ErrPoolNotFound) {
+		t.Fatalf("expected ErrPoolNotFound, got %v", err)
+	}
+}
+
+func TestLiquidity_AddAndRemove(t *testing.T) {
+	fq := &fakeLiquidityQueries{}
+	poolID := fq.addPool("AOG", "USDT", 0, 0, 0)
+	s := NewLiquidityService(fq)
+	ctx := context.Background()
+	alice, bob := uuid.New(), uuid.New()
+
+	// The first deposit sets the price and mints sqrt(x*y) shares.
+	r, err := s.AddLiquidity(ctx, alice, poolID, models.AddLiquidityRequest{Amount0: "100", Amount1: "400"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Shares != "200" || r.Pool.Price != 4 || r.Position.PoolShare != 100 || r.Refund0 != "" {
+		t.Fatalf("unexpected first deposit: %+v", r)
+	}
+
+	// An unbalanced deposit is matched to the pool ratio and the excess
+	// token1 refunded.
+	r, err = s.AddLiquidity(ctx, bob, poolID, models.AddLiquidityRequest{Amount0: "50", Amount1: "300"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Amount0 != "50" || r.Amount1 != "200" || r.Refund1 != "100" || r.Shares != "100" || r.Pool.Reserve0 != "150" || r.Pool.TotalShares != "300" {
+		t.Fatalf("unexpected matched deposit: %+v", r)
+	}
+
+	if _, err := s.AddLiquidity(ctx, bob, poolID, models.AddLiquidityRequest{Amount0: "10", Amount1: "40", MinShares: "21"}); !errors.Is(err, ErrSlippageExceeded) {
+		t.Fatalf("expected ErrSlippageExceeded, got %v", err)
+	}
+	// Non-finite amounts and bounds are rejected before they reach the pool.
+	for _, bad := range []models.AddLiquidityRequest{
+		{Amount0: "NaN", Amount1: "40"},
+		{Amount0: "10", Amount1: "Inf"},
+		{Amount0: "10", Amount1: "40", MinShares: "NaN"},
+		{Amount0: "10", Amount1: "40", MinShares: "+Inf"},
+	} {
+		if _, err := s.AddLiquidity(ctx, bob, poolID, bad); !errors.Is(err, ErrInvalidLiquidityAmount) {
+			t.Fatalf("AddLiquidity(%+v): expected ErrInvalidLiquidityAmount, got %v", bad, err)
+		}
+	}
+	for _, bad := range []models.RemoveLiquidityRequest{
+		{Shares: "NaN"},
+		{Shares: "50", MinAmount0: "NaN"},
+		{Shares: "50", MinAmount1: "-Inf"},
+	} {
+		if _, err := s.RemoveLiquidity(ctx, bob, poolID, bad); !errors.Is(err, ErrInvalidLiquidityAmount) {
+			t.Fatalf("RemoveLiquidity(%+v): expected ErrInvalidLiquidityAmount, got %v", bad, err)
+		}
+	}
+	if p := fq.pools[toPgUUID(poolID)]; numericString(p.Reserve0) != "150" || numericString(p.TotalShares) != "300" {
+		t.Fatalf("rejected requests changed the pool: %+v", p)
+	}
+	if _, err := s.RemoveLiquidity(ctx, bob, poolID, models.RemoveLiquidityRequest{Shares: "101"}); !errors.Is(err, ErrInsufficientShares) {
+		t.Fatalf("expected ErrInsufficientShares, got %v", err)
+	}
+	if _, err := s.RemoveLiquidity(ctx, bob, poolID, models.RemoveLiquidityRequest{Shares: "50", MinAmount1: "101"}); !errors.Is(err, ErrSlippageExceeded) {
+		t.Fatalf("expected ErrSlippageExceeded, got %v", err)
+	}
+
+	// Burning half of bob's shares returns a sixth of each reserve.
+	r, err = s.RemoveLiquidity(ctx, bob, poolID, models.RemoveLiquidityRequest{Shares: "50", MinAmount0: "25", MinAmount1: "100"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Amount0 != "25" || r.Amount1 != "100" || r.Position.Shares != "50" || r.Position.Amount1 != "100" || r.Position.Status != "active" {
+		t.Fatalf("unexpected withdrawal: %+v", r)
+	}
+
+	// Everyone leaving empties the pool and closes the positions.
+	if _, err := s.RemoveLiquidity(ctx, bob, poolID, models.RemoveLiquidityRequest{Shares: "50"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r, err = s.RemoveLiquidity(ctx, alice, poolID, models.RemoveLiquidityRequest{Shares: "200"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Position.Status != "withdrawn" || r.Pool.Reserve0 != "0" || r.Pool.Reserve1 != "0" || r.Pool.TotalShares != "0" ||
+		!approx(mustParseAmount(r.Amount0), 100) || !approx(mustParseAmount(r.Amount1), 400) {
+		t.Fatalf("unexpected final withdrawal: %+v", r)
+	}
+	if _, err := s.RemoveLiquidity(ctx, alice, poolID, models.RemoveLiquidityRequest{Shares: "1"}); !errors.Is(err, ErrPositionNotFound) {
+		t.Fatalf("expected ErrPositionNotFound, got %v", err)
+	}
+}
+
+func TestSwapAmounts(t *testing.T) {
+	out, fee, impact := swapAmounts(1000, 1000, 100, 30)
+	// 0.3% of 100 stays in the pool; 99.7 trades against x*y=k.
+	if !approx(fee, 0.3) || !approx(out, 1000*99.7/1099.7) || !approx(impact, 99.7/1099.7*100) {
+		t.Fatalf("unexpected swap: out %v fee %v impact %v", out, fee, impact)
+	}
+	if out, fee, _ := swapAmounts(1000, 4000, 10, 0); fee != 0 || !approx(out, 4000*10.0/1010) {
+		t.Fatalf("unexpected fee-free swap: out %v fee %v", out, fee)
+	}
+}
+
+func TestLiquidity_QuoteAndSwap(t *testing.T) {
+	fq := &fakeLiquidityQueries{}
+	poolID := fq.addPool("AOG", "USDT", 1000, 4000, 2000)
+	s := NewLiquidityService(fq).WithConfig(config.LiquidityConfig{SwapFeeBps: 30, DefaultSlippageBps: 50})
+	ctx := context.Background()
+	user := uuid.New()
+
+	quote, err := s.Quote(ctx, poolID, "aog", "10", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantOut := 4000 * 9.97 / 1009.97
+	if quote.TokenOut != "USDT" || quote.SpotPrice != 4 || quote.Fee != "0.03" || quote.SlippageBps != 50 ||
+		!approx(mustParseAmount(quote.AmountOut), wantOut) || !approx(mustParseAmount(quote.MinimumReceived), wantOut*0.995) {
+		t.Fatalf("unexpected quote: %+v", quote)
+	}
+
+	if _, err := s.Swap(ctx, user, poolID, models.SwapRequest{TokenIn: "AOG", AmountIn: "10", MinAmountOut: "39.5"}); !errors.Is(err, ErrSlippageExceeded) {
+		t.Fatalf("expected ErrSlippageExceeded, got %v", err)
+	}
+	past := time.Now().Add(-time.Minute)
+	if _, err := s.Swap(ctx, user, poolID, models.SwapRequest{TokenIn: "AOG", AmountIn: "10", MinAmountOut: "0", Deadline: &past}); !errors.Is(err, ErrDeadlineExceeded) {
+		t.Fatalf("expected ErrDeadlineExceeded, got %v", err)
+	}
+	if _, err := s.Swap(ctx, user, poolID, models.SwapRequest{TokenIn: "BNB", AmountIn: "10", MinAmountOut: "0"}); !errors.Is(err, ErrInvalidSwap) {
+		t.Fatalf("expected ErrInvalidSwap, got %v", err)
+	}
+
+	// NaN and infinite amounts never reach pricing, and a NaN bound cannot
+	// switch the slippage check off.
+	for _, bad := range []models.SwapRequest{
+		{TokenIn: "AOG", AmountIn: "NaN", MinAmountOut: "0"},
+		{TokenIn: "AOG", AmountIn: "Inf", MinAmountOut: "0"},
+		{TokenIn: "AOG", AmountIn: "10", MinAmountOut: "NaN"},
+	} {
+		if _, err := s.Swap(ctx, user, poolID, bad); !errors.Is(err, ErrInvalidSwap) {
+			t.Fatalf("Swap(%+v): expected ErrInvalidSwap, got %v", bad, err)
+		}
+	}
+	if _, err := s.Quote(ctx, poolID, "AOG", "NaN", 0); !errors.Is(err, ErrInvalidSwap) {
+		t.Fatalf("expected ErrInvalidSwap for a NaN quote, got %v", err)
+	}
+	if len(fq.swaps) != 0 || numericString(fq.pools[toPgUUID(poolID)].Reserve0) != "1000" {
+		t.Fatalf("rejected swaps changed state: %d swaps", len(fq.swaps))
+	}
+
+	receipt, err := s.Swap(ctx, user, poolID, models.SwapRequest{TokenIn: "AOG", AmountIn: "10", MinAmountOut: quote.MinimumReceived})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if receipt.Swap.AmountOut != quote.AmountOut || receipt.Pool.Reserve0 != "1010" {
+		t.Fatalf("swap did not match its quote: %+v", receipt)
+	}
+	// The fee stays in the pool, so k grows.
+	r0, r1 := mustParseAmount(receipt.Pool.Reserve0), mustParseAmount(receipt.Pool.Reserve1)
+	if r0*r1 <= 1000*4000 {
+		t.Fatalf("expected k to grow, got %v", r0*r1)
+	}
+
+	// Selling token1 moves the reserves the other way.
+	receipt, err = s.Swap(ctx, user, poolID, models.SwapRequest{TokenIn: "USDT", AmountIn: "100", MinAmountOut: "0"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if receipt.Swap.TokenOut != "AOG" || mustParseAmount(receipt.Pool.Reserve1) != r1+100 || mustParseAmount(receipt.Pool.Reserve0) >= r0 {
+		t.Fatalf("unexpected reverse swap: %+v", receipt)
+	}
+
+	history, err := s.ListUserSwaps(ctx, user, 20, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history) != 2 || history[0].TokenIn != "USDT" || history[1].AmountIn != "10" {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+
+	empty := fq.addPool("AOG", "ETH", 0, 0, 0)
+	if _, err := s.Quote(ctx, empty, "AOG", "1", 0); !errors.Is(err, ErrInsufficientLiquidity) {
+		t.Fatalf("expected ErrInsufficientLiquidity, got %v", err)
+	}
+}
+
+func TestLiquidity_Route(t *testing.T) {
+	fq := &fakeLiquidityQueries{}
+	direct := fq.addPool("AOG", "USDT", 20, 80, 40)
+	aogEth := fq.addPool("AOG", "ETH", 10000, 10, 300)
+	ethUsdt := fq.addPool("ETH", "USDT", 100, 400000, 6000)
+	fq.addPool("BNB", "BUSD", 100, 30000, 1700)
+	s := NewLiquidityService(fq).WithConfig(config.LiquidityConfig{SwapFeeBps: 30, DefaultSlippageBps: 50, MaxHops: 3})
+	ctx := context.Background()
+	user := uuid.New()
+
+	// The thin direct pool loses to the deeper path through ETH.
+	quote, err := s.QuoteRoute(ctx, "aog", "usdt", "10", 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(quote.Hops) != 2 || quote.Hops[0].PoolID != aogEth || quote.Hops[1].PoolID != ethUsdt ||
+		quote.Hops[0].AmountOut != quote.Hops[1].AmountIn || quote.Hops[1].AmountOut != quote.AmountOut {
+		t.Fatalf("unexpected route: %+v", quote)
+	}
+	wantImpact := (1 - (1-quote.Hops[0].PriceImpact/100)*(1-quote.Hops[1].PriceImpact/100)) * 100
+	if !approx(quote.PriceImpact, wantImpact) {
+		t.Fatalf("price impact = %v, want %v", quote.PriceImpact, wantImpact)
+	}
+	single, err := s.QuoteRoute(ctx, "AOG", "USDT", "10", 1, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(single.Hops) != 1 || single.Hops[0].PoolID != direct || mustParseAmount(single.AmountOut) >= mustParseAmount(quote.AmountOut) {
+		t.Fatalf("unexpected single-hop route: %+v", single)
+	}
+
+	if _, err := s.QuoteRoute(ctx, "AOG", "BNB", "10", 0, 0); !errors.Is(err, ErrNoRoute) {
+		t.Fatalf("expected ErrNoRoute, got %v", err)
+	}
+	if _, err := s.QuoteRoute(ctx, "AOG", "AOG", "10", 0, 0); !errors.Is(err, ErrInvalidSwap) {
+		t.Fatalf("expected ErrInvalidSwap, got %v", err)
+	}
+
+	if _, err := s.QuoteRoute(ctx, "AOG", "USDT", "NaN", 0, 0); !errors.Is(err, ErrInvalidSwap) {
+		t.Fatalf("expected ErrInvalidSwap for a NaN route quote, got %v", err)
+	}
+	for _, bad := range []models.RouteSwapRequest{
+		{TokenIn: "AOG", TokenOut: "USDT", AmountIn: "NaN", MinAmountOut: "0"},
+		{TokenIn: "AOG", TokenOut: "USDT", AmountIn: "-Inf", MinAmountOut: "0"},
+		{TokenIn: "AOG", TokenOut: "USDT", AmountIn: "10", MinAmountOut: "NaN"},
+	} {
+		if _, err := s.SwapRoute(ctx, user, bad); !errors.Is(err, ErrInvalidSwap) {
+			t.Fatalf("SwapRoute(%+v): expected ErrInvalidSwap, got %v", bad, err)
+		}
+	}
+
+	// A failing bound leaves every pool untouched.
+	req := models.RouteSwapRequest{TokenIn: "AOG", TokenOut: "USDT", AmountIn: "10", MinAmountOut: "1000"}
+	if _, err := s.SwapRoute(ctx, user, req); !errors.Is(err, ErrSlippageExceeded) {
+		t.Fatalf("expected ErrSlippageExceeded, got %v", err)
+	}
+	if len(fq.swaps) != 0 || numericFloat(fq.pools[toPgUUID(aogEth)].Reserve0) != 10000 {
+		t.Fatalf("failed route changed state: %d swaps", len(fq.swaps))
+	}
+
+	req.MinAmountOut = quote.MinimumReceived
+	receipt, err := s.SwapRoute(ctx, user, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(receipt.Swaps) != 2 || receipt.Route.AmountOut != quote.AmountOut || len(fq.swaps) != 2 {
+		t.Fatalf("unexpected receipt: %+v", receipt)
+	}
+	eth := mustParseAmount(quote.Hops[0].AmountOut)
+	first, second := fq.pools[toPgUUID(aogEth)], fq.pools[toPgUUID(ethUsdt)]
+	if numericFloat(first.Reserve0) != 10010 || !approx(numericFloat(first.Reserve1), 10-eth) ||
+		!approx(numericFloat(second.Reserve0), 100+eth) || numericFloat(fq.pools[toPgUUID(direct)].Reserve0) != 20 {
+		t.Fatalf("unexpected reserves after route: %+v / %+v", first, second)
+	}
+}
+
+func TestImpermanentLoss(t *testing.T) {
+	if il := impermanentLoss(4, 4); il != 0 {
+		t.Fatalf("unchanged price: got %v", il)
+	}
+	// A 4x move costs 20% against holding, whichever way it goes.
+	if il := impermanentLoss(1, 4); !approx(il, -20) {
+		t.Fatalf("4x up: got %v", il)
+	}
+	if il := impermanentLoss(4, 1); !approx(il, -20) {
+		t.Fatalf("4x down: got %v", il)
+	}
+	if apr := feeAPR(7, 7, 365); !approx(apr, 100) {
+		t.Fatalf("feeAPR = %v, want 100", apr)
+	}
+}
+
+func TestLiquidity_FeesAndPositionReport(t *testing.T) {
+	fq := &fakeLiquidityQueries{prices: map[string]float64{"AOG": 4, "USDT": 1}}
+	poolID := fq.addPool("AOG", "USDT", 0, 0, 0)
+	s := NewLiquidityService(fq).WithConfig(config.LiquidityConfig{SwapFeeBps: 30, DefaultSlippageBps: 50})
+	ctx := context.Background()
+	alice, bob := uuid.New(), uuid.New()
+
+	deposit, err := s.AddLiquidity(ctx, alice, poolID, models.AddLiquidityRequest{Amount0: "1000", Amount1: "4000"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := s.Swap(ctx, bob, poolID, models.SwapRequest{TokenIn: "AOG", AmountIn: "100", MinAmountOut: "0"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Alice holds every share, so she earned the whole 0.3 AOG fee.
+	detail, err := s.GetPosition(ctx, alice, deposit.Position.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pool := fq.pools[toPgUUID(poolID)]
+	r0, r1 := numericFloat(pool.Reserve0), numericFloat(pool.Reserve1)
+	if !approx(mustParseAmount(detail.FeesEarned0), 0.3) || detail.FeesEarned1 != "0" || !approx(mustParseAmount(detail.FeesValue), 1.2) {
+		t.Fatalf("unexpected fees: %+v", detail)
+	}
+	if detail.HodlValue != "8000" || !approx(mustParseAmount(detail.Value), r0*4+r1) ||
+		!approx(detail.ImpermanentLoss, impermanentLoss(4, r1/r0)) || detail.ImpermanentLoss >= 0 {
+		t.Fatalf("unexpected report: %+v", detail)
+	}
+	if _, err := s.GetPosition(ctx, bob, deposit.Position.ID); !errors.Is(err, ErrPositionNotFound) {
+		t.Fatalf("expected ErrPositionNotFound, got %v", err)
+	}
+
+	// The fee income drives the pool's APR.
+	p, err := s.GetPool(ctx, poolID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantAPR := 1.2 / 7 * 365 / (r0*4 + r1) * 100
+	if !approx(mustParseAmount(p.Fees.Fees7d), 1.2) || !approx(p.Fees.APR7d, wantAPR) || !approx(p.APR, wantAPR) {
+		t.Fatalf("unexpected pool fees: %+v (apr %v)", p.Fees, p.APR)
+	}
+
+	// Bob joins after the first swap and only shares in later fees.
+	joined, err := s.AddLiquidity(ctx, bob, poolID, models.AddLiquidityRequest{Amount0: "1100", Amount1: "4000"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := s.Swap(ctx, alice, poolID, models.SwapRequest{TokenIn: "USDT", AmountIn: "1000", MinAmountOut: "0"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bobShare := mustParseAmount(joined.Position.Shares) / mustParseAmount(joined.Pool.TotalShares)
+	bobDetail, err := s.GetPosition(ctx, bob, joined.Position.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bobDetail.FeesEarned0 != "0" || !approx(mustParseAmount(bobDetail.FeesEarned1), 3*bobShare) {
+		t.Fatalf("unexpected fees for late joiner: %+v", bobDetail.LiquidityPosition)
+	}
+
+	// Withdrawing settles fees into the position rather than losing them.
+	out, err := s.RemoveLiquidity(ctx, alice, poolID, models.RemoveLiquidityRequest{Shares: "1000"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !approx(mustParseAmount(out.Position.FeesEarned0), 0.3) || !approx(mustParseAmount(out.Position.FeesEarned1), 3*(1-bobShare)) {
+		t.Fatalf("unexpected settled fees: %+v", out.Position)
+	}
+}
+
+func TestRewardPerShare(t *testing.T) {
+	start := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(10 * 24 * time.Hour)
+	day := 24 * time.Hour
+
+	// 100 a day over 50 shares is 2 per share per day.
+	if got := rewardPerShare(1, 50, 100, start, end, start.Add(day), start.Add(3*day)); got != 5 {
+		t.Fatalf("got %v, want 5", got)
+	}
+	// Nothing accrues before the start, after the end or with nothing staked.
+	if got := rewardPerShare(0, 50, 100, start, end, start.Add(-5*day), start.Add(day)); got != 2 {
+		t.Fatalf("before start: got %v, want 2", got)
+	}
+	if got := rewardPerShare(0, 50, 100, start, end, end.Add(-day), end.Add(5*day)); got != 2 {
+		t.Fatalf("after end: got %v, want 2", got)
+	}
+	if got := rewardPerShare(3, 0, 100, start, end, start, end); got != 3 {
+		t.Fatalf("nothing staked: got %v, want 3", got)
+	}
+}
+
+func TestLiquidity_Farming(t *testing.T) {
+	fq := &fakeLiquidityQueries{prices: map[string]float64{"AOG": 1, "USDT": 1}}
+	poolID := fq.addPool("AOG", "USDT", 0, 0, 0)
+	s := NewLiquidityService(fq)
+	ctx := context.Background()
+	admin, alice, bob := uuid.New(), uuid.New(), uuid.New()
+	near := func(got string, want float64) bool { return math.Abs(mustParseAmount(got)-want) < 1e-3 }
+
+	if _, err := s.AddLiquidity(ctx, alice, poolID, models.AddLiquidityRequest{Amount0: "200", Amount1: "200"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := s.AddLiquidity(ctx, bob, poolID, models.AddLiquidityRequest{Amount0: "100", Amount1: "100"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	start := time.Now().Add(-72 * time.Hour)
+	req := models.CreateFarmRequest{PoolID: poolID, RewardPerDay: "100", StartsAt: &start, EndsAt: time.Now().Add(30 * 24 * time.Hour)}
+	if _, err := s.CreateFarm(ctx, admin, models.CreateFarmRequest{PoolID: poolID, RewardToken: "DOGE", RewardPerDay: "100", EndsAt: req.EndsAt}); !errors.Is(err, ErrInvalidFarm) {
+		t.Fatalf("expected ErrInvalidFarm, got %v", err)
+	}
+	if _, err := s.CreateFarm(ctx, admin, models.CreateFarmRequest{PoolID: poolID, RewardPerDay: "NaN", EndsAt: req.EndsAt}); !errors.Is(err, ErrInvalidFarm) {
+		t.Fatalf("expected ErrInvalidFarm for a NaN emission, got %v", err)
+	}
+	farm, err := s.CreateFarm(ctx, admin, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := s.StakeFarm(ctx, alice, farm.ID, models.FarmStakeRequest{Shares: "NaN"}); !errors.Is(err, ErrInvalidLiquidityAmount) {
+		t.Fatalf("expected ErrInvalidLiquidityAmount for NaN shares, got %v", err)
+	}
+	if farm.RewardToken != "AOG" || !farm.IsLive || farm.TotalStaked != "0" {
+		t.Fatalf("unexpected farm: %+v", farm)
+	}
+
+	if _, err := s.StakeFarm(ctx, alice, farm.ID, models.FarmStakeRequest{Shares: "150"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Staked shares are locked: they can't be staked twice or withdrawn.
+	if _, err := s.StakeFarm(ctx, alice, farm.ID, models.FarmStakeRequest{Shares: "60"}); !errors.Is(err, ErrInsufficientShares) {
+		t.Fatalf("expected ErrInsufficientShares, got %v", err)
+	}
+	if _, err := s.RemoveLiquidity(ctx, alice, poolID, models.RemoveLiquidityRequest{Shares: "100"}); !errors.Is(err, ErrSharesFarmed) {
+		t.Fatalf("expected ErrSharesFarmed, got %v", err)
+	}
+
+	// Alice alone earns the first day; the second is split 150:50.
+	fq.advanceFarm(farm.ID, 24*time.Hour)
+	if _, err := s.StakeFarm(ctx, bob, farm.ID, models.FarmStakeRequest{Shares: "50"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fq.advanceFarm(farm.ID, 24*time.Hour)
+
+	mine, err := s.ListUserFarms(ctx, bob)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mine) != 1 || mine[0].Shares != "50" || !near(mine[0].PendingRewards, 25) {
+		t.Fatalf("unexpected farm stakes: %+v", mine)
+	}
+	claimed, err := s.ClaimFarmRewards(ctx, alice, farm.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !near(claimed, 175) {
+		t.Fatalf("alice claimed %s, want 175", claimed)
+	}
+	// Claiming again right away pays (almost) nothing.
+	if again, err := s.ClaimFarmRewards(ctx, alice, farm.ID); err != nil || !near(again, 0) {
+		t.Fatalf("second claim: %s, %v", again, err)
+	}
+
+	exit, err := s.ExitFarm(ctx, bob, farm.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exit.Shares != "50" || !near(exit.Claimed, 25) || exit.Stake.Status != "exited" {
+		t.Fatalf("unexpected exit: %+v", exit)
+	}
+	if _, err := s.ClaimFarmRewards(ctx, bob, farm.ID); !errors.Is(err, ErrFarmStakeNotFound) {
+		t.Fatalf("expected ErrFarmStakeNotFound, got %v", err)
+	}
+	if _, err := s.RemoveLiquidity(ctx, bob, poolID, models.RemoveLiquidityRequest{Shares: "100"}); err != nil {
+		t.Fatalf("exited shares should be withdrawable: %v", err)
+	}
+
+	got, err := s.GetFarm(ctx, farm.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.TotalStaked != "150" || !near(got.RewardsPaid, 200) {
+		t.Fatalf("unexpected farm totals: %+v", got)
+	}
+
+	ended := fq.farms[toPgUUID(farm.ID)]
+	ended.EndsAt.Time = time.Now().Add(-time.Minute)
+	fq.farms[ended.ID] = ended
+	if _, err := s.StakeFarm(ctx, alice, farm.ID, models.FarmStakeRequest{Shares: "1"}); !errors.Is(err, ErrFarmEnded) {
+		t.Fatalf("expected ErrFarmEnded, got %v", err)
+	}
+	if farms, err := s.ListFarms(ctx, false); err != nil || len(farms) != 0 {
+		t.Fatalf("ended farm listed: %+v, %v", farms, err)
+	}
+}
+
+func TestLiquidity_PoolAdmin(t *testing.T) {
+	fq := &fakeLiquidityQueries{}
+	fq.addToken("AOG", true)
+	fq.addToken("USDT", true)
+	fq.addToken("OLD", false)
+	s := NewLiquidityService(fq).WithConfig(config.LiquidityConfig{SwapFeeBps: 30, FeeTiers: []int{5, 30, 100}, DefaultSlippageBps: 50})
+	ctx := context.Background()
+	admin := models.AuditContext{UserID: uuid.New(), IPAddress: "203.0.113.7", UserAgent: "test"}
+
+	pool, err := s.CreatePool(ctx, admin, models.CreatePoolRequest{Token0: "aog", Token1: "usdt"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pool.Name != "AOG/USDT" || pool.FeeBps != 30 || pool.Status != "active" || !pool.IsActive {
+		t.Fatalf("unexpected pool: %+v", pool)
+	}
+	if _, err := s.CreatePool(ctx, admin, models.CreatePoolRequest{Token0: "USDT", Token1: "AOG", FeeBps: 30}); !errors.Is(err, ErrPoolExists) {
+		t.Fatalf("expected ErrPoolExists for the reversed pair, got %v", err)
+	}
+	for _, req := range []models.CreatePoolRequest{
+		{Token0: "AOG", Token1: "AOG"},
+		{Token0: "AOG", Token1: "OLD"},
+		{Token0: "AOG", Token1: "BTC"},
+		{Token0: "AOG", Token1: "USDT", FeeBps: 42},
+	} {
+		if _, err := s.CreatePool(ctx, admin, req); !errors.Is(err, ErrInvalidPool) {
+			t.Fatalf("expected ErrInvalidPool for %+v, got %v", req, err)
+		}
+	}
+	if _, err := s.SetPoolFee(ctx, admin, pool.ID, 5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wide, err := s.CreatePool(ctx, admin, models.CreatePoolRequest{Token0: "AOG", Token1: "USDT", FeeBps: 100})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := s.SetPoolFee(ctx, admin, wide.ID, 5); !errors.Is(err, ErrPoolExists) {
+		t.Fatalf("expected ErrPoolExists moving onto a taken tier, got %v", err)
+	}
+
+	// Swaps are priced at the pool's own fee tier.
+	user := uuid.New()
+	if _, err := s.AddLiquidity(ctx, user, pool.ID, models.AddLiquidityRequest{Amount0: "1000", Amount1: "4000"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	quote, err := s.Quote(ctx, pool.ID, "AOG", "10", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if quote.FeeBps != 5 || quote.Fee != "0.005" {
+		t.Fatalf("expected the 5 bps tier, got %+v", quote)
+	}
+
+	if _, err := s.PausePool(ctx, admin, pool.ID, "incident"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := s.PausePool(ctx, admin, pool.ID, ""); !errors.Is(err, ErrInvalidPoolStatus) {
+		t.Fatalf("expected ErrInvalidPoolStatus pausing twice, got %v", err)
+	}
+	if _, err := s.Swap(ctx, user, pool.ID, models.SwapRequest{TokenIn: "AOG", AmountIn: "10", MinAmountOut: "0"}); !errors.Is(err, ErrPoolInactive) {
+		t.Fatalf("expected ErrPoolInactive swapping into a paused pool, got %v", err)
+	}
+	if _, err := s.AddLiquidity(ctx, user, pool.ID, models.AddLiquidityRequest{Amount0: "1", Amount1: "4"}); !errors.Is(err, ErrPoolInactive) {
+		t.Fatalf("expected ErrPoolInactive depositing into a paused pool, got %v", err)
+	}
+	if _, err := s.UnpausePool(ctx, admin, pool.ID, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := s.Swap(ctx, user, pool.ID, models.SwapRequest{TokenIn: "AOG", AmountIn: "10", MinAmountOut: "0"}); err != nil {
+		t.Fatalf("unexpected error after unpausing: %v", err)
+	}
+
+	deprecated, err := s.DeprecatePool(ctx, admin, pool.ID, "migrating")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deprecated.Status != "deprecated" || deprecated.IsActive {
+		t.Fatalf("unexpected deprecated pool: %+v", deprecated)
+	}
+	if _, err := s.UnpausePool(ctx, admin, pool.ID, ""); !errors.Is(err, ErrInvalidPoolStatus) {
+		t.Fatalf("expected ErrInvalidPoolStatus, got %v", err)
+	}
+	if _, err := s.SetPoolFee(ctx, admin, pool.ID, 30); !errors.Is(err, ErrInvalidPoolStatus) {
+		t.Fatalf("expected ErrInvalidPoolStatus, got %v", err)
+	}
+	if _, err := s.RemoveLiquidity(ctx, user, pool.ID, models.RemoveLiquidityRequest{Shares: "100"}); err != nil {
+		t.Fatalf("withdrawals must stay open on a deprecated pool: %v", err)
+	}
+	listed, err := s.ListPools(ctx, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(listed) != 1 || listed[0].ID != wide.ID {
+		t.Fatalf("expected only the live pool to be listed, got %+v", listed)
+	}
+	if _, err := s.CreatePool(ctx, admin, models.CreatePoolRequest{Token0: "AOG", Token1: "USDT", FeeBps: 5}); err != nil {
+		t.Fatalf("a deprecated pool's tier should be free again: %v", err)
+	}
+
+	audit, err := s.ListPoolAudit(ctx, pool.ID, 20, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var actions []string
+	for _, a := range audit {
+		actions = append(actions, a.Action)
+	}
+	want := []string{"pool.deprecate", "pool.unpause", "pool.pause", "pool.set_fee", "pool.create"}
+	if strings.Join(actions, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected audit trail: %v", actions)
+	}
+	if *audit[0].UserID != admin.UserID || audit[0].IPAddress != "203.0.113.7" || string(audit[0].Details) != `{"from":"active","reason":"migrating","to":"deprecated"}` {
+		t.Fatalf("unexpected audit entry: %+v", audit[0])
+	}
+}
+
+func TestAccumulatePrices(t *testing.T) {
+	now := time.Now()
+	cum0, cum1 := accumulatePrices(10, 1, 4, now.Add(-time.Minute), now)
+	if !approx(cum0, 10+4*60) || !approx(cum1, 1+0.25*60) {
+		t.Fatalf("unexpected accumulators: %v %v", cum0, cum1)
+	}
+	if cum0, cum1 := accumulatePrices(10, 1, 4, time.Time{}, now); cum0 != 10 || cum1 != 1 {
+		t.Fatalf("a pool never priced should not accumulate, got %v %v", cum0, cum1)
+	}
+	if cum0, cum1 := accumulatePrices(10, 1, 0, now.Add(-time.Minute), now); cum0 != 10 || cum1 != 1 {
+		t.Fatalf("an empty pool should not accumulate, got %v %v", cum0, cum1)
+	}
+}
+
+func TestLiquidity_TWAPAndPriceSource(t *testing.T) {
+	fq := &fakeLiquidityQueries{}
+	poolID := fq.addPool("AOG", "USDT", 1000, 4000, 2000)
+	fq.addPool("BNB", "AOG", 10, 1000, 100)
+	s := NewLiquidityService(fq).WithConfig(config.LiquidityConfig{OracleQuote: "USDT", OracleWindow: 30 * time.Minute})
+	ctx := context.Background()
+	user := uuid.New()
+
+	if _, err := s.TWAP(ctx, poolID, time.Hour); !errors.Is(err, ErrNoPrice) {
+		t.Fatalf("expected ErrNoPrice before any reserve change, got %v", err)
+	}
+
+	// A balanced deposit starts the history at a price of 4.
+	if _, err := s.AddLiquidity(ctx, user, poolID, models.AddLiquidityRequest{Amount0: "10", Amount1: "40"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fq.agePool(poolID, 2*time.Hour)
+	receipt, err := s.Swap(ctx, user, poolID, models.SwapRequest{TokenIn: "USDT", AmountIn: "1000", MinAmountOut: "0"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	moved := receipt.Pool.Price
+	fq.agePool(poolID, time.Hour)
+
+	// Two hours at 4 and one at the moved price.
+	twap, err := s.TWAP(ctx, poolID, 2*time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(twap.Price0-(2*4+moved)/3) > 1e-6 || twap.SpotPrice != moved || twap.To.Sub(twap.From).Round(time.Minute) != 3*time.Hour {
+		t.Fatalf("unexpected TWAP: %+v (moved price %v)", twap, moved)
+	}
+	if math.Abs(twap.Price1-(2*0.25+1/moved)/3) > 1e-6 {
+		t.Fatalf("expected the inverse price averaged on its own, got %+v", twap)
+	}
+
+	// A window longer than the pool's life averages from its first
+	// observation.
+	life, err := s.TWAP(ctx, poolID, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !life.From.Equal(twap.From) || math.Abs(life.Price0-twap.Price0) > 1e-6 {
+		t.Fatalf("expected a young pool to average over its life, got %+v", life)
+	}
+
+	recent, err := s.TWAP(ctx, poolID, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(recent.Price0-moved) > 1e-6 || recent.Window != "30m0s" {
+		t.Fatalf("expected the default window to see only the moved price, got %+v", recent)
+	}
+
+	price, err := s.TokenPrice(ctx, "aog")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(price-moved) > 1e-6 {
+		t.Fatalf("expected AOG at its TWAP in USDT, got %v", price)
+	}
+	if price, err := s.TokenPrice(ctx, "USDT"); err != nil || price != 1 {
+		t.Fatalf("expected the quote token at 1, got %v, %v", price, err)
+	}
+
+	// Pool TVL is valued at the oracle's prices rather than the stored
+	// market price (AOG is stored at 100 here).
+	fq.prices = map[string]float64{"AOG": 100, "USDT": 1}
+	receipt, err = s.Swap(ctx, user, poolID, models.SwapRequest{TokenIn: "USDT", AmountIn: "1", MinAmountOut: "0"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pool := fq.pools[toPgUUID(poolID)]
+	wantTVL := numericFloat(pool.Reserve0)*price + numericFloat(pool.Reserve1)
+	if tvl := numericFloat(pool.TotalLiquidity); math.Abs(tvl-wantTVL) > 1e-3*wantTVL {
+		t.Fatalf("pool TVL = %v; want %v at the oracle price", tvl, wantTVL)
+	}
+	if _, err := s.TokenPrice(ctx, "BNB"); !errors.Is(err, ErrNoPrice) {
+		t.Fatalf("expected ErrNoPrice without a BNB/USDT pool, got %v", err)
+	}
+	if _, err := s.TWAP(ctx, uuid.New(), 0); !errors.Is(err, ErrPoolNotFound) {
+		t.Fatalf("expected ErrPoolNotFound, got %v", err)
+	}
+}
+
+func TestConcentratedSwap(t *testing.T) {
+	// Inside a single range the pool trades like a constant-product pool
+	// with virtual reserves L/sqrtP and L*sqrtP.
+	single := curve{
+		concentrated: true, feeBps: 30, sqrtPrice: 1, liquidity: 1000,
+		ticks: []tickState{{tick: -6000, liquidityNet: 1000}, {tick: 6000, liquidityNet: -1000}},
+	}
+	res, err := priceSwap(single, swapSide{zeroForOne: true}, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, fee, _ := swapAmounts(1000, 1000, 10, 30)
+	if !approx(res.out, out) || !approx(res.fee, fee) || !approx(res.growth, fee/1000) || len(res.crossed) != 0 {
+		t.Fatalf("unexpected swap: %+v, want out %v fee %v", res, out, fee)
+	}
+	if res.tick >= 0 || res.sqrtPrice >= 1 || res.liquidity != 1000 {
+		t.Fatalf("selling token0 should lower the price within the range, got %+v", res)
+	}
+
+	// Buying through tick 600 swaps the first range's liquidity for the
+	// second's.
+	stacked := curve{
+		concentrated: true, feeBps: 30, sqrtPrice: 1, liquidity: 1000,
+		ticks: []tickState{{tick: -600, liquidityNet: 1000}, {tick: 600, liquidityNet: -500}, {tick: 1200, liquidityNet: -500}},
+	}
+	res, err = priceSwap(stacked, swapSide{zeroForOne: false}, 40)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.crossed) != 1 || res.crossed[0].tick != 600 || res.liquidity != 500 || res.tick < 600 || res.tick >= 1200 {
+		t.Fatalf("expected to cross tick 600 into the second range, got %+v", res)
+	}
+	toTick := 1000 * (tickSqrtPrice(600) - 1) / (1 - 0.003)
+	if want := toTick*0.003/1000 + (40-toTick)*0.003/500; !approx(res.growth, want) {
+		t.Fatalf("fees should accrue to the liquidity in range at each step, got growth %v want %v", res.growth, want)
+	}
+	if _, err := priceSwap(stacked, swapSide{zeroForOne: false}, 1000); !errors.Is(err, ErrInsufficientLiquidity) {
+		t.Fatalf("expected ErrInsufficientLiquidity past the last range, got %v", err)
+	}
+
+	sqrtA, sqrtB := tickSqrtPrice(-600), tickSqrtPrice(600)
+	l := liquidityForAmounts(1, sqrtA, sqrtB, 100, 300)
+	a0, a1 := amountsForLiquidity(1, sqrtA, sqrtB, l)
+	if !approx(a0, 100) || a1 > 300 {
+		t.Fatalf("the scarcer amount should bound the liquidity, got %v %v", a0, a1)
+	}
+	if got := growthInside(-10, 10, 20, 0, 3, 5); got != 3 {
+		t.Fatalf("expected fee growth inside 3, got %v", got)
+	}
+}
+
+func TestLiquidity_ConcentratedPositions(t *testing.T) {
+	fq := &fakeLiquidityQueries{prices: map[string]float64{"AOG": 1, "USDT": 1}}
+	fq.addToken("AOG", true)
+	fq.addToken("USDT", true)
+	s := NewLiquidityService(fq).WithConfig(config.LiquidityConfig{SwapFeeBps: 30, FeeTiers: []int{30}, DefaultSlippageBps: 50, MaxHops: 2})
+	ctx := context.Background()
+	admin := models.AuditContext{UserID: uuid.New()}
+	user := uuid.New()
+
+	if _, err := s.CreatePool(ctx, admin, models.CreatePoolRequest{Token0: "AOG", Token1: "USDT", Type: "concentrated"}); !errors.Is(err, ErrInvalidPool) {
+		t.Fatalf("expected ErrInvalidPool without an initial price, got %v", err)
+	}
+	pool, err := s.CreatePool(ctx, admin, models.CreatePoolRequest{Token0: "AOG", Token1: "USDT", Type: "concentrated", InitialPrice: 1, TickSpacing: 60})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pool.Type != "concentrated" || pool.TickSpacing != 60 || pool.Tick == nil || *pool.Tick != 0 || pool.Price != 1 {
+		t.Fatalf("unexpected pool: %+v", pool)
+	}
+
+	ticks := func(lower, upper int) (*int, *int) { return &lower, &upper }
+	deposit := func(amount0, amount1 string, lower, upper int) *models.LiquidityReceipt {
+		t.Helper()
+		lo, hi := ticks(lower, upper)
+		r, err := s.AddLiquidity(ctx, user, pool.ID, models.AddLiquidityRequest{Amount0: amount0, Amount1: amount1, TickLower: lo, TickUpper: hi})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return r
+	}
+	if _, err := s.AddLiquidity(ctx, user, pool.ID, models.AddLiquidityRequest{Amount0: "1", Amount1: "1"}); !errors.Is(err, ErrInvalidRange) {
+		t.Fatalf("expected ErrInvalidRange without ticks, got %v", err)
+	}
+	lo, hi := ticks(-610, 600)
+	if _, err := s.AddLiquidity(ctx, user, pool.ID, models.AddLiquidityRequest{Amount0: "1", Amount1: "1", TickLower: lo, TickUpper: hi}); !errors.Is(err, ErrInvalidRange) {
+		t.Fatalf("expected ErrInvalidRange off the tick spacing, got %v", err)
+	}
+
+	wide := deposit("1000", "1000", -600, 600)
+	above := deposit("500", "0", 600, 1200)
+	below := deposit("0", "500", -1200, -600)
+	if wide.Position.Range == nil || wide.Position.Range.Status != "in_range" || wide.Refund0 != "" || wide.Refund1 != "" {
+		t.Fatalf("unexpected in-range position: %+v", wide.Position)
+	}
+	if above.Position.Range.Status != "below_range" || above.Amount1 != "0" || below.Position.Range.Status != "above_range" || below.Amount0 != "0" {
+		t.Fatalf("out-of-range positions should hold one token: %+v %+v", above, below)
+	}
+	if wide.Position.NftID == above.Position.NftID || above.Position.NftID == below.Position.NftID {
+		t.Fatalf("positions should get distinct ids: %d %d %d", wide.Position.NftID, above.Position.NftID, below.Position.NftID)
+	}
+	if _, err := s.CreateFarm(ctx, uuid.New(), models.CreateFarmRequest{PoolID: pool.ID, RewardPerDay: "10", EndsAt: time.Now().Add(time.Hour)}); !errors.Is(err, ErrInvalidFarm) {
+		t.Fatalf("expected ErrInvalidFarm on a concentrated pool, got %v", err)
+	}
+
+	// Buying AOG pushes the price through the wide range into the one
+	// above it; only the ranges the price passed through earn the fee.
+	quote, err := s.Quote(ctx, pool.ID, "USDT", "1500", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	route, err := s.QuoteRoute(ctx, "USDT", "AOG", "1500", 0, 0)
+	if err != nil || route.AmountOut != quote.AmountOut {
+		t.Fatalf("route should match the pool quote %s, got %+v, %v", quote.AmountOut, route, err)
+	}
+	swap, err := s.Swap(ctx, user, pool.ID, models.SwapRequest{TokenIn: "USDT", AmountIn: "1500"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if swap.Swap.AmountOut != quote.AmountOut || *swap.Pool.Tick < 600 || *swap.Pool.Tick >= 1200 {
+		t.Fatalf("unexpected swap: %+v", swap)
+	}
+	view := func(id uuid.UUID) models.PositionDetail {
+		t.Helper()
+		d, err := s.GetPosition(ctx, user, id)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return *d
+	}
+	w, a, b := view(wide.Position.ID), view(above.Position.ID), view(below.Position.ID)
+	fees := func(d models.PositionDetail) float64 { f, _ := strconv.ParseFloat(d.FeesEarned1, 64); return f }
+	if w.Range.Status != "above_range" || a.Range.Status != "in_range" || b.Range.Status != "above_range" {
+		t.Fatalf("unexpected range statuses: %s %s %s", w.Range.Status, a.Range.Status, b.Range.Status)
+	}
+	if fees(w) <= 0 || fees(a) <= 0 || fees(b) != 0 || math.Abs(fees(w)+fees(a)-4.5) > 1e-6 {
+		t.Fatalf("the 4.5 USDT fee should go to the ranges crossed, got %v %v %v", fees(w), fees(a), fees(b))
+	}
+	if a.PoolShare != 100 || w.Current0 != "0" {
+		t.Fatalf("unexpected position detail: %+v %+v", a, w)
+	}
+
+	// Withdrawing the wide range pays its USDT and uncollected fees.
+	if _, err := s.RemoveLiquidity(ctx, user, pool.ID, models.RemoveLiquidityRequest{Shares: wide.Shares}); !errors.Is(err, ErrInvalidRange) {
+		t.Fatalf("expected ErrInvalidRange without a position id, got %v", err)
+	}
+	if _, err := s.RemoveLiquidity(ctx, uuid.New(), pool.ID, models.RemoveLiquidityRequest{Shares: wide.Shares, PositionID: &wide.Position.ID}); !errors.Is(err, ErrPositionNotFound) {
+		t.Fatalf("expected ErrPositionNotFound for another user, got %v", err)
+	}
+	out, err := s.RemoveLiquidity(ctx, user, pool.ID, models.RemoveLiquidityRequest{Shares: wide.Shares, PositionID: &wide.Position.ID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got1, _ := strconv.ParseFloat(out.Amount1, 64)
+	l, _ := strconv.ParseFloat(wide.Shares, 64)
+	if want := l*(tickSqrtPrice(600)-tickSqrtPrice(-600)) + fees(w); out.Amount0 != "0" || math.Abs(got1-want) > 1e-6 || out.Position.Status != "withdrawn" {
+		t.Fatalf("unexpected withdrawal %+v, want %v USDT", out, want)
+	}
+	if p := fq.pools[toPgUUID(pool.ID)]; numericFloat(p.Liquidity) != numericFloat(fq.positions[toPgUUID(above.Position.ID)].Shares) {
+		t.Fatalf("only the range above should stay active, got liquidity %v", numericFloat(p.Liquidity))
+	}
+
+	// Selling AOG back walks down through the empty gap the wide range
+	// left into the range below.
+	if _, err := s.Swap(ctx, user, pool.ID, models.SwapRequest{TokenIn: "AOG", AmountIn: "600"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b = view(below.Position.ID)
+	if f0, _ := strconv.ParseFloat(b.FeesEarned0, 64); b.Range.Status != "in_range" || f0 <= 0 {
+		t.Fatalf("the range below should now be active and earning: %+v", b)
+	}
+}
+
+func TestCurveDepth(t *testing.T) {
+	// A constant-product pool's depth to a price is the swap, without fee,
+	// that moves it there.
+	cp := curve{reserve0: 1000, reserve1: 1000}
+	a0, a1 := curveDepth(cp, 1.21)
+	out, _, _ := swapAmounts(1000, 1000, a1, 0)
+	if !approx(a1, 100) || !approx(a0, out) {
+		t.Fatalf("unexpected ask depth %v %v, want 100 in for %v out", a0, a1, out)
+	}
+	a0, a1 = curveDepth(cp, 1/1.21)
+	out, _, _ = swapAmounts(1000, 1000, a0, 0)
+	if !approx(a0, 100) || !approx(a1, out) {
+		t.Fatalf("unexpected bid depth %v %v, want 100 in for %v out", a0, a1, out)
+	}
+
+	// A concentrated pool runs out of depth at the edge of its ranges and
+	// takes on each range's liquidity as its price crosses into it.
+	stacked := curve{
+		concentrated: true, sqrtPrice: 1, liquidity: 1000,
+		ticks: []tickState{{tick: -600, liquidityNet: 1000}, {tick: 600, liquidityNet: -500}, {tick: 1200, liquidityNet: -500}},
+	}
+	edge0, edge1 := curveDepth(stacked, 10)
+	want1 := 1000*(tickSqrtPrice(600)-1) + 500*(tickSqrtPrice(1200)-tickSqrtPrice(600))
+	if !approx(edge1, want1) {
+		t.Fatalf("expected %v token1 to the top of the ranges, got %v", want1, edge1)
+	}
+	if b0, b1 := curveDepth(stacked, 20); b0 != edge0 || b1 != edge1 {
+		t.Fatalf("no depth past the last range, got %v %v", b0, b1)
+	}
+	if _, b1 := curveDepth(stacked, 0.5); !approx(b1, 1000*(1-tickSqrtPrice(-600))) {
+		t.Fatalf("expected the bid side to stop at tick -600, got %v", b1)
+	}
+}
+
+func TestLiquidity_PoolAnalytics(t *testing.T) {
+	fq := &fakeLiquidityQueries{}
+	poolID := fq.addPool("AOG", "USDT", 1000, 1000, 1000)
+	s := NewLiquidityService(fq)
+	ctx := context.Background()
+
+	if err := s.SnapshotPools(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	snap := fq.snapshots[0]
+	if snap.SnapshotAt.Time != snap.SnapshotAt.Time.Truncate(time.Hour) || snap.SnapshotAt.Time.Sub(snap.FromAt.Time) != time.Hour {
+		t.Fatalf("snapshots should be hourly, got %+v", snap)
+	}
+
+	bucket := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	fq.history = []db.GetPoolHistoryRow{{
+		BucketStart: pgtype.Timestamp{Time: bucket, Valid: true},
+		Reserve0:    numeric(1000), Reserve1: numeric(1000), TotalShares: numeric(1000),
+		Price: numeric(1), PriceLow: numeric(0.9), PriceHigh: numeric(1.1), Tvl: numeric(2000),
+		Providers: 3, Swaps: 7, Volume0: numeric(50), Volume1: numeric(40), VolumeValue: numeric(90),
+		Fees0: numeric(0.15), Fees1: numeric(0.12), FeesValue: numeric(0.27),
+	}}
+	history, err := s.PoolHistory(ctx, poolID, "1d", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fq.historyAt.Bucket != "day" || fq.historyAt.ToAt.Time.Sub(fq.historyAt.FromAt.Time) != 90*24*time.Hour {
+		t.Fatalf("expected 90 days of daily buckets by default, got %+v", fq.historyAt)
+	}
+	if len(history) != 1 || !history[0].Time.Equal(bucket) || history[0].TVL != "2000" || history[0].Swaps != 7 || history[0].VolumeValue != "90" || history[0].PriceHigh != 1.1 {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+	now := time.Now()
+	for _, tc := range []struct {
+		interval string
+		from, to time.Time
+	}{
+		{"5m", time.Time{}, time.Time{}},
+		{"1h", now, now.Add(-time.Hour)},
+		{"1h", now.Add(-365 * 24 * time.Hour), now},
+	} {
+		if _, err := s.PoolHistory(ctx, poolID, tc.interval, tc.from, tc.to); !errors.Is(err, ErrInvalidHistoryRange) {
+			t.Fatalf("expected ErrInvalidHistoryRange for %+v, got %v", tc, err)
+		}
+	}
+	if _, err := s.PoolHistory(ctx, uuid.New(), "1h", time.Time{}, time.Time{}); !errors.Is(err, ErrPoolNotFound) {
+		t.Fatalf("expected ErrPoolNotFound, got %v", err)
+	}
+
+	depth, err := s.PoolDepth(ctx, poolID, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if depth.Price != 1 || depth.StepBps != 50 || len(depth.Asks) != 20 || len(depth.Bids) != 20 || depth.Token0 != "AOG" {
+		t.Fatalf("unexpected depth: %+v", depth)
+	}
+	prev := 0.0
+	for _, level := range depth.Asks {
+		amount, _ := strconv.ParseFloat(level.Amount1, 64)
+		if level.Price <= depth.Price || amount <= prev {
+			t.Fatalf("asks should climb from the price with growing depth: %+v", depth.Asks)
+		}
+		prev = amount
+	}
+	if depth, _ := s.PoolDepth(ctx, poolID, 5, 5000); len(depth.Bids) != 1 || len(depth.Asks) != 5 {
+		t.Fatalf("bids should stop before a zero price, got %+v", depth)
+	}
+	if _, err := s.PoolDepth(ctx, poolID, 101, 0); !errors.Is(err, ErrInvalidDepth) {
+		t.Fatalf("expected ErrInvalidDepth, got %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+// internal/services/numeric.go
+package services
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// numericFloat returns n as a float64, or 0 when n is NULL or unreadable.
+func numericFloat(n pgtype.Numeric) float64 {
+	if !n.Valid {
+		return 0
+	}
+	fv, err := n.Float64Value()
+	if err != nil || !fv.Valid {
+		return 0
+	}
+	return fv.Float64
+}
+
+// numericString formats n the same way the API renders amounts.
+func numericString(n pgtype.Numeric) string {
+	return formatAmount(numericFloat(n))
+}
+
+// numericExact formats n without going through float64 so that exported
+// amounts round-trip exactly. Trailing fractional zeros are trimmed.
+func numericExact(n pgtype.Numeric) string {
+	if !n.Valid || n.NaN || n.Int == nil {
+		return "0"
+	}
+	r := new(big.Rat).SetInt(n.Int)
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs32(n.Exp))), nil)
+	decimals := 0
+	if n.Exp > 0 {
+		r.Mul(r, new(big.Rat).SetInt(scale))
+	} else if n.Exp < 0 {
+		r.Quo(r, new(big.Rat).SetInt(scale))
+		decimals = int(-n.Exp)
+	}
+	s := r.FloatString(decimals)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+func abs32(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// floatNumeric converts f into a pgtype.Numeric.
+func floatNumeric(f float64) (pgtype.Numeric, error) {
+	var n pgtype.Numeric
+	err := n.Scan(formatAmount(f))
+	return n, err
+}
+
+func formatAmount(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// parsePositiveAmount parses a decimal amount string that must be finite
+// and > 0. ParseFloat accepts "NaN" and "Inf", which would pass a plain
+// comparison and poison every balance they touch.
+func parsePositiveAmount(s string) (float64, bool) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) || f <= 0 {
+		return 0, false
+	}
+	return f, true
+}
+
+// toPgUUID converts uuid.UUID -> pgtype.UUID.
+func toPgUUID(id uuid.UUID) pgtype.UUID {
+	var p pgtype.UUID
+	copy(p.Bytes[:], id[:])
+	p.Valid = true
+	return p
+}
@@ -0,0 +1,192 @@
+// internal/services/oracle.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jd7008911/aogeri-api/internal/db"
+	"github.com/jd7008911/aogeri-api/internal/models"
+)
+
+var ErrNoPrice = errors.New("no price available")
+
+// PriceSource prices a token in a common quote currency, for services that
+// value holdings.
+type PriceSource interface {
+	TokenPrice(ctx context.Context, symbol string) (float64, error)
+}
+
+// accumulatePrices advances a pool's price accumulators from last to now
+// at price, the price of token0 in token1 held over that time. An empty
+// pool, or one never priced, adds nothing.
+func accumulatePrices(cum0, cum1, price float64, last, now time.Time) (float64, float64) {
+	elapsed := now.Sub(last).Seconds()
+	if last.IsZero() || elapsed <= 0 || price <= 0 {
+		return cum0, cum1
+	}
+	return cum0 + price*elapsed, cum1 + elapsed/price
+}
+
+// spotPrice is a locked pool's marginal price of token0 in token1.
+func spotPrice(pool db.LiquidityPool) float64 {
+	return lockedCurve(pool, nil).price()
+}
+
+// poolTWAP averages a pool's prices from the last observation at or before
+// from up to now, and returns when that window really started. The
+// accumulators are read as of now, so the current price counts for the
+// time since it was stored.
+func poolTWAP(ctx context.Context, q liquidityQuerier, pool db.LiquidityPool, from, now time.Time) (twap0, twap1 float64, start time.Time, err error) {
+	price := spotPrice(pool)
+	cum0, cum1 := accumulatePrices(numericFloat(pool.Price0Cumulative), numericFloat(pool.Price1Cumulative),
+		price, pool.PriceUpdatedAt.Time, now)
+
+	obs, err := q.GetPriceObservation(ctx, db.GetPriceObservationParams{
+		PoolID: pool.ID,
+		At:     pgtype.Timestamp{Time: from, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		// The pool is younger than the window: average over its life.
+		obs, err = q.GetFirstPriceObservation(ctx, pool.ID)
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, 0, now, fmt.Errorf("%w: pool has no price history", ErrNoPrice)
+		}
+		return 0, 0, now, err
+	}
+	start = obs.ObservedAt.Time
+	elapsed := now.Sub(start).Seconds()
+	if elapsed <= 0 {
+		if price <= 0 {
+			return 0, 0, start, fmt.Errorf("%w: pool is empty", ErrNoPrice)
+		}
+		return price, 1 / price, start, nil
+	}
+	twap0 = (cum0 - numericFloat(obs.Price0Cumulative)) / elapsed
+	twap1 = (cum1 - numericFloat(obs.Price1Cumulative)) / elapsed
+	return twap0, twap1, start, nil
+}
+
+// TWAP returns a pool's time-weighted average prices over the trailing
+// window, which defaults to the configured oracle window. The window
+// starts later than asked when the pool has less price history.
+func (s *LiquidityService) TWAP(ctx context.Context, poolID uuid.UUID, window time.Duration) (*models.PoolTWAP, error) {
+	if window <= 0 {
+		window = s.cfg.OracleWindow
+	}
+	row, err := s.queries.GetPool(ctx, toPgUUID(poolID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPoolNotFound
+		}
+		return nil, err
+	}
+	pool, err := s.queries.GetPoolState(ctx, row.ID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	twap0, twap1, start, err := poolTWAP(ctx, s.queries, pool, now.Add(-window), now)
+	if err != nil {
+		return nil, err
+	}
+	return &models.PoolTWAP{
+		PoolID:    poolID,
+		Token0:    row.Token0Symbol,
+		Token1:    row.Token1Symbol,
+		Window:    window.String(),
+		From:      start,
+		To:        now,
+		Price0:    twap0,
+		Price1:    twap1,
+		SpotPrice: spotPrice(pool),
+	}, nil
+}
+
+// TokenPrice prices symbol in the oracle's quote token at its TWAP over the
+// oracle window, read from the active pool pairing the two with the
+// deepest quote reserve. The quote token itself is worth 1.
+func (s *LiquidityService) TokenPrice(ctx context.Context, symbol string) (float64, error) {
+	return s.tokenPrice(ctx, s.queries, symbol)
+}
+
+// tokenPrice is TokenPrice read through q, so it can run inside a
+// transaction.
+func (s *LiquidityService) tokenPrice(ctx context.Context, q liquidityQuerier, symbol string) (float64, error) {
+	symbol = strings.ToUpper(symbol)
+	quote := strings.ToUpper(s.cfg.OracleQuote)
+	if quote == "" {
+		return 0, fmt.Errorf("%w: no oracle quote token configured", ErrNoPrice)
+	}
+	if symbol == quote {
+		return 1, nil
+	}
+
+	rows, err := q.ListPools(ctx, pgtype.Text{String: symbol, Valid: true})
+	if err != nil {
+		return 0, err
+	}
+	var (
+		best  db.GetPoolRow
+		depth float64
+	)
+	for _, r := range rows {
+		row := db.GetPoolRow(r)
+		side, err := resolveSwapSide(row, symbol)
+		if err != nil || row.Status != poolActive || side.tokenOut != quote {
+			continue
+		}
+		if _, quoteReserve := side.reserves(numericFloat(row.Reserve0), numericFloat(row.Reserve1)); quoteReserve > depth {
+			best, depth = row, quoteReserve
+		}
+	}
+	if depth == 0 {
+		return 0, fmt.Errorf("%w: no %s/%s pool", ErrNoPrice, symbol, quote)
+	}
+
+	pool, err := q.GetPoolState(ctx, best.ID)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	twap0, twap1, _, err := poolTWAP(ctx, q, pool, now.Add(-s.cfg.OracleWindow), now)
+	if err != nil {
+		return 0, err
+	}
+	if best.Token0Symbol == symbol {
+		return twap0, nil
+	}
+	return twap1, nil
+}
+
+// poolPrices prices a pool's two tokens for its TVL. A token the oracle
+// cannot price is left null, so the stored market price is used for it.
+func (s *LiquidityService) poolPrices(ctx context.Context, q liquidityQuerier, poolID pgtype.UUID) (price0, price1 pgtype.Numeric, err error) {
+	row, err := q.GetPool(ctx, poolID)
+	if err != nil {
+		return price0, price1, err
+	}
+	price := func(symbol string) (pgtype.Numeric, error) {
+		p, err := s.tokenPrice(ctx, q, symbol)
+		if errors.Is(err, ErrNoPrice) {
+			return pgtype.Numeric{}, nil
+		}
+		if err != nil {
+			return pgtype.Numeric{}, err
+		}
+		return floatNumeric(p)
+	}
+	if price0, err = price(row.Token0Symbol); err != nil {
+		return price0, price1, err
+	}
+	price1, err = price(row.Token1Symbol)
+	return price0, price1, err
+}
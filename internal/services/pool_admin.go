@@ -0,0 +1,302 @@
+// internal/services/pool_admin.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jd7008911/aogeri-api/internal/db"
+	"github.com/jd7008911/aogeri-api/internal/models"
+)
+
+var (
+	ErrInvalidPool       = errors.New("invalid pool")
+	ErrPoolExists        = errors.New("pool already exists for this pair and fee tier")
+	ErrInvalidPoolStatus = errors.New("pool status does not allow this change")
+)
+
+// Pool statuses. Paused pools take no deposits or swaps but still pay out
+// withdrawals; deprecated pools stay withdraw-only and are no longer
+// listed.
+const (
+	poolActive     = "active"
+	poolPaused     = "paused"
+	poolDeprecated = "deprecated"
+)
+
+const poolAuditResource = "liquidity_pool"
+
+// CreatePool opens an active, empty pool for two active tokens. A pair may
+// have one live pool per fee tier, in either token order, of either type.
+func (s *LiquidityService) CreatePool(ctx context.Context, audit models.AuditContext, req models.CreatePoolRequest) (*models.Pool, error) {
+	symbol0, symbol1 := strings.ToUpper(req.Token0), strings.ToUpper(req.Token1)
+	if symbol0 == symbol1 {
+		return nil, fmt.Errorf("%w: token0 and token1 must differ", ErrInvalidPool)
+	}
+	feeBps := req.FeeBps
+	if feeBps == 0 {
+		feeBps = s.cfg.SwapFeeBps
+	}
+	if err := s.checkFeeTier(feeBps); err != nil {
+		return nil, err
+	}
+	name := req.Name
+	if name == "" {
+		name = symbol0 + "/" + symbol1
+	}
+	params, err := poolCurveParams(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var poolID uuid.UUID
+	err = s.inTx(ctx, func(q liquidityQuerier) error {
+		token0, err := activeToken(ctx, q, symbol0)
+		if err != nil {
+			return err
+		}
+		token1, err := activeToken(ctx, q, symbol1)
+		if err != nil {
+			return err
+		}
+		exists, err := q.PoolPairExists(ctx, db.PoolPairExistsParams{TokenA: token0.ID, TokenB: token1.ID, FeeBps: int32(feeBps)})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("%w: %s/%s at %d bps", ErrPoolExists, symbol0, symbol1, feeBps)
+		}
+		params.Name, params.Token0ID, params.Token1ID, params.FeeBps = name, token0.ID, token1.ID, int32(feeBps)
+		created, err := q.CreatePool(ctx, params)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				return fmt.Errorf("%w: %s/%s at %d bps", ErrPoolExists, symbol0, symbol1, feeBps)
+			}
+			return err
+		}
+		poolID, _ = pgToUUID(created.ID)
+		details := map[string]any{
+			"name":    name,
+			"token0":  symbol0,
+			"token1":  symbol1,
+			"fee_bps": feeBps,
+			"type":    params.PoolType,
+		}
+		if params.PoolType == poolConcentrated {
+			details["tick_spacing"] = params.TickSpacing
+			details["initial_price"] = req.InitialPrice
+		}
+		return recordAudit(ctx, q, audit, "pool.create", poolID, details)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPool(ctx, poolID)
+}
+
+// SetPoolFee moves a live pool to another configured fee tier. Swaps
+// priced from then on pay the new fee.
+func (s *LiquidityService) SetPoolFee(ctx context.Context, audit models.AuditContext, poolID uuid.UUID, feeBps int) (*models.Pool, error) {
+	if err := s.checkFeeTier(feeBps); err != nil {
+		return nil, err
+	}
+	err := s.inTx(ctx, func(q liquidityQuerier) error {
+		pool, err := lockPool(ctx, q, poolID)
+		if err != nil {
+			return err
+		}
+		if pool.Status == poolDeprecated {
+			return fmt.Errorf("%w: pool is deprecated", ErrInvalidPoolStatus)
+		}
+		if int(pool.FeeBps) == feeBps {
+			return nil
+		}
+		exists, err := q.PoolPairExists(ctx, db.PoolPairExistsParams{TokenA: pool.Token0ID, TokenB: pool.Token1ID, FeeBps: int32(feeBps)})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("%w: the pair already has a pool at %d bps", ErrPoolExists, feeBps)
+		}
+		if err := q.SetPoolFee(ctx, db.SetPoolFeeParams{ID: pool.ID, FeeBps: int32(feeBps)}); err != nil {
+			return err
+		}
+		return recordAudit(ctx, q, audit, "pool.set_fee", poolID, map[string]any{
+			"from_fee_bps": pool.FeeBps,
+			"to_fee_bps":   feeBps,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPool(ctx, poolID)
+}
+
+// PausePool stops deposits and swaps on an active pool. Withdrawals keep
+// working.
+func (s *LiquidityService) PausePool(ctx context.Context, audit models.AuditContext, poolID uuid.UUID, reason string) (*models.Pool, error) {
+	return s.setPoolStatus(ctx, audit, poolID, "pool.pause", reason, poolPaused, poolActive)
+}
+
+// UnpausePool reopens a paused pool to deposits and swaps.
+func (s *LiquidityService) UnpausePool(ctx context.Context, audit models.AuditContext, poolID uuid.UUID, reason string) (*models.Pool, error) {
+	return s.setPoolStatus(ctx, audit, poolID, "pool.unpause", reason, poolActive, poolPaused)
+}
+
+// DeprecatePool puts an active or paused pool into withdraw-only mode for
+// good. It drops out of listings and routing, and its pair and fee tier
+// become free for a new pool.
+func (s *LiquidityService) DeprecatePool(ctx context.Context, audit models.AuditContext, poolID uuid.UUID, reason string) (*models.Pool, error) {
+	return s.setPoolStatus(ctx, audit, poolID, "pool.deprecate", reason, poolDeprecated, poolActive, poolPaused)
+}
+
+// setPoolStatus moves a pool to status if it is currently in one of from,
+// and audits the change.
+func (s *LiquidityService) setPoolStatus(ctx context.Context, audit models.AuditContext, poolID uuid.UUID, action, reason, status string, from ...string) (*models.Pool, error) {
+	err := s.inTx(ctx, func(q liquidityQuerier) error {
+		pool, err := lockPool(ctx, q, poolID)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(from, pool.Status) {
+			return fmt.Errorf("%w: pool is %s", ErrInvalidPoolStatus, pool.Status)
+		}
+		if err := q.SetPoolStatus(ctx, db.SetPoolStatusParams{ID: pool.ID, Status: status}); err != nil {
+			return err
+		}
+		details := map[string]any{"from": pool.Status, "to": status}
+		if reason != "" {
+			details["reason"] = reason
+		}
+		return recordAudit(ctx, q, audit, action, poolID, details)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPool(ctx, poolID)
+}
+
+// ListPoolAudit returns the admin changes made to a pool, newest first.
+func (s *LiquidityService) ListPoolAudit(ctx context.Context, poolID uuid.UUID, limit, offset int) ([]models.AuditLog, error) {
+	if _, err := s.queries.GetPool(ctx, toPgUUID(poolID)); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPoolNotFound
+		}
+		return nil, err
+	}
+	rows, err := s.queries.ListAuditLogs(ctx, db.ListAuditLogsParams{
+		ResourceType: poolAuditResource,
+		ResourceID:   poolID.String(),
+		PageLimit:    int32(limit),
+		PageOffset:   int32(offset),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]models.AuditLog, 0, len(rows))
+	for _, r := range rows {
+		id, _ := pgToUUID(r.ID)
+		entry := models.AuditLog{
+			ID:        id,
+			UserEmail: r.UserEmail,
+			Action:    r.Action,
+			Details:   json.RawMessage(r.Details),
+			IPAddress: r.IpAddress,
+			UserAgent: r.UserAgent,
+			CreatedAt: r.CreatedAt.Time,
+		}
+		if user, err := pgToUUID(r.UserID); err == nil {
+			entry.UserID = &user
+		}
+		out = append(out, entry)
+	}
+	return out, nil
+}
+
+// poolCurveParams sets a new pool's type and, for a concentrated pool, its
+// tick spacing and starting price.
+func poolCurveParams(req models.CreatePoolRequest) (db.CreatePoolParams, error) {
+	params := db.CreatePoolParams{PoolType: poolConstantProduct, TickSpacing: 1}
+	var err error
+	if params.SqrtPrice, err = floatNumeric(0); err != nil {
+		return params, err
+	}
+	if req.Type != poolConcentrated {
+		return params, nil
+	}
+	if req.InitialPrice <= 0 {
+		return params, fmt.Errorf("%w: a concentrated pool needs an initial_price", ErrInvalidPool)
+	}
+	tick := priceTick(req.InitialPrice)
+	if tick < minTick || tick >= maxTick {
+		return params, fmt.Errorf("%w: initial_price out of range", ErrInvalidPool)
+	}
+	params.PoolType, params.TickSpacing, params.Tick = poolConcentrated, defaultTickSpacing, int32(tick)
+	if req.TickSpacing > 0 {
+		params.TickSpacing = int32(req.TickSpacing)
+	}
+	if params.SqrtPrice, err = floatNumeric(math.Sqrt(req.InitialPrice)); err != nil {
+		return params, err
+	}
+	return params, nil
+}
+
+func (s *LiquidityService) checkFeeTier(feeBps int) error {
+	if !slices.Contains(s.cfg.FeeTiers, feeBps) {
+		return fmt.Errorf("%w: fee_bps must be one of %v", ErrInvalidPool, s.cfg.FeeTiers)
+	}
+	return nil
+}
+
+func activeToken(ctx context.Context, q liquidityQuerier, symbol string) (db.GetTokenBySymbolRow, error) {
+	token, err := q.GetTokenBySymbol(ctx, symbol)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return token, fmt.Errorf("%w: unknown token %s", ErrInvalidPool, symbol)
+		}
+		return token, err
+	}
+	if !token.IsActive {
+		return token, fmt.Errorf("%w: token %s is not active", ErrInvalidPool, symbol)
+	}
+	return token, nil
+}
+
+func lockPool(ctx context.Context, q liquidityQuerier, poolID uuid.UUID) (db.LiquidityPool, error) {
+	pool, err := q.GetPoolForUpdate(ctx, toPgUUID(poolID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return pool, ErrPoolNotFound
+	}
+	return pool, err
+}
+
+// recordAudit logs an admin change to a pool in the same transaction as
+// the change.
+func recordAudit(ctx context.Context, q liquidityQuerier, audit models.AuditContext, action string, poolID uuid.UUID, details map[string]any) error {
+	raw, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	params := db.CreateAuditLogParams{
+		UserID:       toPgUUID(audit.UserID),
+		Action:       action,
+		ResourceType: poolAuditResource,
+		ResourceID:   poolID.String(),
+		Details:      raw,
+		UserAgent:    audit.UserAgent,
+	}
+	if ip, err := netip.ParseAddr(audit.IPAddress); err == nil {
+		params.IpAddress = &ip
+	}
+	return q.CreateAuditLog(ctx, params)
+}
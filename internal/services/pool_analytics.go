@@ -0,0 +1,222 @@
+// internal/services/pool_analytics.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jd7008911/aogeri-api/internal/db"
+	"github.com/jd7008911/aogeri-api/internal/models"
+)
+
+var (
+	ErrInvalidHistoryRange = errors.New("invalid history range")
+	ErrInvalidDepth        = errors.New("invalid depth request")
+)
+
+// historyInterval is a history bucket: its date_trunc unit, length and the
+// window served when none is asked for.
+type historyInterval struct {
+	bucket        string
+	step          time.Duration
+	defaultWindow time.Duration
+}
+
+var historyIntervals = map[string]historyInterval{
+	"1h": {"hour", time.Hour, 7 * 24 * time.Hour},
+	"1d": {"day", 24 * time.Hour, 90 * 24 * time.Hour},
+}
+
+const (
+	maxHistoryPoints    = 2000
+	defaultDepthLevels  = 20
+	maxDepthLevels      = 100
+	defaultDepthStepBps = 50
+)
+
+// SnapshotPools writes this hour's analytics snapshot for every pool that
+// is not deprecated, with the swaps of the hour before it. Snapshots are
+// keyed by hour so reruns and replicas are no-ops.
+func (s *LiquidityService) SnapshotPools(ctx context.Context) error {
+	at := time.Now().UTC().Truncate(time.Hour)
+	n, err := s.queries.CreatePoolSnapshots(ctx, db.CreatePoolSnapshotsParams{
+		SnapshotAt: pgtype.Timestamp{Time: at, Valid: true},
+		FromAt:     pgtype.Timestamp{Time: at.Add(-time.Hour), Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("pool snapshots: %d pools at %s", n, at.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// PoolHistory returns a pool's snapshots between from and to, bucketed by
+// interval (1h or 1d). Each point starts at its time; its swaps, volume
+// and fees are summed over the bucket and its reserves, TVL, price and
+// providers are as of the bucket's end. A zero to means now and a zero
+// from a week of hourly or 90 days of daily points.
+func (s *LiquidityService) PoolHistory(ctx context.Context, poolID uuid.UUID, interval string, from, to time.Time) ([]models.PoolHistoryPoint, error) {
+	iv, ok := historyIntervals[interval]
+	if !ok {
+		return nil, fmt.Errorf("%w: interval must be 1h or 1d", ErrInvalidHistoryRange)
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-iv.defaultWindow)
+	}
+	if !to.After(from) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidHistoryRange)
+	}
+	if to.Sub(from)/iv.step > maxHistoryPoints {
+		return nil, fmt.Errorf("%w: at most %d points per request", ErrInvalidHistoryRange, maxHistoryPoints)
+	}
+	if _, err := s.queries.GetPool(ctx, toPgUUID(poolID)); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPoolNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := s.queries.GetPoolHistory(ctx, db.GetPoolHistoryParams{
+		Bucket: iv.bucket,
+		PoolID: toPgUUID(poolID),
+		FromAt: pgtype.Timestamp{Time: from.UTC(), Valid: true},
+		ToAt:   pgtype.Timestamp{Time: to.UTC(), Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]models.PoolHistoryPoint, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, models.PoolHistoryPoint{
+			Time:        r.BucketStart.Time,
+			Reserve0:    numericString(r.Reserve0),
+			Reserve1:    numericString(r.Reserve1),
+			TotalShares: numericString(r.TotalShares),
+			Price:       numericFloat(r.Price),
+			PriceLow:    numericFloat(r.PriceLow),
+			PriceHigh:   numericFloat(r.PriceHigh),
+			TVL:         numericString(r.Tvl),
+			Providers:   int(r.Providers),
+			Swaps:       r.Swaps,
+			Volume0:     numericString(r.Volume0),
+			Volume1:     numericString(r.Volume1),
+			VolumeValue: numericString(r.VolumeValue),
+			Fees0:       numericString(r.Fees0),
+			Fees1:       numericString(r.Fees1),
+			FeesValue:   numericString(r.FeesValue),
+		})
+	}
+	return out, nil
+}
+
+// PoolDepth reads an order book off a pool's curve: levels prices every
+// stepBps above and below its price, each with the cumulative amounts the
+// pool trades to move its price there, fees excluded. levels and stepBps
+// default to 20 and 50.
+func (s *LiquidityService) PoolDepth(ctx context.Context, poolID uuid.UUID, levels, stepBps int) (*models.PoolDepth, error) {
+	if levels == 0 {
+		levels = defaultDepthLevels
+	}
+	if stepBps == 0 {
+		stepBps = defaultDepthStepBps
+	}
+	if levels < 0 || levels > maxDepthLevels {
+		return nil, fmt.Errorf("%w: levels must be between 1 and %d", ErrInvalidDepth, maxDepthLevels)
+	}
+	if stepBps < 0 || stepBps >= bpsDenominator {
+		return nil, fmt.Errorf("%w: step_bps out of range", ErrInvalidDepth)
+	}
+	row, err := s.queries.GetPool(ctx, toPgUUID(poolID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPoolNotFound
+		}
+		return nil, err
+	}
+	c, err := quoteCurve(ctx, s.queries, row)
+	if err != nil {
+		return nil, err
+	}
+
+	price := c.price()
+	depth := &models.PoolDepth{
+		PoolID:  poolID,
+		Token0:  row.Token0Symbol,
+		Token1:  row.Token1Symbol,
+		Price:   price,
+		StepBps: stepBps,
+		Bids:    []models.DepthLevel{},
+		Asks:    []models.DepthLevel{},
+	}
+	if price <= 0 {
+		return depth, nil
+	}
+	step := float64(stepBps) / bpsDenominator
+	for i := 1; i <= levels; i++ {
+		ask := price * (1 + float64(i)*step)
+		a0, a1 := curveDepth(c, ask)
+		depth.Asks = append(depth.Asks, models.DepthLevel{Price: ask, Amount0: formatAmount(a0), Amount1: formatAmount(a1)})
+		if bid := price * (1 - float64(i)*step); bid > 0 {
+			b0, b1 := curveDepth(c, bid)
+			depth.Bids = append(depth.Bids, models.DepthLevel{Price: bid, Amount0: formatAmount(b0), Amount1: formatAmount(b1)})
+		}
+	}
+	return depth, nil
+}
+
+// curveDepth is how much of each token a pool trades moving its price of
+// token0 from the current price to target, fees excluded. Moving up it
+// sells amount0 for amount1; moving down it buys amount0 for amount1. A
+// constant-product pool is one range of liquidity sqrt(reserve0 *
+// reserve1) over every price; a concentrated pool's liquidity changes at
+// each initialized tick on the way.
+func curveDepth(c curve, target float64) (amount0, amount1 float64) {
+	sqrtT := math.Sqrt(target)
+	if !c.concentrated {
+		return liquidityDelta(math.Sqrt(c.reserve0*c.reserve1), math.Sqrt(c.price()), sqrtT)
+	}
+	sqrtP, liquidity, tick := c.sqrtPrice, c.liquidity, c.tick
+	up := sqrtT > sqrtP
+	for {
+		next, ok := nextTick(c.ticks, tick, !up)
+		stop := sqrtT
+		if ok {
+			if st := tickSqrtPrice(next.tick); (up && st < sqrtT) || (!up && st > sqrtT) {
+				stop = st
+			}
+		}
+		a0, a1 := liquidityDelta(liquidity, sqrtP, stop)
+		amount0, amount1 = amount0+a0, amount1+a1
+		if stop == sqrtT {
+			return amount0, amount1
+		}
+		sqrtP = stop
+		if up {
+			liquidity, tick = liquidity+next.liquidityNet, next.tick
+		} else {
+			liquidity, tick = liquidity-next.liquidityNet, next.tick-1
+		}
+	}
+}
+
+// liquidityDelta is the token0 and token1 that liquidity holds between two
+// square-root prices.
+func liquidityDelta(liquidity, sqrtA, sqrtB float64) (amount0, amount1 float64) {
+	if liquidity <= 0 || sqrtA <= 0 || sqrtB <= 0 {
+		return 0, 0
+	}
+	lo, hi := math.Min(sqrtA, sqrtB), math.Max(sqrtA, sqrtB)
+	return liquidity * (1/lo - 1/hi), liquidity * (hi - lo)
+}
@@ -0,0 +1,260 @@
+// internal/services/proposal_actions.go
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jd7008911/aogeri-api/internal/db"
+	"github.com/jd7008911/aogeri-api/internal/models"
+)
+
+// Actions a proposal payload can carry.
+const (
+	ActionSetProductAPY        = "set_product_apy"
+	ActionAddToken             = "add_token"
+	ActionSetTokenActive       = "set_token_active"
+	ActionSetSecurityThreshold = "set_security_threshold"
+	ActionSetPoolFee           = "set_pool_fee"
+)
+
+var (
+	errExecutorUnavailable = errors.New("no executor configured for proposal action")
+	errInvalidPayload      = errors.New("invalid proposal payload")
+)
+
+var tokenSymbolPattern = regexp.MustCompile(`^[A-Za-z0-9]{1,10}$`)
+
+type productUpdater interface {
+	SetProductAPY(ctx context.Context, productID uuid.UUID, apy float64) error
+}
+
+type tokenManager interface {
+	AddToken(ctx context.Context, p models.AddTokenParams) error
+	SetTokenActive(ctx context.Context, symbol string, active bool) error
+}
+
+type thresholdSetter interface {
+	SetThreshold(ctx context.Context, name string, value float64) error
+}
+
+type poolFeeSetter interface {
+	SetPoolFee(ctx context.Context, audit models.AuditContext, poolID uuid.UUID, feeBps int) (*models.Pool, error)
+}
+
+// ProposalExecutors are the services passed proposals apply their payloads
+// through.
+type ProposalExecutors struct {
+	Products   productUpdater
+	Tokens     tokenManager
+	Thresholds thresholdSetter
+	Pools      poolFeeSetter
+}
+
+// proposalAction validates and applies one payload action. Actions that
+// are audited record audit as the author of the change.
+type proposalAction struct {
+	validate func(raw json.RawMessage) error
+	execute  func(ctx context.Context, e ProposalExecutors, audit models.AuditContext, raw json.RawMessage) error
+}
+
+var proposalActions = map[string]proposalAction{
+	ActionSetProductAPY: {
+		validate: func(raw json.RawMessage) error {
+			p, err := decodeParams[models.SetProductAPYParams](raw)
+			if err != nil {
+				return err
+			}
+			if p.ProductID == uuid.Nil {
+				return errors.New("product_id is required")
+			}
+			if p.APY < 0 || p.APY > 1000 {
+				return errors.New("apy must be between 0 and 1000")
+			}
+			return nil
+		},
+		execute: func(ctx context.Context, e ProposalExecutors, _ models.AuditContext, raw json.RawMessage) error {
+			if e.Products == nil {
+				return errExecutorUnavailable
+			}
+			p, err := decodeParams[models.SetProductAPYParams](raw)
+			if err != nil {
+				return err
+			}
+			return e.Products.SetProductAPY(ctx, p.ProductID, p.APY)
+		},
+	},
+	ActionAddToken: {
+		validate: func(raw json.RawMessage) error {
+			p, err := decodeParams[models.AddTokenParams](raw)
+			if err != nil {
+				return err
+			}
+			if !tokenSymbolPattern.MatchString(p.Symbol) {
+				return errors.New("symbol must be 1-10 letters or digits")
+			}
+			if strings.TrimSpace(p.Name) == "" || len(p.Name) > 100 {
+				return errors.New("name is required and at most 100 characters")
+			}
+			if p.Decimals < 0 || p.Decimals > 36 {
+				return errors.New("decimals must be between 0 and 36")
+			}
+			return nil
+		},
+		execute: func(ctx context.Context, e ProposalExecutors, _ models.AuditContext, raw json.RawMessage) error {
+			if e.Tokens == nil {
+				return errExecutorUnavailable
+			}
+			p, err := decodeParams[models.AddTokenParams](raw)
+			if err != nil {
+				return err
+			}
+			return e.Tokens.AddToken(ctx, p)
+		},
+	},
+	ActionSetTokenActive: {
+		validate: func(raw json.RawMessage) error {
+			p, err := decodeParams[models.SetTokenActiveParams](raw)
+			if err != nil {
+				return err
+			}
+			if !tokenSymbolPattern.MatchString(p.Symbol) {
+				return errors.New("symbol must be 1-10 letters or digits")
+			}
+			return nil
+		},
+		execute: func(ctx context.Context, e ProposalExecutors, _ models.AuditContext, raw json.RawMessage) error {
+			if e.Tokens == nil {
+				return errExecutorUnavailable
+			}
+			p, err := decodeParams[models.SetTokenActiveParams](raw)
+			if err != nil {
+				return err
+			}
+			return e.Tokens.SetTokenActive(ctx, p.Symbol, p.Active)
+		},
+	},
+	ActionSetSecurityThreshold: {
+		validate: func(raw json.RawMessage) error {
+			p, err := decodeParams[models.SetSecurityThresholdParams](raw)
+			if err != nil {
+				return err
+			}
+			return validateThreshold(p.Name, p.Value)
+		},
+		execute: func(ctx context.Context, e ProposalExecutors, _ models.AuditContext, raw json.RawMessage) error {
+			if e.Thresholds == nil {
+				return errExecutorUnavailable
+			}
+			p, err := decodeParams[models.SetSecurityThresholdParams](raw)
+			if err != nil {
+				return err
+			}
+			return e.Thresholds.SetThreshold(ctx, p.Name, p.Value)
+		},
+	},
+	ActionSetPoolFee: {
+		validate: func(raw json.RawMessage) error {
+			p, err := decodeParams[models.SetPoolFeeParams](raw)
+			if err != nil {
+				return err
+			}
+			if p.PoolID == uuid.Nil {
+				return errors.New("pool_id is required")
+			}
+			if p.FeeBps <= 0 || p.FeeBps >= bpsDenominator {
+				return fmt.Errorf("fee_bps must be between 1 and %d", bpsDenominator-1)
+			}
+			return nil
+		},
+		execute: func(ctx context.Context, e ProposalExecutors, audit models.AuditContext, raw json.RawMessage) error {
+			if e.Pools == nil {
+				return errExecutorUnavailable
+			}
+			p, err := decodeParams[models.SetPoolFeeParams](raw)
+			if err != nil {
+				return err
+			}
+			_, err = e.Pools.SetPoolFee(ctx, audit, p.PoolID, p.FeeBps)
+			return err
+		},
+	},
+}
+
+// decodeParams strictly decodes action parameters into T.
+func decodeParams[T any](raw json.RawMessage) (T, error) {
+	var p T
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return p, fmt.Errorf("invalid params: %v", err)
+	}
+	return p, nil
+}
+
+// validatePayload checks that pt accepts the payload's action and that its
+// parameters are well formed.
+func validatePayload(pt ProposalType, payload *models.ProposalPayload) error {
+	allowed := false
+	for _, a := range pt.Actions {
+		if a == payload.Action {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("proposal_type %q does not accept action %q", pt.Name, payload.Action)
+	}
+	if len(payload.Params) == 0 {
+		return errors.New("payload params are required")
+	}
+	return proposalActions[payload.Action].validate(payload.Params)
+}
+
+// executePayload applies a stored payload through the configured executors,
+// on behalf of the proposal's proposer. The payload is validated again
+// first, since the rules may have changed since the proposal was created.
+func (g *GovernanceService) executePayload(ctx context.Context, p db.GovernanceProposal) error {
+	raw := p.Payload
+	var payload models.ProposalPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidPayload, err)
+	}
+	action, ok := proposalActions[payload.Action]
+	if !ok {
+		return fmt.Errorf("%w: unknown action %q", errInvalidPayload, payload.Action)
+	}
+	if err := action.validate(payload.Params); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidPayload, err)
+	}
+	audit := models.AuditContext{
+		UserID:    uuid.UUID(p.ProposerID.Bytes),
+		UserAgent: "governance proposal " + uuid.UUID(p.ID.Bytes).String(),
+	}
+	return action.execute(ctx, g.executors, audit, payload.Params)
+}
+
+// payloadRejected reports whether err means the payload can never be
+// applied: it is invalid, its target does not exist or refuses the change,
+// or the database refused its values. Any other error may be transient and
+// is retried.
+func payloadRejected(err error) bool {
+	for _, target := range []error{
+		errInvalidPayload, ErrProductNotFound, ErrTokenNotFound, ErrInvalidThreshold,
+		ErrPoolNotFound, ErrInvalidPool, ErrPoolExists, ErrInvalidPoolStatus,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	// Class 22 is bad data and class 23 a violated constraint.
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (strings.HasPrefix(pgErr.Code, "22") || strings.HasPrefix(pgErr.Code, "23"))
+}
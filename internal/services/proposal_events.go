@@ -0,0 +1,108 @@
+// internal/services/proposal_events.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jd7008911/aogeri-api/internal/db"
+	"github.com/jd7008911/aogeri-api/internal/models"
+)
+
+// Proposal timeline event types. Each is recorded at most once per proposal.
+const (
+	EventCreated         = "created"
+	EventActivated       = "activated"
+	EventFirstVote       = "first_vote"
+	EventQuorumReached   = "quorum_reached"
+	EventClosed          = "closed"
+	EventExecuted        = "executed"
+	EventExecutionFailed = "execution_failed"
+	EventCancelled       = "cancelled"
+)
+
+// turnoutMilestones are the turnout percentages of eligible power that get
+// a timeline event, recorded as "turnout_<n>".
+var turnoutMilestones = []int{25, 50, 75}
+
+// recordProposalEvent adds an event to a proposal's timeline. actor may be
+// the zero UUID for transitions made by the scheduler.
+func recordProposalEvent(ctx context.Context, q governanceQuerier, proposalID pgtype.UUID, eventType string, actor pgtype.UUID, data any) error {
+	var raw []byte
+	if data != nil {
+		var err error
+		if raw, err = json.Marshal(data); err != nil {
+			return err
+		}
+	}
+	return q.RecordProposalEvent(ctx, db.RecordProposalEventParams{
+		ProposalID: proposalID,
+		EventType:  eventType,
+		ActorID:    actor,
+		Data:       raw,
+	})
+}
+
+// recordVoteMilestones records the turnout milestones p has reached with
+// its current tallies. Milestones already on the timeline are left alone.
+func recordVoteMilestones(ctx context.Context, q governanceQuerier, p db.GovernanceProposal) error {
+	total := numericFloat(p.TotalVotes)
+	if total <= 0 {
+		return nil
+	}
+	if err := recordProposalEvent(ctx, q, p.ID, EventFirstVote, pgtype.UUID{}, nil); err != nil {
+		return err
+	}
+	eligible := numericFloat(p.EligiblePower)
+	if eligible <= 0 {
+		return nil
+	}
+	turnout := total / eligible * 100
+	data := map[string]any{"turnout": turnout, "total_votes": formatAmount(total)}
+	for _, m := range turnoutMilestones {
+		if turnout < float64(m) {
+			break
+		}
+		if err := recordProposalEvent(ctx, q, p.ID, fmt.Sprintf("turnout_%d", m), pgtype.UUID{}, data); err != nil {
+			return err
+		}
+	}
+	if turnout >= numericFloat(p.Quorum) {
+		return recordProposalEvent(ctx, q, p.ID, EventQuorumReached, pgtype.UUID{}, data)
+	}
+	return nil
+}
+
+// GetTimeline returns the lifecycle events of a proposal in the order they
+// happened.
+func (g *GovernanceService) GetTimeline(ctx context.Context, proposalID uuid.UUID) ([]models.ProposalEvent, error) {
+	if _, err := g.queries.GetProposalByID(ctx, toPgUUID(proposalID)); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrProposalNotFound
+		}
+		return nil, err
+	}
+	rows, err := g.queries.ListProposalEvents(ctx, toPgUUID(proposalID))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]models.ProposalEvent, 0, len(rows))
+	for _, r := range rows {
+		e := models.ProposalEvent{
+			Type:      r.EventType,
+			Data:      json.RawMessage(r.Data),
+			CreatedAt: r.CreatedAt.Time,
+		}
+		if r.ActorID.Valid {
+			actor, _ := pgToUUID(r.ActorID)
+			e.ActorID = &actor
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
@@ -0,0 +1,400 @@
+// internal/services/results.go
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jd7008911/aogeri-api/internal/db"
+	"github.com/jd7008911/aogeri-api/internal/models"
+)
+
+// ballotCSVHeader is the column layout of CSV ballot exports. Rankings are
+// written as space-separated option indices.
+var ballotCSVHeader = []string{"voter", "snapshot_power", "delegate_id", "vote_choice", "ranking", "voted_at"}
+
+// GetResults returns a proposal's stored tallies, its result once finalized
+// and the Merkle root of its ballot set.
+func (g *GovernanceService) GetResults(ctx context.Context, proposalID uuid.UUID) (*models.ProposalResults, error) {
+	p, err := proposalByID(ctx, g.queries, toPgUUID(proposalID))
+	if err != nil {
+		return nil, err
+	}
+	r := resultsModel(p)
+	return &r, nil
+}
+
+// ExportBallots returns every ballot of a proposal together with its
+// results, in voter order.
+func (g *GovernanceService) ExportBallots(ctx context.Context, proposalID uuid.UUID) (*models.BallotExport, error) {
+	p, err := proposalByID(ctx, g.queries, toPgUUID(proposalID))
+	if err != nil {
+		return nil, err
+	}
+	ballots, err := listBallots(ctx, g.queries, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.BallotExport{ProposalResults: resultsModel(p), Ballots: ballots}, nil
+}
+
+func listBallots(ctx context.Context, q governanceQuerier, proposalID pgtype.UUID) ([]models.Ballot, error) {
+	rows, err := q.ListProposalBallots(ctx, proposalID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]models.Ballot, 0, len(rows))
+	for _, r := range rows {
+		voter, _ := pgToUUID(r.Voter)
+		b := models.Ballot{
+			Voter:         voter,
+			SnapshotPower: numericExact(r.SnapshotPower),
+			VoteChoice:    r.VoteChoice,
+		}
+		if r.DelegateID.Valid {
+			d, _ := pgToUUID(r.DelegateID)
+			b.DelegateID = &d
+		}
+		if len(r.Ballot) > 0 {
+			if err := json.Unmarshal(r.Ballot, &b.Ranking); err != nil {
+				return nil, err
+			}
+		}
+		if r.VotedAt.Valid {
+			t := r.VotedAt.Time.UTC()
+			b.VotedAt = &t
+		}
+		out = append(out, b)
+	}
+	return out, nil
+}
+
+func resultsModel(p db.GovernanceProposal) models.ProposalResults {
+	m := proposalModel(p)
+	r := models.ProposalResults{
+		ProposalID:    m.ID,
+		Status:        m.Status,
+		VotingMethod:  m.VotingMethod,
+		Options:       m.Options,
+		Quorum:        m.Quorum,
+		Threshold:     m.Threshold,
+		EligiblePower: m.EligiblePower,
+		Tally: models.ResultsTally{
+			ForVotes:     m.ForVotes,
+			AgainstVotes: m.AgainstVotes,
+			AbstainVotes: m.AbstainVotes,
+			TotalVotes:   numericString(p.TotalVotes),
+			OptionVotes:  m.OptionVotes,
+		},
+		Result:      m.Result,
+		BallotRoot:  p.BallotRoot.String,
+		BallotCount: int(p.BallotCount.Int32),
+	}
+	return r
+}
+
+// ballotLeaf is the canonical encoding of a ballot in the Merkle tree:
+//
+//	voter|snapshot_power|delegate_id|vote_choice|ranking|voted_at
+//
+// with an empty field for a missing delegate, vote or timestamp, the
+// ranking as comma-separated option indices and voted_at in RFC 3339 UTC.
+func ballotLeaf(b models.Ballot) []byte {
+	var delegate, votedAt string
+	if b.DelegateID != nil {
+		delegate = b.DelegateID.String()
+	}
+	if b.VotedAt != nil {
+		votedAt = b.VotedAt.UTC().Format(time.RFC3339Nano)
+	}
+	ranking := make([]string, len(b.Ranking))
+	for i, o := range b.Ranking {
+		ranking[i] = strconv.Itoa(o)
+	}
+	return []byte(strings.Join([]string{
+		b.Voter.String(), b.SnapshotPower, delegate, b.VoteChoice, strings.Join(ranking, ","), votedAt,
+	}, "|"))
+}
+
+// BallotRoot returns the hex Merkle root of a ballot set. Ballots are
+// ordered by voter and hashed as an RFC 6962 Merkle tree: leaves are
+// SHA-256(0x00 || leaf) and nodes SHA-256(0x01 || left || right).
+func BallotRoot(ballots []models.Ballot) string {
+	sorted := append([]models.Ballot(nil), ballots...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Voter.String() < sorted[j].Voter.String() })
+	leaves := make([][]byte, len(sorted))
+	for i, b := range sorted {
+		leaves[i] = ballotLeaf(b)
+	}
+	root := merkleRoot(leaves)
+	return hex.EncodeToString(root[:])
+}
+
+func merkleRoot(leaves [][]byte) [32]byte {
+	switch len(leaves) {
+	case 0:
+		return sha256.Sum256(nil)
+	case 1:
+		return sha256.Sum256(append([]byte{0x00}, leaves[0]...))
+	}
+	// Split at the largest power of two below the leaf count.
+	k := 1
+	for k*2 < len(leaves) {
+		k *= 2
+	}
+	left, right := merkleRoot(leaves[:k]), merkleRoot(leaves[k:])
+	node := make([]byte, 0, 1+2*sha256.Size)
+	node = append(node, 0x01)
+	node = append(node, left[:]...)
+	node = append(node, right[:]...)
+	return sha256.Sum256(node)
+}
+
+// ExportCheck is the outcome of recomputing a ballot export.
+type ExportCheck struct {
+	BallotCount  int
+	ComputedRoot string
+	Tally        models.ResultsTally
+	Outcome      string
+	Mismatches   []string
+}
+
+// OK reports whether everything the export states matched the recomputation.
+func (c *ExportCheck) OK() bool { return len(c.Mismatches) == 0 }
+
+// VerifyBallotExport recomputes the ballot root and the tally of an export,
+// following delegations the same way the server does, and compares them
+// with the values the export states. Values the export leaves empty, such
+// as the root of a proposal not yet finalized, are not compared.
+func VerifyBallotExport(e models.BallotExport) (*ExportCheck, error) {
+	method, ok := tallyMethods[e.VotingMethod]
+	if !ok {
+		return nil, fmt.Errorf("unknown voting method %q", e.VotingMethod)
+	}
+	ballots, err := effectiveBallots(e.Ballots)
+	if err != nil {
+		return nil, err
+	}
+	t := method.tally(len(e.Options), ballots)
+
+	c := &ExportCheck{
+		BallotCount:  len(e.Ballots),
+		ComputedRoot: BallotRoot(e.Ballots),
+		Tally: models.ResultsTally{
+			ForVotes:     formatAmount(t.forVotes),
+			AgainstVotes: formatAmount(t.againstVotes),
+			AbstainVotes: formatAmount(t.abstainVotes),
+			TotalVotes:   formatAmount(t.participation),
+		},
+	}
+	mismatch := func(format string, args ...any) {
+		c.Mismatches = append(c.Mismatches, fmt.Sprintf(format, args...))
+	}
+	if e.BallotRoot != "" && e.BallotRoot != c.ComputedRoot {
+		mismatch("ballot root: export %s, computed %s", e.BallotRoot, c.ComputedRoot)
+	}
+	if e.BallotCount != 0 && e.BallotCount != c.BallotCount {
+		mismatch("ballot count: export %d, computed %d", e.BallotCount, c.BallotCount)
+	}
+	for _, f := range []struct {
+		name     string
+		stated   string
+		computed float64
+	}{
+		{"for_votes", e.Tally.ForVotes, t.forVotes},
+		{"against_votes", e.Tally.AgainstVotes, t.againstVotes},
+		{"abstain_votes", e.Tally.AbstainVotes, t.abstainVotes},
+		{"total_votes", e.Tally.TotalVotes, t.participation},
+	} {
+		if f.stated != "" && !amountMatches(f.stated, f.computed) {
+			mismatch("%s: export %s, computed %s", f.name, f.stated, formatAmount(f.computed))
+		}
+	}
+	if method.ranked {
+		for i, v := range t.optionVotes {
+			c.Tally.OptionVotes = append(c.Tally.OptionVotes, formatAmount(v))
+			if len(e.Tally.OptionVotes) > 0 && (i >= len(e.Tally.OptionVotes) || !amountMatches(e.Tally.OptionVotes[i], v)) {
+				mismatch("option %d votes: computed %s", i, formatAmount(v))
+			}
+		}
+	}
+
+	if e.Result != nil {
+		p := db.GovernanceProposal{VotingMethod: e.VotingMethod}
+		if p.Options, err = json.Marshal(e.Options); err != nil {
+			return nil, err
+		}
+		if p.EligiblePower, err = floatNumeric(mustParseAmount(e.EligiblePower)); err != nil {
+			return nil, err
+		}
+		if p.Quorum, err = floatNumeric(e.Quorum); err != nil {
+			return nil, err
+		}
+		if p.Threshold, err = floatNumeric(e.Threshold); err != nil {
+			return nil, err
+		}
+		r := proposalResult(p, t)
+		c.Outcome = r.Outcome
+		if r.Outcome != e.Result.Outcome {
+			mismatch("outcome: export %s, computed %s", e.Result.Outcome, r.Outcome)
+		}
+		if method.ranked && !sameOption(r.WinningOption, e.Result.WinningOption) {
+			mismatch("winning option differs from the export")
+		}
+	}
+	return c, nil
+}
+
+// effectiveBallots turns an export into one ballot per direct voter with
+// the power that reaches them: their own plus that of holders whose
+// delegation chain, followed for at most maxDelegationDepth hops, ends at
+// them. Powers are summed exactly before conversion.
+func effectiveBallots(export []models.Ballot) ([]ballot, error) {
+	byVoter := make(map[uuid.UUID]models.Ballot, len(export))
+	for _, b := range export {
+		byVoter[b.Voter] = b
+	}
+	power := map[uuid.UUID]*big.Rat{}
+	roots := map[uuid.UUID]float64{}
+	for _, b := range export {
+		p, ok := new(big.Rat).SetString(b.SnapshotPower)
+		if !ok {
+			return nil, fmt.Errorf("voter %s: invalid snapshot_power %q", b.Voter, b.SnapshotPower)
+		}
+		cur := b
+		for depth := 0; ; depth++ {
+			if cur.VoteChoice != "" {
+				if power[cur.Voter] == nil {
+					power[cur.Voter] = new(big.Rat)
+				}
+				power[cur.Voter].Add(power[cur.Voter], p)
+				f, _ := p.Float64()
+				roots[cur.Voter] += math.Sqrt(f)
+				break
+			}
+			if cur.DelegateID == nil || depth == maxDelegationDepth {
+				break
+			}
+			next, ok := byVoter[*cur.DelegateID]
+			if !ok {
+				break
+			}
+			cur = next
+		}
+	}
+
+	voters := make([]uuid.UUID, 0, len(power))
+	for v := range power {
+		voters = append(voters, v)
+	}
+	sort.Slice(voters, func(i, j int) bool { return voters[i].String() < voters[j].String() })
+	out := make([]ballot, 0, len(voters))
+	for _, v := range voters {
+		f, _ := power[v].Float64()
+		b := byVoter[v]
+		out = append(out, ballot{choice: b.VoteChoice, ranking: b.Ranking, power: f, rootPower: roots[v]})
+	}
+	return out, nil
+}
+
+func amountMatches(stated string, computed float64) bool {
+	v, err := strconv.ParseFloat(stated, 64)
+	return err == nil && tied(v, computed)
+}
+
+func mustParseAmount(s string) float64 {
+	v, _ := strconv.ParseFloat(s, 64)
+	return v
+}
+
+func sameOption(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// WriteBallotsCSV writes ballots in the CSV export layout.
+func WriteBallotsCSV(w io.Writer, ballots []models.Ballot) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(ballotCSVHeader); err != nil {
+		return err
+	}
+	for _, b := range ballots {
+		var delegate, votedAt string
+		if b.DelegateID != nil {
+			delegate = b.DelegateID.String()
+		}
+		if b.VotedAt != nil {
+			votedAt = b.VotedAt.UTC().Format(time.RFC3339Nano)
+		}
+		ranking := make([]string, len(b.Ranking))
+		for i, o := range b.Ranking {
+			ranking[i] = strconv.Itoa(o)
+		}
+		if err := cw.Write([]string{
+			b.Voter.String(), b.SnapshotPower, delegate, b.VoteChoice, strings.Join(ranking, " "), votedAt,
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadBallotsCSV parses ballots written by WriteBallotsCSV.
+func ReadBallotsCSV(r io.Reader) ([]models.Ballot, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 || strings.Join(records[0], ",") != strings.Join(ballotCSVHeader, ",") {
+		return nil, errors.New("missing or unexpected CSV header")
+	}
+	out := make([]models.Ballot, 0, len(records)-1)
+	for i, rec := range records[1:] {
+		line := i + 2
+		voter, err := uuid.Parse(rec[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid voter: %v", line, err)
+		}
+		b := models.Ballot{Voter: voter, SnapshotPower: rec[1], VoteChoice: rec[3]}
+		if rec[2] != "" {
+			d, err := uuid.Parse(rec[2])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid delegate_id: %v", line, err)
+			}
+			b.DelegateID = &d
+		}
+		for _, f := range strings.Fields(rec[4]) {
+			o, err := strconv.Atoi(f)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid ranking: %v", line, err)
+			}
+			b.Ranking = append(b.Ranking, o)
+		}
+		if rec[5] != "" {
+			t, err := time.Parse(time.RFC3339Nano, rec[5])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid voted_at: %v", line, err)
+			}
+			b.VotedAt = &t
+		}
+		out = append(out, b)
+	}
+	return out, nil
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jd7008911/aogeri-api/internal/models"
+)
+
+func TestMerkleRoot(t *testing.T) {
+	leaf := func(s string) [32]byte { return sha256.Sum256(append([]byte{0x00}, s...)) }
+	node := func(l, r [32]byte) [32]byte {
+		return sha256.Sum256(append(append([]byte{0x01}, l[:]...), r[:]...))
+	}
+
+	if got := merkleRoot(nil); hex.EncodeToString(got[:]) != "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Fatalf("unexpected empty root: %x", got)
+	}
+	if got := merkleRoot([][]byte{[]byte("a")}); got != leaf("a") {
+		t.Fatalf("unexpected single-leaf root: %x", got)
+	}
+	// Three leaves split 2+1; five split 4+1.
+	three := [][]byte{[]byte("a"), []byte("b"), []byte("c")}
+	if got, want := merkleRoot(three), node(node(leaf("a"), leaf("b")), leaf("c")); got != want {
+		t.Fatalf("unexpected three-leaf root: %x, want %x", got, want)
+	}
+	five := append(three, []byte("d"), []byte("e"))
+	want := node(node(node(leaf("a"), leaf("b")), node(leaf("c"), leaf("d"))), leaf("e"))
+	if got := merkleRoot(five); got != want {
+		t.Fatalf("unexpected five-leaf root: %x, want %x", got, want)
+	}
+}
+
+func TestBallotRoot_OrderIndependent(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	ballots := []models.Ballot{
+		{Voter: uuid.New(), SnapshotPower: "10", VoteChoice: "for", VotedAt: &at},
+		{Voter: uuid.New(), SnapshotPower: "2.5"},
+		{Voter: uuid.New(), SnapshotPower: "7", VoteChoice: "ranked", Ranking: []int{1, 0}, VotedAt: &at},
+	}
+	root := BallotRoot(ballots)
+	reversed := []models.Ballot{ballots[2], ballots[1], ballots[0]}
+	if BallotRoot(reversed) != root {
+		t.Fatal("root depends on input order")
+	}
+	ballots[1].SnapshotPower = "2.6"
+	if BallotRoot(ballots) == root {
+		t.Fatal("root did not change with a ballot")
+	}
+}
+
+func TestExportAndVerifyBallots(t *testing.T) {
+	now := time.Now()
+	alice, bob, carol := uuid.New(), uuid.New(), uuid.New()
+	fq := &fakeGovQueries{power: map[pgtype.UUID]string{
+		toPgUUID(alice): "100",
+		toPgUUID(bob):   "50",
+		toPgUUID(carol): "30",
+	}}
+	g := NewGovernanceService(fq, nil).WithConfig(testGovernanceConfig())
+	ctx := context.Background()
+
+	if _, err := g.Delegate(ctx, alice, models.DelegateRequest{DelegateID: bob}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pid := openProposal(fq, now.Add(-time.Hour), now.Add(time.Hour), "pending")
+	if _, err := g.CastVote(ctx, pid, bob, models.VoteRequest{VoteChoice: "for"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := g.CastVote(ctx, pid, carol, models.VoteRequest{VoteChoice: "against"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p := fq.proposals[toPgUUID(pid)]
+	p.VotingEnd = pgtype.Timestamp{Time: now.Add(-time.Minute), Valid: true}
+	fq.proposals[p.ID] = p
+	if err := g.ProcessProposals(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	export, err := g.ExportBallots(ctx, pid)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(export.Ballots) != 3 || export.BallotCount != 3 || export.BallotRoot == "" || export.Result == nil {
+		t.Fatalf("unexpected export: %+v", export)
+	}
+	if export.BallotRoot != BallotRoot(export.Ballots) {
+		t.Fatal("stored root does not match the exported ballots")
+	}
+	check, err := VerifyBallotExport(*export)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !check.OK() || check.Tally.ForVotes != "150" || check.Tally.AgainstVotes != "30" || check.Outcome != "passed" {
+		t.Fatalf("expected export to verify: %+v", check)
+	}
+
+	// The CSV form round-trips to the same root.
+	var buf bytes.Buffer
+	if err := WriteBallotsCSV(&buf, export.Ballots); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parsed, err := ReadBallotsCSV(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if BallotRoot(parsed) != export.BallotRoot {
+		t.Fatal("CSV round trip changed the ballot root")
+	}
+
+	// Flipping a single ballot breaks both the root and the tally.
+	for i := range export.Ballots {
+		if export.Ballots[i].Voter == carol {
+			export.Ballots[i].VoteChoice = "for"
+		}
+	}
+	check, err = VerifyBallotExport(*export)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if check.OK() || len(check.Mismatches) < 3 {
+		t.Fatalf("expected tampered export to fail: %+v", check.Mismatches)
+	}
+}
@@ -0,0 +1,168 @@
+// internal/services/rewards_pool.go
+package services
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/jd7008911/aogeri-api/internal/db"
+	"github.com/jd7008911/aogeri-api/internal/models"
+)
+
+// Emission schedules supported by rewards pools.
+const (
+	ScheduleFixed       = "fixed"
+	ScheduleHalving     = "halving"
+	ScheduleLinearDecay = "linear_decay"
+)
+
+// emissionSchedule describes how a rewards pool releases its budget.
+type emissionSchedule struct {
+	Kind        string
+	Daily       float64
+	HalvingDays int32
+	DecayDays   int32
+	Start       time.Time
+}
+
+func poolSchedule(p db.RewardsPool) emissionSchedule {
+	return emissionSchedule{
+		Kind:        p.Schedule,
+		Daily:       numericFloat(p.DailyEmission),
+		HalvingDays: p.HalvingDays,
+		DecayDays:   p.DecayDays,
+		Start:       p.StartsAt.Time,
+	}
+}
+
+// rate returns the daily emission at t.
+func (e emissionSchedule) rate(t time.Time) float64 {
+	d := t.Sub(e.Start).Hours() / 24
+	if d < 0 {
+		return 0
+	}
+	switch e.Kind {
+	case ScheduleHalving:
+		if e.HalvingDays <= 0 {
+			return e.Daily
+		}
+		return e.Daily * math.Pow(0.5, math.Floor(d/float64(e.HalvingDays)))
+	case ScheduleLinearDecay:
+		if e.DecayDays <= 0 || d >= float64(e.DecayDays) {
+			return 0
+		}
+		return e.Daily * (1 - d/float64(e.DecayDays))
+	default:
+		return e.Daily
+	}
+}
+
+// emitted returns the total emission since the schedule started up to t.
+func (e emissionSchedule) emitted(t time.Time) float64 {
+	d := t.Sub(e.Start).Hours() / 24
+	if d <= 0 {
+		return 0
+	}
+	switch e.Kind {
+	case ScheduleHalving:
+		if e.HalvingDays <= 0 {
+			return e.Daily * d
+		}
+		h := float64(e.HalvingDays)
+		periods := math.Floor(d / h)
+		// Full periods form a geometric series: 2h·E·(1 - 0.5^n).
+		full := 2 * h * e.Daily * (1 - math.Pow(0.5, periods))
+		return full + (d-periods*h)*e.Daily*math.Pow(0.5, periods)
+	case ScheduleLinearDecay:
+		if e.DecayDays <= 0 {
+			return 0
+		}
+		n := float64(e.DecayDays)
+		d = math.Min(d, n)
+		return e.Daily * (d - d*d/(2*n))
+	default:
+		return e.Daily * d
+	}
+}
+
+// emittedBetween returns the emission between from and to.
+func (e emissionSchedule) emittedBetween(from, to time.Time) float64 {
+	if !to.After(from) {
+		return 0
+	}
+	return e.emitted(to) - e.emitted(from)
+}
+
+// poolAvailable is what a pool can pay out by until: the smaller of its
+// budget and what the schedule will have emitted, less what is already paid.
+func poolAvailable(p db.RewardsPool, until time.Time) float64 {
+	available := math.Min(numericFloat(p.Budget), poolSchedule(p).emitted(until)) - numericFloat(p.Distributed)
+	return math.Max(available, 0)
+}
+
+// sustainableAPY is the APY the pool can fund for totalStaked over the next
+// year given its schedule and remaining budget.
+func sustainableAPY(p db.RewardsPool, totalStaked float64, now time.Time) float64 {
+	if totalStaked <= 0 {
+		return 0
+	}
+	remaining := math.Max(numericFloat(p.Budget)-numericFloat(p.Distributed), 0)
+	yearly := math.Min(poolSchedule(p).emittedBetween(now, now.Add(365*24*time.Hour)), remaining)
+	return yearly / totalStaked * 100
+}
+
+type rewardsPoolQuerier interface {
+	ListRewardsPoolStats(ctx context.Context) ([]db.ListRewardsPoolStatsRow, error)
+}
+
+// RewardsPoolService reports on the pools that fund staking rewards.
+type RewardsPoolService struct {
+	queries rewardsPoolQuerier
+}
+
+func NewRewardsPoolService(queries rewardsPoolQuerier) *RewardsPoolService {
+	return &RewardsPoolService{queries: queries}
+}
+
+// PoolStats returns budget, emission and runway metrics for active pools.
+func (s *RewardsPoolService) PoolStats(ctx context.Context) ([]models.RewardPoolStats, error) {
+	rows, err := s.queries.ListRewardsPoolStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	out := make([]models.RewardPoolStats, 0, len(rows))
+	for _, r := range rows {
+		pool := db.RewardsPool{
+			ID:            r.ID,
+			TokenID:       r.TokenID,
+			Budget:        r.Budget,
+			Distributed:   r.Distributed,
+			Schedule:      r.Schedule,
+			DailyEmission: r.DailyEmission,
+			HalvingDays:   r.HalvingDays,
+			DecayDays:     r.DecayDays,
+			StartsAt:      r.StartsAt,
+		}
+		remaining := math.Max(numericFloat(r.Budget)-numericFloat(r.Distributed), 0)
+		liability := numericFloat(r.DailyLiability)
+		stats := models.RewardPoolStats{
+			TokenSymbol:    r.Symbol,
+			Schedule:       r.Schedule,
+			Budget:         numericString(r.Budget),
+			Distributed:    numericString(r.Distributed),
+			Remaining:      formatAmount(remaining),
+			DailyEmission:  formatAmount(poolSchedule(pool).rate(now)),
+			TotalStaked:    numericString(r.TotalStaked),
+			DailyLiability: formatAmount(liability),
+			SustainableAPY: sustainableAPY(pool, numericFloat(r.TotalStaked), now),
+		}
+		if liability > 0 {
+			runway := remaining / liability
+			stats.RunwayDays = &runway
+		}
+		out = append(out, stats)
+	}
+	return out, nil
+}
@@ -0,0 +1,263 @@
+// internal/services/router.go
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jd7008911/aogeri-api/internal/db"
+	"github.com/jd7008911/aogeri-api/internal/models"
+)
+
+var ErrNoRoute = errors.New("no route between tokens")
+
+// routeHop is one priced step of a route.
+type routeHop struct {
+	pool db.GetPoolRow
+	side swapSide
+	in   float64
+	swapResult
+}
+
+// routePool is a pool the router may swap through, with its curve.
+type routePool struct {
+	row   db.GetPoolRow
+	curve curve
+}
+
+// findRoute searches the active pools for the path of at most maxHops
+// that returns the most tokenOut for amountIn, pricing every hop with its
+// pool's curve, fee tier and price impact. Ties go to the shorter path. It returns nil when
+// the tokens are not connected.
+func findRoute(pools []routePool, tokenIn, tokenOut string, amountIn float64, maxHops int) []routeHop {
+	adjacent := make(map[string][]routePool)
+	for _, p := range pools {
+		if numericFloat(p.row.Reserve0) <= 0 && numericFloat(p.row.Reserve1) <= 0 {
+			continue
+		}
+		adjacent[p.row.Token0Symbol] = append(adjacent[p.row.Token0Symbol], p)
+		adjacent[p.row.Token1Symbol] = append(adjacent[p.row.Token1Symbol], p)
+	}
+
+	var (
+		best    []routeHop
+		bestOut float64
+		path    []routeHop
+		visited = map[string]bool{tokenIn: true}
+	)
+	var walk func(token string, amount float64)
+	walk = func(token string, amount float64) {
+		if token == tokenOut {
+			if amount > bestOut || (amount == bestOut && len(path) < len(best)) {
+				best, bestOut = append([]routeHop(nil), path...), amount
+			}
+			return
+		}
+		if len(path) == maxHops {
+			return
+		}
+		for _, p := range adjacent[token] {
+			side, err := resolveSwapSide(p.row, token)
+			if err != nil || visited[side.tokenOut] {
+				continue
+			}
+			res, err := priceSwap(p.curve, side, amount)
+			if err != nil {
+				continue
+			}
+
+			visited[side.tokenOut] = true
+			path = append(path, routeHop{p.row, side, amount, res})
+			walk(side.tokenOut, res.out)
+			path = path[:len(path)-1]
+			visited[side.tokenOut] = false
+		}
+	}
+	walk(tokenIn, amountIn)
+	return best
+}
+
+// routeImpact compounds the hops' price impacts into one percentage.
+func routeImpact(hops []routeHop) float64 {
+	kept := 1.0
+	for _, h := range hops {
+		kept *= 1 - h.impact/100
+	}
+	return (1 - kept) * 100
+}
+
+func routeModel(tokenIn, tokenOut string, hops []routeHop, slippageBps int) models.RouteQuote {
+	out := hops[len(hops)-1].out
+	m := models.RouteQuote{
+		TokenIn:         tokenIn,
+		TokenOut:        tokenOut,
+		AmountIn:        formatAmount(hops[0].in),
+		AmountOut:       formatAmount(out),
+		PriceImpact:     routeImpact(hops),
+		SlippageBps:     slippageBps,
+		MinimumReceived: formatAmount(out * float64(bpsDenominator-slippageBps) / bpsDenominator),
+		Hops:            make([]models.RouteHop, 0, len(hops)),
+	}
+	for _, h := range hops {
+		poolID, _ := pgToUUID(h.pool.ID)
+		m.Hops = append(m.Hops, models.RouteHop{
+			PoolID:      poolID,
+			PoolName:    h.pool.Name,
+			TokenIn:     h.side.tokenIn,
+			TokenOut:    h.side.tokenOut,
+			AmountIn:    formatAmount(h.in),
+			AmountOut:   formatAmount(h.out),
+			Fee:         formatAmount(h.fee),
+			PriceImpact: h.impact,
+		})
+	}
+	return m
+}
+
+func (s *LiquidityService) routeParams(tokenIn, tokenOut, amountIn string, maxHops int) (string, string, float64, int, error) {
+	tokenIn, tokenOut = strings.ToUpper(tokenIn), strings.ToUpper(tokenOut)
+	if tokenIn == "" || tokenOut == "" || tokenIn == tokenOut {
+		return "", "", 0, 0, fmt.Errorf("%w: token_in and token_out must be two different tokens", ErrInvalidSwap)
+	}
+	in, ok := parsePositiveAmount(amountIn)
+	if !ok {
+		return "", "", 0, 0, fmt.Errorf("%w: amount_in must be a positive finite number", ErrInvalidSwap)
+	}
+	if maxHops == 0 || maxHops > s.cfg.MaxHops {
+		maxHops = s.cfg.MaxHops
+	}
+	if maxHops < 1 {
+		return "", "", 0, 0, fmt.Errorf("%w: max_hops must be at least 1", ErrInvalidSwap)
+	}
+	return tokenIn, tokenOut, in, maxHops, nil
+}
+
+// listRoutablePools returns the pools that take swaps.
+func listRoutablePools(ctx context.Context, q liquidityQuerier) ([]routePool, error) {
+	rows, err := q.ListPools(ctx, pgtype.Text{})
+	if err != nil {
+		return nil, err
+	}
+	pools := make([]routePool, 0, len(rows))
+	for _, r := range rows {
+		if r.Status != poolActive {
+			continue
+		}
+		row := db.GetPoolRow(r)
+		c, err := quoteCurve(ctx, q, row)
+		if err != nil {
+			return nil, err
+		}
+		pools = append(pools, routePool{row, c})
+	}
+	return pools, nil
+}
+
+// QuoteRoute finds the best route of at most maxHops pools for selling
+// amountIn of tokenIn for tokenOut. maxHops is capped by, and defaults to,
+// the configured limit.
+func (s *LiquidityService) QuoteRoute(ctx context.Context, tokenIn, tokenOut, amountIn string, maxHops, slippageBps int) (*models.RouteQuote, error) {
+	tokenIn, tokenOut, in, maxHops, err := s.routeParams(tokenIn, tokenOut, amountIn, maxHops)
+	if err != nil {
+		return nil, err
+	}
+	if slippageBps == 0 {
+		slippageBps = s.cfg.DefaultSlippageBps
+	}
+	if slippageBps < 0 || slippageBps >= bpsDenominator {
+		return nil, fmt.Errorf("%w: slippage_bps out of range", ErrInvalidSwap)
+	}
+	pools, err := listRoutablePools(ctx, s.queries)
+	if err != nil {
+		return nil, err
+	}
+	hops := findRoute(pools, tokenIn, tokenOut, in, maxHops)
+	if hops == nil {
+		return nil, fmt.Errorf("%w: %s to %s within %d hops", ErrNoRoute, tokenIn, tokenOut, maxHops)
+	}
+	quote := routeModel(tokenIn, tokenOut, hops, slippageBps)
+	return &quote, nil
+}
+
+// SwapRoute sells along the best route in one transaction. The route's
+// pools are locked in a fixed order and every hop is repriced against the
+// locked pools, so either every hop executes or none does.
+func (s *LiquidityService) SwapRoute(ctx context.Context, userID uuid.UUID, req models.RouteSwapRequest) (*models.RouteReceipt, error) {
+	if req.Deadline != nil && time.Now().After(*req.Deadline) {
+		return nil, ErrDeadlineExceeded
+	}
+	tokenIn, tokenOut, in, maxHops, err := s.routeParams(req.TokenIn, req.TokenOut, req.AmountIn, req.MaxHops)
+	if err != nil {
+		return nil, err
+	}
+	minOut, err := parseMinAmount(req.MinAmountOut)
+	if err != nil {
+		return nil, fmt.Errorf("%w: min_amount_out must be a non-negative finite number", ErrInvalidSwap)
+	}
+
+	var receipt *models.RouteReceipt
+	err = s.inTx(ctx, func(q liquidityQuerier) error {
+		pools, err := listRoutablePools(ctx, q)
+		if err != nil {
+			return err
+		}
+		hops := findRoute(pools, tokenIn, tokenOut, in, maxHops)
+		if hops == nil {
+			return fmt.Errorf("%w: %s to %s within %d hops", ErrNoRoute, tokenIn, tokenOut, maxHops)
+		}
+
+		order := make([]pgtype.UUID, 0, len(hops))
+		for _, h := range hops {
+			order = append(order, h.pool.ID)
+		}
+		sort.Slice(order, func(i, j int) bool { return bytes.Compare(order[i].Bytes[:], order[j].Bytes[:]) < 0 })
+		locked := make(map[pgtype.UUID]db.LiquidityPool, len(order))
+		for _, id := range order {
+			poolID, _ := pgToUUID(id)
+			pool, err := lockActivePool(ctx, q, poolID)
+			if err != nil {
+				return err
+			}
+			locked[id] = pool
+		}
+
+		amount := in
+		for i := range hops {
+			c, err := lockedPoolCurve(ctx, q, locked[hops[i].pool.ID])
+			if err != nil {
+				return err
+			}
+			res, err := priceSwap(c, hops[i].side, amount)
+			if err != nil {
+				return err
+			}
+			hops[i].in, hops[i].swapResult = amount, res
+			amount = res.out
+		}
+		if amount < minOut {
+			return fmt.Errorf("%w: would receive %s, at least %s required", ErrSlippageExceeded, formatAmount(amount), formatAmount(minOut))
+		}
+
+		receipt = &models.RouteReceipt{Swaps: make([]models.Swap, 0, len(hops))}
+		for _, h := range hops {
+			swap, err := s.applySwap(ctx, q, locked[h.pool.ID], h.side, userID, h.in, h.swapResult)
+			if err != nil {
+				return err
+			}
+			receipt.Swaps = append(receipt.Swaps, swap)
+		}
+		receipt.Route = routeModel(tokenIn, tokenOut, hops, s.cfg.DefaultSlippageBps)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
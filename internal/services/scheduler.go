@@ -0,0 +1,58 @@
+// internal/services/scheduler.go
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+type scheduledJob struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context) error
+}
+
+// Scheduler runs registered jobs on fixed intervals until its context is cancelled.
+type Scheduler struct {
+	jobs []scheduledJob
+	wg   sync.WaitGroup
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// Every registers run to be executed once at startup and then every interval.
+func (s *Scheduler) Every(name string, interval time.Duration, run func(ctx context.Context) error) {
+	s.jobs = append(s.jobs, scheduledJob{name: name, interval: interval, run: run})
+}
+
+// Start launches one goroutine per job. Errors are logged and do not stop the job.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, j := range s.jobs {
+		j := j
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			ticker := time.NewTicker(j.interval)
+			defer ticker.Stop()
+			for {
+				if err := j.run(ctx); err != nil && ctx.Err() == nil {
+					log.Printf("job %s failed: %v", j.name, err)
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	}
+}
+
+// Wait blocks until every job goroutine has returned.
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
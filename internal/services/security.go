@@ -1,12 +1,131 @@
 // internal/services/security.go
 package services
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jd7008911/aogeri-api/internal/auth"
+	"github.com/jd7008911/aogeri-api/internal/db"
+)
+
+// Security monitor severities.
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Security thresholds that governance can override. Unset thresholds fall
+// back to the configured defaults.
+const (
+	ThresholdRewardTolerance  = "reward_tolerance_percent"
+	ThresholdMaxLoginAttempts = auth.ThresholdMaxLoginAttempts
+)
+
+// securityThresholds bounds the values accepted for each threshold.
+var securityThresholds = map[string]struct{ min, max float64 }{
+	ThresholdRewardTolerance:  {0, 100},
+	ThresholdMaxLoginAttempts: {1, 100},
+}
+
+var ErrInvalidThreshold = errors.New("invalid security threshold")
+
+// thresholdCacheTTL is how long a threshold read is served from the cache.
+// SetThreshold clears it, so overrides apply on this replica at once and on
+// others within the TTL.
+const thresholdCacheTTL = time.Minute
+
+type securityQuerier interface {
+	CreateSecurityMonitor(ctx context.Context, arg db.CreateSecurityMonitorParams) (db.SecurityMonitor, error)
+	UpsertSecurityThreshold(ctx context.Context, arg db.UpsertSecurityThresholdParams) error
+	GetSecurityThreshold(ctx context.Context, name string) (pgtype.Numeric, error)
+}
 
 type SecurityService struct {
-	ctx context.Context
+	queries securityQuerier
+	cache   auth.Store
+}
+
+func NewSecurityService(queries securityQuerier) *SecurityService {
+	return &SecurityService{queries: queries}
+}
+
+// WithCache caches threshold reads in store.
+func (s *SecurityService) WithCache(store auth.Store) *SecurityService {
+	s.cache = store
+	return s
+}
+
+func thresholdCacheKey(name string) string {
+	return "security:threshold:" + name
+}
+
+// Raise records an active security monitor for metric.
+func (s *SecurityService) Raise(ctx context.Context, metric, value, severity string) error {
+	log.Printf("security monitor [%s] %s: %s", severity, metric, value)
+	_, err := s.queries.CreateSecurityMonitor(ctx, db.CreateSecurityMonitorParams{
+		MetricName:  metric,
+		MetricValue: value,
+		Severity:    pgtype.Text{String: severity, Valid: true},
+	})
+	return err
+}
+
+// validateThreshold checks name is a known threshold and value is in range.
+func validateThreshold(name string, value float64) error {
+	bounds, ok := securityThresholds[name]
+	if !ok {
+		return fmt.Errorf("%w: unknown threshold %q", ErrInvalidThreshold, name)
+	}
+	if value < bounds.min || value > bounds.max {
+		return fmt.Errorf("%w: %s must be between %s and %s", ErrInvalidThreshold, name, formatAmount(bounds.min), formatAmount(bounds.max))
+	}
+	return nil
+}
+
+// SetThreshold overrides a security threshold.
+func (s *SecurityService) SetThreshold(ctx context.Context, name string, value float64) error {
+	if err := validateThreshold(name, value); err != nil {
+		return err
+	}
+	n, err := floatNumeric(value)
+	if err != nil {
+		return err
+	}
+	if err := s.queries.UpsertSecurityThreshold(ctx, db.UpsertSecurityThresholdParams{Name: name, Value: n}); err != nil {
+		return err
+	}
+	if s.cache != nil {
+		if err := s.cache.Delete(ctx, thresholdCacheKey(name)); err != nil {
+			log.Printf("cache delete %s: %v", thresholdCacheKey(name), err)
+		}
+	}
+	return nil
 }
 
-func NewSecurityService(queries any) *SecurityService {
-	return &SecurityService{}
+// Threshold returns the override for name, or fallback when none is set or
+// it cannot be read. Reads are cached, including the absence of an
+// override.
+func (s *SecurityService) Threshold(ctx context.Context, name string, fallback float64) float64 {
+	v, err := cached(ctx, s.cache, thresholdCacheKey(name), thresholdCacheTTL, func() (*float64, error) {
+		n, err := s.queries.GetSecurityThreshold(ctx, name)
+		if errors.Is(err, pgx.ErrNoRows) || (err == nil && !n.Valid) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		f := numericFloat(n)
+		return &f, nil
+	})
+	if err != nil || v == nil {
+		return fallback
+	}
+	return *v
 }
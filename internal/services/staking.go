@@ -4,27 +4,66 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
 	"strconv"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jd7008911/aogeri-api/internal/auth"
+	"github.com/jd7008911/aogeri-api/internal/config"
 	"github.com/jd7008911/aogeri-api/internal/db"
 	"github.com/jd7008911/aogeri-api/internal/models"
 )
 
+var (
+	ErrStakeNotFound         = errors.New("stake not found")
+	ErrStakeInvalidState     = errors.New("stake is not in a valid state for this operation")
+	ErrEarlyUnstakeForbidden = errors.New("early unstake is not allowed for this staking product")
+	ErrInvalidStakeAmount    = errors.New("invalid stake amount")
+	ErrInvalidRewardRange    = errors.New("invalid reward history range")
+	ErrRewardsBudgetExceeded = errors.New("rewards pool cannot fund this stake")
+	ErrProductNotFound       = errors.New("staking product not found")
+)
+
 type stakingQuerier interface {
 	GetTokenList(ctx context.Context) ([]db.GetTokenListRow, error)
+	GetStakingProductForDuration(ctx context.Context, arg db.GetStakingProductForDurationParams) (db.StakingProduct, error)
 	CreateStake(ctx context.Context, arg db.CreateStakeParams) (db.Stake, error)
 	GetStakeByID(ctx context.Context, id pgtype.UUID) (db.GetStakeByIDRow, error)
+	GetStakeForUpdate(ctx context.Context, id pgtype.UUID) (db.GetStakeForUpdateRow, error)
 	GetUserStakes(ctx context.Context, userID pgtype.UUID) ([]db.GetUserStakesRow, error)
-	Unstake(ctx context.Context, arg db.UnstakeParams) error
+	BeginUnbonding(ctx context.Context, arg db.BeginUnbondingParams) error
+	MarkStakeWithdrawn(ctx context.Context, id pgtype.UUID) error
+	StartUnbondingMaturedStakes(ctx context.Context, defaultUnbondingDays int32) (int64, error)
+	ReleaseUnbondedStakes(ctx context.Context) (int64, error)
+	UpdateStakeRewards(ctx context.Context, arg db.UpdateStakeRewardsParams) error
+	UpdateStakePosition(ctx context.Context, arg db.UpdateStakePositionParams) error
+	CreateSplitStake(ctx context.Context, arg db.CreateSplitStakeParams) (db.Stake, error)
+	CreateStakeHistory(ctx context.Context, arg db.CreateStakeHistoryParams) error
+	GetStakeHistory(ctx context.Context, stakeID pgtype.UUID) ([]db.StakeHistory, error)
+	ListStakesDueForSnapshot(ctx context.Context, snapshotAt pgtype.Timestamp) ([]db.ListStakesDueForSnapshotRow, error)
+	CreateRewardSnapshot(ctx context.Context, arg db.CreateRewardSnapshotParams) (int64, error)
+	GetRewardSnapshotsAt(ctx context.Context, snapshotAt pgtype.Timestamp) ([]db.StakeRewardSnapshot, error)
+	GetStakeRewardSeries(ctx context.Context, arg db.GetStakeRewardSeriesParams) ([]db.GetStakeRewardSeriesRow, error)
+	GetStakeTotalsByToken(ctx context.Context, userID pgtype.UUID) ([]db.GetStakeTotalsByTokenRow, error)
+	GetStakingOverview(ctx context.Context, userID pgtype.UUID) (db.GetStakingOverviewRow, error)
+	GetStakeLockTiers(ctx context.Context, userID pgtype.UUID) ([]db.GetStakeLockTiersRow, error)
+	GetRewardsPoolForUpdate(ctx context.Context, tokenID pgtype.UUID) (db.RewardsPool, error)
+	GetRewardObligations(ctx context.Context, arg db.GetRewardObligationsParams) (db.GetRewardObligationsRow, error)
+	RecordPoolDistribution(ctx context.Context, arg db.RecordPoolDistributionParams) error
+	SetStakingProductAPY(ctx context.Context, arg db.SetStakingProductAPYParams) (int64, error)
 }
 
 type StakingService struct {
 	queries stakingQuerier
 	auth    *auth.AuthService
+	tx      TxRunner
+	cfg     config.StakingConfig
+	monitor *SecurityService
+	cache   auth.Store
 }
 
 func NewStakingService(queries stakingQuerier, auth *auth.AuthService) *StakingService {
@@ -34,6 +73,39 @@ func NewStakingService(queries stakingQuerier, auth *auth.AuthService) *StakingS
 	}
 }
 
+// WithTx makes multi-step stake updates run inside a single transaction.
+func (s *StakingService) WithTx(tx TxRunner) *StakingService {
+	s.tx = tx
+	return s
+}
+
+// WithConfig sets the lifecycle defaults used for stakes without a product.
+func (s *StakingService) WithConfig(cfg config.StakingConfig) *StakingService {
+	s.cfg = cfg
+	return s
+}
+
+// WithMonitor raises reward reconciliation mismatches as security monitors.
+func (s *StakingService) WithMonitor(m *SecurityService) *StakingService {
+	s.monitor = m
+	return s
+}
+
+// WithCache caches staking statistics in store for cfg.StatsCacheTTL.
+func (s *StakingService) WithCache(store auth.Store) *StakingService {
+	s.cache = store
+	return s
+}
+
+// inTx runs fn in a transaction when a TxRunner is configured, otherwise
+// directly against the service queries.
+func (s *StakingService) inTx(ctx context.Context, fn func(q stakingQuerier) error) error {
+	if s.tx == nil {
+		return fn(s.queries)
+	}
+	return s.tx.ExecTx(ctx, func(q *db.Queries) error { return fn(q) })
+}
+
 func (s *StakingService) CreateStake(ctx context.Context, userID uuid.UUID, req models.StakeRequest) (*models.Stake, error) {
 	// Get token ID from symbol
 	tokens, err := s.queries.GetTokenList(ctx)
@@ -61,6 +133,22 @@ func (s *StakingService) CreateStake(ctx context.Context, userID uuid.UUID, req
 	// Get current APY from external service or database
 	apy := s.calculateAPY(req.TokenSymbol)
 
+	// Prefer the staking product matching the lock duration; it carries the
+	// APY and the unstake rules for the position.
+	var productID pgtype.UUID
+	product, err := s.queries.GetStakingProductForDuration(ctx, db.GetStakingProductForDurationParams{
+		TokenID:         tokenID,
+		MinDurationDays: int32(req.DurationDays),
+	})
+	if err == nil {
+		productID = product.ID
+		if fv, err := product.Apy.Float64Value(); err == nil && fv.Valid {
+			apy = fv.Float64
+		}
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
 	// Convert uuid.UUID -> pgtype.UUID
 	var uid pgtype.UUID
 	copy(uid.Bytes[:], userID[:])
@@ -81,13 +169,26 @@ func (s *StakingService) CreateStake(ctx context.Context, userID uuid.UUID, req
 	// Convert end date -> pgtype.Timestamp
 	endPg := pgtype.Timestamp{Time: endDate, Valid: true}
 
-	stake, err := s.queries.CreateStake(ctx, db.CreateStakeParams{
-		UserID:       uid,
-		TokenID:      tokenID,
-		Amount:       amt,
-		Apy:          apyn,
-		EndDate:      endPg,
-		AutoCompound: pgtype.Bool{Bool: req.AutoCompound, Valid: true},
+	var stake db.Stake
+	err = s.inTx(ctx, func(q stakingQuerier) error {
+		promised := accruedRewards(numericFloat(amt), apy, time.Now(), endDate)
+		if err := checkRewardsBudget(ctx, q, tokenID, promised, endDate); err != nil {
+			return err
+		}
+		var err error
+		stake, err = q.CreateStake(ctx, db.CreateStakeParams{
+			UserID:       uid,
+			TokenID:      tokenID,
+			Amount:       amt,
+			Apy:          apyn,
+			EndDate:      endPg,
+			AutoCompound: pgtype.Bool{Bool: req.AutoCompound, Valid: true},
+			ProductID:    productID,
+		})
+		if err != nil {
+			return err
+		}
+		return recordStakeHistory(ctx, q, stake.ID, uid, "created", numericFloat(amt), 0, numericFloat(amt), pgtype.UUID{})
 	})
 	if err != nil {
 		return nil, err
@@ -158,17 +259,213 @@ func (s *StakingService) CreateStake(ctx context.Context, userID uuid.UUID, req
 	}, nil
 }
 
-func (s *StakingService) Unstake(ctx context.Context, stakeID, userID uuid.UUID) error {
+// Unstake moves an active stake owned by userID into unbonding. Unstaking
+// before end_date forfeits the product's early-unstake penalty, or is
+// rejected when the product does not allow it.
+func (s *StakingService) Unstake(ctx context.Context, stakeID, userID uuid.UUID) (*models.UnstakeResult, error) {
 	var id pgtype.UUID
 	copy(id.Bytes[:], stakeID[:])
 	id.Valid = true
-	var uid pgtype.UUID
-	copy(uid.Bytes[:], userID[:])
-	uid.Valid = true
-	return s.queries.Unstake(ctx, db.UnstakeParams{
-		ID:     id,
-		UserID: uid,
+
+	var result *models.UnstakeResult
+	err := s.inTx(ctx, func(q stakingQuerier) error {
+		row, err := s.lockOwnedStake(ctx, q, id, userID)
+		if err != nil {
+			return err
+		}
+		if !row.Status.Valid || row.Status.String != "active" {
+			return ErrStakeInvalidState
+		}
+
+		amount := 0.0
+		if fv, err := row.Amount.Float64Value(); err == nil && fv.Valid {
+			amount = fv.Float64
+		}
+
+		allowed, penaltyPct, unbonding := s.unstakePolicy(row)
+		now := time.Now()
+		penalty := 0.0
+		if row.EndDate.Valid && now.Before(row.EndDate.Time) {
+			if !allowed {
+				return ErrEarlyUnstakeForbidden
+			}
+			penalty = earlyUnstakePenalty(amount, penaltyPct)
+		}
+
+		var pn pgtype.Numeric
+		if err := pn.Scan(strconv.FormatFloat(penalty, 'f', -1, 64)); err != nil {
+			return err
+		}
+		until := now.Add(unbonding)
+		if err := q.BeginUnbonding(ctx, db.BeginUnbondingParams{
+			ID:             id,
+			PenaltyAmount:  pn,
+			UnbondingUntil: pgtype.Timestamp{Time: until, Valid: true},
+		}); err != nil {
+			return err
+		}
+		if err := recordStakeHistory(ctx, q, id, row.UserID, "unstake", amount, 0, amount, pgtype.UUID{}); err != nil {
+			return err
+		}
+
+		result = &models.UnstakeResult{
+			StakeID:        stakeID,
+			Status:         "unbonding",
+			Principal:      strconv.FormatFloat(amount, 'f', -1, 64),
+			Penalty:        strconv.FormatFloat(penalty, 'f', -1, 64),
+			Payout:         strconv.FormatFloat(amount-penalty, 'f', -1, 64),
+			UnbondingUntil: &until,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Release completes a withdrawable stake, paying out principal minus any
+// early-unstake penalty.
+func (s *StakingService) Release(ctx context.Context, stakeID, userID uuid.UUID) (*models.UnstakeResult, error) {
+	var id pgtype.UUID
+	copy(id.Bytes[:], stakeID[:])
+	id.Valid = true
+
+	var result *models.UnstakeResult
+	err := s.inTx(ctx, func(q stakingQuerier) error {
+		row, err := s.lockOwnedStake(ctx, q, id, userID)
+		if err != nil {
+			return err
+		}
+		if !row.Status.Valid || row.Status.String != "withdrawable" {
+			return ErrStakeInvalidState
+		}
+		if err := q.MarkStakeWithdrawn(ctx, id); err != nil {
+			return err
+		}
+
+		amount := 0.0
+		if fv, err := row.Amount.Float64Value(); err == nil && fv.Valid {
+			amount = fv.Float64
+		}
+		penalty := 0.0
+		if fv, err := row.PenaltyAmount.Float64Value(); err == nil && fv.Valid {
+			penalty = fv.Float64
+		}
+		if err := recordStakeHistory(ctx, q, id, row.UserID, "release", amount-penalty, 0, 0, pgtype.UUID{}); err != nil {
+			return err
+		}
+		result = &models.UnstakeResult{
+			StakeID:   stakeID,
+			Status:    "withdrawn",
+			Principal: strconv.FormatFloat(amount, 'f', -1, 64),
+			Penalty:   strconv.FormatFloat(penalty, 'f', -1, 64),
+			Payout:    strconv.FormatFloat(amount-penalty, 'f', -1, 64),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ProcessLifecycle moves matured stakes into unbonding and unbonded stakes
+// into withdrawable. It is safe to run concurrently from several replicas.
+func (s *StakingService) ProcessLifecycle(ctx context.Context) error {
+	days := int32(s.cfg.UnbondingPeriod / (24 * time.Hour))
+	matured, err := s.queries.StartUnbondingMaturedStakes(ctx, days)
+	if err != nil {
+		return err
+	}
+	released, err := s.queries.ReleaseUnbondedStakes(ctx)
+	if err != nil {
+		return err
+	}
+	if matured > 0 || released > 0 {
+		log.Printf("stake lifecycle: %d matured, %d withdrawable", matured, released)
+	}
+	return nil
+}
+
+// lockOwnedStake loads a stake FOR UPDATE and hides stakes owned by other
+// users behind ErrStakeNotFound.
+func (s *StakingService) lockOwnedStake(ctx context.Context, q stakingQuerier, id pgtype.UUID, userID uuid.UUID) (db.GetStakeForUpdateRow, error) {
+	row, err := q.GetStakeForUpdate(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return row, ErrStakeNotFound
+		}
+		return row, err
+	}
+	if !row.UserID.Valid || uuid.UUID(row.UserID.Bytes) != userID {
+		return row, ErrStakeNotFound
+	}
+	return row, nil
+}
+
+// unstakePolicy resolves the early-unstake rules for a stake, falling back to
+// the configured defaults when the stake has no product.
+func (s *StakingService) unstakePolicy(row db.GetStakeForUpdateRow) (allowed bool, penaltyPct float64, unbonding time.Duration) {
+	allowed = true
+	penaltyPct = s.cfg.EarlyUnstakePenalty
+	unbonding = s.cfg.UnbondingPeriod
+	if !row.ProductID.Valid {
+		return
+	}
+	if row.EarlyUnstakeAllowed.Valid {
+		allowed = row.EarlyUnstakeAllowed.Bool
+	}
+	if fv, err := row.EarlyUnstakePenalty.Float64Value(); err == nil && fv.Valid {
+		penaltyPct = fv.Float64
+	}
+	if row.UnbondingDays.Valid {
+		unbonding = time.Duration(row.UnbondingDays.Int32) * 24 * time.Hour
+	}
+	return
+}
+
+// checkRewardsBudget rejects a stake promising rewards the token's rewards
+// pool cannot fund on top of what active stakes are already owed, settled
+// or not. Both sides are projected to the same horizon: the later of the
+// new stake's end and the last end of the stakes already owed, against
+// what the pool's schedule will have emitted by then. Tokens without a
+// pool are not budgeted. The pool row is locked so concurrent stakes are
+// checked one at a time.
+func checkRewardsBudget(ctx context.Context, q stakingQuerier, tokenID pgtype.UUID, promised float64, until time.Time) error {
+	pool, err := q.GetRewardsPoolForUpdate(ctx, tokenID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	owed, err := q.GetRewardObligations(ctx, db.GetRewardObligationsParams{
+		TokenID: tokenID,
+		Until:   pgtype.Timestamp{Time: until, Valid: true},
 	})
+	if err != nil {
+		return err
+	}
+	horizon := until
+	if owed.Horizon.Valid && owed.Horizon.Time.After(horizon) {
+		horizon = owed.Horizon.Time
+	}
+	if numericFloat(owed.Outstanding)+promised > poolAvailable(pool, horizon) {
+		return ErrRewardsBudgetExceeded
+	}
+	return nil
+}
+
+// earlyUnstakePenalty returns the share of amount forfeited at penaltyPct percent.
+func earlyUnstakePenalty(amount, penaltyPct float64) float64 {
+	if penaltyPct <= 0 || amount <= 0 {
+		return 0
+	}
+	if penaltyPct >= 100 {
+		return amount
+	}
+	return amount * penaltyPct / 100.0
 }
 
 func (s *StakingService) CalculateRewards(ctx context.Context, stakeID uuid.UUID) (string, error) {
@@ -187,8 +484,6 @@ func (s *StakingService) CalculateRewards(ctx context.Context, stakeID uuid.UUID
 	if !stake.StartDate.Valid {
 		return "0", errors.New("stake has no start date")
 	}
-	start := stake.StartDate.Time
-	durationDays := time.Since(start).Hours() / 24
 
 	// Convert amount and apy to float64
 	amountF := 0.0
@@ -204,12 +499,512 @@ func (s *StakingService) CalculateRewards(ctx context.Context, stakeID uuid.UUID
 		}
 	}
 
-	dailyRate := apyF / 365.0 / 100.0
-	rewards := amountF * dailyRate * durationDays
+	from := accrualStart(stake.StartDate, stake.RewardsSettledAt)
+	rewards := accruedRewards(amountF, apyF, from, time.Now())
 
 	return strconv.FormatFloat(rewards, 'f', -1, 64), nil
 }
 
+// ClaimRewards settles the rewards accrued on an active stake owned by userID
+// and returns the claimed amount.
+func (s *StakingService) ClaimRewards(ctx context.Context, stakeID, userID uuid.UUID) (string, error) {
+	var claimed float64
+	err := s.inTx(ctx, func(q stakingQuerier) error {
+		row, err := s.lockOwnedStake(ctx, q, toPgUUID(stakeID), userID)
+		if err != nil {
+			return err
+		}
+		if !row.Status.Valid || row.Status.String != "active" {
+			return ErrStakeInvalidState
+		}
+		claimed, err = s.settleRewards(ctx, q, row, time.Now())
+		return err
+	})
+	if err != nil {
+		return "0", err
+	}
+	return formatAmount(claimed), nil
+}
+
+// accruedRewards returns simple-interest rewards for amount at apy percent
+// between from and to.
+func accruedRewards(amount, apy float64, from, to time.Time) float64 {
+	if !to.After(from) {
+		return 0
+	}
+	durationDays := to.Sub(from).Hours() / 24
+	dailyRate := apy / 365.0 / 100.0
+	return amount * dailyRate * durationDays
+}
+
+// accrualStart is the point from which unsettled rewards accrue.
+func accrualStart(start, settled pgtype.Timestamp) time.Time {
+	if settled.Valid && settled.Time.After(start.Time) {
+		return settled.Time
+	}
+	return start.Time
+}
+
+// weightedStart blends the start of existing principal with added principal
+// starting at now, weighted by amount.
+func weightedStart(start time.Time, existing, added float64, now time.Time) time.Time {
+	total := existing + added
+	if total <= 0 || !now.After(start) {
+		return start
+	}
+	shift := time.Duration(float64(now.Sub(start)) * added / total)
+	return start.Add(shift)
+}
+
+// PartialWithdraw splits amount off an active stake into a new unbonding
+// stake, leaving the remainder active with its original APY and lock.
+// Accrued rewards on the whole position are settled first.
+func (s *StakingService) PartialWithdraw(ctx context.Context, stakeID, userID uuid.UUID, amount string) (*models.StakeAdjustment, error) {
+	withdraw, ok := parsePositiveAmount(amount)
+	if !ok {
+		return nil, ErrInvalidStakeAmount
+	}
+	id := toPgUUID(stakeID)
+
+	var result models.StakeAdjustment
+	err := s.inTx(ctx, func(q stakingQuerier) error {
+		row, err := s.lockOwnedStake(ctx, q, id, userID)
+		if err != nil {
+			return err
+		}
+		if !row.Status.Valid || row.Status.String != "active" {
+			return ErrStakeInvalidState
+		}
+		current := numericFloat(row.Amount)
+		if withdraw >= current {
+			return ErrInvalidStakeAmount
+		}
+
+		now := time.Now()
+		settled, err := s.settleRewards(ctx, q, row, now)
+		if err != nil {
+			return err
+		}
+
+		allowed, penaltyPct, unbonding := s.unstakePolicy(row)
+		penalty := 0.0
+		if row.EndDate.Valid && now.Before(row.EndDate.Time) {
+			if !allowed {
+				return ErrEarlyUnstakeForbidden
+			}
+			penalty = earlyUnstakePenalty(withdraw, penaltyPct)
+		}
+
+		withdrawN, err := floatNumeric(withdraw)
+		if err != nil {
+			return err
+		}
+		penaltyN, err := floatNumeric(penalty)
+		if err != nil {
+			return err
+		}
+		remaining := current - withdraw
+		remainingN, err := floatNumeric(remaining)
+		if err != nil {
+			return err
+		}
+
+		split, err := q.CreateSplitStake(ctx, db.CreateSplitStakeParams{
+			UserID:         row.UserID,
+			TokenID:        row.TokenID,
+			Amount:         withdrawN,
+			Apy:            row.Apy,
+			StartDate:      row.StartDate,
+			EndDate:        row.EndDate,
+			AutoCompound:   row.AutoCompound,
+			ProductID:      row.ProductID,
+			ParentStakeID:  id,
+			PenaltyAmount:  penaltyN,
+			UnbondingUntil: pgtype.Timestamp{Time: now.Add(unbonding), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if err := q.UpdateStakePosition(ctx, db.UpdateStakePositionParams{
+			ID:        id,
+			Amount:    remainingN,
+			StartDate: row.StartDate,
+			EndDate:   row.EndDate,
+		}); err != nil {
+			return err
+		}
+
+		if err := recordStakeHistory(ctx, q, id, row.UserID, "partial_withdraw", withdraw, settled, remaining, split.ID); err != nil {
+			return err
+		}
+		if err := recordStakeHistory(ctx, q, split.ID, row.UserID, "created", withdraw, 0, withdraw, id); err != nil {
+			return err
+		}
+
+		splitID, _ := pgToUUID(split.ID)
+		result = models.StakeAdjustment{
+			StakeID:        stakeID,
+			Amount:         formatAmount(remaining),
+			RewardsSettled: formatAmount(settled),
+			SplitStakeID:   &splitID,
+			Penalty:        formatAmount(penalty),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// TopUp adds principal to an active stake. Accrued rewards are settled
+// first, then start_date moves to the amount-weighted average of the
+// existing start and now. Accrual runs from the later of start_date and
+// the last settlement, so the settlement keeps the blended start from
+// paying the existing principal twice. end_date is left as it was: the
+// added principal joins the existing lock and never extends it.
+func (s *StakingService) TopUp(ctx context.Context, stakeID, userID uuid.UUID, amount string) (*models.StakeAdjustment, error) {
+	added, ok := parsePositiveAmount(amount)
+	if !ok {
+		return nil, ErrInvalidStakeAmount
+	}
+	id := toPgUUID(stakeID)
+
+	var result models.StakeAdjustment
+	err := s.inTx(ctx, func(q stakingQuerier) error {
+		row, err := s.lockOwnedStake(ctx, q, id, userID)
+		if err != nil {
+			return err
+		}
+		if !row.Status.Valid || row.Status.String != "active" {
+			return ErrStakeInvalidState
+		}
+
+		now := time.Now()
+		settled, err := s.settleRewards(ctx, q, row, now)
+		if err != nil {
+			return err
+		}
+
+		current := numericFloat(row.Amount)
+		total := current + added
+		start := weightedStart(row.StartDate.Time, current, added, now)
+		totalN, err := floatNumeric(total)
+		if err != nil {
+			return err
+		}
+		if err := q.UpdateStakePosition(ctx, db.UpdateStakePositionParams{
+			ID:        id,
+			Amount:    totalN,
+			StartDate: pgtype.Timestamp{Time: start, Valid: true},
+			EndDate:   row.EndDate,
+		}); err != nil {
+			return err
+		}
+		if err := recordStakeHistory(ctx, q, id, row.UserID, "top_up", added, settled, total, pgtype.UUID{}); err != nil {
+			return err
+		}
+
+		result = models.StakeAdjustment{
+			StakeID:        stakeID,
+			Amount:         formatAmount(total),
+			RewardsSettled: formatAmount(settled),
+			StartDate:      &start,
+		}
+		if row.EndDate.Valid {
+			result.EndDate = &row.EndDate.Time
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetStakeHistory lists the principal changes of a stake owned by userID.
+func (s *StakingService) GetStakeHistory(ctx context.Context, stakeID, userID uuid.UUID) ([]models.StakeHistoryEntry, error) {
+	stake, err := s.queries.GetStakeByID(ctx, toPgUUID(stakeID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrStakeNotFound
+		}
+		return nil, err
+	}
+	if !stake.UserID.Valid || uuid.UUID(stake.UserID.Bytes) != userID {
+		return nil, ErrStakeNotFound
+	}
+
+	rows, err := s.queries.GetStakeHistory(ctx, toPgUUID(stakeID))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]models.StakeHistoryEntry, 0, len(rows))
+	for _, r := range rows {
+		id, _ := pgToUUID(r.ID)
+		entry := models.StakeHistoryEntry{
+			ID:             id,
+			EventType:      r.EventType,
+			Amount:         numericString(r.Amount),
+			RewardsSettled: numericString(r.RewardsSettled),
+			BalanceAfter:   numericString(r.BalanceAfter),
+			CreatedAt:      r.CreatedAt.Time,
+		}
+		if related, err := pgToUUID(r.RelatedStakeID); err == nil {
+			entry.RelatedStakeID = &related
+		}
+		out = append(out, entry)
+	}
+	return out, nil
+}
+
+// rewardIntervals maps the accepted reward history intervals to date_trunc units.
+var rewardIntervals = map[string]string{
+	"day":   "day",
+	"week":  "week",
+	"month": "month",
+}
+
+// GetStakeRewards returns the reward snapshots of a stake owned by userID
+// between from and to, bucketed by interval (day, week or month).
+func (s *StakingService) GetStakeRewards(ctx context.Context, stakeID, userID uuid.UUID, from, to time.Time, interval string) ([]models.RewardPoint, error) {
+	bucket, ok := rewardIntervals[interval]
+	if !ok || !to.After(from) {
+		return nil, ErrInvalidRewardRange
+	}
+	stake, err := s.queries.GetStakeByID(ctx, toPgUUID(stakeID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrStakeNotFound
+		}
+		return nil, err
+	}
+	if !stake.UserID.Valid || uuid.UUID(stake.UserID.Bytes) != userID {
+		return nil, ErrStakeNotFound
+	}
+
+	rows, err := s.queries.GetStakeRewardSeries(ctx, db.GetStakeRewardSeriesParams{
+		Bucket:  bucket,
+		StakeID: toPgUUID(stakeID),
+		FromAt:  pgtype.Timestamp{Time: from, Valid: true},
+		ToAt:    pgtype.Timestamp{Time: to, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]models.RewardPoint, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, models.RewardPoint{
+			Time:              r.BucketStart.Time,
+			Principal:         numericString(r.Principal),
+			AccruedRewards:    numericString(r.AccruedRewards),
+			CumulativeRewards: numericString(r.CumulativeRewards),
+			RewardsClaimed:    numericString(r.RewardsClaimed),
+		})
+	}
+	return out, nil
+}
+
+// GetStakingStats returns staking statistics for userID alongside the
+// protocol-wide figures. Both halves are cached briefly.
+func (s *StakingService) GetStakingStats(ctx context.Context, userID uuid.UUID) (*models.StakingStats, error) {
+	protocol, err := cached(ctx, s.cache, "staking:stats:protocol", s.cfg.StatsCacheTTL, func() (models.StakingSummary, error) {
+		return s.stakingSummary(ctx, pgtype.UUID{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	user, err := cached(ctx, s.cache, "staking:stats:user:"+userID.String(), s.cfg.StatsCacheTTL, func() (models.StakingSummary, error) {
+		return s.stakingSummary(ctx, toPgUUID(userID))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &models.StakingStats{User: user, Protocol: protocol}, nil
+}
+
+// stakingSummary aggregates stakes of userID, or of everyone when userID is
+// not valid.
+func (s *StakingService) stakingSummary(ctx context.Context, userID pgtype.UUID) (models.StakingSummary, error) {
+	out := models.StakingSummary{
+		Tokens:    []models.TokenStakeStats{},
+		LockTiers: []models.LockTierStats{},
+	}
+
+	totals, err := s.queries.GetStakeTotalsByToken(ctx, userID)
+	if err != nil {
+		return out, err
+	}
+	totalValue, apyValue := 0.0, 0.0
+	for _, t := range totals {
+		value := numericFloat(t.TotalValue)
+		apy := numericFloat(t.WeightedApy)
+		totalValue += value
+		apyValue += value * apy
+		out.Tokens = append(out.Tokens, models.TokenStakeStats{
+			Symbol:      t.Symbol,
+			TotalStaked: numericString(t.TotalStaked),
+			TotalValue:  formatAmount(value),
+			WeightedAPY: apy,
+			StakeCount:  t.StakeCount,
+		})
+	}
+	out.TotalValue = formatAmount(totalValue)
+	if totalValue > 0 {
+		out.WeightedAPY = apyValue / totalValue
+	}
+
+	overview, err := s.queries.GetStakingOverview(ctx, userID)
+	if err != nil {
+		return out, err
+	}
+	out.ActiveStakers = overview.ActiveStakers
+	out.LifetimeRewardsPaid = numericString(overview.RewardsPaid)
+	out.Maturing7Days = models.MaturityBucket{Count: overview.Maturing7dCount, Value: numericString(overview.Maturing7dValue)}
+	out.Maturing30Days = models.MaturityBucket{Count: overview.Maturing30dCount, Value: numericString(overview.Maturing30dValue)}
+
+	tiers, err := s.queries.GetStakeLockTiers(ctx, userID)
+	if err != nil {
+		return out, err
+	}
+	for _, t := range tiers {
+		out.LockTiers = append(out.LockTiers, models.LockTierStats{
+			Tier:       t.Tier,
+			StakeCount: t.StakeCount,
+			TotalValue: numericString(t.TotalValue),
+		})
+	}
+	return out, nil
+}
+
+// SnapshotRewards writes today's reward snapshot for every active stake that
+// does not have one yet, then reconciles the snapshots against claimed
+// rewards. Snapshots are keyed by day so reruns and replicas are no-ops;
+// only the run that actually inserts rows reconciles, so a mismatch is
+// raised once per day rather than on every tick or by every replica that
+// listed the same stakes.
+func (s *StakingService) SnapshotRewards(ctx context.Context) error {
+	at := time.Now().UTC().Truncate(24 * time.Hour)
+	atPg := pgtype.Timestamp{Time: at, Valid: true}
+
+	due, err := s.queries.ListStakesDueForSnapshot(ctx, atPg)
+	if err != nil {
+		return err
+	}
+	var inserted int64
+	for _, st := range due {
+		amount := numericFloat(st.Amount)
+		accrued := accruedRewards(amount, numericFloat(st.Apy), st.LastSnapshotAt.Time, at)
+		accruedN, err := floatNumeric(accrued)
+		if err != nil {
+			return err
+		}
+		cumulativeN, err := floatNumeric(numericFloat(st.CumulativeRewards) + accrued)
+		if err != nil {
+			return err
+		}
+		n, err := s.queries.CreateRewardSnapshot(ctx, db.CreateRewardSnapshotParams{
+			StakeID:           st.ID,
+			UserID:            st.UserID,
+			SnapshotAt:        atPg,
+			Principal:         st.Amount,
+			Apy:               st.Apy,
+			AccruedRewards:    accruedN,
+			CumulativeRewards: cumulativeN,
+			RewardsClaimed:    st.RewardsClaimed,
+		})
+		if err != nil {
+			return err
+		}
+		inserted += n
+	}
+	if inserted == 0 {
+		return nil
+	}
+	log.Printf("reward snapshots: %d stakes at %s", inserted, at.Format("2006-01-02"))
+	return s.ReconcileRewards(ctx, at)
+}
+
+// ReconcileRewards compares claimed rewards against the accrued rewards
+// recorded in the snapshots taken at the given time and raises a security
+// monitor for each stake that has claimed more than it accrued.
+func (s *StakingService) ReconcileRewards(ctx context.Context, at time.Time) error {
+	snaps, err := s.queries.GetRewardSnapshotsAt(ctx, pgtype.Timestamp{Time: at, Valid: true})
+	if err != nil {
+		return err
+	}
+	tolerance := s.cfg.RewardTolerance
+	if s.monitor != nil {
+		tolerance = s.monitor.Threshold(ctx, ThresholdRewardTolerance, tolerance)
+	}
+	for _, r := range snaps {
+		claimed := numericFloat(r.RewardsClaimed)
+		accrued := numericFloat(r.CumulativeRewards)
+		// Claims are read when the snapshot is written, so allow for rewards
+		// accrued between snapshot_at and created_at.
+		allowance := accrued * tolerance / 100.0
+		if r.CreatedAt.Valid {
+			allowance += accruedRewards(numericFloat(r.Principal), numericFloat(r.Apy), r.SnapshotAt.Time, r.CreatedAt.Time)
+		}
+		if claimed <= accrued+allowance+1e-9 {
+			continue
+		}
+		stakeID, _ := pgToUUID(r.StakeID)
+		value := fmt.Sprintf("stake %s claimed %s but accrued %s", stakeID, formatAmount(claimed), formatAmount(accrued))
+		if s.monitor == nil {
+			log.Printf("reward reconciliation mismatch: %s", value)
+			continue
+		}
+		if err := s.monitor.Raise(ctx, "staking_reward_mismatch", value, SeverityCritical); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// settleRewards credits rewards accrued since the last settlement and
+// restarts accrual at now. It returns the settled amount.
+func (s *StakingService) settleRewards(ctx context.Context, q stakingQuerier, row db.GetStakeForUpdateRow, now time.Time) (float64, error) {
+	from := accrualStart(row.StartDate, row.RewardsSettledAt)
+	rewards := accruedRewards(numericFloat(row.Amount), numericFloat(row.Apy), from, now)
+	rn, err := floatNumeric(rewards)
+	if err != nil {
+		return 0, err
+	}
+	if err := q.UpdateStakeRewards(ctx, db.UpdateStakeRewardsParams{ID: row.ID, RewardsClaimed: rn}); err != nil {
+		return 0, err
+	}
+	if err := q.RecordPoolDistribution(ctx, db.RecordPoolDistributionParams{TokenID: row.TokenID, Distributed: rn}); err != nil {
+		return 0, err
+	}
+	return rewards, nil
+}
+
+// recordStakeHistory appends a history row for a principal change.
+func recordStakeHistory(ctx context.Context, q stakingQuerier, stakeID, userID pgtype.UUID, event string, amount, rewards, balance float64, related pgtype.UUID) error {
+	amountN, err := floatNumeric(amount)
+	if err != nil {
+		return err
+	}
+	rewardsN, err := floatNumeric(rewards)
+	if err != nil {
+		return err
+	}
+	balanceN, err := floatNumeric(balance)
+	if err != nil {
+		return err
+	}
+	return q.CreateStakeHistory(ctx, db.CreateStakeHistoryParams{
+		StakeID:        stakeID,
+		UserID:         userID,
+		EventType:      event,
+		Amount:         amountN,
+		RewardsSettled: rewardsN,
+		BalanceAfter:   balanceN,
+		RelatedStakeID: related,
+	})
+}
+
 func (s *StakingService) calculateAPY(tokenSymbol string) float64 {
 	// In production, fetch from external API or contract
 	switch tokenSymbol {
@@ -242,54 +1037,7 @@ func (s *StakingService) GetUserStakes(ctx context.Context, userID uuid.UUID) ([
 
 	var out []models.Stake
 	for _, r := range rows {
-		id, _ := pgToUUID(r.ID)
-		uid2, _ := pgToUUID(r.UserID)
-		amt := "0"
-		if r.Amount.Valid {
-			if fv, err := r.Amount.Float64Value(); err == nil {
-				amt = strconv.FormatFloat(fv.Float64, 'f', -1, 64)
-			}
-		}
-		apy := 0.0
-		if r.Apy.Valid {
-			if fv, err := r.Apy.Float64Value(); err == nil {
-				apy = fv.Float64
-			}
-		}
-		var start time.Time
-		if r.StartDate.Valid {
-			start = r.StartDate.Time
-		}
-		var end *time.Time
-		if r.EndDate.Valid {
-			t := r.EndDate.Time
-			end = &t
-		}
-
-		status := ""
-		if r.Status.Valid {
-			status = r.Status.String
-		}
-
-		out = append(out, models.Stake{
-			ID:           id,
-			UserID:       uid2,
-			TokenSymbol:  r.Symbol,
-			Amount:       amt,
-			APY:          apy,
-			StartDate:    start,
-			EndDate:      end,
-			Status:       status,
-			AutoCompound: r.AutoCompound.Bool,
-			RewardsClaimed: func() string {
-				if r.RewardsClaimed.Valid {
-					if rv, err := r.RewardsClaimed.Float64Value(); err == nil {
-						return strconv.FormatFloat(rv.Float64, 'f', -1, 64)
-					}
-				}
-				return "0"
-			}(),
-		})
+		out = append(out, stakeModel(db.GetStakeByIDRow(r)))
 	}
 
 	return out, nil
@@ -305,6 +1053,11 @@ func (s *StakingService) GetStakeByID(ctx context.Context, stakeID uuid.UUID) (m
 		return models.Stake{}, err
 	}
 
+	return stakeModel(r), nil
+}
+
+// stakeModel converts a stake row joined with its token into the API model.
+func stakeModel(r db.GetStakeByIDRow) models.Stake {
 	pid, _ := pgToUUID(r.ID)
 	uid2, _ := pgToUUID(r.UserID)
 	amt := "0"
@@ -332,6 +1085,22 @@ func (s *StakingService) GetStakeByID(ctx context.Context, stakeID uuid.UUID) (m
 	if r.Status.Valid {
 		status = r.Status.String
 	}
+	var unbondingUntil *time.Time
+	if r.UnbondingUntil.Valid {
+		t := r.UnbondingUntil.Time
+		unbondingUntil = &t
+	}
+	var withdrawnAt *time.Time
+	if r.WithdrawnAt.Valid {
+		t := r.WithdrawnAt.Time
+		withdrawnAt = &t
+	}
+	penalty := ""
+	if r.PenaltyAmount.Valid {
+		if fv, err := r.PenaltyAmount.Float64Value(); err == nil && fv.Float64 > 0 {
+			penalty = strconv.FormatFloat(fv.Float64, 'f', -1, 64)
+		}
+	}
 
 	return models.Stake{
 		ID:           pid,
@@ -351,5 +1120,25 @@ func (s *StakingService) GetStakeByID(ctx context.Context, stakeID uuid.UUID) (m
 			}
 			return "0"
 		}(),
-	}, nil
+		UnbondingUntil: unbondingUntil,
+		WithdrawnAt:    withdrawnAt,
+		PenaltyAmount:  penalty,
+	}
+}
+
+// SetProductAPY changes the APY offered by a staking product. Existing
+// stakes keep the APY they were opened with.
+func (s *StakingService) SetProductAPY(ctx context.Context, productID uuid.UUID, apy float64) error {
+	n, err := floatNumeric(apy)
+	if err != nil {
+		return err
+	}
+	rows, err := s.queries.SetStakingProductAPY(ctx, db.SetStakingProductAPYParams{ID: toPgUUID(productID), Apy: n})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrProductNotFound
+	}
+	return nil
 }
@@ -2,31 +2,61 @@ package services
 
 import (
 	"context"
+	"errors"
 	"math"
 	"strconv"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jd7008911/aogeri-api/internal/config"
 	"github.com/jd7008911/aogeri-api/internal/db"
 	"github.com/jd7008911/aogeri-api/internal/models"
 )
 
 type fakeQueries struct {
 	tokens        []db.GetTokenListRow
+	product       *db.StakingProduct
 	created       db.Stake
+	createdArg    db.CreateStakeParams
 	createdCalled bool
 	getStakeRow   db.GetStakeByIDRow
+	lockedRow     *db.GetStakeForUpdateRow
 	userStakes    []db.GetUserStakesRow
-	unstakeCalled bool
+	unbonding     *db.BeginUnbondingParams
+	withdrawn     bool
+	settled       []db.UpdateStakeRewardsParams
+	position      *db.UpdateStakePositionParams
+	split         *db.CreateSplitStakeParams
+	history       []db.CreateStakeHistoryParams
+	due           []db.ListStakesDueForSnapshotRow
+	snapshots     []db.CreateRewardSnapshotParams
+	snapshotsAt   []db.StakeRewardSnapshot
+	monitors      []db.CreateSecurityMonitorParams
+	tokenTotals   []db.GetStakeTotalsByTokenRow
+	totalsCalls   int
+	pool          *db.RewardsPool
+	obligations   string
+	// obligationsUntil is the last end date of the stakes owed obligations.
+	obligationsUntil time.Time
+	distributed      []db.RecordPoolDistributionParams
+	thresholds       map[string]string
 }
 
 func (f *fakeQueries) GetTokenList(ctx context.Context) ([]db.GetTokenListRow, error) {
 	return f.tokens, nil
 }
+func (f *fakeQueries) GetStakingProductForDuration(ctx context.Context, arg db.GetStakingProductForDurationParams) (db.StakingProduct, error) {
+	if f.product == nil {
+		return db.StakingProduct{}, pgx.ErrNoRows
+	}
+	return *f.product, nil
+}
 func (f *fakeQueries) CreateStake(ctx context.Context, arg db.CreateStakeParams) (db.Stake, error) {
 	f.createdCalled = true
+	f.createdArg = arg
 	return f.created, nil
 }
 func (f *fakeQueries) GetStakeByID(ctx context.Context, id pgtype.UUID) (db.GetStakeByIDRow, error) {
@@ -35,10 +65,127 @@ func (f *fakeQueries) GetStakeByID(ctx context.Context, id pgtype.UUID) (db.GetS
 func (f *fakeQueries) GetUserStakes(ctx context.Context, userID pgtype.UUID) ([]db.GetUserStakesRow, error) {
 	return f.userStakes, nil
 }
-func (f *fakeQueries) Unstake(ctx context.Context, arg db.UnstakeParams) error {
-	f.unstakeCalled = true
+func (f *fakeQueries) GetStakeForUpdate(ctx context.Context, id pgtype.UUID) (db.GetStakeForUpdateRow, error) {
+	if f.lockedRow != nil {
+		return *f.lockedRow, nil
+	}
+	r := f.getStakeRow
+	return db.GetStakeForUpdateRow{
+		ID: r.ID, UserID: r.UserID, TokenID: r.TokenID, Amount: r.Amount, Apy: r.Apy,
+		StartDate: r.StartDate, EndDate: r.EndDate, Status: r.Status, AutoCompound: r.AutoCompound,
+		RewardsClaimed: r.RewardsClaimed, ProductID: r.ProductID, PenaltyAmount: r.PenaltyAmount,
+	}, nil
+}
+func (f *fakeQueries) BeginUnbonding(ctx context.Context, arg db.BeginUnbondingParams) error {
+	f.unbonding = &arg
+	return nil
+}
+func (f *fakeQueries) MarkStakeWithdrawn(ctx context.Context, id pgtype.UUID) error {
+	f.withdrawn = true
+	return nil
+}
+func (f *fakeQueries) StartUnbondingMaturedStakes(ctx context.Context, defaultUnbondingDays int32) (int64, error) {
+	return 0, nil
+}
+func (f *fakeQueries) ReleaseUnbondedStakes(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+func (f *fakeQueries) UpdateStakeRewards(ctx context.Context, arg db.UpdateStakeRewardsParams) error {
+	f.settled = append(f.settled, arg)
 	return nil
 }
+func (f *fakeQueries) UpdateStakePosition(ctx context.Context, arg db.UpdateStakePositionParams) error {
+	f.position = &arg
+	return nil
+}
+func (f *fakeQueries) CreateSplitStake(ctx context.Context, arg db.CreateSplitStakeParams) (db.Stake, error) {
+	f.split = &arg
+	id := uuid.New()
+	var idPg pgtype.UUID
+	copy(idPg.Bytes[:], id[:])
+	idPg.Valid = true
+	return db.Stake{ID: idPg, UserID: arg.UserID, Amount: arg.Amount, Status: pgtype.Text{String: "unbonding", Valid: true}}, nil
+}
+func (f *fakeQueries) CreateStakeHistory(ctx context.Context, arg db.CreateStakeHistoryParams) error {
+	f.history = append(f.history, arg)
+	return nil
+}
+func (f *fakeQueries) GetStakeHistory(ctx context.Context, stakeID pgtype.UUID) ([]db.StakeHistory, error) {
+	return nil, nil
+}
+func (f *fakeQueries) ListStakesDueForSnapshot(ctx context.Context, snapshotAt pgtype.Timestamp) ([]db.ListStakesDueForSnapshotRow, error) {
+	return f.due, nil
+}
+func (f *fakeQueries) CreateRewardSnapshot(ctx context.Context, arg db.CreateRewardSnapshotParams) (int64, error) {
+	for _, sn := range f.snapshots {
+		if sn.StakeID == arg.StakeID && sn.SnapshotAt == arg.SnapshotAt {
+			return 0, nil
+		}
+	}
+	f.snapshots = append(f.snapshots, arg)
+	return 1, nil
+}
+func (f *fakeQueries) GetRewardSnapshotsAt(ctx context.Context, snapshotAt pgtype.Timestamp) ([]db.StakeRewardSnapshot, error) {
+	return f.snapshotsAt, nil
+}
+func (f *fakeQueries) GetStakeRewardSeries(ctx context.Context, arg db.GetStakeRewardSeriesParams) ([]db.GetStakeRewardSeriesRow, error) {
+	return nil, nil
+}
+func (f *fakeQueries) GetStakeTotalsByToken(ctx context.Context, userID pgtype.UUID) ([]db.GetStakeTotalsByTokenRow, error) {
+	f.totalsCalls++
+	if userID.Valid {
+		return nil, nil
+	}
+	return f.tokenTotals, nil
+}
+func (f *fakeQueries) GetStakingOverview(ctx context.Context, userID pgtype.UUID) (db.GetStakingOverviewRow, error) {
+	return db.GetStakingOverviewRow{ActiveStakers: 3}, nil
+}
+func (f *fakeQueries) GetStakeLockTiers(ctx context.Context, userID pgtype.UUID) ([]db.GetStakeLockTiersRow, error) {
+	return nil, nil
+}
+func (f *fakeQueries) GetRewardsPoolForUpdate(ctx context.Context, tokenID pgtype.UUID) (db.RewardsPool, error) {
+	if f.pool == nil {
+		return db.RewardsPool{}, pgx.ErrNoRows
+	}
+	return *f.pool, nil
+}
+func (f *fakeQueries) GetRewardObligations(ctx context.Context, arg db.GetRewardObligationsParams) (db.GetRewardObligationsRow, error) {
+	row := db.GetRewardObligationsRow{Horizon: arg.Until}
+	if f.obligationsUntil.After(arg.Until.Time) {
+		row.Horizon.Time = f.obligationsUntil
+	}
+	if f.obligations == "" {
+		return row, row.Outstanding.Scan("0")
+	}
+	return row, row.Outstanding.Scan(f.obligations)
+}
+func (f *fakeQueries) RecordPoolDistribution(ctx context.Context, arg db.RecordPoolDistributionParams) error {
+	f.distributed = append(f.distributed, arg)
+	return nil
+}
+func (f *fakeQueries) CreateSecurityMonitor(ctx context.Context, arg db.CreateSecurityMonitorParams) (db.SecurityMonitor, error) {
+	f.monitors = append(f.monitors, arg)
+	return db.SecurityMonitor{}, nil
+}
+func (f *fakeQueries) SetStakingProductAPY(ctx context.Context, arg db.SetStakingProductAPYParams) (int64, error) {
+	return 0, nil
+}
+func (f *fakeQueries) UpsertSecurityThreshold(ctx context.Context, arg db.UpsertSecurityThresholdParams) error {
+	if f.thresholds == nil {
+		f.thresholds = map[string]string{}
+	}
+	f.thresholds[arg.Name] = numericString(arg.Value)
+	return nil
+}
+func (f *fakeQueries) GetSecurityThreshold(ctx context.Context, name string) (pgtype.Numeric, error) {
+	v, ok := f.thresholds[name]
+	if !ok {
+		return pgtype.Numeric{}, pgx.ErrNoRows
+	}
+	var n pgtype.Numeric
+	return n, n.Scan(v)
+}
 
 func TestCalculateAPY(t *testing.T) {
 	s := NewStakingService(&fakeQueries{}, nil)
@@ -207,10 +354,456 @@ func TestGetUserStakes_GetStakeByID_Unstake(t *testing.T) {
 		t.Fatalf("unexpected stake: %+v", st)
 	}
 
-	if err := s.Unstake(context.Background(), stakeID, uid); err != nil {
+	if _, err := s.Unstake(context.Background(), stakeID, uid); err != nil {
 		t.Fatalf("unstake error: %v", err)
 	}
-	if !fq.unstakeCalled {
-		t.Fatalf("expected Unstake to be called on querier")
+	if fq.unbonding == nil {
+		t.Fatalf("expected BeginUnbonding to be called on querier")
+	}
+}
+
+func lockedStake(owner uuid.UUID, amount, status string, end time.Time) *db.GetStakeForUpdateRow {
+	var amt pgtype.Numeric
+	_ = amt.Scan(amount)
+	var ownerPg pgtype.UUID
+	copy(ownerPg.Bytes[:], owner[:])
+	ownerPg.Valid = true
+	return &db.GetStakeForUpdateRow{
+		ID:      ownerPg,
+		UserID:  ownerPg,
+		Amount:  amt,
+		EndDate: pgtype.Timestamp{Time: end, Valid: true},
+		Status:  pgtype.Text{String: status, Valid: true},
+	}
+}
+
+func TestUnstake_EarlyAppliesDefaultPenalty(t *testing.T) {
+	uid := uuid.New()
+	fq := &fakeQueries{lockedRow: lockedStake(uid, "200", "active", time.Now().Add(48*time.Hour))}
+	s := NewStakingService(fq, nil).WithConfig(config.StakingConfig{EarlyUnstakePenalty: 10, UnbondingPeriod: 7 * 24 * time.Hour})
+
+	res, err := s.Unstake(context.Background(), uuid.New(), uid)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Penalty != "20" || res.Payout != "180" || res.Status != "unbonding" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if res.UnbondingUntil == nil || time.Until(*res.UnbondingUntil) < 6*24*time.Hour {
+		t.Fatalf("expected ~7 day unbonding period, got %v", res.UnbondingUntil)
+	}
+}
+
+func TestUnstake_MaturedHasNoPenalty(t *testing.T) {
+	uid := uuid.New()
+	fq := &fakeQueries{lockedRow: lockedStake(uid, "200", "active", time.Now().Add(-time.Hour))}
+	s := NewStakingService(fq, nil).WithConfig(config.StakingConfig{EarlyUnstakePenalty: 10})
+
+	res, err := s.Unstake(context.Background(), uuid.New(), uid)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Penalty != "0" || res.Payout != "200" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+}
+
+func TestUnstake_ProductForbidsEarlyUnstake(t *testing.T) {
+	uid := uuid.New()
+	row := lockedStake(uid, "200", "active", time.Now().Add(48*time.Hour))
+	row.ProductID = row.ID
+	row.EarlyUnstakeAllowed = pgtype.Bool{Bool: false, Valid: true}
+	fq := &fakeQueries{lockedRow: row}
+	s := NewStakingService(fq, nil)
+
+	if _, err := s.Unstake(context.Background(), uuid.New(), uid); !errors.Is(err, ErrEarlyUnstakeForbidden) {
+		t.Fatalf("expected ErrEarlyUnstakeForbidden, got %v", err)
+	}
+	if fq.unbonding != nil {
+		t.Fatalf("stake must not change state")
+	}
+}
+
+func TestUnstake_WrongOwnerOrState(t *testing.T) {
+	owner := uuid.New()
+	fq := &fakeQueries{lockedRow: lockedStake(owner, "1", "active", time.Now())}
+	s := NewStakingService(fq, nil)
+	if _, err := s.Unstake(context.Background(), uuid.New(), uuid.New()); !errors.Is(err, ErrStakeNotFound) {
+		t.Fatalf("expected ErrStakeNotFound for other user's stake, got %v", err)
+	}
+
+	fq.lockedRow = lockedStake(owner, "1", "unbonding", time.Now())
+	if _, err := s.Unstake(context.Background(), uuid.New(), owner); !errors.Is(err, ErrStakeInvalidState) {
+		t.Fatalf("expected ErrStakeInvalidState, got %v", err)
+	}
+	if _, err := s.Release(context.Background(), uuid.New(), owner); !errors.Is(err, ErrStakeInvalidState) {
+		t.Fatalf("expected ErrStakeInvalidState for release while unbonding, got %v", err)
+	}
+}
+
+func TestRelease_Withdrawable(t *testing.T) {
+	uid := uuid.New()
+	row := lockedStake(uid, "100", "withdrawable", time.Now())
+	_ = row.PenaltyAmount.Scan("10")
+	fq := &fakeQueries{lockedRow: row}
+	s := NewStakingService(fq, nil)
+
+	res, err := s.Release(context.Background(), uuid.New(), uid)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !fq.withdrawn || res.Payout != "90" || res.Status != "withdrawn" {
+		t.Fatalf("unexpected release: withdrawn=%v result=%+v", fq.withdrawn, res)
+	}
+}
+
+func TestPartialWithdraw_SplitsPositionAndSettlesRewards(t *testing.T) {
+	uid := uuid.New()
+	row := lockedStake(uid, "100", "active", time.Now().Add(30*24*time.Hour))
+	_ = row.Apy.Scan("36.5")
+	row.StartDate = pgtype.Timestamp{Time: time.Now().Add(-10 * 24 * time.Hour), Valid: true}
+	fq := &fakeQueries{lockedRow: row}
+	s := NewStakingService(fq, nil).WithConfig(config.StakingConfig{EarlyUnstakePenalty: 10})
+
+	res, err := s.PartialWithdraw(context.Background(), uuid.New(), uid, "40")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Amount != "60" || res.Penalty != "4" || res.SplitStakeID == nil {
+		t.Fatalf("unexpected adjustment: %+v", res)
+	}
+	settled, _ := strconv.ParseFloat(res.RewardsSettled, 64)
+	if math.Abs(settled-1.0) > 1e-3 {
+		t.Fatalf("settled rewards = %v; want ~1.0", settled)
+	}
+	if len(fq.settled) != 1 || fq.split == nil || fq.position == nil {
+		t.Fatalf("expected settle, split and position update")
+	}
+	if len(fq.history) != 2 || fq.history[0].EventType != "partial_withdraw" || fq.history[1].EventType != "created" {
+		t.Fatalf("unexpected history rows: %+v", fq.history)
+	}
+
+	if _, err := s.PartialWithdraw(context.Background(), uuid.New(), uid, "100"); !errors.Is(err, ErrInvalidStakeAmount) {
+		t.Fatalf("expected ErrInvalidStakeAmount when withdrawing the full position, got %v", err)
+	}
+}
+
+func TestStakeAdjustments_RejectNonFiniteAmounts(t *testing.T) {
+	uid := uuid.New()
+	for _, amount := range []string{"NaN", "Inf", "+Inf", "-Inf", "1e400"} {
+		fq := &fakeQueries{lockedRow: lockedStake(uid, "100", "active", time.Now().Add(24*time.Hour))}
+		s := NewStakingService(fq, nil)
+		if _, err := s.PartialWithdraw(context.Background(), uuid.New(), uid, amount); !errors.Is(err, ErrInvalidStakeAmount) {
+			t.Fatalf("PartialWithdraw(%q): expected ErrInvalidStakeAmount, got %v", amount, err)
+		}
+		if _, err := s.TopUp(context.Background(), uuid.New(), uid, amount); !errors.Is(err, ErrInvalidStakeAmount) {
+			t.Fatalf("TopUp(%q): expected ErrInvalidStakeAmount, got %v", amount, err)
+		}
+		if fq.position != nil || fq.split != nil {
+			t.Fatalf("%q must not change the stake", amount)
+		}
+	}
+}
+
+func TestTopUp_WeightedStartKeepsLock(t *testing.T) {
+	uid := uuid.New()
+	start := time.Now().Add(-10 * 24 * time.Hour)
+	end := start.Add(30 * 24 * time.Hour)
+	row := lockedStake(uid, "100", "active", end)
+	row.StartDate = pgtype.Timestamp{Time: start, Valid: true}
+	_ = row.Apy.Scan("36.5")
+	fq := &fakeQueries{lockedRow: row}
+	s := NewStakingService(fq, nil)
+
+	res, err := s.TopUp(context.Background(), uuid.New(), uid, "100")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Amount != "200" {
+		t.Fatalf("amount = %s; want 200", res.Amount)
+	}
+	// The original 100 is paid its 10 days at 0.1% a day before the top-up.
+	if settled, _ := strconv.ParseFloat(res.RewardsSettled, 64); math.Abs(settled-1) > 1e-3 {
+		t.Fatalf("rewards settled = %s; want ~1", res.RewardsSettled)
+	}
+	// Half the principal is new, so the start moves halfway to now.
+	wantStart := start.Add(5 * 24 * time.Hour)
+	if d := res.StartDate.Sub(wantStart); d > time.Minute || d < -time.Minute {
+		t.Fatalf("start = %v; want ~%v", res.StartDate, wantStart)
+	}
+	if !res.EndDate.Equal(end) || fq.position == nil || !fq.position.EndDate.Time.Equal(end) {
+		t.Fatalf("top-up must not move the lock: got %v, want %v", res.EndDate, end)
+	}
+	// Accrual resumes from the settlement, not the blended start, so the
+	// existing principal is not paid twice.
+	settledAt := pgtype.Timestamp{Time: time.Now(), Valid: true}
+	if from := accrualStart(fq.position.StartDate, settledAt); !from.Equal(settledAt.Time) {
+		t.Fatalf("accrual after top-up starts at %v; want the settlement at %v", from, settledAt.Time)
+	}
+	if len(fq.history) != 1 || fq.history[0].EventType != "top_up" {
+		t.Fatalf("unexpected history rows: %+v", fq.history)
+	}
+}
+
+func TestSnapshotRewards_AccruesSinceLastSnapshot(t *testing.T) {
+	at := time.Now().UTC().Truncate(24 * time.Hour)
+	var amount, apy, cumulative pgtype.Numeric
+	_ = amount.Scan("1000")
+	_ = apy.Scan("36.5")
+	_ = cumulative.Scan("2")
+	fq := &fakeQueries{due: []db.ListStakesDueForSnapshotRow{{
+		ID:                toPgUUID(uuid.New()),
+		Amount:            amount,
+		Apy:               apy,
+		LastSnapshotAt:    pgtype.Timestamp{Time: at.Add(-24 * time.Hour), Valid: true},
+		CumulativeRewards: cumulative,
+	}}}
+	s := NewStakingService(fq, nil)
+
+	if err := s.SnapshotRewards(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fq.snapshots) != 1 {
+		t.Fatalf("expected one snapshot, got %d", len(fq.snapshots))
+	}
+	snap := fq.snapshots[0]
+	if !snap.SnapshotAt.Time.Equal(at) {
+		t.Fatalf("snapshot_at = %v; want %v", snap.SnapshotAt.Time, at)
+	}
+	if got := numericFloat(snap.AccruedRewards); math.Abs(got-1) > 1e-9 {
+		t.Fatalf("accrued = %v; want 1", got)
+	}
+	if got := numericFloat(snap.CumulativeRewards); math.Abs(got-3) > 1e-9 {
+		t.Fatalf("cumulative = %v; want 3", got)
+	}
+}
+
+func TestSnapshotRewards_ReconcilesOncePerSnapshot(t *testing.T) {
+	at := time.Now().UTC().Truncate(24 * time.Hour)
+	var amount, apy, claimed pgtype.Numeric
+	_ = amount.Scan("1000")
+	_ = apy.Scan("36.5")
+	_ = claimed.Scan("50")
+	fq := &fakeQueries{
+		due: []db.ListStakesDueForSnapshotRow{{ID: toPgUUID(uuid.New()), Amount: amount, Apy: apy}},
+		snapshotsAt: []db.StakeRewardSnapshot{{
+			StakeID: toPgUUID(uuid.New()), SnapshotAt: pgtype.Timestamp{Time: at, Valid: true},
+			Principal: amount, Apy: apy, RewardsClaimed: claimed,
+		}},
+	}
+	s := NewStakingService(fq, nil).WithMonitor(NewSecurityService(fq))
+
+	if err := s.SnapshotRewards(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fq.monitors) != 1 {
+		t.Fatalf("expected one monitor, got %d", len(fq.monitors))
+	}
+	// A replica that listed the same stakes before the insert landed writes
+	// nothing and must not raise again.
+	if err := s.SnapshotRewards(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fq.snapshots) != 1 || len(fq.monitors) != 1 {
+		t.Fatalf("expected no new snapshot or monitor on conflict, got %d snapshots and %d monitors", len(fq.snapshots), len(fq.monitors))
+	}
+	// Later ticks the same day find nothing due and must not raise again.
+	fq.due = nil
+	if err := s.SnapshotRewards(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fq.monitors) != 1 {
+		t.Fatalf("expected no new monitor without new snapshots, got %d", len(fq.monitors))
+	}
+}
+
+func TestReconcileRewards_RaisesMonitorOnOverclaim(t *testing.T) {
+	at := time.Now().UTC().Truncate(24 * time.Hour)
+	snap := func(claimed string) db.StakeRewardSnapshot {
+		var principal, apy, cumulative, c pgtype.Numeric
+		_ = principal.Scan("1000")
+		_ = apy.Scan("36.5")
+		_ = cumulative.Scan("10")
+		_ = c.Scan(claimed)
+		return db.StakeRewardSnapshot{
+			StakeID:           toPgUUID(uuid.New()),
+			SnapshotAt:        pgtype.Timestamp{Time: at, Valid: true},
+			Principal:         principal,
+			Apy:               apy,
+			CumulativeRewards: cumulative,
+			RewardsClaimed:    c,
+			CreatedAt:         pgtype.Timestamp{Time: at.Add(time.Hour), Valid: true},
+		}
+	}
+	// 10 accrued, 1% tolerance plus one hour of accrual (~0.04) after the snapshot.
+	fq := &fakeQueries{snapshotsAt: []db.StakeRewardSnapshot{snap("10.1"), snap("12")}}
+	s := NewStakingService(fq, nil).
+		WithConfig(config.StakingConfig{RewardTolerance: 1}).
+		WithMonitor(NewSecurityService(fq))
+
+	if err := s.ReconcileRewards(context.Background(), at); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fq.monitors) != 1 {
+		t.Fatalf("expected one monitor, got %d", len(fq.monitors))
+	}
+	if m := fq.monitors[0]; m.MetricName != "staking_reward_mismatch" || m.Severity.String != SeverityCritical {
+		t.Fatalf("unexpected monitor: %+v", m)
+	}
+
+	// A governance override of the tolerance takes precedence over config.
+	if err := NewSecurityService(fq).SetThreshold(context.Background(), ThresholdRewardTolerance, 25); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fq.monitors = nil
+	if err := s.ReconcileRewards(context.Background(), at); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fq.monitors) != 0 {
+		t.Fatalf("expected no monitors with a 25%% tolerance, got %d", len(fq.monitors))
+	}
+	if err := NewSecurityService(fq).SetThreshold(context.Background(), ThresholdRewardTolerance, 150); !errors.Is(err, ErrInvalidThreshold) {
+		t.Fatalf("expected ErrInvalidThreshold, got %v", err)
+	}
+}
+
+type memStore map[string]string
+
+func (m memStore) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	m[key] = value.(string)
+	return nil
+}
+func (m memStore) Get(ctx context.Context, key string) (string, error) {
+	return m[key], nil
+}
+func (m memStore) Delete(ctx context.Context, key string) error {
+	delete(m, key)
+	return nil
+}
+
+func TestSecurityThreshold_CachedUntilSet(t *testing.T) {
+	fq := &fakeQueries{}
+	store := memStore{}
+	sec := NewSecurityService(fq).WithCache(store)
+	ctx := context.Background()
+
+	if got := sec.Threshold(ctx, ThresholdMaxLoginAttempts, 5); got != 5 {
+		t.Fatalf("threshold = %v; want the fallback 5", got)
+	}
+	// The missing override is cached, so a write behind the service's back
+	// is not seen until the entry expires.
+	fq.thresholds = map[string]string{ThresholdMaxLoginAttempts: "3"}
+	if got := sec.Threshold(ctx, ThresholdMaxLoginAttempts, 5); got != 5 {
+		t.Fatalf("threshold = %v; want the cached fallback 5", got)
+	}
+	if err := sec.SetThreshold(ctx, ThresholdMaxLoginAttempts, 10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := sec.Threshold(ctx, ThresholdMaxLoginAttempts, 5); got != 10 {
+		t.Fatalf("threshold = %v; want 10 after SetThreshold", got)
+	}
+}
+
+func TestGetStakingStats_ValueWeightedAPYAndCache(t *testing.T) {
+	num := func(v string) pgtype.Numeric {
+		var n pgtype.Numeric
+		_ = n.Scan(v)
+		return n
+	}
+	fq := &fakeQueries{tokenTotals: []db.GetStakeTotalsByTokenRow{
+		{Symbol: "AOG", TotalStaked: num("100"), TotalValue: num("300"), WeightedApy: num("20"), StakeCount: 2},
+		{Symbol: "BNB", TotalStaked: num("1"), TotalValue: num("100"), WeightedApy: num("10"), StakeCount: 1},
+	}}
+	store := memStore{}
+	s := NewStakingService(fq, nil).
+		WithConfig(config.StakingConfig{StatsCacheTTL: time.Minute}).
+		WithCache(store)
+
+	stats, err := s.GetStakingStats(context.Background(), uuid.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p := stats.Protocol
+	if p.TotalValue != "400" || math.Abs(p.WeightedAPY-17.5) > 1e-9 || p.ActiveStakers != 3 || len(p.Tokens) != 2 {
+		t.Fatalf("unexpected protocol stats: %+v", p)
+	}
+	if len(stats.User.Tokens) != 0 || stats.User.TotalValue != "0" {
+		t.Fatalf("unexpected user stats: %+v", stats.User)
+	}
+
+	if _, err := s.GetStakingStats(context.Background(), uuid.New()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Second call: protocol summary from cache, new user computed.
+	if fq.totalsCalls != 3 {
+		t.Fatalf("GetStakeTotalsByToken called %d times; want 3", fq.totalsCalls)
+	}
+}
+
+func TestEmissionSchedules(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(days int) time.Time { return start.AddDate(0, 0, days) }
+
+	fixed := emissionSchedule{Kind: ScheduleFixed, Daily: 10, Start: start}
+	if got := fixed.emittedBetween(at(10), at(20)); math.Abs(got-100) > 1e-9 {
+		t.Fatalf("fixed emitted = %v; want 100", got)
+	}
+
+	halving := emissionSchedule{Kind: ScheduleHalving, Daily: 100, HalvingDays: 10, Start: start}
+	if got := halving.rate(at(25)); got != 25 {
+		t.Fatalf("halving rate = %v; want 25", got)
+	}
+	// 10 days at 100, 10 at 50, 5 at 25
+	if got := halving.emitted(at(25)); math.Abs(got-1625) > 1e-9 {
+		t.Fatalf("halving emitted = %v; want 1625", got)
+	}
+
+	linear := emissionSchedule{Kind: ScheduleLinearDecay, Daily: 100, DecayDays: 100, Start: start}
+	if got := linear.rate(at(50)); math.Abs(got-50) > 1e-9 {
+		t.Fatalf("linear rate = %v; want 50", got)
+	}
+	if got := linear.emitted(at(200)); math.Abs(got-5000) > 1e-9 {
+		t.Fatalf("linear emitted = %v; want 5000", got)
+	}
+}
+
+func TestCreateStake_RewardsBudgetExceeded(t *testing.T) {
+	tidPg := toPgUUID(uuid.New())
+	num := func(v string) pgtype.Numeric {
+		var n pgtype.Numeric
+		_ = n.Scan(v)
+		return n
+	}
+	// Fixed 1/day since 10 days ago: ~40 available by the end of a 30-day stake.
+	pool := db.RewardsPool{
+		TokenID:       tidPg,
+		Budget:        num("1000"),
+		Distributed:   num("0"),
+		Schedule:      ScheduleFixed,
+		DailyEmission: num("1"),
+		StartsAt:      pgtype.Timestamp{Time: time.Now().Add(-10 * 24 * time.Hour), Valid: true},
+	}
+	fq := &fakeQueries{tokens: []db.GetTokenListRow{{ID: tidPg, Symbol: "AOG"}}, pool: &pool, obligations: "30"}
+	s := NewStakingService(fq, nil)
+
+	// 1000 at 33.29% for 30 days promises ~27.4 on top of 30 owed.
+	_, err := s.CreateStake(context.Background(), uuid.New(), models.StakeRequest{TokenSymbol: "AOG", Amount: "1000", DurationDays: 30})
+	if !errors.Is(err, ErrRewardsBudgetExceeded) {
+		t.Fatalf("expected ErrRewardsBudgetExceeded, got %v", err)
+	}
+	if fq.createdCalled {
+		t.Fatalf("stake must not be created over budget")
+	}
+
+	// The same 30 are owed over the next 100 days, when the pool will have
+	// emitted ~110, so the budget is measured over that horizon instead.
+	fq.obligationsUntil = time.Now().Add(100 * 24 * time.Hour)
+	if _, err := s.CreateStake(context.Background(), uuid.New(), models.StakeRequest{TokenSymbol: "AOG", Amount: "1000", DurationDays: 30}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	fq.obligations, fq.obligationsUntil = "0", time.Time{}
+	if _, err := s.CreateStake(context.Background(), uuid.New(), models.StakeRequest{TokenSymbol: "AOG", Amount: "1000", DurationDays: 30}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
 }
@@ -0,0 +1,314 @@
+// internal/services/swaps.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jd7008911/aogeri-api/internal/db"
+	"github.com/jd7008911/aogeri-api/internal/models"
+)
+
+const bpsDenominator = 10000
+
+// swapAmounts prices a constant-product swap. The fee is taken from the
+// input and stays in the pool, so it accrues to liquidity providers.
+// impact is the percentage by which the execution price, fee excluded,
+// falls short of the spot price.
+func swapAmounts(reserveIn, reserveOut, amountIn float64, feeBps int) (amountOut, fee, impact float64) {
+	fee = amountIn * float64(feeBps) / bpsDenominator
+	net := amountIn - fee
+	amountOut = reserveOut * net / (reserveIn + net)
+	impact = net / (reserveIn + net) * 100
+	return amountOut, fee, impact
+}
+
+// swapSide resolves which side of a pool symbol sells into.
+type swapSide struct {
+	zeroForOne            bool
+	tokenIn, tokenOut     string
+	tokenInID, tokenOutID pgtype.UUID
+}
+
+func resolveSwapSide(pool db.GetPoolRow, symbol string) (swapSide, error) {
+	switch strings.ToUpper(symbol) {
+	case pool.Token0Symbol:
+		return swapSide{true, pool.Token0Symbol, pool.Token1Symbol, pool.Token0ID, pool.Token1ID}, nil
+	case pool.Token1Symbol:
+		return swapSide{false, pool.Token1Symbol, pool.Token0Symbol, pool.Token1ID, pool.Token0ID}, nil
+	}
+	return swapSide{}, fmt.Errorf("%w: %s is not in this pool", ErrInvalidSwap, symbol)
+}
+
+func (s swapSide) reserves(reserve0, reserve1 float64) (in, out float64) {
+	if s.zeroForOne {
+		return reserve0, reserve1
+	}
+	return reserve1, reserve0
+}
+
+// quoteCurve loads the curve a quote prices against, with the ticks of a
+// concentrated pool.
+func quoteCurve(ctx context.Context, q liquidityQuerier, pool db.GetPoolRow) (curve, error) {
+	if pool.PoolType != poolConcentrated {
+		return rowCurve(pool, nil), nil
+	}
+	ticks, err := q.ListPoolTicks(ctx, pool.ID)
+	if err != nil {
+		return curve{}, err
+	}
+	return rowCurve(pool, ticks), nil
+}
+
+// lockedPoolCurve loads the curve a swap through a locked pool prices
+// against.
+func lockedPoolCurve(ctx context.Context, q liquidityQuerier, pool db.LiquidityPool) (curve, error) {
+	if pool.PoolType != poolConcentrated {
+		return lockedCurve(pool, nil), nil
+	}
+	ticks, err := q.ListPoolTicks(ctx, pool.ID)
+	if err != nil {
+		return curve{}, err
+	}
+	return lockedCurve(pool, ticks), nil
+}
+
+// Quote prices selling amountIn of tokenIn into a pool along its current
+// curve and fee tier. slippageBps sets the minimum received and defaults to the
+// configured tolerance.
+func (s *LiquidityService) Quote(ctx context.Context, poolID uuid.UUID, tokenIn, amountIn string, slippageBps int) (*models.SwapQuote, error) {
+	in, ok := parsePositiveAmount(amountIn)
+	if !ok {
+		return nil, fmt.Errorf("%w: amount_in must be a positive finite number", ErrInvalidSwap)
+	}
+	if slippageBps == 0 {
+		slippageBps = s.cfg.DefaultSlippageBps
+	}
+	if slippageBps < 0 || slippageBps >= bpsDenominator {
+		return nil, fmt.Errorf("%w: slippage_bps out of range", ErrInvalidSwap)
+	}
+	pool, err := s.queries.GetPool(ctx, toPgUUID(poolID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPoolNotFound
+		}
+		return nil, err
+	}
+	side, err := resolveSwapSide(pool, tokenIn)
+	if err != nil {
+		return nil, err
+	}
+	c, err := quoteCurve(ctx, s.queries, pool)
+	if err != nil {
+		return nil, err
+	}
+	res, err := priceSwap(c, side, in)
+	if err != nil {
+		return nil, err
+	}
+	out := res.out
+	return &models.SwapQuote{
+		PoolID:          poolID,
+		TokenIn:         side.tokenIn,
+		TokenOut:        side.tokenOut,
+		AmountIn:        formatAmount(in),
+		AmountOut:       formatAmount(out),
+		Fee:             formatAmount(res.fee),
+		FeeBps:          int(pool.FeeBps),
+		SpotPrice:       c.spot(side),
+		ExecutionPrice:  out / in,
+		PriceImpact:     res.impact,
+		SlippageBps:     slippageBps,
+		MinimumReceived: formatAmount(out * float64(bpsDenominator-slippageBps) / bpsDenominator),
+	}, nil
+}
+
+// Swap sells req.AmountIn of req.TokenIn into a pool and records the swap.
+// It fails without touching the pool when the deadline has passed or the
+// output would be below req.MinAmountOut.
+func (s *LiquidityService) Swap(ctx context.Context, userID, poolID uuid.UUID, req models.SwapRequest) (*models.SwapReceipt, error) {
+	if req.Deadline != nil && time.Now().After(*req.Deadline) {
+		return nil, ErrDeadlineExceeded
+	}
+	in, ok := parsePositiveAmount(req.AmountIn)
+	if !ok {
+		return nil, fmt.Errorf("%w: amount_in must be a positive finite number", ErrInvalidSwap)
+	}
+	minOut, err := parseMinAmount(req.MinAmountOut)
+	if err != nil {
+		return nil, fmt.Errorf("%w: min_amount_out must be a non-negative finite number", ErrInvalidSwap)
+	}
+
+	var receipt *models.SwapReceipt
+	err = s.inTx(ctx, func(q liquidityQuerier) error {
+		row, err := q.GetPool(ctx, toPgUUID(poolID))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrPoolNotFound
+			}
+			return err
+		}
+		side, err := resolveSwapSide(row, req.TokenIn)
+		if err != nil {
+			return err
+		}
+		pool, err := lockActivePool(ctx, q, poolID)
+		if err != nil {
+			return err
+		}
+		c, err := lockedPoolCurve(ctx, q, pool)
+		if err != nil {
+			return err
+		}
+		res, err := priceSwap(c, side, in)
+		if err != nil {
+			return err
+		}
+		if res.out < minOut {
+			return fmt.Errorf("%w: would receive %s, at least %s required", ErrSlippageExceeded, formatAmount(res.out), formatAmount(minOut))
+		}
+
+		swap, err := s.applySwap(ctx, q, pool, side, userID, in, res)
+		if err != nil {
+			return err
+		}
+		updated, err := q.GetPool(ctx, pool.ID)
+		if err != nil {
+			return err
+		}
+		receipt = &models.SwapReceipt{Swap: swap, Pool: poolModel(updated)}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// applySwap moves a priced swap through a locked pool's reserves and
+// records it. A concentrated pool also takes the swap's new price and
+// in-range liquidity, and flips the ticks it crossed.
+func (s *LiquidityService) applySwap(ctx context.Context, q liquidityQuerier, pool db.LiquidityPool, side swapSide, userID uuid.UUID, in float64, res swapResult) (models.Swap, error) {
+	out, fee, impact := res.out, res.fee, res.impact
+	reserve0, reserve1 := numericFloat(pool.Reserve0), numericFloat(pool.Reserve1)
+	if side.zeroForOne {
+		reserve0, reserve1 = reserve0+in, reserve1-out
+	} else {
+		reserve0, reserve1 = reserve0-out, reserve1+in
+	}
+	if err := s.setReserves(ctx, q, pool, reserve0, reserve1, numericFloat(pool.TotalShares)); err != nil {
+		return models.Swap{}, err
+	}
+	if err := recordSwapFees(ctx, q, pool, side, in, fee, res.growth); err != nil {
+		return models.Swap{}, err
+	}
+	if pool.PoolType == poolConcentrated {
+		if err := crossTicks(ctx, q, pool, res); err != nil {
+			return models.Swap{}, err
+		}
+	}
+
+	params := db.CreateSwapParams{
+		PoolID:     pool.ID,
+		UserID:     toPgUUID(userID),
+		TokenInID:  side.tokenInID,
+		TokenOutID: side.tokenOutID,
+	}
+	var err error
+	if params.AmountIn, err = floatNumeric(in); err != nil {
+		return models.Swap{}, err
+	}
+	if params.AmountOut, err = floatNumeric(out); err != nil {
+		return models.Swap{}, err
+	}
+	if params.Fee, err = floatNumeric(fee); err != nil {
+		return models.Swap{}, err
+	}
+	if params.PriceImpact, err = floatNumeric(impact); err != nil {
+		return models.Swap{}, err
+	}
+	if params.Reserve0After, err = floatNumeric(reserve0); err != nil {
+		return models.Swap{}, err
+	}
+	if params.Reserve1After, err = floatNumeric(reserve1); err != nil {
+		return models.Swap{}, err
+	}
+	swap, err := q.CreateSwap(ctx, params)
+	if err != nil {
+		return models.Swap{}, err
+	}
+	id, _ := pgToUUID(swap.ID)
+	poolID, _ := pgToUUID(pool.ID)
+	return models.Swap{
+		ID:          id,
+		PoolID:      poolID,
+		UserID:      &userID,
+		TokenIn:     side.tokenIn,
+		TokenOut:    side.tokenOut,
+		AmountIn:    formatAmount(in),
+		AmountOut:   formatAmount(out),
+		Fee:         formatAmount(fee),
+		PriceImpact: impact,
+		CreatedAt:   swap.CreatedAt.Time,
+	}, nil
+}
+
+// ListPoolSwaps returns a pool's swaps, newest first.
+func (s *LiquidityService) ListPoolSwaps(ctx context.Context, poolID uuid.UUID, limit, offset int) ([]models.Swap, error) {
+	rows, err := s.queries.ListPoolSwaps(ctx, db.ListPoolSwapsParams{
+		PoolID:     toPgUUID(poolID),
+		PageLimit:  int32(limit),
+		PageOffset: int32(offset),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]models.Swap, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, swapModel(db.ListUserSwapsRow(r)))
+	}
+	return out, nil
+}
+
+// ListUserSwaps returns a user's swaps across pools, newest first.
+func (s *LiquidityService) ListUserSwaps(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.Swap, error) {
+	rows, err := s.queries.ListUserSwaps(ctx, db.ListUserSwapsParams{
+		UserID:     toPgUUID(userID),
+		PageLimit:  int32(limit),
+		PageOffset: int32(offset),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]models.Swap, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, swapModel(r))
+	}
+	return out, nil
+}
+
+func swapModel(r db.ListUserSwapsRow) models.Swap {
+	id, _ := pgToUUID(r.ID)
+	poolID, _ := pgToUUID(r.PoolID)
+	m := models.Swap{
+		ID:          id,
+		PoolID:      poolID,
+		TokenIn:     r.TokenIn,
+		TokenOut:    r.TokenOut,
+		AmountIn:    numericString(r.AmountIn),
+		AmountOut:   numericString(r.AmountOut),
+		Fee:         numericString(r.Fee),
+		PriceImpact: numericFloat(r.PriceImpact),
+		CreatedAt:   r.CreatedAt.Time,
+	}
+	if user, err := pgToUUID(r.UserID); err == nil {
+		m.UserID = &user
+	}
+	return m
+}
@@ -0,0 +1,278 @@
+// internal/services/tally.go
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/jd7008911/aogeri-api/internal/db"
+	"github.com/jd7008911/aogeri-api/internal/models"
+)
+
+// Voting methods a proposal type can count its votes with.
+const (
+	MethodTokenWeighted = "token_weighted"
+	MethodQuadratic     = "quadratic"
+	MethodRankedChoice  = "ranked_choice"
+)
+
+// Bounds on the options of a ranked-choice proposal.
+const (
+	minProposalOptions = 2
+	maxProposalOptions = 10
+)
+
+// ErrInvalidBallot is returned for a vote that does not fit the proposal's
+// voting method.
+var ErrInvalidBallot = errors.New("invalid ballot")
+
+// tallyEpsilon is the relative tolerance under which two vote totals are
+// treated as tied. Totals are sums of decimal powers in float64.
+const tallyEpsilon = 1e-9
+
+// ballot is one direct voter's vote and the snapshotted power that reaches
+// them, their own plus any delegated to them. rootPower is the sum of the
+// square roots of each of those holders' power.
+type ballot struct {
+	choice    string
+	ranking   []int
+	power     float64
+	rootPower float64
+}
+
+// voteTally is the count of a proposal's ballots under its voting method.
+// participation is the raw power cast and is what quorum is measured on;
+// the other totals are weighted by the method.
+type voteTally struct {
+	forVotes      float64
+	againstVotes  float64
+	abstainVotes  float64
+	participation float64
+
+	// Ranked choice only.
+	optionVotes []float64
+	rounds      []models.RunoffRound
+	winner      int
+	winnerShare float64
+}
+
+// tallyMethod counts ballots for one voting method. Ranked methods take
+// ballots that rank the proposal's options instead of for/against/abstain.
+type tallyMethod struct {
+	ranked bool
+	tally  func(options int, ballots []ballot) voteTally
+}
+
+var tallyMethods = map[string]tallyMethod{
+	MethodTokenWeighted: {tally: weightedTally(func(b ballot) float64 { return b.power })},
+	// Quadratic voting weighs each holder by the square root of their own
+	// power, so doubling a stake adds about 41% more weight. Roots are taken
+	// before delegated power is added, so pooling power in a delegate does
+	// not shrink it.
+	MethodQuadratic:    {tally: weightedTally(func(b ballot) float64 { return b.rootPower })},
+	MethodRankedChoice: {ranked: true, tally: instantRunoff},
+}
+
+// weightedTally counts for/against/abstain ballots, each with the weight
+// the method gives it.
+func weightedTally(weight func(ballot) float64) func(int, []ballot) voteTally {
+	return func(_ int, ballots []ballot) voteTally {
+		t := voteTally{winner: -1}
+		for _, b := range ballots {
+			if b.power <= 0 {
+				continue
+			}
+			w := weight(b)
+			switch b.choice {
+			case "for":
+				t.forVotes += w
+			case "against":
+				t.againstVotes += w
+			case "abstain":
+				t.abstainVotes += w
+			default:
+				continue
+			}
+			t.participation += b.power
+		}
+		return t
+	}
+}
+
+// instantRunoff counts ranked ballots over rounds. Each round a ballot
+// counts, with its full power, for its highest-ranked option still in the
+// race. An option holding more than half of the power still counting wins;
+// otherwise the weakest option is eliminated and the next round begins.
+//
+// Ties for elimination are broken deterministically: the tied option with
+// fewer votes in the most recent earlier round where the tied options
+// differ is eliminated, and if they were level in every round the option
+// listed last is eliminated. A two-way tie in the final round is resolved
+// the same way, leaving the other option as the winner.
+func instantRunoff(options int, ballots []ballot) voteTally {
+	t := voteTally{winner: -1, optionVotes: make([]float64, options)}
+	eliminated := make([]bool, options)
+	var history [][]float64
+
+	for {
+		counts := make([]float64, options)
+		var continuing, exhausted float64
+		for _, b := range ballots {
+			if b.power <= 0 {
+				continue
+			}
+			pick := -1
+			for _, o := range b.ranking {
+				if o >= 0 && o < options && !eliminated[o] {
+					pick = o
+					break
+				}
+			}
+			if pick < 0 {
+				exhausted += b.power
+				continue
+			}
+			counts[pick] += b.power
+			continuing += b.power
+		}
+		if len(history) == 0 {
+			copy(t.optionVotes, counts)
+			t.participation = continuing + exhausted
+		}
+
+		round := models.RunoffRound{Votes: make([]string, options), Exhausted: formatAmount(exhausted)}
+		for i, c := range counts {
+			round.Votes[i] = formatAmount(c)
+		}
+
+		var remaining []int
+		for i := range counts {
+			if !eliminated[i] {
+				remaining = append(remaining, i)
+			}
+		}
+		if continuing <= 0 || len(remaining) == 0 {
+			t.rounds = append(t.rounds, round)
+			return t
+		}
+
+		leader := remaining[0]
+		for _, o := range remaining[1:] {
+			if counts[o] > counts[leader] && !tied(counts[o], counts[leader]) {
+				leader = o
+			}
+		}
+		if len(remaining) == 1 || (counts[leader]*2 > continuing && !tied(counts[leader]*2, continuing)) {
+			t.rounds = append(t.rounds, round)
+			t.winner = leader
+			t.winnerShare = counts[leader] / continuing * 100
+			return t
+		}
+
+		loser := runoffLoser(remaining, counts, history)
+		eliminated[loser] = true
+		round.Eliminated = &loser
+		t.rounds = append(t.rounds, round)
+		history = append(history, counts)
+	}
+}
+
+// runoffLoser picks the option to eliminate among remaining, applying the
+// tie-breaking rules documented on instantRunoff.
+func runoffLoser(remaining []int, counts []float64, history [][]float64) int {
+	weakest := func(candidates []int, votes []float64) []int {
+		low := votes[candidates[0]]
+		for _, o := range candidates[1:] {
+			if votes[o] < low {
+				low = votes[o]
+			}
+		}
+		var out []int
+		for _, o := range candidates {
+			if tied(votes[o], low) {
+				out = append(out, o)
+			}
+		}
+		return out
+	}
+
+	candidates := weakest(remaining, counts)
+	for r := len(history) - 1; r >= 0 && len(candidates) > 1; r-- {
+		candidates = weakest(candidates, history[r])
+	}
+	sort.Ints(candidates)
+	return candidates[len(candidates)-1]
+}
+
+// tied reports whether two vote totals are equal within tallyEpsilon.
+func tied(a, b float64) bool {
+	scale := math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+	return math.Abs(a-b) <= tallyEpsilon*scale
+}
+
+// validateBallot checks a vote against the proposal's voting method and
+// number of options.
+func validateBallot(m tallyMethod, options int, req models.VoteRequest) error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrInvalidBallot, fmt.Sprintf(format, args...))
+	}
+	if !m.ranked {
+		if len(req.Ranking) > 0 {
+			return invalid("ranking is only accepted on ranked_choice proposals")
+		}
+		switch req.VoteChoice {
+		case "for", "against", "abstain":
+			return nil
+		}
+		return invalid("vote_choice must be for, against or abstain")
+	}
+
+	if req.VoteChoice != "" {
+		return invalid("ranked_choice proposals take a ranking, not vote_choice")
+	}
+	if len(req.Ranking) == 0 {
+		return invalid("ranking is required")
+	}
+	if len(req.Ranking) > options {
+		return invalid("ranking lists more than the %d options", options)
+	}
+	seen := make(map[int]bool, len(req.Ranking))
+	for _, o := range req.Ranking {
+		if o < 0 || o >= options {
+			return invalid("option %d does not exist", o)
+		}
+		if seen[o] {
+			return invalid("option %d is ranked twice", o)
+		}
+		seen[o] = true
+	}
+	return nil
+}
+
+// ballotsFromRows converts effective votes to ballots. Rows arrive ordered
+// by voter so float sums are reproducible.
+func ballotsFromRows(rows []db.ListEffectiveVotesRow) ([]ballot, error) {
+	out := make([]ballot, 0, len(rows))
+	for _, r := range rows {
+		b := ballot{choice: r.VoteChoice, power: numericFloat(r.VotePower), rootPower: numericFloat(r.RootPower)}
+		if len(r.Ballot) > 0 {
+			if err := json.Unmarshal(r.Ballot, &b.ranking); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, b)
+	}
+	return out, nil
+}
+
+// proposalOptions decodes a proposal's stored options.
+func proposalOptions(raw []byte) []string {
+	var options []string
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &options)
+	}
+	return options
+}
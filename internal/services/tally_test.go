@@ -0,0 +1,184 @@
+package services
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/jd7008911/aogeri-api/internal/models"
+)
+
+func TestWeightedTally(t *testing.T) {
+	ballots := []ballot{
+		{choice: "for", power: 900, rootPower: 30},
+		{choice: "against", power: 100, rootPower: 10},
+		{choice: "against", power: 100, rootPower: 10},
+		{choice: "against", power: 100, rootPower: 10},
+		{choice: "against", power: 100, rootPower: 10},
+		{choice: "abstain", power: 16, rootPower: 4},
+		{choice: "for", power: 0},
+	}
+
+	token := tallyMethods[MethodTokenWeighted].tally(0, ballots)
+	if token.forVotes != 900 || token.againstVotes != 400 || token.abstainVotes != 16 || token.participation != 1316 {
+		t.Fatalf("unexpected token-weighted tally: %+v", token)
+	}
+
+	// sqrt(900) = 30 for, 4 * sqrt(100) = 40 against: the whale is outvoted.
+	quad := tallyMethods[MethodQuadratic].tally(0, ballots)
+	if quad.forVotes != 30 || quad.againstVotes != 40 || quad.abstainVotes != 4 || quad.participation != 1316 {
+		t.Fatalf("unexpected quadratic tally: %+v", quad)
+	}
+}
+
+func TestQuadraticTally_RootsBeforeDelegation(t *testing.T) {
+	// Four holders of 100 delegating to one voter weigh 4 * sqrt(100) = 40,
+	// the same as voting directly, not sqrt(400) = 20.
+	delegated := []ballot{
+		{choice: "for", power: 900, rootPower: 30},
+		{choice: "against", power: 400, rootPower: 40},
+	}
+	quad := tallyMethods[MethodQuadratic].tally(0, delegated)
+	if quad.forVotes != 30 || quad.againstVotes != 40 || quad.participation != 1300 {
+		t.Fatalf("unexpected quadratic tally: %+v", quad)
+	}
+}
+
+func TestInstantRunoff(t *testing.T) {
+	rank := func(power float64, options ...int) ballot {
+		return ballot{choice: "ranked", ranking: options, power: power}
+	}
+	cases := []struct {
+		name       string
+		options    int
+		ballots    []ballot
+		winner     int
+		share      float64
+		eliminated []int
+	}{
+		{
+			name:    "first round majority",
+			options: 3,
+			ballots: []ballot{rank(60, 0), rank(30, 1), rank(10, 2)},
+			winner:  0, share: 60,
+		},
+		{
+			name:    "transfers decide the winner",
+			options: 3,
+			ballots: []ballot{rank(40, 0), rank(35, 1), rank(25, 2, 1)},
+			winner:  1, share: 60, eliminated: []int{2},
+		},
+		{
+			name:    "exhausted ballots leave the count",
+			options: 3,
+			ballots: []ballot{rank(40, 0), rank(35, 1), rank(25, 2)},
+			winner:  0, share: 40.0 / 75 * 100, eliminated: []int{2},
+		},
+		{
+			name:    "elimination tie broken by the previous round",
+			options: 4,
+			ballots: []ballot{rank(40, 0), rank(20, 1, 2), rank(21, 2), rank(1, 3, 1)},
+			// Round 2: options 1 and 2 both hold 21; option 1 had fewer in
+			// round 1, so it goes and its ballots carry option 2 past 0.
+			winner: 2, share: 41.0 / 81 * 100, eliminated: []int{3, 1},
+		},
+		{
+			name:    "tie in every round eliminates the option listed last",
+			options: 3,
+			ballots: []ballot{rank(40, 0), rank(30, 1), rank(30, 2)},
+			winner:  0, share: 40.0 / 70 * 100, eliminated: []int{2},
+		},
+		{
+			name:    "final two-way tie goes to the option listed first",
+			options: 2,
+			ballots: []ballot{rank(50, 1), rank(50, 0)},
+			winner:  0, share: 100, eliminated: []int{1},
+		},
+		{
+			name:    "no ballots has no winner",
+			options: 3,
+			winner:  -1,
+		},
+		{
+			name:    "zero power is ignored",
+			options: 2,
+			ballots: []ballot{rank(0, 1), rank(5, 0)},
+			winner:  0, share: 100,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := instantRunoff(tc.options, tc.ballots)
+			if got.winner != tc.winner {
+				t.Fatalf("winner = %d, want %d (rounds %+v)", got.winner, tc.winner, got.rounds)
+			}
+			if tc.share > 0 && !tied(got.winnerShare, tc.share) {
+				t.Fatalf("winner share = %v, want %v", got.winnerShare, tc.share)
+			}
+			var eliminated []int
+			for _, r := range got.rounds {
+				if r.Eliminated != nil {
+					eliminated = append(eliminated, *r.Eliminated)
+				}
+			}
+			if len(eliminated) != len(tc.eliminated) {
+				t.Fatalf("eliminated = %v, want %v", eliminated, tc.eliminated)
+			}
+			for i := range eliminated {
+				if eliminated[i] != tc.eliminated[i] {
+					t.Fatalf("eliminated = %v, want %v", eliminated, tc.eliminated)
+				}
+			}
+		})
+	}
+}
+
+func TestInstantRunoff_BallotOrderDoesNotMatter(t *testing.T) {
+	var ballots []ballot
+	for i := 0; i < 40; i++ {
+		ballots = append(ballots, ballot{ranking: []int{i % 4, (i + 1) % 4, (i + 2) % 4}, power: float64(i%7) + 0.1})
+	}
+	want := instantRunoff(4, ballots)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		r.Shuffle(len(ballots), func(a, b int) { ballots[a], ballots[b] = ballots[b], ballots[a] })
+		got := instantRunoff(4, ballots)
+		if got.winner != want.winner || len(got.rounds) != len(want.rounds) {
+			t.Fatalf("shuffle %d: winner %d in %d rounds, want %d in %d", i, got.winner, len(got.rounds), want.winner, len(want.rounds))
+		}
+	}
+}
+
+func TestValidateBallot(t *testing.T) {
+	ranked := tallyMethods[MethodRankedChoice]
+	weighted := tallyMethods[MethodTokenWeighted]
+	cases := []struct {
+		name   string
+		method tallyMethod
+		req    models.VoteRequest
+		ok     bool
+	}{
+		{"choice", weighted, models.VoteRequest{VoteChoice: "abstain"}, true},
+		{"missing choice", weighted, models.VoteRequest{}, false},
+		{"ranking on weighted", weighted, models.VoteRequest{VoteChoice: "for", Ranking: []int{0}}, false},
+		{"partial ranking", ranked, models.VoteRequest{Ranking: []int{2}}, true},
+		{"full ranking", ranked, models.VoteRequest{Ranking: []int{2, 0, 1}}, true},
+		{"choice on ranked", ranked, models.VoteRequest{VoteChoice: "for", Ranking: []int{0}}, false},
+		{"empty ranking", ranked, models.VoteRequest{}, false},
+		{"unknown option", ranked, models.VoteRequest{Ranking: []int{3}}, false},
+		{"negative option", ranked, models.VoteRequest{Ranking: []int{-1}}, false},
+		{"duplicate option", ranked, models.VoteRequest{Ranking: []int{1, 1}}, false},
+		{"too many", ranked, models.VoteRequest{Ranking: []int{0, 1, 2, 0}}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateBallot(tc.method, 3, tc.req)
+			if tc.ok && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tc.ok && !errors.Is(err, ErrInvalidBallot) {
+				t.Fatalf("expected ErrInvalidBallot, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+// internal/services/tx.go
+package services
+
+import (
+	"context"
+
+	"github.com/jd7008911/aogeri-api/internal/db"
+)
+
+// TxRunner executes fn against queries bound to a single database transaction.
+// *db.Database satisfies it.
+type TxRunner interface {
+	ExecTx(ctx context.Context, fn func(*db.Queries) error) error
+}